@@ -47,6 +47,12 @@ var ShowCmd = &cobra.Command{
 	Short: "Show modules information",
 }
 
+// BaselineCmd to export and import file integrity baselines.
+var BaselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Export and import file integrity baselines",
+}
+
 // withECSVersion is a modifier that adds ecs.version to events.
 var withECSVersion = processing.WithFields(common.MapStr{
 	"ecs": common.MapStr{
@@ -74,6 +80,7 @@ func Initialize(settings instance.Settings) *cmd.BeatsRootCmd {
 	)
 	rootCmd := cmd.GenRootCmdWithSettings(create, settings)
 	rootCmd.AddCommand(ShowCmd)
+	rootCmd.AddCommand(BaselineCmd)
 	return rootCmd
 }
 
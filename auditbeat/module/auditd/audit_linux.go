@@ -106,16 +106,26 @@ func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
 	_, _, kernel, _ := kernelVersion()
 	log.Infof("auditd module is running as euid=%v on kernel=%v", os.Geteuid(), kernel)
 
-	client, err := newAuditClient(&config, log)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create audit client")
-	}
-
 	reassemblerGapsMetric.Set(0)
 	kernelLostMetric.Set(0)
 	userspaceLostMetric.Set(0)
 	receivedMetric.Set(0)
 
+	if len(config.ReplayFiles) > 0 {
+		// No connection to the kernel is needed to replay audit messages.
+		log.Infof("Replaying audit messages from %v", config.ReplayFiles)
+		return &MetricSet{
+			BaseMetricSet: base,
+			config:        config,
+			log:           log,
+		}, nil
+	}
+
+	client, err := newAuditClient(&config, log)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create audit client")
+	}
+
 	return &MetricSet{
 		BaseMetricSet:        base,
 		client:               client,
@@ -162,8 +172,15 @@ func closeAuditClient(client *libaudit.AuditClient) error {
 }
 
 // Run initializes the audit client and receives audit messages from the
-// kernel until the reporter's done channel is closed.
+// kernel until the reporter's done channel is closed. If replay_files are
+// configured, the messages are read from the files instead and Run returns
+// once all of them have been reported.
 func (ms *MetricSet) Run(reporter mb.PushReporterV2) {
+	if len(ms.config.ReplayFiles) > 0 {
+		ms.replay(reporter)
+		return
+	}
+
 	defer closeAuditClient(ms.client)
 
 	if err := ms.addRules(reporter); err != nil {
@@ -46,6 +46,7 @@ type Config struct {
 	RulesBlob    string   `config:"audit_rules"`         // Audit rules. One rule per line.
 	RuleFiles    []string `config:"audit_rule_files"`    // List of rule files.
 	SocketType   string   `config:"socket_type"`         // Socket type to use with the kernel (unicast or multicast).
+	ReplayFiles  []string `config:"replay_files"`        // Files to read audit messages from instead of the kernel.
 
 	// Tuning options (advanced, use with care)
 	ReassemblerMaxInFlight uint32        `config:"reassembler.max_in_flight"`
@@ -106,9 +107,31 @@ func (c *Config) Validate() error {
 			"'%v' (use unicast, multicast, or don't set a value)", c.SocketType))
 	}
 
+	if _, err = c.replayFiles(); err != nil {
+		errs = append(errs, err)
+	}
+
 	return errs.Err()
 }
 
+// replayFiles returns the files that audit messages are replayed from, with
+// the wildcards expanded.
+func (c Config) replayFiles() ([]string, error) {
+	var paths []string
+	for _, pattern := range c.ReplayFiles {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid replay_files pattern '%v'", pattern)
+		}
+		if len(files) == 0 {
+			return nil, errors.Errorf("no files match replay_files pattern '%v'", pattern)
+		}
+		sort.Strings(files)
+		paths = append(paths, files...)
+	}
+	return paths, nil
+}
+
 // Rules returns a list of rules specified in the config.
 func (c Config) rules() []auditRule {
 	return c.auditRules
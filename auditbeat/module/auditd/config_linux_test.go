@@ -93,6 +93,20 @@ func TestConfigValidateConnectionType(t *testing.T) {
 	t.Log(err)
 }
 
+func TestConfigValidateReplayFiles(t *testing.T) {
+	config := defaultConfig
+	config.ReplayFiles = []string{"testdata/*.log", "testdata/does-not-exist.log"}
+	err := config.Validate()
+	assert.Error(t, err)
+	t.Log(err)
+
+	config.ReplayFiles = config.ReplayFiles[:1]
+	files, err := config.replayFiles()
+	if assert.NoError(t, err) {
+		assert.Contains(t, files, "testdata/chown.log")
+	}
+}
+
 func TestConfigRuleOrdering(t *testing.T) {
 	const fileMode = 0644
 	config := defaultConfig
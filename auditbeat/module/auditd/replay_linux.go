@@ -0,0 +1,151 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auditd
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/v7/metricbeat/mb"
+	"github.com/elastic/go-libaudit/v2"
+	"github.com/elastic/go-libaudit/v2/auparse"
+)
+
+// Maximum length of a line in a replayed file. The kernel limits audit
+// messages to 8970 bytes, but auditd's enriched format appends to them.
+const maxReplayLineSize = 64 * 1024
+
+// replay reads audit messages from the configured files and reports the
+// events in the same way as the messages received from the kernel. The
+// messages are reassembled into events and enriched, so that audit rules
+// and the resulting events can be examined without a live audit subsystem.
+func (ms *MetricSet) replay(reporter mb.PushReporterV2) {
+	files, err := ms.config.replayFiles()
+	if err != nil {
+		reporter.Error(err)
+		ms.log.Errorw("Failure replaying audit messages", "error", err)
+		return
+	}
+	if rules := ms.config.rules(); len(rules) > 0 {
+		ms.log.Infof("%d audit rules were validated but are not installed when replaying audit messages.", len(rules))
+	}
+
+	out := make(chan []*auparse.AuditMessage, ms.config.StreamBufferQueueSize)
+	reassembler, err := libaudit.NewReassembler(int(ms.config.ReassemblerMaxInFlight), ms.config.ReassemblerTimeout, &stream{reporter.Done(), out})
+	if err != nil {
+		err = errors.Wrap(err, "failed to create Reassembler")
+		reporter.Error(err)
+		ms.log.Errorw("Failure replaying audit messages", "error", err)
+		return
+	}
+
+	go func() {
+		defer ms.log.Debug("replay goroutine exited")
+		defer close(out)
+		// Closing the Reassembler flushes the events that are still in
+		// flight at the end of the files.
+		defer reassembler.Close()
+
+		for _, path := range files {
+			if err := ms.replayFile(path, reassembler, reporter.Done()); err != nil {
+				reporter.Error(err)
+				ms.log.Errorw("Failure replaying audit messages", "file", path, "error", err)
+			}
+		}
+	}()
+
+	// A single consumer preserves the order of the events.
+	for msgs := range out {
+		reporter.Event(buildMetricbeatEvent(msgs, ms.config))
+	}
+	ms.log.Infof("Finished replaying audit messages from %d files", len(files))
+}
+
+// replayFile pushes the audit messages of a file to the Reassembler. Lines
+// that are not audit messages are skipped. A file without any audit message
+// is rejected, as it is most likely in an unsupported format, like the binary
+// netlink messages captured from the kernel.
+func (ms *MetricSet) replayFile(path string, reassembler *libaudit.Reassembler, done <-chan struct{}) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to open replay file")
+	}
+	defer f.Close()
+
+	var replayed, skipped int
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, maxReplayLineSize)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		select {
+		case <-done:
+			return nil
+		default:
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		msg, err := parseReplayLine(line)
+		if err != nil {
+			ms.log.Debugw("Skipping line that is not an audit message",
+				"file", path, "line", lineNum, "error", err)
+			skipped++
+			continue
+		}
+		if filterRecordType(msg.RecordType) {
+			continue
+		}
+		receivedMetric.Inc()
+		reassembler.PushMessage(msg)
+		replayed++
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, "failed to read replay file")
+	}
+	if replayed == 0 && skipped > 0 {
+		return errors.Errorf("no audit messages found in %v (%d lines skipped), "+
+			"only the text format of audit.log is supported", path, skipped)
+	}
+	ms.log.Infof("Replayed %d audit messages from %v (%d lines skipped)", replayed, path, skipped)
+	return nil
+}
+
+// parseReplayLine parses an audit message in the format written by auditd
+// to audit.log, which is the message type followed by the raw message as it
+// is received from the kernel:
+//
+//   type=SYSCALL msg=audit(1490137971.011:50406): arch=c000003e ...
+//
+// The node prefix of messages forwarded by audisp and the interpreted
+// fields that auditd appends in the ENRICHED log format are removed.
+func parseReplayLine(line string) (*auparse.AuditMessage, error) {
+	if strings.HasPrefix(line, "node=") {
+		if idx := strings.Index(line, " type="); idx >= 0 {
+			line = line[idx+1:]
+		}
+	}
+	if idx := strings.IndexByte(line, '\x1d'); idx >= 0 {
+		line = line[:idx]
+	}
+	return auparse.ParseLogLine(line)
+}
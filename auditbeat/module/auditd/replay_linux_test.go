@@ -0,0 +1,125 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auditd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/go-libaudit/v2"
+	"github.com/elastic/go-libaudit/v2/aucoalesce"
+	"github.com/elastic/go-libaudit/v2/auparse"
+
+	"github.com/elastic/beats/v7/metricbeat/mb"
+	mbtest "github.com/elastic/beats/v7/metricbeat/mb/testing"
+)
+
+// TestReplayGoldenFiles checks that replaying the golden files produces the
+// same events as receiving their messages from the kernel.
+func TestReplayGoldenFiles(t *testing.T) {
+	aucoalesce.HardcodeUsers(knownUsers...)
+	aucoalesce.HardcodeGroups(knownGroups...)
+
+	sourceFiles, err := filepath.Glob(filepath.Join(testDir, testPattern))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range sourceFiles {
+		testName := strings.TrimSuffix(filepath.Base(file), testExt)
+		t.Run(testName, func(t *testing.T) {
+			config := configForGolden()
+			config["replay_files"] = []string{file}
+			ms := mbtest.NewPushMetricSetV2(t, config)
+
+			ctx, cancel := context.WithTimeout(context.Background(), fileTimeout)
+			defer cancel()
+			reporter := terminableReporter{
+				ctx:    ctx,
+				cancel: cancel,
+				isLast: func(mb.Event) bool { return false },
+			}
+			// Run returns when all messages have been replayed.
+			ms.Run(&reporter)
+			if ctx.Err() != nil {
+				t.Fatal("timeout replaying", file)
+			}
+
+			assertNoErrors(t, reporter.events)
+			golden := readGoldenFile(t, file+goldenSuffix)
+			assert.EqualValues(t, golden, normalize(t, reporter.events))
+		})
+	}
+}
+
+func TestReplayUnsupportedFormat(t *testing.T) {
+	f, err := ioutil.TempFile("", "audit-replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	// A netlink message header followed by the raw message.
+	f.Write([]byte("\x50\x00\x00\x00\x28\x05\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\n" +
+		"audit(1490137971.011:50406): pid=1 uid=0 msg='op=test'\n"))
+	f.Close()
+
+	config := configForGolden()
+	config["replay_files"] = []string{f.Name()}
+	ms := mbtest.NewPushMetricSetV2(t, config).(*MetricSet)
+
+	done := make(chan struct{})
+	defer close(done)
+	out := make(chan []*auparse.AuditMessage, 1)
+	reassembler, err := libaudit.NewReassembler(5, time.Second, &stream{done, out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reassembler.Close()
+
+	err = ms.replayFile(f.Name(), reassembler, done)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "only the text format of audit.log is supported")
+	}
+}
+
+func TestParseReplayLine(t *testing.T) {
+	const expected = `audit(1490137971.011:50406): pid=1 uid=0 msg='op=test'`
+
+	for _, line := range []string{
+		`type=USER_END msg=audit(1490137971.011:50406): pid=1 uid=0 msg='op=test'`,
+		`node=web-1 type=USER_END msg=audit(1490137971.011:50406): pid=1 uid=0 msg='op=test'`,
+		"type=USER_END msg=audit(1490137971.011:50406): pid=1 uid=0 msg='op=test'\x1dUID=\"root\"",
+	} {
+		msg, err := parseReplayLine(line)
+		if assert.NoError(t, err, line) {
+			assert.Equal(t, auparse.AUDIT_USER_END, msg.RecordType, line)
+			assert.Equal(t, expected, msg.RawData, line)
+			assert.EqualValues(t, 50406, msg.Sequence, line)
+		}
+	}
+
+	_, err := parseReplayLine("----")
+	assert.Error(t, err)
+}
@@ -0,0 +1,308 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package file_integrity
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// Imported baseline that the next scan is compared against.
+	baselineBucketName = "file.baseline.v1"
+
+	baselineVersion            = 1
+	baselineSignatureAlgorithm = "ed25519"
+)
+
+// baselineFile is the portable representation of a baseline. The signature
+// covers the exact bytes of the baseline document.
+type baselineFile struct {
+	Baseline  json.RawMessage   `json:"baseline"`
+	Signature baselineSignature `json:"signature"`
+}
+
+type baselineSignature struct {
+	Algorithm string `json:"algorithm"`
+	Value     []byte `json:"value"`
+}
+
+type baseline struct {
+	Version  int             `json:"version"`
+	Created  time.Time       `json:"created"`
+	Hostname string          `json:"hostname,omitempty"`
+	Files    []baselineEntry `json:"files"`
+}
+
+// baselineEntry describes a file of the baseline. The readable fields are
+// informational, Metadata contains the flatbuffers-encoded event as it is
+// persisted in the datastore and is the only field used on import.
+type baselineEntry struct {
+	Path       string            `json:"path"`
+	TargetPath string            `json:"target_path,omitempty"`
+	Type       string            `json:"type,omitempty"`
+	Size       uint64            `json:"size,omitempty"`
+	UID        uint32            `json:"uid"`
+	GID        uint32            `json:"gid"`
+	SID        string            `json:"sid,omitempty"`
+	Mode       string            `json:"mode,omitempty"`
+	Hashes     map[string]string `json:"hash,omitempty"`
+	Metadata   []byte            `json:"metadata"`
+}
+
+func newBaselineEntry(e *Event, metadata []byte) baselineEntry {
+	entry := baselineEntry{
+		Path:       e.Path,
+		TargetPath: e.TargetPath,
+		Metadata:   metadata,
+	}
+	if info := e.Info; info != nil {
+		entry.Type = info.Type.String()
+		entry.Size = info.Size
+		entry.UID = info.UID
+		entry.GID = info.GID
+		entry.SID = info.SID
+		entry.Mode = fmt.Sprintf("%#04o", uint32(info.Mode))
+	}
+	if len(e.Hashes) > 0 {
+		entry.Hashes = make(map[string]string, len(e.Hashes))
+		for hashType, digest := range e.Hashes {
+			entry.Hashes[string(hashType)] = digest.String()
+		}
+	}
+	return entry
+}
+
+// exportBaseline writes the contents of the file_integrity datastore to w as
+// a baseline signed with key.
+func exportBaseline(db *bolt.DB, key ed25519.PrivateKey, w io.Writer) (int, error) {
+	doc := baseline{
+		Version: baselineVersion,
+		Created: time.Now().UTC(),
+		Files:   []baselineEntry{},
+	}
+	doc.Hostname, _ = os.Hostname()
+
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.New("the datastore contains no file_integrity data")
+		}
+		return b.ForEach(func(k, v []byte) error {
+			e, err := decodeBaselineEvent(string(k), v)
+			if err != nil {
+				return err
+			}
+			doc.Files = append(doc.Files, newBaselineEntry(e, append([]byte(nil), v...)))
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to encode baseline")
+	}
+	// Indenting would change the signed bytes, keep the output compact.
+	out, err := json.Marshal(baselineFile{
+		Baseline: data,
+		Signature: baselineSignature{
+			Algorithm: baselineSignatureAlgorithm,
+			Value:     ed25519.Sign(key, data),
+		},
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to encode baseline")
+	}
+	if _, err = w.Write(append(out, '\n')); err != nil {
+		return 0, errors.Wrap(err, "failed to write baseline")
+	}
+	return len(doc.Files), nil
+}
+
+// importBaseline verifies the baseline read from r with key and stores it in
+// the datastore, replacing any baseline imported before. The next scan of
+// the metricset reports every difference to it as drift.
+func importBaseline(db *bolt.DB, key ed25519.PublicKey, r io.Reader) (int, error) {
+	doc, err := readBaseline(key, r)
+	if err != nil {
+		return 0, err
+	}
+
+	// Entries are re-encoded with the import time so that they don't depend
+	// on the clock of the host that exported them.
+	now := time.Now().UTC()
+	builder, release := fbGetBuilder()
+	defer release()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket([]byte(baselineBucketName)); err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+		b, err := tx.CreateBucket([]byte(baselineBucketName))
+		if err != nil {
+			return err
+		}
+		for _, entry := range doc.Files {
+			e, err := decodeBaselineEvent(entry.Path, entry.Metadata)
+			if err != nil {
+				return err
+			}
+			e.Timestamp = now
+			// The builder's memory is reused, copy the data as Bolt requires
+			// it to remain valid until the transaction ends.
+			builder.Reset()
+			data := append([]byte(nil), fbEncodeEvent(builder, e)...)
+			if err = b.Put([]byte(e.Path), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to store baseline")
+	}
+	return len(doc.Files), nil
+}
+
+// readBaseline reads a baseline from r and verifies its signature.
+func readBaseline(key ed25519.PublicKey, r io.Reader) (*baseline, error) {
+	var file baselineFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, errors.Wrap(err, "failed to decode baseline")
+	}
+	if file.Signature.Algorithm != baselineSignatureAlgorithm {
+		return nil, errors.Errorf("unsupported baseline signature algorithm '%v'", file.Signature.Algorithm)
+	}
+	if !ed25519.Verify(key, file.Baseline, file.Signature.Value) {
+		return nil, errors.New("invalid baseline signature")
+	}
+
+	var doc baseline
+	if err := json.Unmarshal(file.Baseline, &doc); err != nil {
+		return nil, errors.Wrap(err, "failed to decode baseline")
+	}
+	if doc.Version != baselineVersion {
+		return nil, errors.Errorf("unsupported baseline version %v", doc.Version)
+	}
+	return &doc, nil
+}
+
+// decodeBaselineEvent decodes a flatbuffers-encoded event, returning an
+// error instead of panicking on malformed data.
+func decodeBaselineEvent(path string, data []byte) (e *Event, err error) {
+	if path == "" || len(data) == 0 {
+		return nil, errors.Errorf("invalid baseline entry for path '%v'", path)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			e, err = nil, errors.Errorf("invalid baseline entry for path '%v': %v", path, r)
+		}
+	}()
+	return fbDecodeEvent(path, data), nil
+}
+
+// diffBaseline compares an event with the baseline entry for its path.
+// Attributes that depend on the host, like the inode and the timestamps, are
+// ignored.
+func diffBaseline(base, current *Event) (Action, bool) {
+	switch {
+	case base == nil && current.Info == nil:
+		return None, false
+	case base == nil:
+		return Created, true
+	case current.Info == nil:
+		return Deleted, true
+	}
+
+	result := None
+	for hashType, value := range current.Hashes {
+		if baseValue, found := base.Hashes[hashType]; found && !bytes.Equal(baseValue, value) {
+			result |= Updated
+			break
+		}
+	}
+
+	if base.TargetPath != current.TargetPath || base.Info == nil {
+		result |= AttributesModified
+	} else if o, n := base.Info, current.Info; o.UID != n.UID || o.GID != n.GID || o.SID != n.SID ||
+		o.Mode != n.Mode || o.Type != n.Type || o.SetUID != n.SetUID || o.SetGID != n.SetGID ||
+		(n.Type == FileType && o.Size != n.Size) {
+		result |= AttributesModified
+	}
+	return result, result != None
+}
+
+// loadSigningKey reads a PEM encoded ed25519 private key in PKCS #8 form.
+func loadSigningKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse private key in %v", path)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.Errorf("private key in %v is not an ed25519 key", path)
+	}
+	return priv, nil
+}
+
+// loadVerifyKey reads a PEM encoded ed25519 public key in PKIX form.
+func loadVerifyKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse public key in %v", path)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.Errorf("public key in %v is not an ed25519 key", path)
+	}
+	return pub, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.Errorf("no PEM data found in %v", path)
+	}
+	return block, nil
+}
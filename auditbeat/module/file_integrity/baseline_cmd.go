@@ -0,0 +1,143 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package file_integrity
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/elastic/beats/v7/auditbeat/cmd"
+	"github.com/elastic/beats/v7/libbeat/cmd/instance"
+	"github.com/elastic/beats/v7/libbeat/paths"
+)
+
+var (
+	baselineSigningKey string
+	baselineVerifyKey  string
+)
+
+func init() {
+	exportCmd := cobra.Command{
+		Use:   "export <file>",
+		Short: "Export the file_integrity datastore to a signed baseline file",
+		Args:  cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			if err := runBaselineExport(args[0]); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to export baseline: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+	exportCmd.Flags().StringVar(&baselineSigningKey, "signing-key", "", "PEM encoded ed25519 private key used to sign the baseline")
+	exportCmd.MarkFlagRequired("signing-key")
+
+	importCmd := cobra.Command{
+		Use:   "import <file>",
+		Short: "Import a signed baseline that the next file_integrity scan is compared against",
+		Args:  cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			if err := runBaselineImport(args[0]); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to import baseline: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+	importCmd.Flags().StringVar(&baselineVerifyKey, "verify-key", "", "PEM encoded ed25519 public key used to verify the baseline")
+	importCmd.MarkFlagRequired("verify-key")
+
+	cmd.BaselineCmd.AddCommand(&exportCmd, &importCmd)
+}
+
+func runBaselineExport(path string) error {
+	key, err := loadSigningKey(baselineSigningKey)
+	if err != nil {
+		return err
+	}
+	db, err := openBaselineDatastore(true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	n, err := exportBaseline(db, key, f)
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d files to %v\n", n, path)
+	return nil
+}
+
+func runBaselineImport(path string) error {
+	key, err := loadVerifyKey(baselineVerifyKey)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	db, err := openBaselineDatastore(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	n, err := importBaseline(db, key, f)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d files from %v. Drift is reported by the next scan.\n", n, path)
+	return nil
+}
+
+// openBaselineDatastore opens the datastore of the configured Auditbeat
+// instance. It fails instead of blocking when Auditbeat is running.
+func openBaselineDatastore(readOnly bool) (*bolt.DB, error) {
+	if _, err := instance.NewInitializedBeat(cmd.AuditbeatSettings()); err != nil {
+		return nil, errors.Wrap(err, "error initializing beat")
+	}
+
+	path := paths.Resolve(paths.Data, "beat.db")
+	if _, err := os.Stat(path); readOnly && err != nil {
+		return nil, errors.Wrapf(err, "failed to open datastore")
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second, ReadOnly: readOnly})
+	if err == bolt.ErrTimeout {
+		return nil, errors.Errorf("datastore %v is in use, stop Auditbeat first", path)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open datastore %v", path)
+	}
+	return db, nil
+}
@@ -0,0 +1,177 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package file_integrity
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func openTestDB(t *testing.T) *bolt.DB {
+	t.Helper()
+	f, err := ioutil.TempFile("", "baseline")
+	require.NoError(t, err)
+	f.Close()
+	db, err := bolt.Open(f.Name(), 0600, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Close()
+		os.Remove(f.Name())
+	})
+	return db
+}
+
+func TestBaselineExportImport(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	src := openTestDB(t)
+	require.NoError(t, src.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucket([]byte(bucketName))
+		if err != nil {
+			return err
+		}
+		e := testEvent()
+		builder, release := fbGetBuilder()
+		defer release()
+		return b.Put([]byte(e.Path), append([]byte(nil), fbEncodeEvent(builder, e)...))
+	}))
+
+	var buf bytes.Buffer
+	n, err := exportBaseline(src, priv, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	exported := buf.Bytes()
+
+	doc, err := readBaseline(pub, bytes.NewReader(exported))
+	require.NoError(t, err)
+	require.Len(t, doc.Files, 1)
+	assert.Equal(t, "/home/user/file.txt", doc.Files[0].Path)
+	assert.Equal(t, "file", doc.Files[0].Type)
+	assert.Equal(t, "0600", doc.Files[0].Mode)
+	assert.EqualValues(t, 500, doc.Files[0].UID)
+	assert.Equal(t, "1234", doc.Files[0].Hashes["sha256"])
+
+	dst := openTestDB(t)
+	n, err = importBaseline(dst, pub, bytes.NewReader(exported))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.NoError(t, dst.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(baselineBucketName))
+		require.NotNil(t, b)
+		e := fbDecodeEvent("/home/user/file.txt", b.Get([]byte("/home/user/file.txt")))
+		expected := testEvent()
+		assert.Equal(t, expected.Info.Mode, e.Info.Mode)
+		assert.Equal(t, expected.Hashes, e.Hashes)
+		assert.True(t, e.Timestamp.After(expected.Timestamp))
+		return nil
+	}))
+
+	// Baselines that were modified or signed with another key are rejected.
+	tampered := bytes.Replace(exported, []byte(`"uid":500`), []byte(`"uid":0`), 1)
+	require.NotEqual(t, exported, tampered)
+	_, err = importBaseline(dst, pub, bytes.NewReader(tampered))
+	assert.Error(t, err)
+
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, err = importBaseline(dst, otherPub, bytes.NewReader(exported))
+	assert.Error(t, err)
+
+	_, err = exportBaseline(openTestDB(t), priv, &buf)
+	assert.Error(t, err, "datastore without file_integrity data")
+}
+
+func TestBaselineKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "baseline-keys")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	writePEM := func(name, blockType string, der []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+		return path
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+	privPath := writePEM("private.pem", "PRIVATE KEY", der)
+	der, err = x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	pubPath := writePEM("public.pem", "PUBLIC KEY", der)
+
+	loadedPriv, err := loadSigningKey(privPath)
+	require.NoError(t, err)
+	assert.Equal(t, priv, loadedPriv)
+	loadedPub, err := loadVerifyKey(pubPath)
+	require.NoError(t, err)
+	assert.Equal(t, pub, loadedPub)
+
+	_, err = loadSigningKey(pubPath)
+	assert.Error(t, err)
+	_, err = loadVerifyKey(filepath.Join(dir, "missing.pem"))
+	assert.Error(t, err)
+}
+
+func TestDiffBaseline(t *testing.T) {
+	base := testEvent()
+
+	action, drifted := diffBaseline(base, testEvent())
+	assert.False(t, drifted)
+	assert.EqualValues(t, None, action)
+
+	// Host specific attributes are ignored.
+	e := testEvent()
+	e.Info.Inode++
+	e.Info.MTime = e.Info.MTime.Add(1)
+	e.Info.CTime = e.Info.CTime.Add(1)
+	_, drifted = diffBaseline(base, e)
+	assert.False(t, drifted)
+
+	e = testEvent()
+	e.Info.Mode = 0644
+	action, drifted = diffBaseline(base, e)
+	assert.True(t, drifted)
+	assert.EqualValues(t, AttributesModified, action)
+
+	e = testEvent()
+	e.Hashes[SHA256] = mustDecodeHex("5678")
+	action, _ = diffBaseline(base, e)
+	assert.EqualValues(t, Updated, action)
+
+	action, _ = diffBaseline(nil, testEvent())
+	assert.EqualValues(t, Created, action)
+
+	e = testEvent()
+	e.Info = nil
+	action, _ = diffBaseline(base, e)
+	assert.EqualValues(t, Deleted, action)
+}
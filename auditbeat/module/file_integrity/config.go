@@ -20,6 +20,8 @@ package file_integrity
 import (
 	"math"
 	"path/filepath"
+	"regexp"
+	"runtime"
 	"sort"
 	"strings"
 
@@ -71,6 +73,24 @@ const (
 	XXH64       HashType = "xxh64"
 )
 
+// Backend identifies the facility used to receive file system notifications.
+type Backend string
+
+// Unpack unpacks a string to a Backend for config parsing.
+func (b *Backend) Unpack(v string) error {
+	*b = Backend(strings.ToLower(v))
+	return nil
+}
+
+// Enum of notification backends.
+const (
+	// BackendFSNotify uses fsnotify (inotify on Linux).
+	BackendFSNotify Backend = "fsnotify"
+	// BackendFanotify uses Linux fanotify. It falls back to fsnotify when
+	// fanotify is not available.
+	BackendFanotify Backend = "fanotify"
+)
+
 // Config contains the configuration parameters for the file integrity
 // metricset.
 type Config struct {
@@ -84,6 +104,20 @@ type Config struct {
 	Recursive           bool            `config:"recursive"` // Recursive enables recursive monitoring of directories.
 	ExcludeFiles        []match.Matcher `config:"exclude_files"`
 	IncludeFiles        []match.Matcher `config:"include_files"`
+	Backend             Backend         `config:"backend"`
+	Diff                DiffConfig      `config:"diff"`
+}
+
+// DiffConfig configures the reporting of content changes of small text files.
+type DiffConfig struct {
+	Enabled          bool     `config:"enabled"`
+	MaxFileSize      string   `config:"max_file_size"` // Largest file whose content is kept.
+	MaxFileSizeBytes uint64   `config:",ignore"`
+	MaxDiffSize      string   `config:"max_diff_size"` // Diffs are truncated to this size.
+	MaxDiffSizeBytes uint64   `config:",ignore"`
+	Redact           []string `config:"redact"` // Regular expressions of content to redact.
+
+	redact []*regexp.Regexp
 }
 
 // Validate validates the config data and return an error explaining all the
@@ -124,6 +158,52 @@ nextHash:
 	if err != nil {
 		errs = append(errs, errors.Wrap(err, "invalid scan_rate_per_sec value"))
 	}
+
+	if c.Diff.Enabled {
+		if err := c.Diff.validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	switch c.Backend {
+	case BackendFSNotify:
+	case BackendFanotify:
+		if runtime.GOOS != "linux" {
+			errs = append(errs, errors.Errorf("backend '%v' is only supported on Linux", c.Backend))
+		}
+	default:
+		errs = append(errs, errors.Errorf("invalid backend value '%v'", c.Backend))
+	}
+	return errs.Err()
+}
+
+func (c *DiffConfig) validate() error {
+	var errs multierror.Errors
+	var err error
+
+	c.MaxFileSizeBytes, err = humanize.ParseBytes(c.MaxFileSize)
+	if err != nil {
+		errs = append(errs, errors.Wrap(err, "invalid diff.max_file_size value"))
+	} else if c.MaxFileSizeBytes == 0 {
+		errs = append(errs, errors.Errorf("diff.max_file_size value (%v) must be positive", c.MaxFileSize))
+	}
+
+	c.MaxDiffSizeBytes, err = humanize.ParseBytes(c.MaxDiffSize)
+	if err != nil {
+		errs = append(errs, errors.Wrap(err, "invalid diff.max_diff_size value"))
+	} else if c.MaxDiffSizeBytes == 0 {
+		errs = append(errs, errors.Errorf("diff.max_diff_size value (%v) must be positive", c.MaxDiffSize))
+	}
+
+	c.redact = c.redact[:0]
+	for _, pattern := range c.Redact {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "invalid diff.redact pattern '%v'", pattern))
+			continue
+		}
+		c.redact = append(c.redact, re)
+	}
 	return errs.Err()
 }
 
@@ -152,6 +232,31 @@ func (c *Config) IsExcludedPath(path string) bool {
 	return false
 }
 
+// IsWatchedPath checks if a path is one of the configured paths or is
+// contained in one of them. Unless recursive is set, only the direct
+// children of the configured paths are contained in them.
+func (c *Config) IsWatchedPath(path string) bool {
+	for _, p := range c.Paths {
+		if path == p {
+			return true
+		}
+		if !c.Recursive {
+			if filepath.Dir(path) == p {
+				return true
+			}
+			continue
+		}
+		prefix := p
+		if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+			prefix += string(filepath.Separator)
+		}
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // IsIncludedPath checks if a path matches the include_files regular expressions.
 func (c *Config) IsIncludedPath(path string) bool {
 	if len(c.IncludeFiles) == 0 {
@@ -172,4 +277,12 @@ var defaultConfig = Config{
 	MaxFileSizeBytes: 100 * 1024 * 1024,
 	ScanAtStart:      true,
 	ScanRatePerSec:   "50 MiB",
+	Backend:          BackendFSNotify,
+	Diff: DiffConfig{
+		MaxFileSize: "64 KiB",
+		MaxDiffSize: "16 KiB",
+		Redact: []string{
+			`(?i)(?:password|passwd|secret|token|api_?key)\s*[:=]\s*(\S+)`,
+		},
+	},
 }
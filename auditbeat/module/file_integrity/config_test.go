@@ -21,6 +21,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp/syntax"
+	"runtime"
 	"testing"
 
 	"github.com/joeshaw/multierror"
@@ -132,6 +133,38 @@ func TestConfigInvalidMaxFileSize(t *testing.T) {
 	t.Fatal("expected error")
 }
 
+func TestConfigBackend(t *testing.T) {
+	config, err := common.NewConfigFrom(map[string]interface{}{
+		"paths":   []string{"/usr/bin"},
+		"backend": "inotify",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := defaultConfig
+	assert.Error(t, config.Unpack(&c))
+
+	config, err = common.NewConfigFrom(map[string]interface{}{
+		"paths":   []string{"/usr/bin"},
+		"backend": "Fanotify",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c = defaultConfig
+	err = config.Unpack(&c)
+	if runtime.GOOS != "linux" {
+		assert.Error(t, err)
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, BackendFanotify, c.Backend)
+}
+
 func TestConfigEvalSymlinks(t *testing.T) {
 	dir := setupTestDir(t)
 	defer os.RemoveAll(dir)
@@ -169,3 +202,25 @@ func TestConfigRemoveDuplicates(t *testing.T) {
 
 	assert.Len(t, c.Paths, 1)
 }
+
+func TestConfigIsWatchedPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skip test on Windows")
+	}
+
+	c := Config{Paths: []string{"/etc", "/"}}
+	assert.True(t, c.IsWatchedPath("/etc"))
+	assert.True(t, c.IsWatchedPath("/etc/passwd"))
+	assert.True(t, c.IsWatchedPath("/bin"))
+	assert.False(t, c.IsWatchedPath("/etc/ssh/sshd_config"))
+	assert.False(t, c.IsWatchedPath("/usr/bin/ls"))
+
+	c.Recursive = true
+	assert.True(t, c.IsWatchedPath("/etc/ssh/sshd_config"))
+	assert.True(t, c.IsWatchedPath("/usr/bin/ls"))
+
+	c.Paths = []string{"/etc"}
+	assert.True(t, c.IsWatchedPath("/etc/ssh/sshd_config"))
+	assert.False(t, c.IsWatchedPath("/etc-backup/passwd"))
+	assert.False(t, c.IsWatchedPath("/"))
+}
@@ -0,0 +1,145 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package file_integrity
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/elastic/beats/v7/libbeat/common/file"
+)
+
+const (
+	redactedText = "[REDACTED]"
+
+	// Number of unchanged lines shown around each change.
+	diffContextLines = 3
+)
+
+// readTextContent returns the content of the file at path if it is not
+// larger than maxSize and looks like text. It returns nil otherwise.
+func readTextContent(path string, maxSize uint64) ([]byte, error) {
+	f, err := file.ReadOpen(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Read one byte more than allowed to detect files that are too large.
+	content, err := ioutil.ReadAll(io.LimitReader(f, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(content)) > maxSize || !isText(content) {
+		return nil, nil
+	}
+	return content, nil
+}
+
+// isText returns true if the content has no known binary file type, is
+// valid UTF-8 and contains no NUL bytes.
+func isText(content []byte) bool {
+	head := content
+	if len(head) > headerSize {
+		head = head[:headerSize]
+	}
+	if getMimeTypeOfContent(head) != "" {
+		return false
+	}
+	return utf8.Valid(content) && bytes.IndexByte(content, 0) < 0
+}
+
+// redact replaces the text matched by the given patterns. When a pattern has
+// capturing groups only the text of the groups is replaced, otherwise the
+// whole match.
+func redact(content []byte, patterns []*regexp.Regexp) []byte {
+	for _, re := range patterns {
+		var out []byte
+		last := 0
+		for _, loc := range re.FindAllSubmatchIndex(content, -1) {
+			spans := [][]int{loc[:2]}
+			if len(loc) > 2 {
+				spans = spans[:0]
+				for i := 2; i+1 < len(loc); i += 2 {
+					if loc[i] >= 0 {
+						spans = append(spans, loc[i:i+2])
+					}
+				}
+			}
+			for _, span := range spans {
+				if span[0] < last {
+					continue
+				}
+				out = append(out, content[last:span[0]]...)
+				out = append(out, redactedText...)
+				last = span[1]
+			}
+		}
+		if out != nil {
+			content = append(out, content[last:]...)
+		}
+	}
+	return content
+}
+
+// splitLines splits content into lines, keeping the line terminators. A
+// missing newline at the end of the content is added for the diff output.
+func splitLines(content []byte) []string {
+	lines := strings.SplitAfter(string(content), "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		lines = lines[:last]
+	} else {
+		lines[last] += "\n"
+	}
+	return lines
+}
+
+// unifiedDiff returns the unified diff between the old and new content of
+// path. The diff is truncated to maxSize bytes, in which case truncated is
+// true.
+func unifiedDiff(path string, old, new []byte, maxSize uint64) (diff string, truncated bool, err error) {
+	diff, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(old),
+		B:        splitLines(new),
+		FromFile: path,
+		ToFile:   path,
+		Context:  diffContextLines,
+	})
+	if err != nil {
+		return "", false, err
+	}
+	if uint64(len(diff)) > maxSize {
+		// Cut at a line boundary if possible, and never in the middle of
+		// a UTF-8 sequence.
+		cut := diff[:maxSize]
+		if i := bytes.LastIndexByte([]byte(cut), '\n'); i > 0 {
+			cut = cut[:i+1]
+		}
+		for len(cut) > 0 && !utf8.ValidString(cut) {
+			cut = cut[:len(cut)-1]
+		}
+		return cut, true, nil
+	}
+	return diff, false, nil
+}
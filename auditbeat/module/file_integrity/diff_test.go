@@ -0,0 +1,96 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package file_integrity
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	patterns := []*regexp.Regexp{
+		regexp.MustCompile(`(?i)(?:password|token)\s*[:=]\s*(\S+)`),
+		regexp.MustCompile(`\d{3}-\d{2}-\d{4}`),
+	}
+	content := []byte("user = bob\npassword = hunter2\nToken: abc ssn 123-45-6789\n")
+	assert.Equal(t,
+		"user = bob\npassword = [REDACTED]\nToken: [REDACTED] ssn [REDACTED]\n",
+		string(redact(content, patterns)))
+
+	assert.Equal(t, "nothing here", string(redact([]byte("nothing here"), patterns)))
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	old := []byte("a\nb\nc\n")
+	new := []byte("a\nB\nc\n")
+
+	diff, truncated, err := unifiedDiff("/etc/test", old, new, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, truncated)
+	assert.Equal(t, "--- /etc/test\n+++ /etc/test\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n", diff)
+
+	diff, truncated, err = unifiedDiff("/etc/test", old, new, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, truncated)
+	assert.Equal(t, "--- /etc/test\n+++ /etc/test\n", diff)
+}
+
+func TestReadTextContent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit-diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	text := filepath.Join(dir, "text.conf")
+	if err := ioutil.WriteFile(text, []byte("key = value\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	content, err := readTextContent(text, 1024)
+	assert.NoError(t, err)
+	assert.Equal(t, "key = value\n", string(content))
+
+	// Too large.
+	content, err = readTextContent(text, 4)
+	assert.NoError(t, err)
+	assert.Nil(t, content)
+
+	// Binary content.
+	binary := filepath.Join(dir, "image.png")
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+	if err := ioutil.WriteFile(binary, png, 0600); err != nil {
+		t.Fatal(err)
+	}
+	content, err = readTextContent(binary, 1024)
+	assert.NoError(t, err)
+	assert.Nil(t, content)
+
+	assert.False(t, isText([]byte("abc\x00def")))
+	assert.False(t, isText([]byte{0xff, 0xfe, 0xfd}))
+	assert.True(t, isText([]byte(strings.Repeat("ä", 10))))
+}
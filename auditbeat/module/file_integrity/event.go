@@ -64,11 +64,14 @@ const (
 	// SourceFSNotify identifies events triggered by a notification from the
 	// file system.
 	SourceFSNotify
+	// SourceFanotify identifies events triggered by a fanotify notification.
+	SourceFanotify
 )
 
 var sourceNames = map[Source]string{
 	SourceScan:     "scan",
 	SourceFSNotify: "fsnotify",
+	SourceFanotify: "fanotify",
 }
 
 // Type identifies the file type (e.g. dir, file, symlink).
@@ -118,11 +121,17 @@ type Event struct {
 	Source     Source              `json:"source"`                // Source of the event.
 	Action     Action              `json:"action"`                // Action (like created, updated).
 	Hashes     map[HashType]Digest `json:"hash,omitempty"`        // File hashes.
+	Process    common.MapStr       `json:"process,omitempty"`     // Process that caused the event (fanotify only).
 
 	// Metadata
 	rtt        time.Duration // Time taken to collect the info.
 	errors     []error       // Errors that occurred while collecting the info.
 	hashFailed bool          // Set when hashing the file failed.
+
+	diff          string // Unified diff of the content (if diffs are enabled).
+	diffTruncated bool   // Set when the diff has been truncated.
+
+	drift bool // Set when the event is a difference to an imported baseline.
 }
 
 // Metadata contains file metadata.
@@ -316,11 +325,26 @@ func buildMetricbeatEvent(e *Event, existedBefore bool) mb.Event {
 		out.MetricSetFields.Put("hash", hashes)
 	}
 
+	if e.diff != "" {
+		out.MetricSetFields.Put("file_integrity.diff", e.diff)
+		if e.diffTruncated {
+			out.MetricSetFields.Put("file_integrity.diff_truncated", true)
+		}
+	}
+
+	if len(e.Process) > 0 {
+		out.MetricSetFields.Put("process", e.Process)
+	}
+
 	out.MetricSetFields.Put("event.kind", "event")
 	out.MetricSetFields.Put("event.category", []string{"file"})
 	if e.Action > 0 {
 		actions := e.Action.InOrder(existedBefore, e.Info != nil)
-		out.MetricSetFields.Put("event.type", actions.ECSTypes())
+		types := actions.ECSTypes()
+		if e.drift {
+			types = append(types, "drift")
+		}
+		out.MetricSetFields.Put("event.type", types)
 		out.MetricSetFields.Put("event.action", actions.StringArray())
 	} else {
 		out.MetricSetFields.Put("event.type", None.ECSTypes())
@@ -0,0 +1,378 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build linux
+
+package file_integrity
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+	"unsafe"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/logp"
+	"github.com/elastic/beats/v7/libbeat/processors/add_process_metadata"
+)
+
+const (
+	// fanotifyEvents is the set of events requested from fanotify.
+	fanotifyEvents = unix.FAN_CREATE | unix.FAN_DELETE | unix.FAN_MOVED_FROM |
+		unix.FAN_MOVED_TO | unix.FAN_MODIFY | unix.FAN_ATTRIB | unix.FAN_ONDIR
+
+	fanotifyBufferSize = 64 * 1024
+)
+
+type fanotifyInfoHeader struct {
+	InfoType uint8
+	Pad      uint8
+	Len      uint16
+}
+
+type fanotifyInfoFID struct {
+	Header fanotifyInfoHeader
+	FSID   [2]int32
+	// followed by struct file_handle and, for DFID_NAME, a null-terminated
+	// file name.
+}
+
+type fileHandleHeader struct {
+	Bytes uint32
+	Type  int32
+}
+
+// fanotifyEvent is a decoded fanotify event. The changed object is identified
+// by the handle of its parent directory and its name.
+type fanotifyEvent struct {
+	mask   uint64
+	pid    int
+	fsid   [2]int32
+	handle unix.FileHandle
+	name   string
+}
+
+type fanotifyReader struct {
+	config  Config
+	fd      int
+	file    *os.File
+	mounts  map[[2]int32]int // fsid to a descriptor used to open handles
+	eventC  chan Event
+	selfPID int
+	log     *logp.Logger
+}
+
+// newFanotifyReader creates a new EventProducer backed by fanotify. When
+// recursive, the whole file systems containing the configured paths are
+// watched and events are filtered by path, so no limit on the number of
+// directories applies. Each event is attributed to the process that caused
+// it.
+func newFanotifyReader(c Config) (EventProducer, error) {
+	return &fanotifyReader{
+		config:  c,
+		fd:      -1,
+		mounts:  map[[2]int32]int{},
+		selfPID: os.Getpid(),
+		log:     logp.NewLogger(moduleName),
+	}, nil
+}
+
+func (r *fanotifyReader) Start(done <-chan struct{}) (<-chan Event, error) {
+	if err := r.init(); err != nil {
+		r.close()
+		if errors.Is(err, unix.EPERM) || errors.Is(err, unix.EINVAL) ||
+			errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.ENODEV) {
+			r.log.Warnw("Failed to initialize fanotify, falling back to fsnotify. "+
+				"fanotify requires CAP_SYS_ADMIN and Linux 5.9 or newer.", "error", err)
+			return newFSNotifyReader(r.config).Start(done)
+		}
+		return nil, err
+	}
+
+	r.eventC = make(chan Event, 1)
+	go r.consumeEvents(done)
+
+	r.log.Infow("Started fanotify watcher",
+		"file_path", r.config.Paths,
+		"recursive", r.config.Recursive)
+	return r.eventC, nil
+}
+
+func (r *fanotifyReader) init() error {
+	fd, err := unix.FanotifyInit(
+		unix.FAN_CLASS_NOTIF|unix.FAN_CLOEXEC|unix.FAN_NONBLOCK|unix.FAN_REPORT_DFID_NAME,
+		unix.O_RDONLY|unix.O_LARGEFILE)
+	if err != nil {
+		return errors.Wrap(err, "fanotify_init failed")
+	}
+	r.fd = fd
+
+	var flags uint = unix.FAN_MARK_ADD
+	var mask uint64 = fanotifyEvents
+	if r.config.Recursive {
+		flags |= unix.FAN_MARK_FILESYSTEM
+	} else {
+		mask |= unix.FAN_EVENT_ON_CHILD
+	}
+
+	for _, path := range r.config.Paths {
+		var st unix.Statfs_t
+		if err := unix.Statfs(path, &st); err != nil {
+			r.log.Warnw("Failed to add watch", "file_path", path, "error", err)
+			continue
+		}
+		if err := unix.FanotifyMark(r.fd, flags, mask, unix.AT_FDCWD, path); err != nil {
+			if err == unix.EPERM || err == unix.EINVAL || err == unix.ENODEV {
+				return errors.Wrapf(err, "failed to add fanotify mark for %v", path)
+			}
+			r.log.Warnw("Failed to add watch", "file_path", path, "error", err)
+			continue
+		}
+		if _, found := r.mounts[st.Fsid.Val]; !found {
+			mountFD, err := unix.Open(path, unix.O_RDONLY|unix.O_CLOEXEC, 0)
+			if err != nil {
+				return errors.Wrapf(err, "failed to open %v", path)
+			}
+			r.mounts[st.Fsid.Val] = mountFD
+		}
+	}
+
+	// The descriptor is non-blocking, so reads are handled by the runtime
+	// poller and can be interrupted by closing the file.
+	r.file = os.NewFile(uintptr(r.fd), "fanotify")
+	return nil
+}
+
+func (r *fanotifyReader) close() {
+	if r.file != nil {
+		r.file.Close()
+	} else if r.fd >= 0 {
+		unix.Close(r.fd)
+	}
+	r.file, r.fd = nil, -1
+	for fsid, fd := range r.mounts {
+		unix.Close(fd)
+		delete(r.mounts, fsid)
+	}
+}
+
+func (r *fanotifyReader) consumeEvents(done <-chan struct{}) {
+	defer close(r.eventC)
+
+	closed := make(chan struct{})
+	defer close(closed)
+	go func() {
+		select {
+		case <-done:
+		case <-closed:
+		}
+		r.file.Close()
+	}()
+	defer r.close()
+
+	buf := make([]byte, fanotifyBufferSize)
+	for {
+		n, err := r.file.Read(buf)
+		if err != nil {
+			select {
+			case <-done:
+				r.log.Debug("fanotify reader terminated")
+			default:
+				r.log.Errorw("Failed to read fanotify events", "error", err)
+			}
+			return
+		}
+
+		events, err := parseFanotifyEvents(buf[:n])
+		if err != nil {
+			r.log.Warnw("Failed to parse fanotify events", "error", err)
+		}
+		for _, fe := range events {
+			ev := r.newEvent(fe)
+			if ev == nil {
+				continue
+			}
+			select {
+			case r.eventC <- *ev:
+			case <-done:
+				return
+			}
+		}
+	}
+}
+
+func (r *fanotifyReader) newEvent(fe fanotifyEvent) *Event {
+	if fe.mask&unix.FAN_Q_OVERFLOW != 0 {
+		r.log.Warn("fanotify queue overflow, events have been lost")
+		return nil
+	}
+	if fe.pid == r.selfPID && !underTest {
+		return nil
+	}
+
+	path, err := r.resolve(fe)
+	if err != nil {
+		r.log.Debugw("Failed to resolve path of fanotify event", "error", err)
+		return nil
+	}
+	if !r.config.IsWatchedPath(path) || r.config.IsExcludedPath(path) || !r.config.IsIncludedPath(path) {
+		return nil
+	}
+	r.log.Debugw("Received fanotify event",
+		"file_path", path,
+		"event_flags", fe.mask,
+		"pid", fe.pid)
+
+	start := time.Now()
+	e := NewEvent(path, fanotifyMaskToAction(fe.mask), SourceFanotify,
+		r.config.MaxFileSizeBytes, r.config.HashTypes)
+	e.Process = r.process(fe.pid)
+	e.rtt = time.Since(start)
+	return &e
+}
+
+// resolve returns the path of the object an event refers to.
+func (r *fanotifyReader) resolve(fe fanotifyEvent) (string, error) {
+	mountFD, found := r.mounts[fe.fsid]
+	if !found {
+		return "", errors.Errorf("unknown file system id %v", fe.fsid)
+	}
+	fd, err := unix.OpenByHandleAt(mountFD, fe.handle, unix.O_PATH|unix.O_CLOEXEC)
+	if err != nil {
+		return "", errors.Wrap(err, "open_by_handle_at failed")
+	}
+	defer unix.Close(fd)
+
+	dir, err := os.Readlink("/proc/self/fd/" + strconv.Itoa(fd))
+	if err != nil {
+		return "", err
+	}
+	if fe.name == "" || fe.name == "." {
+		return dir, nil
+	}
+	return filepath.Join(dir, fe.name), nil
+}
+
+// process returns the metadata of the process with the given PID, as
+// collected by the add_process_metadata processor. The process may already
+// be gone, in which case only the PID is known.
+func (r *fanotifyReader) process(pid int) common.MapStr {
+	if pid <= 0 {
+		return nil
+	}
+	process, err := add_process_metadata.GetProcessMetadata(pid)
+	if err != nil {
+		return common.MapStr{"pid": pid}
+	}
+	return process
+}
+
+// parseFanotifyEvents decodes the events read from a fanotify descriptor
+// initialized with FAN_REPORT_DFID_NAME.
+func parseFanotifyEvents(buf []byte) ([]fanotifyEvent, error) {
+	const metaSize = int(unsafe.Sizeof(unix.FanotifyEventMetadata{}))
+
+	var events []fanotifyEvent
+	for len(buf) >= metaSize {
+		meta := (*unix.FanotifyEventMetadata)(unsafe.Pointer(&buf[0]))
+		if meta.Vers != unix.FANOTIFY_METADATA_VERSION {
+			return events, errors.Errorf("unsupported fanotify metadata version %d", meta.Vers)
+		}
+		evLen := int(meta.Event_len)
+		if evLen < metaSize || evLen > len(buf) || int(meta.Metadata_len) > evLen {
+			return events, errors.New("invalid fanotify event length")
+		}
+
+		ev := fanotifyEvent{mask: meta.Mask, pid: int(meta.Pid)}
+		if meta.Fd >= 0 {
+			// Events are not expected to carry descriptors in FID mode.
+			unix.Close(int(meta.Fd))
+		}
+		if err := ev.parseInfo(buf[meta.Metadata_len:evLen]); err != nil {
+			return events, err
+		}
+		if ev.handle.Size() > 0 || ev.mask&unix.FAN_Q_OVERFLOW != 0 {
+			events = append(events, ev)
+		}
+		buf = buf[evLen:]
+	}
+	return events, nil
+}
+
+func (ev *fanotifyEvent) parseInfo(buf []byte) error {
+	const (
+		infoSize   = int(unsafe.Sizeof(fanotifyInfoFID{}))
+		handleSize = int(unsafe.Sizeof(fileHandleHeader{}))
+	)
+
+	for len(buf) >= int(unsafe.Sizeof(fanotifyInfoHeader{})) {
+		hdr := (*fanotifyInfoHeader)(unsafe.Pointer(&buf[0]))
+		infoLen := int(hdr.Len)
+		if infoLen == 0 || infoLen > len(buf) {
+			return errors.New("invalid fanotify info record length")
+		}
+		info := buf[:infoLen]
+		buf = buf[infoLen:]
+
+		if hdr.InfoType != unix.FAN_EVENT_INFO_TYPE_DFID_NAME || infoLen < infoSize+handleSize {
+			continue
+		}
+		fid := (*fanotifyInfoFID)(unsafe.Pointer(&info[0]))
+		fh := (*fileHandleHeader)(unsafe.Pointer(&info[infoSize]))
+		handle := info[infoSize+handleSize:]
+		if int(fh.Bytes) > len(handle) {
+			return errors.New("invalid fanotify file handle length")
+		}
+
+		ev.fsid = fid.FSID
+		ev.handle = unix.NewFileHandle(fh.Type, handle[:fh.Bytes])
+		name := handle[fh.Bytes:]
+		if i := bytes.IndexByte(name, 0); i >= 0 {
+			name = name[:i]
+		}
+		ev.name = string(name)
+	}
+	return nil
+}
+
+func fanotifyMaskToAction(mask uint64) Action {
+	action := None
+	if mask&unix.FAN_CREATE != 0 {
+		action |= Created
+	}
+	if mask&unix.FAN_DELETE != 0 {
+		action |= Deleted
+	}
+	if mask&unix.FAN_MOVED_FROM != 0 {
+		action |= Moved
+	}
+	if mask&unix.FAN_MOVED_TO != 0 {
+		action |= Created
+	}
+	if mask&unix.FAN_MODIFY != 0 {
+		action |= Updated
+	}
+	if mask&unix.FAN_ATTRIB != 0 {
+		action |= AttributesModified
+	}
+	return action
+}
@@ -0,0 +1,150 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build linux
+
+package file_integrity
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+// makeFanotifyEvent encodes an event as reported with FAN_REPORT_DFID_NAME.
+func makeFanotifyEvent(mask uint64, pid int32, fsid [2]int32, handle []byte, name string) []byte {
+	info := make([]byte, int(unsafe.Sizeof(fanotifyInfoFID{})))
+	fh := make([]byte, int(unsafe.Sizeof(fileHandleHeader{})))
+	*(*fileHandleHeader)(unsafe.Pointer(&fh[0])) = fileHandleHeader{Bytes: uint32(len(handle)), Type: 1}
+	info = append(info, fh...)
+	info = append(info, handle...)
+	info = append(info, name...)
+	info = append(info, 0)
+	for len(info)%4 != 0 {
+		info = append(info, 0)
+	}
+	*(*fanotifyInfoFID)(unsafe.Pointer(&info[0])) = fanotifyInfoFID{
+		Header: fanotifyInfoHeader{InfoType: unix.FAN_EVENT_INFO_TYPE_DFID_NAME, Len: uint16(len(info))},
+		FSID:   fsid,
+	}
+
+	meta := make([]byte, int(unsafe.Sizeof(unix.FanotifyEventMetadata{})))
+	*(*unix.FanotifyEventMetadata)(unsafe.Pointer(&meta[0])) = unix.FanotifyEventMetadata{
+		Event_len:    uint32(len(meta) + len(info)),
+		Vers:         unix.FANOTIFY_METADATA_VERSION,
+		Metadata_len: uint16(len(meta)),
+		Mask:         mask,
+		Fd:           unix.FAN_NOFD,
+		Pid:          pid,
+	}
+	return append(meta, info...)
+}
+
+func TestParseFanotifyEvents(t *testing.T) {
+	buf := makeFanotifyEvent(unix.FAN_CREATE, 42, [2]int32{1, 2}, []byte{1, 2, 3, 4, 5, 6, 7, 8}, "passwd")
+	buf = append(buf, makeFanotifyEvent(unix.FAN_MODIFY|unix.FAN_ATTRIB, 7, [2]int32{1, 2}, []byte{9, 9, 9, 9}, ".")...)
+
+	events, err := parseFanotifyEvents(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, events, 2) {
+		return
+	}
+
+	assert.EqualValues(t, unix.FAN_CREATE, events[0].mask)
+	assert.Equal(t, 42, events[0].pid)
+	assert.Equal(t, [2]int32{1, 2}, events[0].fsid)
+	assert.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8}, events[0].handle.Bytes())
+	assert.EqualValues(t, 1, events[0].handle.Type())
+	assert.Equal(t, "passwd", events[0].name)
+	assert.EqualValues(t, Created, fanotifyMaskToAction(events[0].mask))
+
+	assert.Equal(t, 7, events[1].pid)
+	assert.Equal(t, ".", events[1].name)
+	assert.EqualValues(t, Updated|AttributesModified, fanotifyMaskToAction(events[1].mask))
+
+	_, err = parseFanotifyEvents(buf[:len(buf)-4])
+	assert.Error(t, err)
+}
+
+func TestFanotifyReader(t *testing.T) {
+	fd, err := unix.FanotifyInit(unix.FAN_CLASS_NOTIF|unix.FAN_REPORT_DFID_NAME, unix.O_RDONLY)
+	if err != nil {
+		t.Skipf("fanotify is not available: %v", err)
+	}
+	unix.Close(fd)
+
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	config := defaultConfig
+	config.Paths = []string{dir}
+	config.Recursive = true
+	config.Backend = BackendFanotify
+	r, err := NewEventReader(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	events, err := r.Start(done)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Changes outside of the configured paths are not reported.
+	other, err := ioutil.TempFile("", "audit-other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Close()
+	defer os.Remove(other.Name())
+
+	subdir := filepath.Join(dir, "sub")
+	if err := os.Mkdir(subdir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	event := readTimeout(t, events)
+	assert.Equal(t, subdir, event.Path)
+	assert.EqualValues(t, Created, event.Action)
+
+	txt := filepath.Join(subdir, "test.txt")
+	if err := ioutil.WriteFile(txt, []byte("hello"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	event = readTimeout(t, events)
+	assert.Equal(t, txt, event.Path)
+	assert.EqualValues(t, Created, event.Action&Created)
+	assert.Equal(t, SourceFanotify, event.Source)
+	if assert.NotNil(t, event.Process) {
+		assert.Equal(t, os.Getpid(), event.Process["pid"])
+		assert.NotEmpty(t, event.Process["executable"])
+	}
+}
@@ -37,12 +37,20 @@ type reader struct {
 	log     *logp.Logger
 }
 
-// NewEventReader creates a new EventProducer backed by fsnotify.
+// NewEventReader creates a new EventProducer backed by fsnotify, or by
+// fanotify if it has been selected as backend.
 func NewEventReader(c Config) (EventProducer, error) {
+	if c.Backend == BackendFanotify {
+		return newFanotifyReader(c)
+	}
+	return newFSNotifyReader(c), nil
+}
+
+func newFSNotifyReader(c Config) *reader {
 	return &reader{
 		config: c,
 		log:    logp.NewLogger(moduleName),
-	}, nil
+	}
 }
 
 func (r *reader) Start(done <-chan struct{}) (<-chan Event, error) {
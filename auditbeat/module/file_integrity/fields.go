@@ -32,5 +32,5 @@ func init() {
 // AssetFileIntegrity returns asset data.
 // This is the base64 encoded gzipped contents of module/file_integrity.
 func AssetFileIntegrity() string {
-	return "eJykk81u6jAQhfd5inkBuIohEcriSqnaKlXbFV2wQw4eYgsnRrYD5O2ruCk/ElJsusxo8p3jmTMT2GGXwVZIXIvGYqWF7SIAK6zEDF6FRHi7qjM0Gy32Vqgmgy+OBoFqBMsRtgIlM1Bhg5paZFB2Q/2aDbVircRpBMMPWQQwgYbWmAGnhkcAALbbYwaVVu3efd/I/nclgIIajgbU9iwz7S31LzLOFZWV0sLy2uEN0Ia51gOVLbqWgdQXOZ4Am41iyICJCo0d+qaR67q4vfgtJd0hKdckSX9JzvgOu6PSbKjdmH/6yN9fSDkhSeqee2M/ukufLeah9Nli7ktPYhJKT2IyRq9ZMhDUAfVRC4sZWN2ir9bnczKmYTiN/yayLPLYQ4UQ7wUsi5yQ0dn3zHNkHvfukSHDaUB8lkXukZyeuQ6biev34/qf0g/XbwYhR+S4vnMIOB/H9bgdw+mF+ng6vJUCd5nE5J/fNh07aJ+OPb7R04mn3pZXq/Se2e8AAAD//yR/DPc="
+	return "eJyklm9r2zAQxt/7U9wXqEecOhS/GGT9Q8Y2BmsHhTEc2TpborJkJLmJ9+mHZCdptqyR11eJj/PveXR34nwBT9hnUHGBOZcWa81tHwFYbgVmcMcFwscXcYqm1Ly1XMkMHhgaBKIRLEOoOApqoEaJmlikUPRj/CUbGkU7gXEE4wtZBHABkjSYASOGRQAAtm8xg1qrrvXPR7LvfQhgRQxDA6ray8TOkjuR8a6IqJXmljUeb4BI6lOfiejQp4wkF2S4BZSlokiB8hqNHfPiyGcd3B78FoI8YVLkSbrYkbzxJ+w3StMxdmT+w+flp9ukuEjShT/ukf3oJH1+dTmVPr+6DKWns2QqPZ0l5+gNTUeCeka90dxiBlZ3GKr15SY9p2EYmb1N5H61nAWoJElwA+5XyyQ5W3vHTBdv9h4wQ4aRCeNzv1oGTI5j5tNqMs/DqjKfcpWc2zywBlMukeeG1mHC9fHcgLtjGDlQ/386gpUm9jKdJe/CuunZk/rp2ec7ut2yRbDlx8fFP8zucH9tv8AFdOdXApgWS17xEqx6feGdXiKUV9UIHFQtbu2pg+xkAb5LXnG/p6oKCrQbROmlW43PXHVG9GBcbLfxyk5rlBZKJa37VRWQPa1kRNZIve64Rr9K0UOr0bjkDUMJa2czRkkKgXQN3IBBG8P1ANyzGmJLxmXt3WikpHTeoSXWopbGvaexFaQcvg/WP77d3iyvH25vfq7/6LHTy63uZOk+JkaBoUCFUgKJfL1GD7rDwbqz4mhuCKBwZdljXcuGkzVkm7s/ueG/cB1HvwcAdKa6zQ=="
 }
@@ -181,6 +181,8 @@ func fbWriteEvent(b *flatbuffers.Builder, e *Event) flatbuffers.UOffsetT {
 	switch e.Source {
 	case SourceFSNotify:
 		schema.EventAddSource(b, schema.SourceFSNotify)
+	case SourceFanotify:
+		schema.EventAddSource(b, schema.SourceFanotify)
 	case SourceScan:
 		schema.EventAddSource(b, schema.SourceScan)
 	}
@@ -223,6 +225,8 @@ func fbDecodeEvent(path string, buf []byte) *Event {
 		rtn.Source = SourceScan
 	case schema.SourceFSNotify:
 		rtn.Source = SourceFSNotify
+	case schema.SourceFanotify:
+		rtn.Source = SourceFanotify
 	}
 
 	action := e.Action()
@@ -36,6 +36,8 @@ const (
 	moduleName    = "file_integrity"
 	metricsetName = "file"
 	bucketName    = "file.v1"
+	// Last seen content of files, only used when diffs are enabled.
+	contentBucketName = "file.content.v1"
 
 	// Use old namespace for data until we do some field renaming for GA.
 	namespace = "."
@@ -71,10 +73,12 @@ type MetricSet struct {
 	log     *logp.Logger
 
 	// Runtime params that are initialized on Run().
-	bucket       datastore.BoltBucket
-	scanStart    time.Time
-	scanChan     <-chan Event
-	fsnotifyChan <-chan Event
+	bucket        datastore.BoltBucket
+	contentBucket datastore.Bucket
+	baseline      datastore.BoltBucket // Set while an imported baseline is pending.
+	scanStart     time.Time
+	scanChan      <-chan Event
+	fsnotifyChan  <-chan Event
 
 	// Used when a hash can't be calculated
 	nullHashes map[HashType]Digest
@@ -133,6 +137,9 @@ func (ms *MetricSet) Run(reporter mb.PushReporterV2) {
 				// When the scan completes purge datastore keys that no longer
 				// exist on disk based on being older than scanStart.
 				ms.purgeDeleted(reporter)
+				if ms.baseline != nil {
+					ms.reportBaselineMissing(reporter)
+				}
 				continue
 			}
 
@@ -145,6 +152,12 @@ func (ms *MetricSet) Run(reporter mb.PushReporterV2) {
 
 // Close cleans up the MetricSet when it finishes.
 func (ms *MetricSet) Close() error {
+	if ms.baseline != nil {
+		ms.baseline.Close()
+	}
+	if ms.contentBucket != nil {
+		ms.contentBucket.Close()
+	}
 	if ms.bucket != nil {
 		return ms.bucket.Close()
 	}
@@ -161,6 +174,23 @@ func (ms *MetricSet) init(reporter mb.PushReporterV2) bool {
 	}
 	ms.bucket = bucket.(datastore.BoltBucket)
 
+	if ms.config.Diff.Enabled {
+		ms.contentBucket, err = datastore.OpenBucket(contentBucketName)
+		if err != nil {
+			err = errors.Wrap(err, "failed to open persistent datastore for file contents")
+			reporter.Error(err)
+			ms.log.Errorw("Failed to initialize", "error", err)
+			return false
+		}
+	}
+
+	if err = ms.openBaseline(); err != nil {
+		err = errors.Wrap(err, "failed to open imported baseline")
+		reporter.Error(err)
+		ms.log.Errorw("Failed to initialize", "error", err)
+		return false
+	}
+
 	ms.fsnotifyChan, err = ms.reader.Start(reporter.Done())
 	if err != nil {
 		err = errors.Wrap(err, "failed to start fsnotify event producer")
@@ -227,9 +257,17 @@ func (ms *MetricSet) reportEvent(reporter mb.PushReporterV2, event *Event) bool
 	}
 
 	changed, lastEvent := ms.hasFileChangedSinceLastEvent(event)
+	existedBefore := lastEvent != nil
+	if ms.baseline != nil && event.Source == SourceScan {
+		// Scan results are reported relative to the imported baseline.
+		changed, existedBefore = ms.hasFileDriftedFromBaseline(event)
+	}
+	if ms.contentBucket != nil {
+		ms.updateContent(event, changed && lastEvent != nil)
+	}
 	if changed {
 		// Publish event if it changed.
-		if ok := reporter.Event(buildMetricbeatEvent(event, lastEvent != nil)); !ok {
+		if ok := reporter.Event(buildMetricbeatEvent(event, existedBefore)); !ok {
 			return false
 		}
 	}
@@ -260,6 +298,53 @@ func (ms *MetricSet) reportEvent(reporter mb.PushReporterV2, event *Event) bool
 	return true
 }
 
+// updateContent keeps the content of small text files in the datastore and
+// adds the diff to the previously seen content to the event if requested.
+func (ms *MetricSet) updateContent(event *Event, withDiff bool) {
+	var content []byte
+	if event.Info != nil && event.Info.Type == FileType && event.Info.Size <= ms.config.Diff.MaxFileSizeBytes {
+		var err error
+		content, err = readTextContent(event.Path, ms.config.Diff.MaxFileSizeBytes)
+		if err != nil {
+			ms.log.Debugw("Failed to read file content", "file_path", event.Path, "error", err)
+			return
+		}
+	}
+
+	if content == nil {
+		if err := ms.contentBucket.Delete(event.Path); err != nil {
+			ms.log.Errorw("Failed during DB delete", "error", err)
+		}
+		return
+	}
+	content = redact(content, ms.config.Diff.redact)
+
+	var old []byte
+	found := false
+	if err := ms.contentBucket.Load(event.Path, func(blob []byte) error {
+		old = append([]byte(nil), blob...)
+		found = true
+		return nil
+	}); err != nil {
+		ms.log.Warnw("Failed during DB load", "error", err)
+	}
+
+	if found && bytes.Equal(old, content) {
+		return
+	}
+	if withDiff && found && event.Action&Updated != 0 {
+		diff, truncated, err := unifiedDiff(event.Path, old, content, ms.config.Diff.MaxDiffSizeBytes)
+		if err != nil {
+			ms.log.Debugw("Failed to compute diff", "file_path", event.Path, "error", err)
+		}
+		event.diff, event.diffTruncated = diff, truncated
+	}
+
+	if err := ms.contentBucket.Store(event.Path, content); err != nil {
+		ms.log.Errorw("Failed during DB store", "error", err)
+	}
+}
+
 func (ms *MetricSet) hasFileChangedSinceLastEvent(event *Event) (changed bool, lastEvent *Event) {
 	// Load event from DB.
 	lastEvent, err := load(ms.bucket, event.Path)
@@ -296,6 +381,92 @@ func (ms *MetricSet) hasFileChangedSinceLastEvent(event *Event) (changed bool, l
 	return changed, lastEvent
 }
 
+// openBaseline opens the bucket of an imported baseline if it has entries.
+func (ms *MetricSet) openBaseline() error {
+	bucket, err := datastore.OpenBucket(baselineBucketName)
+	if err != nil {
+		return err
+	}
+	baseline := bucket.(datastore.BoltBucket)
+
+	var entries int
+	if err = baseline.View(func(b *bolt.Bucket) error {
+		entries = b.Stats().KeyN
+		return nil
+	}); err != nil || entries == 0 {
+		baseline.Close()
+		return err
+	}
+
+	if !ms.config.ScanAtStart {
+		ms.log.Warnw("An imported baseline is pending but scan_at_start is disabled, drift will not be reported",
+			"baseline_entries", entries)
+		baseline.Close()
+		return nil
+	}
+	ms.log.Infow("Reporting drift from imported baseline", "baseline_entries", entries)
+	ms.baseline = baseline
+	return nil
+}
+
+// hasFileDriftedFromBaseline compares a scan event with the imported
+// baseline. Each baseline entry is only used once.
+func (ms *MetricSet) hasFileDriftedFromBaseline(event *Event) (drifted, existedBefore bool) {
+	base, err := load(ms.baseline, event.Path)
+	if err != nil {
+		ms.log.Warnw("Failed during DB load", "error", err)
+		return false, false
+	}
+	if base != nil {
+		if err = ms.baseline.Delete(event.Path); err != nil {
+			ms.log.Errorw("Failed during DB delete", "error", err)
+		}
+	}
+
+	action, drifted := diffBaseline(base, event)
+	if drifted {
+		event.Action = action
+		event.drift = true
+		ms.log.Debugw("File drifted from baseline",
+			"file_path", event.Path, logp.Namespace("event"), "action", event.Action,
+			"baseline", base, "new", event)
+	}
+	return drifted, base != nil
+}
+
+// reportBaselineMissing reports the files of the imported baseline that were
+// not found by the scan and removes the baseline.
+func (ms *MetricSet) reportBaselineMissing(reporter mb.PushReporterV2) {
+	var missing []*Event
+	if err := ms.baseline.View(func(b *bolt.Bucket) error {
+		return b.ForEach(func(path, _ []byte) error {
+			if ms.config.IsWatchedPath(string(path)) {
+				missing = append(missing, &Event{
+					Timestamp: time.Now().UTC(),
+					Action:    Deleted,
+					Path:      string(path),
+					drift:     true,
+				})
+			}
+			return nil
+		})
+	}); err != nil {
+		ms.log.Errorw("Failed reading imported baseline", "error", err)
+	}
+
+	for _, e := range missing {
+		if !ms.config.IsExcludedPath(e.Path) {
+			reporter.Event(buildMetricbeatEvent(e, true))
+		}
+	}
+
+	if err := ms.baseline.DeleteBucket(); err != nil {
+		ms.log.Errorw("Failed to remove imported baseline", "error", err)
+	}
+	ms.baseline = nil
+	ms.log.Infow("Finished reporting drift from imported baseline", "missing", len(missing))
+}
+
 func (ms *MetricSet) purgeDeleted(reporter mb.PushReporterV2) {
 	for _, prefix := range ms.config.Paths {
 		deleted, err := ms.purgeOlder(ms.scanStart, prefix)
@@ -305,6 +476,16 @@ func (ms *MetricSet) purgeDeleted(reporter mb.PushReporterV2) {
 		}
 
 		for _, e := range deleted {
+			if ms.contentBucket != nil {
+				if err := ms.contentBucket.Delete(e.Path); err != nil {
+					ms.log.Errorw("Failed during DB delete", "error", err)
+				}
+			}
+			if ms.baseline != nil {
+				// Only deletions of baseline files are drift. They are
+				// reported with the rest of the missing baseline files.
+				continue
+			}
 			// Don't persist!
 			if !ms.config.IsExcludedPath(e.Path) {
 				reporter.Event(buildMetricbeatEvent(e, true))
@@ -417,6 +417,158 @@ func TestErrorReporting(t *testing.T) {
 	assert.True(t, found)
 }
 
+func TestContentDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := ioutil.TempFile("", "bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	defer os.Remove(store.Name())
+	ds := datastore.New(store.Name(), 0644)
+	bucket, err := ds.OpenBucket(bucketName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bucket.Close()
+	contentBucket, err := ds.OpenBucket(contentBucketName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer contentBucket.Close()
+
+	config := getConfig(dir)
+	config["diff.enabled"] = true
+	ms, ok := mbtest.NewPushMetricSetV2(t, config).(*MetricSet)
+	if !assert.True(t, ok) {
+		t.Fatal("can't create metricset")
+	}
+	ms.bucket = bucket.(datastore.BoltBucket)
+	ms.contentBucket = contentBucket
+
+	path := filepath.Join(dir, "app.conf")
+	report := func(content string, action Action) mb.Event {
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		var reporter testReporter
+		ev := NewEvent(path, action, SourceFSNotify, ms.config.MaxFileSizeBytes, ms.config.HashTypes)
+		ms.reportEvent(&reporter, &ev)
+		if !assert.Len(t, reporter.events, 1) {
+			t.FailNow()
+		}
+		return reporter.events[0]
+	}
+
+	ev := report("listen = 80\npassword = s3cret\n", Created)
+	_, err = ev.MetricSetFields.GetValue("file_integrity.diff")
+	assert.Error(t, err, "no diff expected for new files")
+
+	ev = report("listen = 8080\npassword = hunter2\n", Updated)
+	diff, err := ev.MetricSetFields.GetValue("file_integrity.diff")
+	if assert.NoError(t, err) {
+		assert.Contains(t, diff, "-listen = 80\n+listen = 8080\n")
+		assert.NotContains(t, diff, "s3cret")
+		assert.NotContains(t, diff, "hunter2")
+	}
+}
+
+func TestBaselineDrift(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	dbFile, err := ioutil.TempFile("", "bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbFile.Close()
+	defer os.Remove(dbFile.Name())
+	ds := datastore.New(dbFile.Name(), 0644)
+	bucket, err := ds.OpenBucket(bucketName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bucket.Close()
+	baseline, err := ds.OpenBucket(baselineBucketName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ms, ok := mbtest.NewPushMetricSetV2(t, getConfig(dir)).(*MetricSet)
+	if !assert.True(t, ok) {
+		t.Fatal("can't create metricset")
+	}
+	ms.bucket = bucket.(datastore.BoltBucket)
+	ms.baseline = baseline.(datastore.BoltBucket)
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	scan := func(path string) Event {
+		return NewEvent(path, None, SourceScan, ms.config.MaxFileSizeBytes, ms.config.HashTypes)
+	}
+
+	// The baseline knows an unchanged, a modified and a missing file. The
+	// files in a subdirectory or in a sibling directory sharing the prefix
+	// are not scanned, so they aren't missing.
+	unchanged, modified := write("unchanged", "a"), write("modified", "b")
+	for _, path := range []string{unchanged, modified, filepath.Join(dir, "missing"),
+		filepath.Join(dir, "sub", "missing"), dir + "-other"} {
+		e := scan(unchanged)
+		e.Path = path
+		e.Info.Inode++
+		if err := store(ms.baseline, &e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("modified", "changed")
+	added := write("added", "c")
+
+	var reporter testReporter
+	for _, path := range []string{unchanged, modified, added} {
+		ev := scan(path)
+		ms.reportEvent(&reporter, &ev)
+	}
+	ms.reportBaselineMissing(&reporter)
+	assert.Nil(t, ms.baseline)
+
+	types := map[string]interface{}{}
+	for _, ev := range reporter.events {
+		path, _ := ev.MetricSetFields.GetValue("file.path")
+		types[path.(string)], _ = ev.MetricSetFields.GetValue("event.type")
+	}
+	assert.Equal(t, map[string]interface{}{
+		modified:                      []string{"change", "drift"},
+		added:                         []string{"creation", "drift"},
+		filepath.Join(dir, "missing"): []string{"deletion", "drift"},
+	}, types)
+
+	// Once reported, the baseline is no longer used.
+	reporter.Clear()
+	write("unchanged", "now changed")
+	ev := scan(unchanged)
+	ms.reportEvent(&reporter, &ev)
+	if assert.Len(t, reporter.events, 1) {
+		eventType, _ := reporter.events[0].MetricSetFields.GetValue("event.type")
+		assert.Equal(t, []string{"change"}, eventType)
+	}
+}
+
 type testReporter struct {
 	events []mb.Event
 	errors []error
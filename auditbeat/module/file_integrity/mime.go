@@ -45,7 +45,13 @@ func getMimeType(path string) string {
 		return ""
 	}
 
-	kind, err := filetype.Match(head[:n])
+	return getMimeTypeOfContent(head[:n])
+}
+
+// getMimeTypeOfContent returns the file type detected from the first bytes of
+// a file, or an empty string if it's unknown.
+func getMimeTypeOfContent(head []byte) string {
+	kind, err := filetype.Match(head)
 	if err != nil {
 		return ""
 	}
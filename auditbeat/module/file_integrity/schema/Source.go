@@ -22,9 +22,11 @@ package schema
 const (
 	SourceScan     = 0
 	SourceFSNotify = 1
+	SourceFanotify = 2
 )
 
 var EnumNamesSource = map[int]string{
 	SourceScan:     "Scan",
 	SourceFSNotify: "FSNotify",
+	SourceFanotify: "Fanotify",
 }
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/elastic/beats/v7/libbeat/common"
 	"github.com/elastic/beats/v7/libbeat/logp"
@@ -44,7 +45,13 @@ func NewWithDefaultRoutes(log *logp.Logger, config *common.Config, ns lookupFunc
 	for api, h := range handlerFuncMap {
 		mux.HandleFunc(api, h)
 	}
-	return New(log, mux, config)
+
+	s, err := New(log, mux, config)
+	if err != nil {
+		return nil, err
+	}
+	mux.HandleFunc("/metrics", makePrometheusHandler(s.log, ns("info"), ns("stats"), ns("dataset")))
+	return s, nil
 }
 
 func makeRootAPIHandler(handler handlerFunc) handlerFunc {
@@ -71,6 +78,37 @@ func makeAPIHandler(ns *monitoring.Namespace) handlerFunc {
 	}
 }
 
+// makePrometheusHandler renders the stats and dataset metrics in the Prometheus
+// text exposition format, or in the OpenMetrics format if the client accepts it.
+func makePrometheusHandler(log *logp.Logger, info, stats, dataset *monitoring.Namespace) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+		if openMetrics {
+			w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		}
+
+		snapshot := monitoring.NewPrometheusSnapshot()
+
+		infoLabels := map[string]string{}
+		for k, v := range monitoring.CollectFlatSnapshot(info.GetRegistry(), monitoring.Full, false).Strings {
+			switch k {
+			case "beat", "name", "version", "uuid", "ephemeral_id":
+				infoLabels[k] = v
+			}
+		}
+		snapshot.Add("beat_info", monitoring.PrometheusGauge, infoLabels, 1)
+
+		snapshot.Collect(stats.GetRegistry(), monitoring.Full, "beat", "")
+		snapshot.Collect(dataset.GetRegistry(), monitoring.Full, "beat_dataset", "id")
+
+		if err := snapshot.WriteText(w, openMetrics); err != nil {
+			log.Warnf("Failed to write metrics response: %v", err)
+		}
+	}
+}
+
 func prettyPrint(w http.ResponseWriter, data common.MapStr, u *url.URL) {
 	query := u.Query()
 	if _, ok := query["pretty"]; ok {
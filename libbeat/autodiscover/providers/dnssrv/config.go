@@ -0,0 +1,51 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package dnssrv
+
+import (
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/autodiscover/template"
+	"github.com/elastic/beats/v7/libbeat/common"
+)
+
+// Config for the DNS SRV autodiscover provider
+type Config struct {
+	// SRV names to resolve, as _service._proto.domain
+	Names []string `config:"names" validate:"required"`
+
+	// Nameservers to query, /etc/resolv.conf is used if none is configured
+	Nameservers []string `config:"nameservers"`
+
+	// Time between resolutions of the names
+	RefreshInterval time.Duration `config:"refresh_interval" validate:"positive,nonzero"`
+
+	// Time to wait for the response of a nameserver
+	Timeout time.Duration `config:"timeout" validate:"positive,nonzero"`
+
+	Builders  []*common.Config        `config:"builders"`
+	Appenders []*common.Config        `config:"appenders"`
+	Templates template.MapperSettings `config:"templates"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		RefreshInterval: 30 * time.Second,
+		Timeout:         5 * time.Second,
+	}
+}
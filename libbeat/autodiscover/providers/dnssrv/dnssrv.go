@@ -0,0 +1,234 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package dnssrv
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/v7/libbeat/autodiscover"
+	"github.com/elastic/beats/v7/libbeat/autodiscover/template"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/common/bus"
+	"github.com/elastic/beats/v7/libbeat/keystore"
+	"github.com/elastic/beats/v7/libbeat/logp"
+)
+
+func init() {
+	autodiscover.Registry.AddProvider("dns_srv", AutodiscoverBuilder)
+}
+
+// Provider is the DNS SRV autodiscover provider. It periodically resolves a list of SRV
+// names, and emits events when instances are added, removed or their priority or weight
+// change.
+type Provider struct {
+	config    *Config
+	bus       bus.Bus
+	uuid      uuid.UUID
+	builders  autodiscover.Builders
+	appenders autodiscover.Appenders
+	templates template.Mapper
+	resolver  srvResolver
+	instances map[string]instance
+	stop      chan struct{}
+	logger    *logp.Logger
+}
+
+// instance is an endpoint resolved from a SRV record.
+type instance struct {
+	name     string
+	host     string
+	port     uint16
+	priority uint16
+	weight   uint16
+}
+
+func (i *instance) id() string {
+	return i.name + ":" + net.JoinHostPort(i.host, fmt.Sprint(i.port))
+}
+
+// AutodiscoverBuilder builds a DNS SRV autodiscover provider, it fails if there is some
+// problem with the configuration
+func AutodiscoverBuilder(
+	beatName string,
+	bus bus.Bus,
+	uuid uuid.UUID,
+	c *common.Config,
+	keystore keystore.Keystore,
+) (autodiscover.Provider, error) {
+	errWrap := func(err error) error {
+		return errors.Wrap(err, "error setting up dns_srv autodiscover provider")
+	}
+
+	config := defaultConfig()
+	err := c.Unpack(&config)
+	if err != nil {
+		return nil, errWrap(err)
+	}
+
+	resolver, err := newResolver(config.Nameservers, config.Timeout)
+	if err != nil {
+		return nil, errWrap(err)
+	}
+
+	mapper, err := template.NewConfigMapper(config.Templates, keystore, nil)
+	if err != nil {
+		return nil, errWrap(err)
+	}
+	if len(mapper.ConditionMaps) == 0 && len(config.Builders) == 0 {
+		return nil, errWrap(fmt.Errorf("no configs or builders defined for autodiscover provider"))
+	}
+
+	builders, err := autodiscover.NewBuilders(config.Builders, nil, nil)
+	if err != nil {
+		return nil, errWrap(err)
+	}
+
+	appenders, err := autodiscover.NewAppenders(config.Appenders)
+	if err != nil {
+		return nil, errWrap(err)
+	}
+
+	return &Provider{
+		config:    config,
+		bus:       bus,
+		uuid:      uuid,
+		builders:  builders,
+		appenders: appenders,
+		templates: mapper,
+		resolver:  resolver,
+		instances: make(map[string]instance),
+		stop:      make(chan struct{}),
+		logger:    logp.NewLogger("autodiscover.dns_srv"),
+	}, nil
+}
+
+// Start the autodiscover process
+func (p *Provider) Start() {
+	go func() {
+		ticker := time.NewTicker(p.config.RefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			p.refresh()
+
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// refresh resolves the names and emits the events for the instances that were added,
+// removed or changed. Instances of names that cannot be resolved are kept.
+func (p *Provider) refresh() {
+	instances := make(map[string]instance)
+	for _, name := range p.config.Names {
+		records, err := p.resolver.LookupSRV(name)
+		if err != nil {
+			p.logger.Errorf("Error resolving %s, keeping the previous instances: %v", name, err)
+			for id, i := range p.instances {
+				if i.name == name {
+					instances[id] = i
+				}
+			}
+			continue
+		}
+
+		for _, record := range records {
+			i := instance{
+				name:     name,
+				host:     strings.TrimSuffix(record.Target, "."),
+				port:     record.Port,
+				priority: record.Priority,
+				weight:   record.Weight,
+			}
+			instances[i.id()] = i
+		}
+	}
+
+	for _, id := range sortedIDs(p.instances) {
+		if _, found := instances[id]; !found {
+			p.emit(p.instances[id], "stop")
+		}
+	}
+	for _, id := range sortedIDs(instances) {
+		if old, found := p.instances[id]; !found || old != instances[id] {
+			p.emit(instances[id], "start")
+		}
+	}
+	p.instances = instances
+}
+
+func (p *Provider) emit(i instance, flag string) {
+	srv := common.MapStr{
+		"name":     i.name,
+		"target":   i.host,
+		"port":     i.port,
+		"priority": i.priority,
+		"weight":   i.weight,
+	}
+
+	event := bus.Event{
+		"provider": p.uuid,
+		"id":       i.id(),
+		flag:       true,
+		"host":     i.host,
+		"port":     int(i.port),
+		"dns_srv":  srv,
+		"meta": common.MapStr{
+			"dns_srv": srv.Clone(),
+		},
+	}
+
+	if config := p.templates.GetConfig(event); config != nil {
+		event["config"] = config
+	} else if config := p.builders.GetConfig(event); config != nil {
+		event["config"] = config
+	}
+
+	p.appenders.Append(event)
+	p.bus.Publish(event)
+}
+
+// Stop the autodiscover process
+func (p *Provider) Stop() {
+	close(p.stop)
+}
+
+// String returns the name of the provider
+func (p *Provider) String() string {
+	return "dns_srv"
+}
+
+func sortedIDs(instances map[string]instance) []string {
+	ids := make([]string, 0, len(instances))
+	for id := range instances {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
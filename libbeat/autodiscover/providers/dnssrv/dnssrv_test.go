@@ -0,0 +1,220 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package dnssrv
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/common/bus"
+	"github.com/elastic/beats/v7/libbeat/logp"
+)
+
+// fakeDNSServer is an in-process DNS server that replies with the configured SRV records.
+type fakeDNSServer struct {
+	sync.Mutex
+	records  map[string][]string
+	failures map[string]int
+}
+
+func (s *fakeDNSServer) set(name string, records ...string) {
+	s.Lock()
+	defer s.Unlock()
+	s.records[dns.Fqdn(name)] = records
+}
+
+func (s *fakeDNSServer) fail(name string, rcode int) {
+	s.Lock()
+	defer s.Unlock()
+	s.failures[dns.Fqdn(name)] = rcode
+}
+
+func (s *fakeDNSServer) ServeDNS(w dns.ResponseWriter, msg *dns.Msg) {
+	s.Lock()
+	defer s.Unlock()
+
+	m := new(dns.Msg)
+	m.SetReply(msg)
+	name := msg.Question[0].Name
+	if rcode, found := s.failures[name]; found {
+		m.SetRcode(msg, rcode)
+	} else if records, found := s.records[name]; found {
+		for _, record := range records {
+			rr, _ := dns.NewRR(name + " 60 IN SRV " + record)
+			m.Answer = append(m.Answer, rr)
+		}
+	} else {
+		m.SetRcode(msg, dns.RcodeNameError)
+	}
+	w.WriteMsg(m)
+}
+
+func serveDNS(t *testing.T) (*fakeDNSServer, string, func()) {
+	l, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	fake := &fakeDNSServer{
+		records:  map[string][]string{},
+		failures: map[string]int{},
+	}
+	s := dns.Server{PacketConn: l, Handler: fake}
+	go s.ActivateAndServe()
+	return fake, l.LocalAddr().String(), func() { s.Shutdown() }
+}
+
+func TestResolver(t *testing.T) {
+	server, addr, shutdown := serveDNS(t)
+	defer shutdown()
+
+	server.set("_redis._tcp.example.com", "10 60 6379 redis1.example.com.", "20 40 6380 redis2.example.com.")
+	server.fail("_broken._tcp.example.com", dns.RcodeServerFailure)
+
+	resolver, err := newResolver([]string{addr}, time.Second)
+	require.NoError(t, err)
+
+	records, err := resolver.LookupSRV("_redis._tcp.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []*net.SRV{
+		{Target: "redis1.example.com.", Port: 6379, Priority: 10, Weight: 60},
+		{Target: "redis2.example.com.", Port: 6380, Priority: 20, Weight: 40},
+	}, records)
+
+	records, err = resolver.LookupSRV("_missing._tcp.example.com")
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+
+	_, err = resolver.LookupSRV("_broken._tcp.example.com")
+	assert.Error(t, err)
+}
+
+func TestProviderEvents(t *testing.T) {
+	server, addr, shutdown := serveDNS(t)
+	defer shutdown()
+
+	server.set("_redis._tcp.example.com", "10 60 6379 redis1.example.com.", "10 40 6379 redis2.example.com.")
+	server.set("_http._tcp.example.com", "0 0 80 web1.example.com.")
+
+	b := bus.New(logp.NewLogger("bus"), "test")
+	listener := b.Subscribe()
+	defer listener.Stop()
+
+	c, err := common.NewConfigFrom(common.MapStr{
+		"names":       []string{"_redis._tcp.example.com", "_http._tcp.example.com"},
+		"nameservers": []string{addr},
+		"templates": []common.MapStr{{
+			"condition": common.MapStr{
+				"equals.dns_srv.name": "_redis._tcp.example.com",
+			},
+			"config": []common.MapStr{{"hosts": []string{"${data.host}:${data.port}"}}},
+		}},
+	})
+	require.NoError(t, err)
+	id, err := uuid.NewV4()
+	require.NoError(t, err)
+	provider, err := AutodiscoverBuilder("mockbeat", b, id, c, nil)
+	require.NoError(t, err)
+	p := provider.(*Provider)
+
+	p.refresh()
+	events := drain(listener)
+	require.Len(t, events, 3)
+	assert.Equal(t, "_http._tcp.example.com:web1.example.com:80", events[0]["id"])
+	assert.Empty(t, events[0]["config"])
+	assert.Equal(t, "_redis._tcp.example.com:redis1.example.com:6379", events[1]["id"])
+	assert.Equal(t, true, events[1]["start"])
+	assert.Equal(t, "redis1.example.com", events[1]["host"])
+	assert.Equal(t, 6379, events[1]["port"])
+	assert.Equal(t, common.MapStr{
+		"name":     "_redis._tcp.example.com",
+		"target":   "redis1.example.com",
+		"port":     uint16(6379),
+		"priority": uint16(10),
+		"weight":   uint16(60),
+	}, events[1]["dns_srv"])
+	assert.Len(t, events[1]["config"], 1)
+
+	// Nothing changed
+	p.refresh()
+	assert.Empty(t, drain(listener))
+
+	// The service scales, and the weight of an instance changes
+	server.set("_redis._tcp.example.com", "10 100 6379 redis1.example.com.", "10 40 6379 redis3.example.com.")
+	p.refresh()
+	events = drain(listener)
+	require.Len(t, events, 3)
+	assert.Equal(t, "_redis._tcp.example.com:redis2.example.com:6379", events[0]["id"])
+	assert.Equal(t, true, events[0]["stop"])
+	assert.Equal(t, "_redis._tcp.example.com:redis1.example.com:6379", events[1]["id"])
+	assert.Equal(t, true, events[1]["start"])
+	assert.Equal(t, uint16(100), events[1]["dns_srv"].(common.MapStr)["weight"])
+	assert.Equal(t, "_redis._tcp.example.com:redis3.example.com:6379", events[2]["id"])
+	assert.Equal(t, true, events[2]["start"])
+
+	// Instances are kept if the name cannot be resolved
+	server.fail("_http._tcp.example.com", dns.RcodeServerFailure)
+	p.refresh()
+	assert.Empty(t, drain(listener))
+
+	// Instances are stopped if the name doesn't exist anymore
+	server.set("_redis._tcp.example.com")
+	server.fail("_http._tcp.example.com", dns.RcodeNameError)
+	p.refresh()
+	events = drain(listener)
+	require.Len(t, events, 3)
+	for _, event := range events {
+		assert.Equal(t, true, event["stop"])
+	}
+}
+
+func TestAutodiscoverBuilderErrors(t *testing.T) {
+	for name, cfg := range map[string]common.MapStr{
+		"no names": {
+			"templates": []common.MapStr{{"config": []common.MapStr{{"hosts": "${data.host}"}}}},
+		},
+		"no templates": {
+			"names": []string{"_redis._tcp.example.com"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, err := common.NewConfigFrom(cfg)
+			require.NoError(t, err)
+			_, err = AutodiscoverBuilder("mockbeat", bus.New(logp.NewLogger("bus"), "test"), uuid.Nil, c, nil)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func drain(listener bus.Listener) []bus.Event {
+	var events []bus.Event
+	for {
+		select {
+		case event := <-listener.Events():
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package dnssrv
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/pkg/errors"
+)
+
+const etcResolvConf = "/etc/resolv.conf"
+
+// srvResolver resolves SRV records. Names that don't exist resolve to no records.
+type srvResolver interface {
+	LookupSRV(name string) ([]*net.SRV, error)
+}
+
+// newResolver returns a resolver that queries the given nameservers, or the ones in
+// /etc/resolv.conf if none is given. If there is no resolv.conf, as happens in Windows,
+// the resolver of the system is used.
+func newResolver(nameservers []string, timeout time.Duration) (srvResolver, error) {
+	if len(nameservers) == 0 {
+		config, err := dns.ClientConfigFromFile(etcResolvConf)
+		if err != nil || len(config.Servers) == 0 {
+			return &netResolver{timeout: timeout}, nil
+		}
+		for _, server := range config.Servers {
+			nameservers = append(nameservers, net.JoinHostPort(server, config.Port))
+		}
+	}
+
+	servers := make([]string, len(nameservers))
+	for i, server := range nameservers {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+			if _, _, err := net.SplitHostPort(server); err != nil {
+				return nil, errors.Wrapf(err, "invalid nameserver '%s'", nameservers[i])
+			}
+		}
+		servers[i] = server
+	}
+
+	return &miekgResolver{
+		udp:     &dns.Client{Net: "udp", Timeout: timeout},
+		tcp:     &dns.Client{Net: "tcp", Timeout: timeout},
+		servers: servers,
+	}, nil
+}
+
+// miekgResolver resolves SRV records querying the configured nameservers in order, till one
+// of them replies.
+type miekgResolver struct {
+	udp     *dns.Client
+	tcp     *dns.Client
+	servers []string
+}
+
+func (r *miekgResolver) LookupSRV(name string) ([]*net.SRV, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), dns.TypeSRV)
+	m.RecursionDesired = true
+
+	var err error
+	for _, server := range r.servers {
+		var resp *dns.Msg
+		resp, _, err = r.udp.Exchange(m, server)
+		if err == nil && resp.Truncated {
+			resp, _, err = r.tcp.Exchange(m, server)
+		}
+		if err != nil {
+			continue
+		}
+
+		switch resp.Rcode {
+		case dns.RcodeSuccess:
+		case dns.RcodeNameError:
+			return nil, nil
+		default:
+			return nil, errors.Errorf("error resolving %s: %s", name, dns.RcodeToString[resp.Rcode])
+		}
+
+		var records []*net.SRV
+		for _, answer := range resp.Answer {
+			if srv, ok := answer.(*dns.SRV); ok {
+				records = append(records, &net.SRV{
+					Target:   srv.Target,
+					Port:     srv.Port,
+					Priority: srv.Priority,
+					Weight:   srv.Weight,
+				})
+			}
+		}
+		return records, nil
+	}
+	return nil, errors.Wrapf(err, "error resolving %s", name)
+}
+
+// netResolver resolves SRV records with the resolver of the system.
+type netResolver struct {
+	timeout time.Duration
+}
+
+func (r *netResolver) LookupSRV(name string) ([]*net.SRV, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	_, records, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+	if dnsErr, ok := err.(*net.DNSError); ok && dnsErr.IsNotFound {
+		return nil, nil
+	}
+	return records, err
+}
@@ -0,0 +1,49 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filesd
+
+import (
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/autodiscover/template"
+	"github.com/elastic/beats/v7/libbeat/common"
+)
+
+// Config for the file based service discovery autodiscover provider
+type Config struct {
+	// Glob patterns of the files containing the target groups
+	Files []string `config:"files" validate:"required"`
+
+	// Time between checks for changes in the files
+	RefreshInterval time.Duration `config:"refresh_interval" validate:"positive,nonzero"`
+
+	// Prefix of the labels used as hints
+	Prefix string `config:"prefix"`
+
+	Hints     *common.Config          `config:"hints"`
+	Builders  []*common.Config        `config:"builders"`
+	Appenders []*common.Config        `config:"appenders"`
+	Templates template.MapperSettings `config:"templates"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		RefreshInterval: 10 * time.Second,
+		Prefix:          "co.elastic",
+	}
+}
@@ -0,0 +1,264 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filesd
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/v7/libbeat/autodiscover"
+	"github.com/elastic/beats/v7/libbeat/autodiscover/builder"
+	"github.com/elastic/beats/v7/libbeat/autodiscover/template"
+	"github.com/elastic/beats/v7/libbeat/cfgfile"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/common/bus"
+	"github.com/elastic/beats/v7/libbeat/common/safemapstr"
+	"github.com/elastic/beats/v7/libbeat/keystore"
+	"github.com/elastic/beats/v7/libbeat/logp"
+)
+
+func init() {
+	autodiscover.Registry.AddProvider("file_sd", AutodiscoverBuilder)
+}
+
+// Provider is the file based service discovery autodiscover provider. It watches files
+// containing lists of targets with labels, and emits events when targets are added, removed
+// or their labels change.
+type Provider struct {
+	config    *Config
+	bus       bus.Bus
+	uuid      uuid.UUID
+	builders  autodiscover.Builders
+	appenders autodiscover.Appenders
+	templates template.Mapper
+	watchers  []*cfgfile.GlobWatcher
+	targets   map[string]target
+	stop      chan struct{}
+	logger    *logp.Logger
+}
+
+// AutodiscoverBuilder builds a file based service discovery autodiscover provider, it fails
+// if there is some problem with the configuration
+func AutodiscoverBuilder(
+	beatName string,
+	bus bus.Bus,
+	uuid uuid.UUID,
+	c *common.Config,
+	keystore keystore.Keystore,
+) (autodiscover.Provider, error) {
+	errWrap := func(err error) error {
+		return errors.Wrap(err, "error setting up file_sd autodiscover provider")
+	}
+
+	config := defaultConfig()
+	err := c.Unpack(&config)
+	if err != nil {
+		return nil, errWrap(err)
+	}
+
+	var watchers []*cfgfile.GlobWatcher
+	for _, glob := range config.Files {
+		if _, err := filepath.Glob(glob); err != nil {
+			return nil, errWrap(errors.Wrapf(err, "invalid files pattern '%s'", glob))
+		}
+		watchers = append(watchers, cfgfile.NewGlobWatcher(glob))
+	}
+
+	mapper, err := template.NewConfigMapper(config.Templates, keystore, nil)
+	if err != nil {
+		return nil, errWrap(err)
+	}
+	if len(mapper.ConditionMaps) == 0 && !config.Hints.Enabled() {
+		return nil, errWrap(fmt.Errorf("no configs or hints defined for autodiscover provider"))
+	}
+
+	builders, err := autodiscover.NewBuilders(config.Builders, config.Hints, nil)
+	if err != nil {
+		return nil, errWrap(err)
+	}
+
+	appenders, err := autodiscover.NewAppenders(config.Appenders)
+	if err != nil {
+		return nil, errWrap(err)
+	}
+
+	return &Provider{
+		config:    config,
+		bus:       bus,
+		uuid:      uuid,
+		builders:  builders,
+		appenders: appenders,
+		templates: mapper,
+		watchers:  watchers,
+		targets:   make(map[string]target),
+		stop:      make(chan struct{}),
+		logger:    logp.NewLogger("autodiscover.file_sd"),
+	}, nil
+}
+
+// Start the autodiscover process
+func (p *Provider) Start() {
+	go func() {
+		ticker := time.NewTicker(p.config.RefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			p.refresh()
+
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// refresh reads the files again if any of them changed, and emits the events for the targets
+// that were added, removed or whose labels changed.
+func (p *Provider) refresh() {
+	var files []string
+	changed := false
+	for _, watcher := range p.watchers {
+		matches, updated, err := watcher.Scan()
+		if err != nil {
+			p.logger.Errorf("Error scanning target files: %v", err)
+			return
+		}
+		files = append(files, matches...)
+		changed = changed || updated
+	}
+	if !changed {
+		return
+	}
+
+	targets := make(map[string]target)
+	for _, file := range files {
+		fileTargets, err := readTargets(file)
+		if err != nil {
+			// Files can be partially written, keep their previous targets till they are valid.
+			p.logger.Errorf("Error reading targets from %s, keeping the previous ones: %v", file, err)
+			for id, t := range p.targets {
+				if t.source == file {
+					targets[id] = t
+				}
+			}
+			continue
+		}
+		for _, t := range fileTargets {
+			targets[t.id()] = t
+		}
+	}
+
+	for _, id := range sortedIDs(p.targets) {
+		if _, found := targets[id]; !found {
+			p.emit(p.targets[id], "stop")
+		}
+	}
+	for _, id := range sortedIDs(targets) {
+		if old, found := p.targets[id]; !found || !reflect.DeepEqual(old.labels, targets[id].labels) {
+			p.emit(targets[id], "start")
+		}
+	}
+	p.targets = targets
+}
+
+func (p *Provider) emit(t target, flag string) {
+	labels := common.MapStr{}
+	metaLabels := common.MapStr{}
+	for k, v := range t.labels {
+		safemapstr.Put(labels, k, v)
+		metaLabels.Put(common.DeDot(k), v)
+	}
+
+	host, port := t.hostPort()
+	event := bus.Event{
+		"provider": p.uuid,
+		"id":       t.id(),
+		flag:       true,
+		"host":     host,
+		"file_sd": common.MapStr{
+			"target": t.address,
+			"source": t.source,
+			"labels": labels,
+		},
+		"meta": common.MapStr{
+			"file_sd": common.MapStr{
+				"target": t.address,
+				"labels": metaLabels,
+			},
+		},
+	}
+	if port != 0 {
+		event["port"] = port
+	}
+
+	p.publish(event)
+}
+
+func (p *Provider) publish(event bus.Event) {
+	if config := p.templates.GetConfig(event); config != nil {
+		event["config"] = config
+	} else if config := p.builders.GetConfig(p.generateHints(event)); config != nil {
+		event["config"] = config
+	}
+
+	p.appenders.Append(event)
+	p.bus.Publish(event)
+}
+
+func (p *Provider) generateHints(event bus.Event) bus.Event {
+	// Try to build a config with enabled builders. Send a provider agnostic payload.
+	// Builders are Beat specific.
+	e := bus.Event{}
+	if host, ok := event["host"]; ok {
+		e["host"] = host
+	}
+	if port, ok := event["port"]; ok {
+		e["port"] = port
+	}
+	if labels, err := common.MapStr(event).GetValue("file_sd.labels"); err == nil {
+		e["hints"] = builder.GenerateHints(labels.(common.MapStr), "", p.config.Prefix)
+	}
+	return e
+}
+
+// Stop the autodiscover process
+func (p *Provider) Stop() {
+	close(p.stop)
+}
+
+// String returns the name of the provider
+func (p *Provider) String() string {
+	return "file_sd"
+}
+
+func sortedIDs(targets map[string]target) []string {
+	ids := make([]string, 0, len(targets))
+	for id := range targets {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,225 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filesd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/common/bus"
+	"github.com/elastic/beats/v7/libbeat/logp"
+)
+
+func TestReadTargets(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := writeFile(t, dir, "targets.json", `[
+  {"targets": ["10.0.0.1:9100", "10.0.0.2:9100"], "labels": {"env": "prod"}},
+  {"targets": ["10.0.0.2:9100", "db.local"], "labels": {"env": "staging", "role": "db"}}
+]`)
+
+	targets, err := readTargets(path)
+	require.NoError(t, err)
+	assert.Equal(t, []target{
+		{source: path, address: "10.0.0.1:9100", labels: map[string]string{"env": "prod"}},
+		{source: path, address: "10.0.0.2:9100", labels: map[string]string{"env": "staging", "role": "db"}},
+		{source: path, address: "db.local", labels: map[string]string{"env": "staging", "role": "db"}},
+	}, targets)
+
+	host, port := targets[0].hostPort()
+	assert.Equal(t, "10.0.0.1", host)
+	assert.Equal(t, 9100, port)
+	host, port = targets[2].hostPort()
+	assert.Equal(t, "db.local", host)
+	assert.Equal(t, 0, port)
+
+	path = writeFile(t, dir, "invalid.yml", `targets: 10.0.0.1`)
+	_, err = readTargets(path)
+	assert.Error(t, err)
+}
+
+func TestProviderEvents(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := writeFile(t, dir, "targets.yml", `
+- targets: ["10.0.0.1:9100", "10.0.0.2:9100"]
+  labels:
+    env: prod
+- targets: ["10.0.0.3:9100"]
+  labels:
+    env: staging
+`)
+
+	b := bus.New(logp.NewLogger("bus"), "test")
+	listener := b.Subscribe()
+	defer listener.Stop()
+
+	p := newTestProvider(t, b, common.MapStr{
+		"files": []string{filepath.Join(dir, "*.yml")},
+		"templates": []common.MapStr{{
+			"condition": common.MapStr{
+				"equals.file_sd.labels.env": "prod",
+			},
+			"config": []common.MapStr{{"hosts": []string{"${data.host}:${data.port}"}}},
+		}},
+	})
+
+	p.refresh()
+	events := drain(listener)
+	require.Len(t, events, 3)
+	assert.Equal(t, path+":10.0.0.1:9100", events[0]["id"])
+	assert.Equal(t, true, events[0]["start"])
+	assert.Equal(t, "10.0.0.1", events[0]["host"])
+	assert.Equal(t, 9100, events[0]["port"])
+	assert.Equal(t, common.MapStr{
+		"target": "10.0.0.1:9100",
+		"source": path,
+		"labels": common.MapStr{"env": "prod"},
+	}, events[0]["file_sd"])
+	assert.Len(t, events[0]["config"], 1)
+	assert.Empty(t, events[2]["config"])
+
+	// Nothing changed
+	p.refresh()
+	assert.Empty(t, drain(listener))
+
+	// A target is removed and the labels of other one change
+	writeFile(t, dir, "targets.yml", `
+- targets: ["10.0.0.1:9100"]
+  labels:
+    env: prod
+- targets: ["10.0.0.3:9100"]
+  labels:
+    env: prod
+`)
+	p.refresh()
+	events = drain(listener)
+	require.Len(t, events, 2)
+	assert.Equal(t, path+":10.0.0.2:9100", events[0]["id"])
+	assert.Equal(t, true, events[0]["stop"])
+	assert.Equal(t, path+":10.0.0.3:9100", events[1]["id"])
+	assert.Equal(t, true, events[1]["start"])
+	assert.Len(t, events[1]["config"], 1)
+
+	// Invalid files keep their previous targets
+	writeFile(t, dir, "targets.yml", `- targets: [`)
+	p.refresh()
+	assert.Empty(t, drain(listener))
+
+	// Removed files stop their targets
+	require.NoError(t, os.Remove(path))
+	p.refresh()
+	events = drain(listener)
+	require.Len(t, events, 2)
+	for _, event := range events {
+		assert.Equal(t, true, event["stop"])
+	}
+}
+
+func TestGenerateHints(t *testing.T) {
+	p := &Provider{config: defaultConfig()}
+	event := bus.Event{
+		"host": "10.0.0.1",
+		"port": 6379,
+		"file_sd": common.MapStr{
+			"labels": common.MapStr{
+				"env": "prod",
+				"co": common.MapStr{
+					"elastic": common.MapStr{
+						"metrics/module": "redis",
+					},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, bus.Event{
+		"host": "10.0.0.1",
+		"port": 6379,
+		"hints": common.MapStr{
+			"metrics": common.MapStr{
+				"module": "redis",
+			},
+		},
+	}, p.generateHints(event))
+}
+
+func TestAutodiscoverBuilderErrors(t *testing.T) {
+	for name, cfg := range map[string]common.MapStr{
+		"no files": {
+			"templates": []common.MapStr{{"config": []common.MapStr{{"hosts": "${data.host}"}}}},
+		},
+		"no templates": {
+			"files": []string{"/etc/targets/*.yml"},
+		},
+		"invalid glob": {
+			"files":     []string{"/etc/targets/[.yml"},
+			"templates": []common.MapStr{{"config": []common.MapStr{{"hosts": "${data.host}"}}}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, err := common.NewConfigFrom(cfg)
+			require.NoError(t, err)
+			_, err = AutodiscoverBuilder("mockbeat", bus.New(logp.NewLogger("bus"), "test"), uuid.Nil, c, nil)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func newTestProvider(t *testing.T, b bus.Bus, cfg common.MapStr) *Provider {
+	c, err := common.NewConfigFrom(cfg)
+	require.NoError(t, err)
+	id, err := uuid.NewV4()
+	require.NoError(t, err)
+	p, err := AutodiscoverBuilder("mockbeat", b, id, c, nil)
+	require.NoError(t, err)
+	return p.(*Provider)
+}
+
+func drain(listener bus.Listener) []bus.Event {
+	var events []bus.Event
+	for {
+		select {
+		case event := <-listener.Events():
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "file_sd")
+	require.NoError(t, err)
+	return dir
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	return path
+}
@@ -0,0 +1,99 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filesd
+
+import (
+	"io/ioutil"
+	"net"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// targetGroup is a list of targets that share the same labels, in the format used by the
+// file based service discovery of Prometheus.
+type targetGroup struct {
+	Targets []string          `yaml:"targets"`
+	Labels  map[string]string `yaml:"labels"`
+}
+
+// target is a target discovered in a file.
+type target struct {
+	source  string
+	address string
+	labels  map[string]string
+}
+
+// id identifies the target, the same address can be discovered in different files.
+func (t *target) id() string {
+	return t.source + ":" + t.address
+}
+
+// hostPort returns the host and the port of the target address, the port is 0 if the
+// address doesn't contain one.
+func (t *target) hostPort() (string, int) {
+	host, rawPort, err := net.SplitHostPort(t.address)
+	if err != nil {
+		return t.address, 0
+	}
+	port, err := strconv.Atoi(rawPort)
+	if err != nil {
+		return t.address, 0
+	}
+	return host, port
+}
+
+// readTargets reads the target groups from a JSON or YAML file. Labels of targets that appear
+// in several groups of the same file are merged, with the later groups taking precedence.
+func readTargets(path string) ([]target, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []targetGroup
+	if err := yaml.Unmarshal(content, &groups); err != nil {
+		return nil, errors.Wrap(err, "invalid target groups")
+	}
+
+	var targets []target
+	index := map[string]int{}
+	for _, group := range groups {
+		for _, address := range group.Targets {
+			if address == "" {
+				continue
+			}
+
+			i, found := index[address]
+			if !found {
+				i = len(targets)
+				index[address] = i
+				targets = append(targets, target{
+					source:  path,
+					address: address,
+					labels:  map[string]string{},
+				})
+			}
+			for k, v := range group.Labels {
+				targets[i].labels[k] = v
+			}
+		}
+	}
+	return targets, nil
+}
@@ -55,12 +55,13 @@ var (
 	moduleRunning = monitoring.NewInt(nil, "libbeat.config.module.running") // Number of modules in the runner list (not necessarily in the running state).
 )
 
-// DynamicConfig loads config files from a given path, allowing to reload new changes
-// while running the beat
+// DynamicConfig loads config files from a given path, or from a remote endpoint, allowing
+// to reload new changes while running the beat
 type DynamicConfig struct {
 	// If path is a relative path, it is relative to the ${path.config}
-	Path   string `config:"path"`
-	Reload Reload `config:"reload"`
+	Path   string        `config:"path"`
+	Remote *RemoteConfig `config:"remote"`
+	Reload Reload        `config:"reload"`
 }
 
 // Reload defines reload behavior and frequency
@@ -100,6 +101,8 @@ type Reloader struct {
 	pipeline beat.PipelineConnector
 	config   DynamicConfig
 	path     string
+	remote   *remoteSource
+	err      error // Error in the remote config settings
 	done     chan struct{}
 	wg       sync.WaitGroup
 }
@@ -107,23 +110,39 @@ type Reloader struct {
 // NewReloader creates new Reloader instance for the given config
 func NewReloader(pipeline beat.PipelineConnector, cfg *common.Config) *Reloader {
 	config := DefaultDynamicConfig
-	cfg.Unpack(&config)
+	err := cfg.Unpack(&config)
 
 	path := config.Path
 	if !filepath.IsAbs(path) {
 		path = paths.Resolve(paths.Config, path)
 	}
 
+	var remote *remoteSource
+	var remoteErr error
+	if cfg.HasField("remote") {
+		if remoteErr = err; remoteErr == nil {
+			remote, remoteErr = newRemoteSource(*config.Remote)
+		}
+	}
+
 	return &Reloader{
 		pipeline: pipeline,
 		config:   config,
 		path:     path,
+		remote:   remote,
+		err:      remoteErr,
 		done:     make(chan struct{}),
 	}
 }
 
 // Check configs are valid (only if reload is disabled)
 func (rl *Reloader) Check(runnerFactory RunnerFactory) error {
+	if rl.remote != nil || rl.err != nil {
+		// Remote configs are checked before being applied, an invalid or unreachable
+		// remote config must not prevent the beat from starting.
+		return errors.Wrap(rl.err, "invalid remote config settings")
+	}
+
 	// If config reload is enabled we ignore errors (as they may be fixed afterwards)
 	if rl.config.Reload.Enabled {
 		return nil
@@ -161,6 +180,15 @@ func (rl *Reloader) Check(runnerFactory RunnerFactory) error {
 
 // Run runs the reloader
 func (rl *Reloader) Run(runnerFactory RunnerFactory) {
+	if rl.err != nil {
+		logp.Err("Config reloader not started, invalid remote config settings: %v", rl.err)
+		return
+	}
+	if rl.remote != nil {
+		rl.runRemote(runnerFactory)
+		return
+	}
+
 	logp.Info("Config reloader started")
 
 	list := NewRunnerList("reload", runnerFactory, rl.pipeline)
@@ -241,6 +269,16 @@ func (rl *Reloader) Load(runnerFactory RunnerFactory) {
 	// Stop all running modules when method finishes
 	defer list.Stop()
 
+	if rl.err != nil {
+		logp.Err("Error loading remote config: %v", rl.err)
+		return
+	}
+	if rl.remote != nil {
+		rl.remote.init(list, runnerFactory)
+		logp.Info("Loading of remote config completed.")
+		return
+	}
+
 	gw := NewGlobWatcher(rl.path)
 
 	debugf("Scan for config files")
@@ -282,6 +320,45 @@ func (rl *Reloader) loadConfigs(files []string) ([]*reload.ConfigWithMeta, error
 	return result, errs.Err()
 }
 
+// runRemote periodically fetches the configs from the remote endpoint, applying them when
+// they change.
+func (rl *Reloader) runRemote(runnerFactory RunnerFactory) {
+	logp.Info("Remote config reloader started for %s", rl.remote.config.URL)
+
+	list := NewRunnerList("reload", runnerFactory, rl.pipeline)
+
+	rl.wg.Add(1)
+	defer rl.wg.Done()
+
+	// Stop all running modules when method finishes
+	defer list.Stop()
+
+	configScans.Add(1)
+	rl.remote.init(list, runnerFactory)
+
+	// Reloading disabled, the config is fetched only once.
+	if !rl.config.Reload.Enabled {
+		<-rl.done
+		logp.Info("Dynamic config reloader stopped")
+		return
+	}
+
+	ticker := time.NewTicker(rl.config.Reload.Period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-rl.done:
+			logp.Info("Dynamic config reloader stopped")
+			return
+
+		case <-ticker.C:
+			debugf("Fetch remote config")
+			configScans.Add(1)
+			rl.remote.update(list, runnerFactory)
+		}
+	}
+}
+
 // Stop stops the reloader and waits for all modules to properly stop
 func (rl *Reloader) Stop() {
 	close(rl.done)
@@ -0,0 +1,299 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cfgfile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/joeshaw/multierror"
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/common/file"
+	"github.com/elastic/beats/v7/libbeat/common/reload"
+	"github.com/elastic/beats/v7/libbeat/common/transport/tlscommon"
+	"github.com/elastic/beats/v7/libbeat/logp"
+	"github.com/elastic/beats/v7/libbeat/monitoring"
+	"github.com/elastic/beats/v7/libbeat/paths"
+)
+
+// maxRemoteConfigSize is the maximum size of a configuration fetched from a remote endpoint.
+const maxRemoteConfigSize = 10 * 1024 * 1024
+
+var (
+	// remoteFetches measures how many times configs were fetched from remote
+	// endpoints, remoteErrors how many of them failed, and remoteRejected how
+	// many fetched configs were not applied because they were invalid.
+	remoteFetches  = monitoring.NewInt(nil, "libbeat.config.remote.fetches")
+	remoteErrors   = monitoring.NewInt(nil, "libbeat.config.remote.errors")
+	remoteRejected = monitoring.NewInt(nil, "libbeat.config.remote.rejected")
+)
+
+// RemoteConfig defines an HTTP(S) endpoint serving a list of configurations, in the same
+// format as the files loaded from the local path.
+type RemoteConfig struct {
+	URL      string            `config:"url" validate:"required"`
+	Username string            `config:"username"`
+	Password string            `config:"password"`
+	Headers  map[string]string `config:"headers"`
+	Timeout  time.Duration     `config:"timeout" validate:"positive"`
+	TLS      *tlscommon.Config `config:"ssl"`
+
+	// File where the last known good configuration is kept. If it is a relative path,
+	// it is relative to the ${path.data}
+	CachePath string `config:"cache_path"`
+}
+
+var defaultRemoteConfig = RemoteConfig{
+	Timeout: 30 * time.Second,
+}
+
+// Unpack sets the defaults of the remote configuration.
+func (c *RemoteConfig) Unpack(from *common.Config) error {
+	type tmpConfig RemoteConfig
+	tmp := tmpConfig(defaultRemoteConfig)
+	if err := from.Unpack(&tmp); err != nil {
+		return err
+	}
+	*c = RemoteConfig(tmp)
+	return nil
+}
+
+// remoteSource fetches configurations from a remote endpoint. It validates them before they
+// are applied, and keeps the last known good configuration on disk, so it can be used when
+// the endpoint is unreachable.
+type remoteSource struct {
+	config    RemoteConfig
+	client    *http.Client
+	cachePath string
+	logger    *logp.Logger
+
+	// ETag and hash of the last fetched content, used to skip unmodified contents.
+	etag     string
+	lastHash string
+
+	// Last configuration applied successfully.
+	lastGood []*reload.ConfigWithMeta
+}
+
+func newRemoteSource(config RemoteConfig) (*remoteSource, error) {
+	tlsConfig, err := tlscommon.LoadTLSConfig(config.TLS)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid TLS configuration for remote config")
+	}
+
+	cachePath := config.CachePath
+	if cachePath == "" {
+		sum := sha256.Sum256([]byte(config.URL))
+		cachePath = filepath.Join("remote_config", hex.EncodeToString(sum[:8])+".yml")
+	}
+	if !filepath.IsAbs(cachePath) {
+		cachePath = paths.Resolve(paths.Data, cachePath)
+	}
+
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig.ToConfig()
+	}
+
+	return &remoteSource{
+		config: config,
+		client: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: transport,
+		},
+		cachePath: cachePath,
+		logger:    logp.NewLogger("cfgfile.remote"),
+	}, nil
+}
+
+// init applies the remote configuration, or the last known good one if the endpoint is
+// unreachable or serves an invalid configuration.
+func (s *remoteSource) init(list *RunnerList, factory RunnerFactory) {
+	if s.update(list, factory) {
+		return
+	}
+
+	content, err := ioutil.ReadFile(s.cachePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.logger.Errorf("Error reading last known good config from %s: %v", s.cachePath, err)
+		}
+		return
+	}
+
+	configs, err := s.parse(content, factory)
+	if err != nil {
+		s.logger.Errorf("Invalid last known good config in %s: %v", s.cachePath, err)
+		return
+	}
+
+	s.logger.Infof("Using last known good config from %s", s.cachePath)
+	if err := list.Reload(configs); err != nil {
+		s.logger.Errorf("Error applying last known good config: %v", err)
+	}
+	s.lastGood = configs
+}
+
+// update fetches the configuration and applies it if it changed and it is valid. If it cannot
+// be applied, the last known good configuration is restored. It returns true if the fetched
+// configuration is running after the update.
+func (s *remoteSource) update(list *RunnerList, factory RunnerFactory) bool {
+	content, etag, modified, err := s.fetch()
+	if err != nil {
+		remoteErrors.Add(1)
+		s.logger.Errorf("Error fetching config from %s: %v", s.config.URL, err)
+		return false
+	}
+	hash := contentHash(content)
+	if !modified || hash == s.lastHash {
+		s.etag = etag
+		return s.lastGood != nil
+	}
+
+	configs, err := s.parse(content, factory)
+	if err != nil {
+		// Remember the ETag of invalid configs so they are not fetched again until they change.
+		s.etag, s.lastHash = etag, hash
+		remoteRejected.Add(1)
+		s.logger.Errorf("Invalid config fetched from %s, keeping the last known good config: %v", s.config.URL, err)
+		return false
+	}
+
+	s.logger.Infof("Applying config fetched from %s", s.config.URL)
+	if err := list.Reload(configs); err != nil {
+		s.logger.Errorf("Error applying config fetched from %s, restoring the last known good config: %v", s.config.URL, err)
+		if s.lastGood != nil {
+			if err := list.Reload(s.lastGood); err != nil {
+				s.logger.Errorf("Error restoring the last known good config: %v", err)
+			}
+		}
+		// The config is fetched and applied again in the next update.
+		s.etag, s.lastHash = "", ""
+		return false
+	}
+
+	s.etag, s.lastHash = etag, hash
+	s.lastGood = configs
+	if err := s.save(content); err != nil {
+		s.logger.Errorf("Error saving last known good config to %s: %v", s.cachePath, err)
+	}
+	return true
+}
+
+// fetch requests the configuration, it returns modified false if the server replied that
+// the content didn't change since the last request.
+func (s *remoteSource) fetch() (content []byte, etag string, modified bool, err error) {
+	remoteFetches.Add(1)
+
+	req, err := http.NewRequest(http.MethodGet, s.config.URL, nil)
+	if err != nil {
+		return nil, "", false, err
+	}
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+	if s.config.Username != "" || s.config.Password != "" {
+		req.SetBasicAuth(s.config.Username, s.config.Password)
+	}
+	for k, v := range s.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, s.etag, false, nil
+	default:
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, "", false, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	content, err = ioutil.ReadAll(io.LimitReader(resp.Body, maxRemoteConfigSize+1))
+	if err != nil {
+		return nil, "", false, err
+	}
+	if len(content) > maxRemoteConfigSize {
+		return nil, "", false, fmt.Errorf("config is bigger than %d bytes", maxRemoteConfigSize)
+	}
+	return content, resp.Header.Get("ETag"), true, nil
+}
+
+// parse reads the list of configurations and checks that the enabled ones can be used to
+// create runners.
+func (s *remoteSource) parse(content []byte, factory RunnerFactory) ([]*reload.ConfigWithMeta, error) {
+	rawConfig, err := common.NewConfigWithYAML(content, s.config.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid config")
+	}
+
+	var list []*common.Config
+	if err := rawConfig.Unpack(&list); err != nil {
+		return nil, errors.Wrap(err, "error reading configuration")
+	}
+
+	var errs multierror.Errors
+	configs := make([]*reload.ConfigWithMeta, 0, len(list))
+	for _, c := range list {
+		if c.Enabled() {
+			if err := factory.CheckConfig(c); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		configs = append(configs, &reload.ConfigWithMeta{Config: c})
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+// save writes the content to the cache file atomically.
+func (s *remoteSource) save(content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(s.cachePath), 0750); err != nil {
+		return err
+	}
+
+	tmp := s.cachePath + ".new"
+	if err := ioutil.WriteFile(tmp, content, 0600); err != nil {
+		return err
+	}
+	return file.SafeFileRotate(s.cachePath, tmp)
+}
+
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(bytes.TrimSpace(content))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,235 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cfgfile
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+)
+
+// checkingRunnerFactory rejects configs with negative ids in CheckConfig, and fails to
+// create runners for ids greater than 100, as happens with errors only detected on start.
+type checkingRunnerFactory struct {
+	runnerFactory
+}
+
+func (f *checkingRunnerFactory) Create(p beat.PipelineConnector, c *common.Config) (Runner, error) {
+	config := struct {
+		ID int64 `config:"id"`
+	}{}
+	if err := c.Unpack(&config); err != nil {
+		return nil, err
+	}
+	if config.ID > 100 {
+		return nil, errors.New("cannot start runner")
+	}
+	return f.runnerFactory.Create(p, c)
+}
+
+func (f *checkingRunnerFactory) CheckConfig(c *common.Config) error {
+	config := struct {
+		ID int64 `config:"id"`
+	}{}
+	if err := c.Unpack(&config); err != nil {
+		return err
+	}
+	if config.ID < 0 {
+		return errors.New("invalid id")
+	}
+	return nil
+}
+
+type configServer struct {
+	sync.Mutex
+	content  string
+	etag     string
+	requests int
+	notMod   int
+	auth     string
+}
+
+func (s *configServer) set(content, etag string) {
+	s.Lock()
+	defer s.Unlock()
+	s.content, s.etag = content, etag
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.requests++
+	s.auth = r.Header.Get("Authorization")
+	if s.etag != "" && r.Header.Get("If-None-Match") == s.etag {
+		s.notMod++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if s.etag != "" {
+		w.Header().Set("ETag", s.etag)
+	}
+	w.Write([]byte(s.content))
+}
+
+func newTestRemoteSource(t *testing.T, url, cachePath string) *remoteSource {
+	cfg := common.MustNewConfigFrom(map[string]interface{}{
+		"url":        url,
+		"headers":    map[string]string{"Authorization": "Bearer secret"},
+		"cache_path": cachePath,
+	})
+	var config RemoteConfig
+	require.NoError(t, cfg.Unpack(&config))
+	source, err := newRemoteSource(config)
+	require.NoError(t, err)
+	return source
+}
+
+func runningIDs(t *testing.T, list *RunnerList) []int64 {
+	var ids []int64
+	for _, r := range list.copyRunnerList() {
+		ids = append(ids, r.(*runner).id)
+	}
+	return ids
+}
+
+func TestRemoteSourceUpdates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remote-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	cachePath := filepath.Join(dir, "cache", "modules.yml")
+
+	server := &configServer{}
+	server.set("- id: 1\n- id: 2\n", `"v1"`)
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	factory := &checkingRunnerFactory{}
+	list := NewRunnerList("test", factory, nil)
+	defer list.Stop()
+	source := newTestRemoteSource(t, httpServer.URL, cachePath)
+
+	source.init(list, factory)
+	assert.ElementsMatch(t, []int64{1, 2}, runningIDs(t, list))
+	assert.Equal(t, "Bearer secret", server.auth)
+	cached, err := ioutil.ReadFile(cachePath)
+	require.NoError(t, err)
+	assert.Equal(t, "- id: 1\n- id: 2\n", string(cached))
+
+	// Not modified
+	assert.True(t, source.update(list, factory))
+	assert.Equal(t, 1, server.notMod)
+	assert.ElementsMatch(t, []int64{1, 2}, runningIDs(t, list))
+
+	// Invalid configs are rejected and not fetched again until they change
+	server.set("- id: 1\n- id: -1\n", `"v2"`)
+	assert.False(t, source.update(list, factory))
+	assert.ElementsMatch(t, []int64{1, 2}, runningIDs(t, list))
+	source.update(list, factory)
+	assert.Equal(t, 2, server.notMod)
+
+	// Configs that fail to start are rolled back and retried
+	server.set("- id: 1\n- id: 101\n", `"v3"`)
+	assert.False(t, source.update(list, factory))
+	assert.ElementsMatch(t, []int64{1, 2}, runningIDs(t, list))
+	requests := server.requests
+	source.update(list, factory)
+	assert.Equal(t, requests+1, server.requests)
+	assert.Equal(t, 2, server.notMod)
+
+	// Valid configs are applied
+	server.set("- id: 3\n", "")
+	assert.True(t, source.update(list, factory))
+	assert.ElementsMatch(t, []int64{3}, runningIDs(t, list))
+	cached, err = ioutil.ReadFile(cachePath)
+	require.NoError(t, err)
+	assert.Equal(t, "- id: 3\n", string(cached))
+
+	// Without ETag, unchanged content is not applied again
+	starts := len(factory.runners)
+	assert.True(t, source.update(list, factory))
+	assert.Equal(t, starts, len(factory.runners))
+}
+
+func TestRemoteSourceLastKnownGood(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remote-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	cachePath := filepath.Join(dir, "modules.yml")
+	require.NoError(t, ioutil.WriteFile(cachePath, []byte("- id: 7\n"), 0600))
+
+	t.Run("unreachable server", func(t *testing.T) {
+		httpServer := httptest.NewServer(http.NotFoundHandler())
+		httpServer.Close()
+
+		factory := &checkingRunnerFactory{}
+		list := NewRunnerList("test", factory, nil)
+		defer list.Stop()
+
+		source := newTestRemoteSource(t, httpServer.URL, cachePath)
+		source.init(list, factory)
+		assert.ElementsMatch(t, []int64{7}, runningIDs(t, list))
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		server := &configServer{}
+		server.set("- id: -1\n", "")
+		httpServer := httptest.NewServer(server)
+		defer httpServer.Close()
+
+		factory := &checkingRunnerFactory{}
+		list := NewRunnerList("test", factory, nil)
+		defer list.Stop()
+
+		source := newTestRemoteSource(t, httpServer.URL, cachePath)
+		source.init(list, factory)
+		assert.ElementsMatch(t, []int64{7}, runningIDs(t, list))
+
+		// The server recovers
+		server.set("- id: 8\n", "")
+		assert.True(t, source.update(list, factory))
+		assert.ElementsMatch(t, []int64{8}, runningIDs(t, list))
+	})
+}
+
+func TestReloaderRemoteCheck(t *testing.T) {
+	factory := &checkingRunnerFactory{}
+
+	// Unreachable remote configs don't fail the check
+	reloader := NewReloader(nil, common.MustNewConfigFrom(map[string]interface{}{
+		"remote.url": "http://localhost:1/modules.yml",
+	}))
+	assert.NoError(t, reloader.Check(factory))
+
+	// Invalid settings fail it
+	reloader = NewReloader(nil, common.MustNewConfigFrom(map[string]interface{}{
+		"remote.timeout": "1s",
+	}))
+	assert.Error(t, reloader.Check(factory))
+}
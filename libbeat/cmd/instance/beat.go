@@ -29,6 +29,7 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"reflect"
 	"runtime"
 	"strings"
 	"time"
@@ -65,6 +66,7 @@ import (
 	"github.com/elastic/beats/v7/libbeat/outputs/elasticsearch"
 	"github.com/elastic/beats/v7/libbeat/paths"
 	"github.com/elastic/beats/v7/libbeat/plugin"
+	"github.com/elastic/beats/v7/libbeat/publisher/logpublish"
 	"github.com/elastic/beats/v7/libbeat/publisher/pipeline"
 	"github.com/elastic/beats/v7/libbeat/publisher/processing"
 	svc "github.com/elastic/beats/v7/libbeat/service"
@@ -363,6 +365,9 @@ func (b *Beat) createBeater(bt beat.Creator) (beat.Beater, error) {
 	}
 
 	reload.Register.MustRegister("output", b.makeOutputReloader(publisher.OutputReloader()))
+	if outputEnabled && !b.Manager.Enabled() {
+		b.reconnectOutputOnSecretChanges(publisher.OutputReloader())
+	}
 
 	// TODO: some beats race on shutdown with publisher.Stop -> do not call Stop yet,
 	//       but refine publisher to disconnect clients on stop automatically
@@ -437,6 +442,14 @@ func (b *Beat) launch(settings Settings, bt beat.Creator) error {
 		return err
 	}
 
+	if queue := logp.PublishQueue(); queue != nil && b.Publisher != nil {
+		p, err := logpublish.New(b.Info, b.Publisher, queue, b.Config.Output.Name())
+		if err != nil {
+			return errw.Wrap(err, "failed to publish the logs")
+		}
+		defer p.Stop()
+	}
+
 	r, err := b.setupMonitoring(settings)
 	if err != nil {
 		return err
@@ -861,6 +874,51 @@ func (b *Beat) makeOutputReloader(outReloader pipeline.OutputReloader) reload.Re
 	})
 }
 
+// reconnectOutputOnSecretChanges reloads the output when the secrets resolved in its
+// configuration change in the keystore, so it reconnects with the new credentials.
+func (b *Beat) reconnectOutputOnSecretChanges(outReloader pipeline.OutputReloader) {
+	store, ok := b.keystore.(keystore.WatchingKeystore)
+	if !ok {
+		return
+	}
+
+	output := b.Config.Output
+	resolved, err := resolveOutputConfig(output)
+	if err != nil {
+		logp.Warn("Failed to resolve output configuration, it won't be reconnected when secrets change: %v", err)
+		return
+	}
+
+	store.OnChange(func(keys []string) {
+		current, err := resolveOutputConfig(output)
+		if err != nil {
+			logp.Err("Failed to resolve output configuration after secrets changed: %v", err)
+			return
+		}
+		if reflect.DeepEqual(resolved, current) {
+			return
+		}
+		resolved = current
+
+		logp.Info("Secrets used by the %s output changed, reconnecting output", output.Name())
+		cfg := common.NewConfig()
+		if err := cfg.SetChild(output.Name(), -1, output.Config()); err != nil {
+			logp.Err("Failed to reload output: %v", err)
+			return
+		}
+		if err := outReloader.Reload(&reload.ConfigWithMeta{Config: cfg}, b.createOutput); err != nil {
+			logp.Err("Failed to reload output: %v", err)
+		}
+	})
+}
+
+// resolveOutputConfig returns the output configuration with all the variables resolved.
+func resolveOutputConfig(output common.ConfigNamespace) (map[string]interface{}, error) {
+	var resolved map[string]interface{}
+	err := output.Config().Unpack(&resolved)
+	return resolved, err
+}
+
 func (b *Beat) makeOutputFactory(
 	cfg common.ConfigNamespace,
 ) func(outputs.Observer) (string, outputs.Group, error) {
@@ -19,9 +19,13 @@ package instance
 
 import (
 	_ "github.com/elastic/beats/v7/libbeat/autodiscover/appenders/config" // Register autodiscover appenders
+	_ "github.com/elastic/beats/v7/libbeat/autodiscover/providers/dnssrv"
+	_ "github.com/elastic/beats/v7/libbeat/autodiscover/providers/filesd"
 	_ "github.com/elastic/beats/v7/libbeat/autodiscover/providers/jolokia"
 	_ "github.com/elastic/beats/v7/libbeat/monitoring/report/elasticsearch" // Register default monitoring reporting
-	_ "github.com/elastic/beats/v7/libbeat/processors/actions"              // Register default processors.
+	_ "github.com/elastic/beats/v7/libbeat/monitoring/report/file"
+	_ "github.com/elastic/beats/v7/libbeat/monitoring/report/otlp"
+	_ "github.com/elastic/beats/v7/libbeat/processors/actions" // Register default processors.
 	_ "github.com/elastic/beats/v7/libbeat/processors/add_cloud_metadata"
 	_ "github.com/elastic/beats/v7/libbeat/processors/add_host_metadata"
 	_ "github.com/elastic/beats/v7/libbeat/processors/add_id"
@@ -0,0 +1,192 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keystore
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/logp"
+)
+
+// cachingKeystore wraps a secret provider, keeping the retrieved secrets during a TTL so the
+// provider is not queried every time the configuration is unpacked.
+type cachingKeystore struct {
+	keystore Keystore
+	ttl      time.Duration
+	now      func() time.Time
+	logger   *logp.Logger
+
+	mutex   sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// batchRetriever is implemented by providers that can retrieve several keys with a single
+// query, like the Vault provider that reads all the fields of a secret at once. Keys that don't
+// exist are not included in the result.
+type batchRetriever interface {
+	RetrieveAll(keys []string) (map[string]*SecureString, error)
+}
+
+type cacheEntry struct {
+	value   []byte
+	found   bool
+	expires time.Time
+}
+
+func newCachingKeystore(keystore Keystore, ttl time.Duration) *cachingKeystore {
+	return &cachingKeystore{
+		keystore: keystore,
+		ttl:      ttl,
+		now:      time.Now,
+		logger:   logp.NewLogger("keystore"),
+		entries:  make(map[string]cacheEntry),
+	}
+}
+
+// Retrieve returns the cached secret, or queries the provider if it is not cached or expired.
+// The provider is queried without holding the lock, so a slow provider doesn't block the
+// retrieval of cached secrets.
+func (c *cachingKeystore) Retrieve(key string) (*SecureString, error) {
+	c.mutex.Lock()
+	entry, cached := c.entries[key]
+	c.mutex.Unlock()
+
+	if !cached || !c.now().Before(entry.expires) {
+		var err error
+		entry, err = c.fetch(key)
+		if err != nil {
+			return nil, err
+		}
+		c.mutex.Lock()
+		c.entries[key] = entry
+		c.mutex.Unlock()
+	}
+
+	if !entry.found {
+		return nil, ErrKeyDoesntExists
+	}
+	return NewSecureString(append([]byte(nil), entry.value...)), nil
+}
+
+// GetConfig returns nil, secrets are retrieved lazily so they cannot be listed upfront.
+func (c *cachingKeystore) GetConfig() (*common.Config, error) {
+	return nil, nil
+}
+
+// IsPersisted returns if the wrapped provider is persisted.
+func (c *cachingKeystore) IsPersisted() bool {
+	return c.keystore.IsPersisted()
+}
+
+// refresh queries the provider again for all the keys retrieved so far, and returns the keys
+// whose values changed. Keys that cannot be retrieved keep their previous values.
+func (c *cachingKeystore) refresh() []string {
+	c.mutex.Lock()
+	old := make(map[string]cacheEntry, len(c.entries))
+	keys := make([]string, 0, len(c.entries))
+	for key, entry := range c.entries {
+		old[key] = entry
+		keys = append(keys, key)
+	}
+	c.mutex.Unlock()
+
+	entries, errs := c.fetchAll(keys)
+	for key, err := range errs {
+		c.logger.Warnf("Failed to refresh secret '%s', keeping the previous value: %v", key, err)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var changed []string
+	for key, entry := range entries {
+		c.entries[key] = entry
+		prev := old[key]
+		if entry.found != prev.found || !bytes.Equal(entry.value, prev.value) {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func (c *cachingKeystore) fetch(key string) (cacheEntry, error) {
+	secret, err := c.keystore.Retrieve(key)
+	if err == ErrKeyDoesntExists {
+		return c.newEntry(nil)
+	}
+	if err != nil {
+		return cacheEntry{}, err
+	}
+	return c.newEntry(secret)
+}
+
+// fetchAll retrieves the given keys, with a single query if the provider supports it. Keys that
+// cannot be retrieved are returned with their errors.
+func (c *cachingKeystore) fetchAll(keys []string) (map[string]cacheEntry, map[string]error) {
+	entries := make(map[string]cacheEntry, len(keys))
+	errs := make(map[string]error)
+
+	batch, ok := c.keystore.(batchRetriever)
+	if !ok {
+		for _, key := range keys {
+			entry, err := c.fetch(key)
+			if err != nil {
+				errs[key] = err
+				continue
+			}
+			entries[key] = entry
+		}
+		return entries, errs
+	}
+
+	secrets, err := batch.RetrieveAll(keys)
+	for _, key := range keys {
+		if err != nil {
+			errs[key] = err
+			continue
+		}
+		entry, err := c.newEntry(secrets[key])
+		if err != nil {
+			errs[key] = err
+			continue
+		}
+		entries[key] = entry
+	}
+	return entries, errs
+}
+
+// newEntry returns a cache entry for the secret, or for a missing key if the secret is nil.
+func (c *cachingKeystore) newEntry(secret *SecureString) (cacheEntry, error) {
+	entry := cacheEntry{expires: c.now().Add(c.ttl)}
+	if secret == nil {
+		return entry, nil
+	}
+
+	var err error
+	entry.value, err = secret.Get()
+	if err != nil {
+		return cacheEntry{}, err
+	}
+	entry.found = true
+	return entry, nil
+}
@@ -0,0 +1,238 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keystore
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/logp"
+)
+
+// ChainKeystore retrieves secrets from the local keystore and from a list of secret providers,
+// querying them in order of priority. Secrets retrieved from providers are refreshed periodically
+// while there are functions registered to be notified of their changes.
+//
+// Operations that modify or list the keystore are delegated to the local keystore.
+type ChainKeystore struct {
+	local     Keystore
+	keystores []Keystore
+	providers []*cachingKeystore
+	period    time.Duration
+	logger    *logp.Logger
+
+	mutex     sync.Mutex
+	listeners map[uint64]func([]string)
+	nextID    uint64
+	done      chan struct{}
+}
+
+// NewChainKeystore returns a keystore that chains the local keystore with the secret providers
+// defined in the given configurations. Keystores with higher priority are queried first, the
+// local keystore has priority 0 and is queried before providers with the same priority.
+func NewChainKeystore(local Keystore, providersCfg []*common.Config) (*ChainKeystore, error) {
+	type prioritized struct {
+		keystore Keystore
+		priority int
+	}
+
+	all := []prioritized{{keystore: local}}
+	c := &ChainKeystore{
+		local:     local,
+		logger:    logp.NewLogger("keystore"),
+		listeners: make(map[uint64]func([]string)),
+	}
+	for _, cfg := range providersCfg {
+		provider, priority, err := newProvider(cfg)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, prioritized{keystore: provider, priority: priority})
+		c.providers = append(c.providers, provider)
+		if c.period == 0 || provider.ttl < c.period {
+			c.period = provider.ttl
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].priority > all[j].priority
+	})
+	for _, p := range all {
+		c.keystores = append(c.keystores, p.keystore)
+	}
+
+	return c, nil
+}
+
+// Retrieve returns the secret from the first keystore that contains the key. An error other
+// than ErrKeyDoesntExists stops the search, so a secret is never silently resolved from a
+// keystore with lower priority.
+func (c *ChainKeystore) Retrieve(key string) (*SecureString, error) {
+	for _, keystore := range c.keystores {
+		secret, err := keystore.Retrieve(key)
+		if err == ErrKeyDoesntExists {
+			continue
+		}
+		return secret, err
+	}
+	return nil, ErrKeyDoesntExists
+}
+
+// GetConfig returns the configuration of the local keystore, secrets from providers are
+// retrieved lazily so they cannot be listed upfront.
+func (c *ChainKeystore) GetConfig() (*common.Config, error) {
+	return c.local.GetConfig()
+}
+
+// IsPersisted checks if the local keystore is persisted.
+func (c *ChainKeystore) IsPersisted() bool {
+	return c.local.IsPersisted()
+}
+
+// OnChange registers a function that is called with the keys whose values changed. The secrets
+// are refreshed periodically while there is any function registered.
+func (c *ChainKeystore) OnChange(fn func(keys []string)) func() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	id := c.nextID
+	c.nextID++
+	c.listeners[id] = fn
+	if len(c.listeners) == 1 && len(c.providers) > 0 {
+		c.done = make(chan struct{})
+		go c.run(c.done)
+	}
+
+	return func() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+
+		if _, found := c.listeners[id]; !found {
+			return
+		}
+		delete(c.listeners, id)
+		if len(c.listeners) == 0 && c.done != nil {
+			close(c.done)
+			c.done = nil
+		}
+	}
+}
+
+func (c *ChainKeystore) run(done chan struct{}) {
+	ticker := time.NewTicker(c.period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		if changed := c.refresh(); len(changed) > 0 {
+			c.logger.Infof("Secrets changed: %v", changed)
+			c.notify(changed)
+		}
+	}
+}
+
+// refresh refreshes the secrets of all the providers and returns the keys that changed.
+func (c *ChainKeystore) refresh() []string {
+	var changed []string
+	for _, provider := range c.providers {
+		changed = append(changed, provider.refresh()...)
+	}
+	return changed
+}
+
+func (c *ChainKeystore) notify(keys []string) {
+	c.mutex.Lock()
+	listeners := make([]func([]string), 0, len(c.listeners))
+	for _, fn := range c.listeners {
+		listeners = append(listeners, fn)
+	}
+	c.mutex.Unlock()
+
+	for _, fn := range listeners {
+		fn(keys)
+	}
+}
+
+// Store adds a key to the local keystore.
+func (c *ChainKeystore) Store(key string, secret []byte) error {
+	w, err := AsWritableKeystore(c.local)
+	if err != nil {
+		return err
+	}
+	return w.Store(key, secret)
+}
+
+// Delete removes a key from the local keystore.
+func (c *ChainKeystore) Delete(key string) error {
+	w, err := AsWritableKeystore(c.local)
+	if err != nil {
+		return err
+	}
+	return w.Delete(key)
+}
+
+// Create creates an empty local keystore.
+func (c *ChainKeystore) Create(override bool) error {
+	w, err := AsWritableKeystore(c.local)
+	if err != nil {
+		return err
+	}
+	return w.Create(override)
+}
+
+// Save persists the changes to the local keystore.
+func (c *ChainKeystore) Save() error {
+	w, err := AsWritableKeystore(c.local)
+	if err != nil {
+		return err
+	}
+	return w.Save()
+}
+
+// List returns the keys in the local keystore.
+func (c *ChainKeystore) List() ([]string, error) {
+	l, err := AsListingKeystore(c.local)
+	if err != nil {
+		return nil, err
+	}
+	return l.List()
+}
+
+// Package returns the raw bytes of the local keystore.
+func (c *ChainKeystore) Package() ([]byte, error) {
+	p, ok := c.local.(Packager)
+	if !ok {
+		return nil, ErrNotWritable
+	}
+	return p.Package()
+}
+
+// ConfiguredPath returns the path of the local keystore.
+func (c *ChainKeystore) ConfiguredPath() string {
+	if p, ok := c.local.(Packager); ok {
+		return p.ConfiguredPath()
+	}
+	return ""
+}
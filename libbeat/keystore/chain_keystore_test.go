@@ -0,0 +1,267 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keystore
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+	ucfg "github.com/elastic/go-ucfg"
+)
+
+// mapKeystore is a lazy keystore backed by a map, that counts the retrieved keys.
+type mapKeystore struct {
+	sync.Mutex
+	secrets   map[string]string
+	err       error
+	retrieved int
+}
+
+func (k *mapKeystore) Retrieve(key string) (*SecureString, error) {
+	k.Lock()
+	defer k.Unlock()
+
+	k.retrieved++
+	if k.err != nil {
+		return nil, k.err
+	}
+	v, found := k.secrets[key]
+	if !found {
+		return nil, ErrKeyDoesntExists
+	}
+	return NewSecureString([]byte(v)), nil
+}
+
+func (k *mapKeystore) set(key, value string) {
+	k.Lock()
+	defer k.Unlock()
+	k.secrets[key] = value
+}
+
+func (k *mapKeystore) GetConfig() (*common.Config, error) { return nil, nil }
+func (k *mapKeystore) IsPersisted() bool                  { return true }
+
+func retrieveString(t *testing.T, keystore Keystore, key string) string {
+	t.Helper()
+	secret, err := keystore.Retrieve(key)
+	require.NoError(t, err)
+	v, err := secret.Get()
+	require.NoError(t, err)
+	return string(v)
+}
+
+func TestCachingKeystore(t *testing.T) {
+	provider := &mapKeystore{secrets: map[string]string{"ES_PWD": "secret"}}
+	now := time.Now()
+	cache := newCachingKeystore(provider, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	assert.Equal(t, "secret", retrieveString(t, cache, "ES_PWD"))
+	_, err := cache.Retrieve("missing")
+	assert.Equal(t, ErrKeyDoesntExists, err)
+	assert.Equal(t, 2, provider.retrieved)
+
+	// Cached values, including missing keys, are returned until they expire.
+	provider.set("ES_PWD", "rotated")
+	assert.Equal(t, "secret", retrieveString(t, cache, "ES_PWD"))
+	_, err = cache.Retrieve("missing")
+	assert.Equal(t, ErrKeyDoesntExists, err)
+	assert.Equal(t, 2, provider.retrieved)
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, "rotated", retrieveString(t, cache, "ES_PWD"))
+	assert.Equal(t, 3, provider.retrieved)
+}
+
+func TestCachingKeystoreRefresh(t *testing.T) {
+	provider := &mapKeystore{secrets: map[string]string{"ES_PWD": "secret", "API_KEY": "key"}}
+	cache := newCachingKeystore(provider, time.Minute)
+
+	assert.Equal(t, "secret", retrieveString(t, cache, "ES_PWD"))
+	assert.Equal(t, "key", retrieveString(t, cache, "API_KEY"))
+	_, err := cache.Retrieve("TOKEN")
+	assert.Equal(t, ErrKeyDoesntExists, err)
+
+	assert.Empty(t, cache.refresh())
+
+	provider.set("ES_PWD", "rotated")
+	provider.set("TOKEN", "token")
+	assert.Equal(t, []string{"ES_PWD", "TOKEN"}, cache.refresh())
+	assert.Equal(t, "rotated", retrieveString(t, cache, "ES_PWD"))
+	assert.Equal(t, "token", retrieveString(t, cache, "TOKEN"))
+
+	// Keys keep their values if the provider fails.
+	provider.err = errors.New("unavailable")
+	assert.Empty(t, cache.refresh())
+	assert.Equal(t, "rotated", retrieveString(t, cache, "ES_PWD"))
+}
+
+// blockingKeystore blocks the retrieval of the keys until it is released.
+type blockingKeystore struct {
+	mapKeystore
+	release chan struct{}
+}
+
+func (k *blockingKeystore) Retrieve(key string) (*SecureString, error) {
+	<-k.release
+	return k.mapKeystore.Retrieve(key)
+}
+
+func TestCachingKeystoreDoesntBlockOnProvider(t *testing.T) {
+	provider := &blockingKeystore{
+		mapKeystore: mapKeystore{secrets: map[string]string{"ES_PWD": "secret", "TOKEN": "token"}},
+		release:     make(chan struct{}, 1),
+	}
+	cache := newCachingKeystore(provider, time.Minute)
+
+	provider.release <- struct{}{}
+	assert.Equal(t, "secret", retrieveString(t, cache, "ES_PWD"))
+
+	// Cached keys are returned while the provider is queried for another key.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.Equal(t, "token", retrieveString(t, cache, "TOKEN"))
+	}()
+	assert.Equal(t, "secret", retrieveString(t, cache, "ES_PWD"))
+
+	close(provider.release)
+	<-done
+}
+
+func TestChainKeystorePriority(t *testing.T) {
+	path := GetTemporaryKeystoreFile()
+	defer os.Remove(path)
+	local := CreateAnExistingKeystore(path)
+
+	dir, err := ioutil.TempDir("", "secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, keyValue), []byte("fromdirectory"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "ES_PWD"), []byte("fromdirectory"), 0600))
+
+	providers := func(priority int) []*common.Config {
+		return []*common.Config{
+			common.MustNewConfigFrom(map[string]interface{}{
+				"type":     "directory",
+				"path":     dir,
+				"priority": priority,
+			}),
+		}
+	}
+
+	chain, err := NewChainKeystore(local, providers(0))
+	require.NoError(t, err)
+	assert.Equal(t, string(secretValue), retrieveString(t, chain, keyValue))
+	assert.Equal(t, "fromdirectory", retrieveString(t, chain, "ES_PWD"))
+	_, err = chain.Retrieve("missing")
+	assert.Equal(t, ErrKeyDoesntExists, err)
+
+	chain, err = NewChainKeystore(local, providers(10))
+	require.NoError(t, err)
+	assert.Equal(t, "fromdirectory", retrieveString(t, chain, keyValue))
+
+	// Operations on the keystore are delegated to the local keystore.
+	assert.True(t, chain.IsPersisted())
+	keys, err := chain.List()
+	require.NoError(t, err)
+	assert.Equal(t, []string{keyValue}, keys)
+	assert.Equal(t, path, chain.ConfiguredPath())
+}
+
+func TestChainKeystoreStopsOnErrors(t *testing.T) {
+	failing := &mapKeystore{err: errors.New("unavailable")}
+	fallback := &mapKeystore{secrets: map[string]string{"ES_PWD": "secret"}}
+	chain := &ChainKeystore{keystores: []Keystore{failing, fallback}}
+
+	_, err := chain.Retrieve("ES_PWD")
+	assert.Equal(t, failing.err, err)
+}
+
+func TestChainKeystoreOnChange(t *testing.T) {
+	provider := &mapKeystore{secrets: map[string]string{"ES_PWD": "secret"}}
+	cache := newCachingKeystore(provider, 10*time.Millisecond)
+	chain := &ChainKeystore{
+		keystores: []Keystore{cache},
+		providers: []*cachingKeystore{cache},
+		period:    cache.ttl,
+		logger:    cache.logger,
+		listeners: make(map[uint64]func([]string)),
+	}
+
+	cfg, err := ucfg.NewFrom(map[string]interface{}{"password": "${ES_PWD}"}, ucfg.VarExp)
+	require.NoError(t, err)
+	resolve := func() string {
+		var config struct {
+			Password string `config:"password"`
+		}
+		require.NoError(t, cfg.Unpack(&config, ucfg.Resolve(ResolverWrap(chain))))
+		return config.Password
+	}
+	assert.Equal(t, "secret", resolve())
+
+	changes := make(chan []string, 1)
+	unregister := chain.OnChange(func(keys []string) { changes <- keys })
+	defer unregister()
+
+	provider.set("ES_PWD", "rotated")
+	select {
+	case keys := <-changes:
+		assert.Equal(t, []string{"ES_PWD"}, keys)
+	case <-time.After(5 * time.Second):
+		t.Fatal("change was not notified")
+	}
+	assert.Equal(t, "rotated", resolve())
+}
+
+func TestFactoryWithProviders(t *testing.T) {
+	path := GetTemporaryKeystoreFile()
+	defer os.Remove(path)
+
+	cfg := common.MustNewConfigFrom(map[string]interface{}{
+		"providers": []map[string]interface{}{
+			{"type": "directory", "path": "/run/secrets"},
+		},
+	})
+	keystore, err := Factory(cfg, path)
+	require.NoError(t, err)
+	assert.IsType(t, &ChainKeystore{}, keystore)
+	_, ok := keystore.(WatchingKeystore)
+	assert.True(t, ok)
+
+	cfg = common.MustNewConfigFrom(map[string]interface{}{
+		"providers": []map[string]interface{}{
+			{"type": "unknown"},
+		},
+	})
+	_, err = Factory(cfg, path)
+	assert.Error(t, err)
+
+	keystore, err = Factory(nil, path)
+	require.NoError(t, err)
+	assert.IsType(t, &FileKeystore{}, keystore)
+}
@@ -17,11 +17,31 @@
 
 package keystore
 
+import (
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+)
+
 // Config Define keystore configurable options
 type Config struct {
-	Path string `config:"path"`
+	Path      string           `config:"path"`
+	Providers []*common.Config `config:"providers"`
 }
 
 var defaultConfig = Config{
 	Path: "",
 }
+
+// providerConfig contains the options shared by all the secret providers.
+type providerConfig struct {
+	Type string `config:"type" validate:"required"`
+	// Keystores with higher priority are queried first, the local keystore has priority 0.
+	Priority int `config:"priority"`
+	// Time to keep a retrieved secret before querying the provider again.
+	TTL time.Duration `config:"ttl" validate:"positive"`
+}
+
+var defaultProviderConfig = providerConfig{
+	TTL: 5 * time.Minute,
+}
@@ -0,0 +1,111 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keystore
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+)
+
+type directoryConfig struct {
+	Path string `config:"path" validate:"required"`
+}
+
+// DirectoryKeystore retrieves secrets from a directory with a file per key, as the secrets
+// mounted by Kubernetes or Docker. Files are read every time a key is retrieved.
+type DirectoryKeystore struct {
+	path string
+}
+
+func newDirectoryKeystoreFromConfig(cfg *common.Config) (Keystore, error) {
+	var config directoryConfig
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+	return NewDirectoryKeystore(config.Path), nil
+}
+
+// NewDirectoryKeystore returns a keystore that reads the secrets from the files in a directory.
+func NewDirectoryKeystore(path string) *DirectoryKeystore {
+	return &DirectoryKeystore{path: path}
+}
+
+// Retrieve returns the content of the file named as the key, without the trailing newline.
+func (k *DirectoryKeystore) Retrieve(key string) (*SecureString, error) {
+	if !isValidFileKey(key) {
+		return nil, ErrKeyDoesntExists
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(k.path, key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrKeyDoesntExists
+		}
+		return nil, err
+	}
+
+	content = bytes.TrimSuffix(content, []byte("\n"))
+	content = bytes.TrimSuffix(content, []byte("\r"))
+	return NewSecureString(content), nil
+}
+
+// GetConfig returns nil, secrets are retrieved lazily.
+func (k *DirectoryKeystore) GetConfig() (*common.Config, error) {
+	return nil, nil
+}
+
+// IsPersisted checks if the directory exists.
+func (k *DirectoryKeystore) IsPersisted() bool {
+	info, err := os.Stat(k.path)
+	return err == nil && info.IsDir()
+}
+
+// List returns the keys in the directory. Hidden files are ignored, as the ones used by
+// Kubernetes to update the secrets atomically.
+func (k *DirectoryKeystore) List() ([]string, error) {
+	files, err := ioutil.ReadDir(k.path)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(k.path, file.Name()))
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		keys = append(keys, file.Name())
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// isValidFileKey checks that a key can be used as a file name without escaping the directory,
+// hidden files are not valid keys.
+func isValidFileKey(key string) bool {
+	return key != "" && !strings.HasPrefix(key, ".") && !strings.ContainsAny(key, `/\`)
+}
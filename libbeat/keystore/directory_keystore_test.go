@@ -0,0 +1,76 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keystore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectoryKeystore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "ES_PWD"), []byte("secret\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "API_KEY"), []byte("id:key"), 0600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "..data"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".hidden"), []byte("hidden"), 0600))
+
+	keystore := NewDirectoryKeystore(dir)
+	assert.True(t, keystore.IsPersisted())
+
+	secret, err := keystore.Retrieve("ES_PWD")
+	require.NoError(t, err)
+	v, _ := secret.Get()
+	assert.Equal(t, "secret", string(v))
+
+	secret, err = keystore.Retrieve("API_KEY")
+	require.NoError(t, err)
+	v, _ = secret.Get()
+	assert.Equal(t, "id:key", string(v))
+
+	for _, key := range []string{"missing", "", "..", "../ES_PWD", "..data", ".hidden"} {
+		_, err = keystore.Retrieve(key)
+		assert.Equal(t, ErrKeyDoesntExists, err, key)
+	}
+
+	keys, err := keystore.List()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"API_KEY", "ES_PWD"}, keys)
+
+	// Secrets are read again when they change.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "ES_PWD"), []byte("rotated\n"), 0600))
+	secret, err = keystore.Retrieve("ES_PWD")
+	require.NoError(t, err)
+	v, _ = secret.Get()
+	assert.Equal(t, "rotated", string(v))
+}
+
+func TestDirectoryKeystoreNotPersisted(t *testing.T) {
+	keystore := NewDirectoryKeystore(filepath.Join(os.TempDir(), "doesnotexist-secrets"))
+	assert.False(t, keystore.IsPersisted())
+
+	_, err := keystore.Retrieve("ES_PWD")
+	assert.Equal(t, ErrKeyDoesntExists, err)
+}
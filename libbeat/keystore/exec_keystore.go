@@ -0,0 +1,106 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keystore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+)
+
+type execConfig struct {
+	Command string        `config:"command" validate:"required"`
+	Args    []string      `config:"args"`
+	Timeout time.Duration `config:"timeout" validate:"positive"`
+	// Exit code used by the command to report that the key doesn't exist.
+	NotFoundExitCode int `config:"not_found_exit_code"`
+}
+
+var defaultExecConfig = execConfig{
+	Timeout:          10 * time.Second,
+	NotFoundExitCode: 1,
+}
+
+// ExecKeystore retrieves secrets running a helper command, that receives the key as its last
+// argument and prints the secret in its standard output.
+type ExecKeystore struct {
+	config execConfig
+}
+
+func newExecKeystoreFromConfig(cfg *common.Config) (Keystore, error) {
+	config := defaultExecConfig
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+	return &ExecKeystore{config: config}, nil
+}
+
+// NewExecKeystore returns a keystore that runs the given command to retrieve the secrets.
+func NewExecKeystore(command string, args []string, timeout time.Duration) *ExecKeystore {
+	config := defaultExecConfig
+	config.Command = command
+	config.Args = args
+	config.Timeout = timeout
+	return &ExecKeystore{config: config}
+}
+
+// Retrieve runs the command for the key and returns its output, without the trailing newline.
+func (k *ExecKeystore) Retrieve(key string) (*SecureString, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), k.config.Timeout)
+	defer cancel()
+
+	args := append(append([]string{}, k.config.Args...), key)
+	cmd := exec.CommandContext(ctx, k.config.Command, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("command '%s' timed out after %v retrieving key '%s'", k.config.Command, k.config.Timeout, key)
+	}
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			if exitErr.ExitCode() == k.config.NotFoundExitCode {
+				return nil, ErrKeyDoesntExists
+			}
+			return nil, fmt.Errorf("command '%s' failed retrieving key '%s': %v: %s",
+				k.config.Command, key, err, strings.TrimSpace(stderr.String()))
+		}
+		return nil, fmt.Errorf("could not run command '%s': %v", k.config.Command, err)
+	}
+
+	secret := bytes.TrimSuffix(stdout.Bytes(), []byte("\n"))
+	secret = bytes.TrimSuffix(secret, []byte("\r"))
+	return NewSecureString(secret), nil
+}
+
+// GetConfig returns nil, secrets are retrieved lazily.
+func (k *ExecKeystore) GetConfig() (*common.Config, error) {
+	return nil, nil
+}
+
+// IsPersisted returns true, the command is the source of the secrets.
+func (k *ExecKeystore) IsPersisted() bool {
+	return true
+}
@@ -0,0 +1,67 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keystore
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecKeystore(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	script := `case "$1" in
+ES_PWD) echo secret ;;
+FAIL) echo "backend unavailable" >&2; exit 2 ;;
+SLOW) exec sleep 5 ;;
+*) exit 1 ;;
+esac`
+	keystore := NewExecKeystore("sh", []string{"-c", script, "helper"}, time.Second)
+
+	secret, err := keystore.Retrieve("ES_PWD")
+	require.NoError(t, err)
+	v, _ := secret.Get()
+	assert.Equal(t, "secret", string(v))
+
+	_, err = keystore.Retrieve("missing")
+	assert.Equal(t, ErrKeyDoesntExists, err)
+
+	_, err = keystore.Retrieve("FAIL")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "backend unavailable")
+	}
+
+	_, err = keystore.Retrieve("SLOW")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "timed out")
+	}
+}
+
+func TestExecKeystoreCommandNotFound(t *testing.T) {
+	keystore := NewExecKeystore("doesnotexist-secret-helper", nil, time.Second)
+
+	_, err := keystore.Retrieve("ES_PWD")
+	assert.Error(t, err)
+	assert.NotEqual(t, ErrKeyDoesntExists, err)
+}
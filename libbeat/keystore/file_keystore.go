@@ -90,7 +90,11 @@ func Factory(cfg *common.Config, defaultPath string) (Keystore, error) {
 	}
 
 	keystore, err := NewFileKeystore(config.Path)
-	return keystore, err
+	if err != nil || len(config.Providers) == 0 {
+		return keystore, err
+	}
+
+	return NewChainKeystore(keystore, config.Providers)
 }
 
 // NewFileKeystore returns an new File based keystore or an error, currently users cannot set their
@@ -41,9 +41,9 @@ var (
 )
 
 // Keystore implement a way to securely saves and retrieves secrets to be used in the configuration
-// Currently all credentials are loaded upfront and are not lazy retrieved, we will eventually move
-// to that concept, so we can deal with tokens that has a limited duration or can be revoked by a
-// remote keystore.
+// The local file keystore loads all credentials upfront, secret providers retrieve them lazily and
+// refresh them periodically, so we can deal with tokens that has a limited duration or can be
+// revoked by a remote keystore.
 type Keystore interface {
 	// Retrieve returns a SecureString instance of the searched key or an error.
 	Retrieve(key string) (*SecureString, error)
@@ -74,6 +74,13 @@ type ListingKeystore interface {
 	List() ([]string, error)
 }
 
+// WatchingKeystore is implemented by keystores whose secrets can change while the beat is running.
+type WatchingKeystore interface {
+	// OnChange registers a function that is called with the keys whose values changed, it returns
+	// a function to unregister it.
+	OnChange(func(keys []string)) func()
+}
+
 // Provider for keystore
 type Provider interface {
 	GetKeystore(event bus.Event) Keystore
@@ -0,0 +1,54 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keystore
+
+import (
+	"fmt"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+)
+
+// providerFactory creates a secret provider from its configuration.
+type providerFactory func(cfg *common.Config) (Keystore, error)
+
+var providerFactories = map[string]providerFactory{
+	"directory": newDirectoryKeystoreFromConfig,
+	"exec":      newExecKeystoreFromConfig,
+	"vault":     newVaultKeystoreFromConfig,
+}
+
+// newProvider creates the secret provider defined in the configuration, wrapped to cache the
+// retrieved secrets during the configured TTL.
+func newProvider(cfg *common.Config) (*cachingKeystore, int, error) {
+	config := defaultProviderConfig
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, 0, fmt.Errorf("could not read secret provider configuration, err: %v", err)
+	}
+
+	factory, found := providerFactories[config.Type]
+	if !found {
+		return nil, 0, fmt.Errorf("unknown secret provider type '%s'", config.Type)
+	}
+
+	provider, err := factory(cfg)
+	if err != nil {
+		return nil, 0, fmt.Errorf("could not create %s secret provider, err: %v", config.Type, err)
+	}
+
+	return newCachingKeystore(provider, config.TTL), config.Priority, nil
+}
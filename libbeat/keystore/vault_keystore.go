@@ -0,0 +1,234 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keystore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/common/transport/tlscommon"
+)
+
+type vaultConfig struct {
+	URL       string            `config:"url" validate:"required"`
+	Token     string            `config:"token"`
+	TokenFile string            `config:"token_file"`
+	Namespace string            `config:"namespace"`
+	Mount     string            `config:"mount" validate:"required"`
+	Path      string            `config:"path" validate:"required"`
+	KVVersion int               `config:"kv_version"`
+	Timeout   time.Duration     `config:"timeout" validate:"positive"`
+	TLS       *tlscommon.Config `config:"ssl"`
+}
+
+var defaultVaultConfig = vaultConfig{
+	Mount:     "secret",
+	KVVersion: 2,
+	Timeout:   10 * time.Second,
+}
+
+func (c *vaultConfig) Validate() error {
+	if (c.Token == "") == (c.TokenFile == "") {
+		return fmt.Errorf("one of token or token_file must be set")
+	}
+	if c.KVVersion != 1 && c.KVVersion != 2 {
+		return fmt.Errorf("unsupported kv_version %d, it must be 1 or 2", c.KVVersion)
+	}
+	return nil
+}
+
+// VaultKeystore retrieves secrets from a secret of a key/value secrets engine compatible with
+// the HTTP API of Vault. Each key is a field of the configured secret.
+type VaultKeystore struct {
+	config vaultConfig
+	url    string
+	client *http.Client
+}
+
+func newVaultKeystoreFromConfig(cfg *common.Config) (Keystore, error) {
+	config := defaultVaultConfig
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+	return newVaultKeystore(config)
+}
+
+// newVaultKeystore returns a keystore that retrieves the secrets from Vault.
+func newVaultKeystore(config vaultConfig) (*VaultKeystore, error) {
+	base, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault url '%s': %v", config.URL, err)
+	}
+
+	tlsConfig, err := tlscommon.LoadTLSConfig(config.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	mount := strings.Trim(config.Mount, "/")
+	path := strings.Trim(config.Path, "/")
+	if config.KVVersion == 2 {
+		base.Path = strings.TrimSuffix(base.Path, "/") + "/v1/" + mount + "/data/" + path
+	} else {
+		base.Path = strings.TrimSuffix(base.Path, "/") + "/v1/" + mount + "/" + path
+	}
+
+	return &VaultKeystore{
+		config: config,
+		url:    base.String(),
+		client: &http.Client{
+			Timeout: config.Timeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig.BuildModuleClientConfig(base.Hostname()),
+			},
+		},
+	}, nil
+}
+
+// Retrieve reads the configured secret and returns the value of the field named as the key.
+func (k *VaultKeystore) Retrieve(key string) (*SecureString, error) {
+	data, err := k.read()
+	if err != nil {
+		return nil, err
+	}
+	return field(data, key)
+}
+
+// RetrieveAll reads the configured secret once and returns the values of the fields named as
+// the keys, keys without field are not included.
+func (k *VaultKeystore) RetrieveAll(keys []string) (map[string]*SecureString, error) {
+	data, err := k.read()
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make(map[string]*SecureString, len(keys))
+	for _, key := range keys {
+		secret, err := field(data, key)
+		if err == ErrKeyDoesntExists {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		secrets[key] = secret
+	}
+	return secrets, nil
+}
+
+// field returns the value of a field of a secret, string values are returned as is and other
+// values encoded in JSON.
+func field(data map[string]interface{}, key string) (*SecureString, error) {
+	value, found := data[key]
+	if !found || value == nil {
+		return nil, ErrKeyDoesntExists
+	}
+	if s, ok := value.(string); ok {
+		return NewSecureString([]byte(s)), nil
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return NewSecureString(raw), nil
+}
+
+// GetConfig returns nil, secrets are retrieved lazily.
+func (k *VaultKeystore) GetConfig() (*common.Config, error) {
+	return nil, nil
+}
+
+// IsPersisted returns true, the secrets are stored in Vault.
+func (k *VaultKeystore) IsPersisted() bool {
+	return true
+}
+
+// read returns the fields of the configured secret, or nil if the secret doesn't exist.
+func (k *VaultKeystore) read() (map[string]interface{}, error) {
+	token, err := k.token()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, k.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if k.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", k.config.Namespace)
+	}
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error reading secret from vault: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, nil
+	case resp.StatusCode != http.StatusOK:
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("error reading secret from vault, status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var secret struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return nil, fmt.Errorf("error decoding vault response: %v", err)
+	}
+
+	// The key/value engine version 2 nests the fields with the metadata of the secret.
+	var data map[string]interface{}
+	if k.config.KVVersion == 2 {
+		var versioned struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		err = json.Unmarshal(secret.Data, &versioned)
+		data = versioned.Data
+	} else {
+		err = json.Unmarshal(secret.Data, &data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error decoding vault secret: %v", err)
+	}
+	return data, nil
+}
+
+// token returns the configured token, the token file is read on every request so tokens
+// renewed by an agent are picked up.
+func (k *VaultKeystore) token() (string, error) {
+	if k.config.TokenFile == "" {
+		return k.config.Token, nil
+	}
+	content, err := ioutil.ReadFile(k.config.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("could not read vault token file: %v", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
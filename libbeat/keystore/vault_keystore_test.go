@@ -0,0 +1,193 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keystore
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+)
+
+// newTestVaultServer returns a Vault server for tests, that counts the received requests if
+// requests is not nil.
+func newTestVaultServer(t *testing.T, requests *int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests != nil {
+			atomic.AddInt64(requests, 1)
+		}
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		assert.Equal(t, "team", r.Header.Get("X-Vault-Namespace"))
+
+		switch r.URL.Path {
+		case "/v1/secret/data/beats":
+			w.Write([]byte(`{"data":{"data":{"ES_PWD":"secret","PORT":9200},"metadata":{"version":3}}}`))
+		case "/v1/kv/beats":
+			w.Write([]byte(`{"data":{"ES_PWD":"v1secret"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+}
+
+func newTestVaultKeystore(t *testing.T, settings map[string]interface{}) Keystore {
+	cfg, err := common.NewConfigFrom(settings)
+	require.NoError(t, err)
+	keystore, err := newVaultKeystoreFromConfig(cfg)
+	require.NoError(t, err)
+	return keystore
+}
+
+func TestVaultKeystoreKVVersion2(t *testing.T) {
+	server := newTestVaultServer(t, nil)
+	defer server.Close()
+
+	keystore := newTestVaultKeystore(t, map[string]interface{}{
+		"url":       server.URL,
+		"token":     "s.token",
+		"namespace": "team",
+		"path":      "beats",
+	})
+
+	secret, err := keystore.Retrieve("ES_PWD")
+	require.NoError(t, err)
+	v, _ := secret.Get()
+	assert.Equal(t, "secret", string(v))
+
+	secret, err = keystore.Retrieve("PORT")
+	require.NoError(t, err)
+	v, _ = secret.Get()
+	assert.Equal(t, "9200", string(v))
+
+	_, err = keystore.Retrieve("missing")
+	assert.Equal(t, ErrKeyDoesntExists, err)
+}
+
+func TestVaultKeystoreKVVersion1(t *testing.T) {
+	server := newTestVaultServer(t, nil)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "vault")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("s.token\n"), 0600))
+
+	keystore := newTestVaultKeystore(t, map[string]interface{}{
+		"url":        server.URL,
+		"token_file": tokenFile,
+		"namespace":  "team",
+		"mount":      "kv",
+		"path":       "beats",
+		"kv_version": 1,
+	})
+
+	secret, err := keystore.Retrieve("ES_PWD")
+	require.NoError(t, err)
+	v, _ := secret.Get()
+	assert.Equal(t, "v1secret", string(v))
+
+	// The token file is read on every request.
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("s.revoked"), 0600))
+	_, err = keystore.Retrieve("ES_PWD")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "permission denied")
+	}
+}
+
+func TestVaultKeystoreSecretNotFound(t *testing.T) {
+	server := newTestVaultServer(t, nil)
+	defer server.Close()
+
+	keystore := newTestVaultKeystore(t, map[string]interface{}{
+		"url":       server.URL,
+		"token":     "s.token",
+		"namespace": "team",
+		"path":      "other",
+	})
+
+	_, err := keystore.Retrieve("ES_PWD")
+	assert.Equal(t, ErrKeyDoesntExists, err)
+}
+
+func TestVaultKeystoreRefreshReadsSecretOnce(t *testing.T) {
+	var requests int64
+	server := newTestVaultServer(t, &requests)
+	defer server.Close()
+
+	cache := newCachingKeystore(newTestVaultKeystore(t, map[string]interface{}{
+		"url":       server.URL,
+		"token":     "s.token",
+		"namespace": "team",
+		"path":      "beats",
+	}), time.Minute)
+
+	assert.Equal(t, "secret", retrieveString(t, cache, "ES_PWD"))
+	assert.Equal(t, "9200", retrieveString(t, cache, "PORT"))
+	_, err := cache.Retrieve("missing")
+	assert.Equal(t, ErrKeyDoesntExists, err)
+	assert.Equal(t, int64(3), atomic.LoadInt64(&requests))
+
+	// All the keys are fields of the same secret, it is read once.
+	assert.Empty(t, cache.refresh())
+	assert.Equal(t, int64(4), atomic.LoadInt64(&requests))
+}
+
+func TestVaultKeystoreInvalidConfig(t *testing.T) {
+	cases := map[string]map[string]interface{}{
+		"no token": {
+			"url":  "http://localhost:8200",
+			"path": "beats",
+		},
+		"token and token file": {
+			"url":        "http://localhost:8200",
+			"path":       "beats",
+			"token":      "s.token",
+			"token_file": "/run/secrets/token",
+		},
+		"invalid kv version": {
+			"url":        "http://localhost:8200",
+			"path":       "beats",
+			"token":      "s.token",
+			"kv_version": 3,
+		},
+	}
+
+	for name, settings := range cases {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := common.NewConfigFrom(settings)
+			require.NoError(t, err)
+			_, err = newVaultKeystoreFromConfig(cfg)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -39,11 +39,22 @@ type Config struct {
 
 	Files FileConfig `config:"files"`
 
+	Publish PublishConfig `config:"publish"`
+
 	environment Environment
 	addCaller   bool // Adds package and line number info to messages.
 	development bool // Controls how DPanic behaves.
 }
 
+// PublishConfig contains the options to publish the log records as events
+// through the publisher pipeline.
+type PublishConfig struct {
+	Enabled    bool     `config:"enabled"`
+	Level      Level    `config:"level"`                        // Minimum level of the records to publish.
+	Selectors  []string `config:"selectors"`                    // Loggers whose records are published, all if empty.
+	BufferSize int      `config:"buffer_size" validate:"min=1"` // Records buffered before dropping them.
+}
+
 // FileConfig contains the configuration options for the file output.
 type FileConfig struct {
 	Path            string        `config:"path" yaml:"path"`
@@ -70,6 +81,10 @@ func DefaultConfig(environment Environment) Config {
 			Interval:        0,
 			RotateOnStartup: true,
 		},
+		Publish: PublishConfig{
+			Level:      WarnLevel,
+			BufferSize: 1024,
+		},
 		environment: environment,
 		addCaller:   true,
 	}
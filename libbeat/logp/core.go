@@ -60,6 +60,7 @@ type coreLogger struct {
 	globalLogger *zap.Logger            // Logger used by legacy global functions (e.g. logp.Info).
 	logger       *Logger                // Logger that is the basis for all logp.Loggers.
 	observedLogs *observer.ObservedLogs // Contains events generated while in observation mode (a testing mode).
+	publishQueue *RecordQueue           // Records to publish as events, nil if publishing is disabled.
 }
 
 // Configure configures the logp package.
@@ -114,6 +115,13 @@ func ConfigureWithOutputs(cfg Config, outputs ...zapcore.Core) error {
 		sink = selectiveWrapper(sink, selectors)
 	}
 
+	var publishQueue *RecordQueue
+	if cfg.Publish.Enabled {
+		var publish zapcore.Core
+		publish, publishQueue = newPublishCore(cfg.Publish)
+		outputs = append(outputs, publish)
+	}
+
 	sink = newMultiCore(append(outputs, sink)...)
 	root := zap.New(sink, makeOptions(cfg)...)
 	storeLogger(&coreLogger{
@@ -122,6 +130,7 @@ func ConfigureWithOutputs(cfg Config, outputs ...zapcore.Core) error {
 		globalLogger: root.WithOptions(zap.AddCallerSkip(1)),
 		logger:       newLogger(root, ""),
 		observedLogs: observedLogs,
+		publishQueue: publishQueue,
 	})
 	return nil
 }
@@ -182,6 +191,12 @@ func ObserverLogs() *observer.ObservedLogs {
 	return loadLogger().observedLogs
 }
 
+// PublishQueue returns the queue with the log records to publish as events,
+// or nil if publishing is not enabled.
+func PublishQueue() *RecordQueue {
+	return loadLogger().publishQueue
+}
+
 // Sync flushes any buffered log entries. Applications should take care to call
 // Sync before exiting.
 func Sync() error {
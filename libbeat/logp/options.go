@@ -59,3 +59,11 @@ func AsJSON() Option {
 		cfg.JSON = true
 	}
 }
+
+// WithPublish specifies the configuration to publish the log records as
+// events.
+func WithPublish(publish PublishConfig) Option {
+	return func(cfg *Config) {
+		cfg.Publish = publish
+	}
+}
@@ -0,0 +1,142 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Record is a log record captured to be published as an event.
+type Record struct {
+	zapcore.Entry
+	Fields map[string]interface{}
+}
+
+// RecordQueue is a bounded queue of log records to publish. Records are
+// dropped when the queue is full, so logging never blocks on the consumer.
+type RecordQueue struct {
+	records chan Record
+	dropped uint64
+}
+
+// Records returns the channel to read the records from.
+func (q *RecordQueue) Records() <-chan Record {
+	return q.records
+}
+
+// Dropped returns the number of records dropped because the queue was full.
+func (q *RecordQueue) Dropped() uint64 {
+	return atomic.LoadUint64(&q.dropped)
+}
+
+func (q *RecordQueue) push(r Record) {
+	select {
+	case q.records <- r:
+	default:
+		atomic.AddUint64(&q.dropped, 1)
+	}
+}
+
+// publishCore is a zapcore.Core that captures the records of the selected
+// loggers into a RecordQueue.
+type publishCore struct {
+	level        zapcore.Level
+	allSelectors bool
+	selectors    map[string]struct{}
+	fields       []zapcore.Field
+	queue        *RecordQueue
+}
+
+func newPublishCore(cfg PublishConfig) (zapcore.Core, *RecordQueue) {
+	queue := &RecordQueue{records: make(chan Record, cfg.BufferSize)}
+
+	selectors := make(map[string]struct{}, len(cfg.Selectors))
+	for _, sel := range cfg.Selectors {
+		selectors[strings.TrimSpace(sel)] = struct{}{}
+	}
+	_, allSelectors := selectors["*"]
+
+	return &publishCore{
+		level:        cfg.Level.ZapLevel(),
+		allSelectors: allSelectors || len(selectors) == 0,
+		selectors:    selectors,
+		queue:        queue,
+	}, queue
+}
+
+// Enabled returns whether a given logging level is enabled when logging a
+// message.
+func (c *publishCore) Enabled(level zapcore.Level) bool {
+	return c.level.Enabled(level)
+}
+
+// With adds structured context to the Core.
+func (c *publishCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	clone.fields = append(clone.fields, c.fields...)
+	clone.fields = append(clone.fields, fields...)
+	return &clone
+}
+
+// Check adds the core to the CheckedEntry if the entry level is enabled and
+// it comes from a selected logger.
+func (c *publishCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) && c.selected(ent.LoggerName) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// selected returns true if the logger or any of its parents is selected.
+func (c *publishCore) selected(name string) bool {
+	if c.allSelectors {
+		return true
+	}
+	for {
+		if _, found := c.selectors[name]; found {
+			return true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return false
+		}
+		name = name[:i]
+	}
+}
+
+// Write captures the entry and its fields into the queue.
+func (c *publishCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	c.queue.push(Record{Entry: ent, Fields: enc.Fields})
+	return nil
+}
+
+// Sync is a no-op, records are consumed asynchronously.
+func (c *publishCore) Sync() error {
+	return nil
+}
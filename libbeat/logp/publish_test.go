@@ -0,0 +1,69 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishQueue(t *testing.T) {
+	err := DevelopmentSetup(ToObserverOutput(), WithPublish(PublishConfig{
+		Enabled:    true,
+		Level:      WarnLevel,
+		Selectors:  []string{"foo"},
+		BufferSize: 2,
+	}))
+	require.NoError(t, err)
+	defer DevelopmentSetup(ToObserverOutput())
+
+	queue := PublishQueue()
+	require.NotNil(t, queue)
+
+	foo := NewLogger("foo").With("x", 1)
+	foo.Info("below the level")
+	NewLogger("bar").Warn("not selected")
+	foo.Warnw("selected", "y", 2)
+	foo.Named("child").Error("selected child")
+	foo.Error("dropped")
+
+	var records []Record
+	for len(queue.Records()) > 0 {
+		records = append(records, <-queue.Records())
+	}
+	require.Len(t, records, 2)
+	assert.Equal(t, uint64(1), queue.Dropped())
+
+	assert.Equal(t, "selected", records[0].Message)
+	assert.Equal(t, "foo", records[0].LoggerName)
+	assert.Equal(t, WarnLevel.ZapLevel(), records[0].Level)
+	assert.Equal(t, map[string]interface{}{"x": int64(1), "y": int64(2)}, records[0].Fields)
+
+	assert.Equal(t, "selected child", records[1].Message)
+	assert.Equal(t, "foo.child", records[1].LoggerName)
+
+	// The records are still written to the configured output.
+	assert.Len(t, ObserverLogs().All(), 5)
+}
+
+func TestPublishDisabled(t *testing.T) {
+	require.NoError(t, DevelopmentSetup(ToObserverOutput()))
+	assert.Nil(t, PublishQueue())
+}
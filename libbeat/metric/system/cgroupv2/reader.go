@@ -0,0 +1,146 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package cgroupv2 reads the metrics and limits of the cgroups of processes on
+// hosts using the unified hierarchy of cgroups v2.
+package cgroupv2
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrCgroupsV2Missing indicates that no cgroup2 filesystem is mounted.
+var ErrCgroupsV2Missing = errors.New("cgroup2 filesystem not mounted")
+
+// Reader reads cgroups v2 metrics and limits.
+type Reader struct {
+	// Mountpoint of the root filesystem. Defaults to / if not set. This can be
+	// useful for example if you mount / as /rootfs inside of a container.
+	rootfsMountpoint  string
+	ignoreRootCgroups bool   // Ignore a cgroup when its path is "/".
+	mountpoint        string // Mountpoint of the cgroup2 filesystem.
+}
+
+// NewReader creates and returns a new Reader. It returns ErrCgroupsV2Missing
+// if there is no cgroup2 filesystem mounted.
+func NewReader(rootfsMountpoint string, ignoreRootCgroups bool) (*Reader, error) {
+	if rootfsMountpoint == "" {
+		rootfsMountpoint = "/"
+	}
+
+	mountpoint, err := unifiedMountpoint(rootfsMountpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{
+		rootfsMountpoint:  rootfsMountpoint,
+		ignoreRootCgroups: ignoreRootCgroups,
+		mountpoint:        mountpoint,
+	}, nil
+}
+
+// GetStatsForProcess returns cgroup metrics and limits associated with a
+// process. It returns nil if the process doesn't belong to a cgroup v2, or
+// if it belongs to the root cgroup and root cgroups are ignored.
+func (r *Reader) GetStatsForProcess(pid int) (*Stats, error) {
+	path, err := processCgroupPath(r.rootfsMountpoint, pid)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" || (path == "/" && r.ignoreRootCgroups) {
+		return nil, nil
+	}
+
+	return getStats(filepath.Join(r.mountpoint, path), path)
+}
+
+// unifiedMountpoint returns the mountpoint of the cgroup2 filesystem, as
+// found in /proc/self/mountinfo.
+func unifiedMountpoint(rootfsMountpoint string) (string, error) {
+	mountinfo, err := os.Open(filepath.Join(rootfsMountpoint, "proc", "self", "mountinfo"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", ErrCgroupsV2Missing
+		}
+		return "", err
+	}
+	defer mountinfo.Close()
+
+	sc := bufio.NewScanner(mountinfo)
+	for sc.Scan() {
+		// https://www.kernel.org/doc/Documentation/filesystems/proc.txt
+		// Example:
+		// 30 23 0:26 / /sys/fs/cgroup rw,nosuid,nodev,noexec,relatime shared:4 - cgroup2 cgroup2 rw,nsdelegate
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 10 {
+			continue
+		}
+
+		var fsType string
+		for i, field := range fields {
+			if field == "-" && i+1 < len(fields) {
+				fsType = fields[i+1]
+				break
+			}
+		}
+		if fsType != "cgroup2" {
+			continue
+		}
+
+		mountpoint := fields[4]
+		if !strings.HasPrefix(mountpoint, rootfsMountpoint) {
+			mountpoint = filepath.Join(rootfsMountpoint, mountpoint)
+		}
+		return mountpoint, nil
+	}
+	if err := sc.Err(); err != nil {
+		return "", err
+	}
+
+	return "", ErrCgroupsV2Missing
+}
+
+// processCgroupPath returns the path of the cgroup v2 of a process, relative
+// to the mountpoint of the cgroup2 filesystem.
+func processCgroupPath(rootfsMountpoint string, pid int) (string, error) {
+	cgroup, err := os.Open(filepath.Join(rootfsMountpoint, "proc", strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return "", err
+	}
+	defer cgroup.Close()
+
+	sc := bufio.NewScanner(cgroup)
+	for sc.Scan() {
+		// http://man7.org/linux/man-pages/man7/cgroups.7.html
+		// Format: hierarchy-ID:controller-list:cgroup-path
+		// The entry of the unified hierarchy is always in the form:
+		// 0::/system.slice/docker-b29faf21b7ef.scope
+		fields := strings.SplitN(sc.Text(), ":", 3)
+		if len(fields) == 3 && fields[0] == "0" && fields[1] == "" {
+			return fields[2], nil
+		}
+	}
+
+	return "", sc.Err()
+}
@@ -0,0 +1,108 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+
+package cgroupv2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/metric/system/pressure"
+)
+
+func TestNewReaderMissing(t *testing.T) {
+	_, err := NewReader("testdata/missing", true)
+	assert.Equal(t, ErrCgroupsV2Missing, err)
+}
+
+func TestGetStatsForProcess(t *testing.T) {
+	reader, err := NewReader("testdata", true)
+	require.NoError(t, err)
+
+	stats, err := reader.GetStatsForProcess(100)
+	require.NoError(t, err)
+	require.NotNil(t, stats)
+
+	assert.Equal(t, "docker-2c5c0ae9e1a6.scope", stats.ID)
+	assert.Equal(t, "/system.slice/docker-2c5c0ae9e1a6.scope", stats.Path)
+
+	quota := uint64(150000)
+	assert.Equal(t, &CPUStats{
+		UsageMicros:      84392130,
+		UserMicros:       61092347,
+		SystemMicros:     23299783,
+		Periods:          12082,
+		ThrottledPeriods: 341,
+		ThrottledMicros:  5123478,
+		QuotaMicros:      &quota,
+		PeriodMicros:     100000,
+		Pressure: &pressure.Pressure{
+			Some: pressure.Stall{Avg10: 2.04, Avg60: 1.12, Avg300: 0.48, TotalMicros: 3416784},
+			Full: &pressure.Stall{Avg10: 1.1, Avg60: 0.62, Avg300: 0.21, TotalMicros: 1808331},
+		},
+	}, stats.CPU)
+
+	require.NotNil(t, stats.Memory)
+	assert.Equal(t, uint64(209715200), stats.Memory.UsageBytes)
+	if assert.NotNil(t, stats.Memory.LimitBytes) {
+		assert.Equal(t, uint64(536870912), *stats.Memory.LimitBytes)
+	}
+	assert.Equal(t, MemoryEvents{Max: 23, OOM: 2, OOMKill: 1}, stats.Memory.Events)
+	if assert.NotNil(t, stats.Memory.Pressure) {
+		assert.Equal(t, uint64(524087), stats.Memory.Pressure.Some.TotalMicros)
+	}
+
+	require.NotNil(t, stats.IO)
+	assert.Equal(t, uint64(11440128), stats.IO.ReadBytes)
+	assert.Equal(t, uint64(2134016), stats.IO.WriteBytes)
+	assert.Equal(t, uint64(512), stats.IO.DiscardBytes)
+	assert.Equal(t, uint64(413), stats.IO.ReadIOs)
+	assert.Equal(t, uint64(154), stats.IO.WriteIOs)
+	assert.Equal(t, uint64(1), stats.IO.DiscardIOs)
+	if assert.NotNil(t, stats.IO.Pressure) {
+		assert.Equal(t, 0.33, stats.IO.Pressure.Some.Avg10)
+	}
+
+	assert.Equal(t, &PidsStats{Current: 12}, stats.Pids)
+}
+
+func TestGetStatsForProcessWithoutControllers(t *testing.T) {
+	reader, err := NewReader("testdata", true)
+	require.NoError(t, err)
+
+	stats, err := reader.GetStatsForProcess(1)
+	require.NoError(t, err)
+	require.NotNil(t, stats)
+
+	assert.Equal(t, &CPUStats{UsageMicros: 1200, UserMicros: 700, SystemMicros: 500}, stats.CPU)
+	assert.Nil(t, stats.Memory)
+	assert.Nil(t, stats.IO)
+	assert.Nil(t, stats.Pids)
+}
+
+func TestGetStatsForProcessCgroupsV1(t *testing.T) {
+	reader, err := NewReader("testdata", true)
+	require.NoError(t, err)
+
+	stats, err := reader.GetStatsForProcess(200)
+	assert.NoError(t, err)
+	assert.Nil(t, stats)
+}
@@ -0,0 +1,341 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cgroupv2
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/v7/libbeat/metric/system/pressure"
+)
+
+// Stats contains the metrics and limits of a cgroup v2. Controllers that are
+// not enabled for the cgroup are nil.
+type Stats struct {
+	ID     string // ID of the cgroup, the last element of its path.
+	Path   string // Path of the cgroup relative to the cgroup2 mountpoint.
+	CPU    *CPUStats
+	Memory *MemoryStats
+	IO     *IOStats
+	Pids   *PidsStats
+}
+
+// CPUStats contains the data of cpu.stat and cpu.max. Usage is always
+// available, throttling information only if the cpu controller is enabled.
+type CPUStats struct {
+	UsageMicros      uint64
+	UserMicros       uint64
+	SystemMicros     uint64
+	Periods          uint64
+	ThrottledPeriods uint64
+	ThrottledMicros  uint64
+	// Maximum CPU time in microseconds that the cgroup can use in each
+	// period, nil if there is no limit.
+	QuotaMicros  *uint64
+	PeriodMicros uint64
+	Pressure     *pressure.Pressure
+}
+
+// MemoryStats contains the data of memory.current, memory.max and
+// memory.events.
+type MemoryStats struct {
+	UsageBytes uint64
+	// Memory usage hard limit, nil if there is no limit.
+	LimitBytes *uint64
+	Events     MemoryEvents
+	Pressure   *pressure.Pressure
+}
+
+// MemoryEvents counts the number of times that the cgroup hit its memory
+// boundaries.
+type MemoryEvents struct {
+	Low     uint64
+	High    uint64
+	Max     uint64
+	OOM     uint64
+	OOMKill uint64
+}
+
+// IOStats contains the data of io.stat, aggregated for all devices.
+type IOStats struct {
+	ReadBytes    uint64
+	WriteBytes   uint64
+	DiscardBytes uint64
+	ReadIOs      uint64
+	WriteIOs     uint64
+	DiscardIOs   uint64
+	Pressure     *pressure.Pressure
+}
+
+// PidsStats contains the data of pids.current and pids.max.
+type PidsStats struct {
+	Current uint64
+	// Maximum number of processes, nil if there is no limit.
+	Limit *uint64
+}
+
+// getStats reads the stats of the cgroup in the given full path.
+func getStats(fullPath, path string) (*Stats, error) {
+	stats := Stats{
+		ID:   filepath.Base(path),
+		Path: path,
+	}
+
+	var err error
+	if stats.CPU, err = getCPUStats(fullPath); err != nil {
+		return nil, errors.Wrap(err, "error reading cpu stats")
+	}
+	if stats.Memory, err = getMemoryStats(fullPath); err != nil {
+		return nil, errors.Wrap(err, "error reading memory stats")
+	}
+	if stats.IO, err = getIOStats(fullPath); err != nil {
+		return nil, errors.Wrap(err, "error reading io stats")
+	}
+	if stats.Pids, err = getPidsStats(fullPath); err != nil {
+		return nil, errors.Wrap(err, "error reading pids stats")
+	}
+
+	if stats.CPU == nil && stats.Memory == nil && stats.IO == nil && stats.Pids == nil {
+		return nil, nil
+	}
+	return &stats, nil
+}
+
+func getCPUStats(path string) (*CPUStats, error) {
+	values, err := parseFlatKeyed(filepath.Join(path, "cpu.stat"))
+	if err != nil || values == nil {
+		return nil, err
+	}
+
+	cpu := CPUStats{
+		UsageMicros:      values["usage_usec"],
+		UserMicros:       values["user_usec"],
+		SystemMicros:     values["system_usec"],
+		Periods:          values["nr_periods"],
+		ThrottledPeriods: values["nr_throttled"],
+		ThrottledMicros:  values["throttled_usec"],
+	}
+
+	// Format: $MAX $PERIOD, where $MAX can be "max".
+	content, err := readFile(filepath.Join(path, "cpu.max"))
+	if err != nil {
+		return nil, err
+	}
+	if fields := strings.Fields(content); len(fields) == 2 {
+		if cpu.QuotaMicros, err = parseLimit(fields[0]); err != nil {
+			return nil, err
+		}
+		if cpu.PeriodMicros, err = strconv.ParseUint(fields[1], 10, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	if cpu.Pressure, err = readPressure(filepath.Join(path, "cpu.pressure")); err != nil {
+		return nil, err
+	}
+	return &cpu, nil
+}
+
+func getMemoryStats(path string) (*MemoryStats, error) {
+	current, err := readFile(filepath.Join(path, "memory.current"))
+	if err != nil || current == "" {
+		return nil, err
+	}
+
+	var memory MemoryStats
+	if memory.UsageBytes, err = strconv.ParseUint(current, 10, 64); err != nil {
+		return nil, err
+	}
+
+	max, err := readFile(filepath.Join(path, "memory.max"))
+	if err != nil {
+		return nil, err
+	}
+	if memory.LimitBytes, err = parseLimit(max); err != nil {
+		return nil, err
+	}
+
+	events, err := parseFlatKeyed(filepath.Join(path, "memory.events"))
+	if err != nil {
+		return nil, err
+	}
+	memory.Events = MemoryEvents{
+		Low:     events["low"],
+		High:    events["high"],
+		Max:     events["max"],
+		OOM:     events["oom"],
+		OOMKill: events["oom_kill"],
+	}
+
+	if memory.Pressure, err = readPressure(filepath.Join(path, "memory.pressure")); err != nil {
+		return nil, err
+	}
+	return &memory, nil
+}
+
+func getIOStats(path string) (*IOStats, error) {
+	// io.stat is empty if the io controller is enabled but there was no IO.
+	content, err := ioutil.ReadFile(filepath.Join(path, "io.stat"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var io IOStats
+	sc := bufio.NewScanner(bytes.NewReader(content))
+	for sc.Scan() {
+		// Format: $MAJ:$MIN rbytes=1 wbytes=2 rios=3 wios=4 dbytes=5 dios=6
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		for _, field := range fields[1:] {
+			key, value, err := parseKeyValue(field, "=")
+			if err != nil {
+				return nil, err
+			}
+			switch key {
+			case "rbytes":
+				io.ReadBytes += value
+			case "wbytes":
+				io.WriteBytes += value
+			case "dbytes":
+				io.DiscardBytes += value
+			case "rios":
+				io.ReadIOs += value
+			case "wios":
+				io.WriteIOs += value
+			case "dios":
+				io.DiscardIOs += value
+			}
+		}
+	}
+
+	if io.Pressure, err = readPressure(filepath.Join(path, "io.pressure")); err != nil {
+		return nil, err
+	}
+	return &io, nil
+}
+
+func getPidsStats(path string) (*PidsStats, error) {
+	current, err := readFile(filepath.Join(path, "pids.current"))
+	if err != nil || current == "" {
+		return nil, err
+	}
+
+	var pids PidsStats
+	if pids.Current, err = strconv.ParseUint(current, 10, 64); err != nil {
+		return nil, err
+	}
+
+	max, err := readFile(filepath.Join(path, "pids.max"))
+	if err != nil {
+		return nil, err
+	}
+	if pids.Limit, err = parseLimit(max); err != nil {
+		return nil, err
+	}
+	return &pids, nil
+}
+
+// readFile returns the trimmed content of a file, or an empty string if the
+// file doesn't exist, as happens with the files of disabled controllers.
+func readFile(path string) (string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// readPressure reads a pressure file, it returns nil if the file doesn't
+// exist, what happens if PSI is disabled in the kernel.
+func readPressure(path string) (*pressure.Pressure, error) {
+	p, err := pressure.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// parseFlatKeyed parses files with a key and a value per line, such as
+// cpu.stat or memory.events. It returns nil if the file doesn't exist.
+func parseFlatKeyed(path string) (map[string]uint64, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	values := map[string]uint64{}
+	sc := bufio.NewScanner(bytes.NewReader(content))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		key, value, err := parseKeyValue(line, " ")
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing %s", path)
+		}
+		values[key] = value
+	}
+	return values, sc.Err()
+}
+
+func parseKeyValue(s, sep string) (string, uint64, error) {
+	parts := strings.SplitN(s, sep, 2)
+	if len(parts) != 2 {
+		return "", 0, errors.Errorf("invalid key/value format '%s'", s)
+	}
+	value, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 64)
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "invalid value for '%s'", parts[0])
+	}
+	return parts[0], value, nil
+}
+
+// parseLimit parses the value of a limit, that is "max" when there is no
+// limit.
+func parseLimit(s string) (*uint64, error) {
+	if s == "" || s == "max" {
+		return nil, nil
+	}
+	value, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid limit '%s'", s)
+	}
+	return &value, nil
+}
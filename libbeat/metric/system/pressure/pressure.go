@@ -0,0 +1,164 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package pressure reads Pressure Stall Information (PSI) as exposed by the
+// Linux kernel in /proc/pressure and in the *.pressure files of cgroups v2.
+package pressure
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+)
+
+// Pressure contains the stall information of a resource.
+type Pressure struct {
+	// Some tracks the share of time in which at least some tasks were
+	// stalled on the resource.
+	Some Stall `json:"some"`
+	// Full tracks the share of time in which all non-idle tasks were stalled
+	// on the resource at the same time. It is not reported for the CPU at the
+	// host level by kernels older than 5.13.
+	Full *Stall `json:"full,omitempty"`
+}
+
+// Stall contains the running averages and the total stall time of a resource.
+type Stall struct {
+	// Averages of the percentage of stalled time over the last 10, 60 and
+	// 300 seconds.
+	Avg10  float64 `json:"avg10"`
+	Avg60  float64 `json:"avg60"`
+	Avg300 float64 `json:"avg300"`
+	// Total stall time in microseconds.
+	TotalMicros uint64 `json:"total_us"`
+}
+
+// ReadFile reads the pressure information from the given file, such as
+// /proc/pressure/cpu or the cpu.pressure file of a cgroup.
+func ReadFile(path string) (*Pressure, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(content)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing %s", path)
+	}
+	return p, nil
+}
+
+// Parse parses the content of a pressure file. The format is:
+//
+//   some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//   full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func Parse(content []byte) (*Pressure, error) {
+	var p Pressure
+	var foundSome bool
+
+	sc := bufio.NewScanner(bytes.NewReader(content))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		stall, err := parseStall(fields[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		switch fields[0] {
+		case "some":
+			p.Some = stall
+			foundSome = true
+		case "full":
+			full := stall
+			p.Full = &full
+		default:
+			return nil, errors.Errorf("unexpected line type '%s'", fields[0])
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if !foundSome {
+		return nil, errors.New("no 'some' line found")
+	}
+
+	return &p, nil
+}
+
+// GetPressureEvent returns the event fields of the pressure information. The
+// averages are reported as ratios between 0 and 1.
+func GetPressureEvent(p *Pressure) common.MapStr {
+	event := common.MapStr{
+		"some": getStallEvent(p.Some),
+	}
+	if p.Full != nil {
+		event["full"] = getStallEvent(*p.Full)
+	}
+	return event
+}
+
+func getStallEvent(s Stall) common.MapStr {
+	return common.MapStr{
+		"10": common.MapStr{
+			"pct": common.Round(s.Avg10/100, common.DefaultDecimalPlacesCount),
+		},
+		"60": common.MapStr{
+			"pct": common.Round(s.Avg60/100, common.DefaultDecimalPlacesCount),
+		},
+		"300": common.MapStr{
+			"pct": common.Round(s.Avg300/100, common.DefaultDecimalPlacesCount),
+		},
+		"total": common.MapStr{
+			"us": s.TotalMicros,
+		},
+	}
+}
+
+func parseStall(fields []string) (Stall, error) {
+	var s Stall
+	for _, field := range fields {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return s, errors.Errorf("invalid field '%s'", field)
+		}
+
+		var err error
+		switch parts[0] {
+		case "avg10":
+			s.Avg10, err = strconv.ParseFloat(parts[1], 64)
+		case "avg60":
+			s.Avg60, err = strconv.ParseFloat(parts[1], 64)
+		case "avg300":
+			s.Avg300, err = strconv.ParseFloat(parts[1], 64)
+		case "total":
+			s.TotalMicros, err = strconv.ParseUint(parts[1], 10, 64)
+		}
+		if err != nil {
+			return s, errors.Wrapf(err, "invalid value for '%s'", parts[0])
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,74 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+
+package pressure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+)
+
+func TestReadFile(t *testing.T) {
+	p, err := ReadFile("testdata/memory")
+	require.NoError(t, err)
+	assert.Equal(t, Stall{Avg10: 1.53, Avg60: 0.87, Avg300: 0.25, TotalMicros: 9872134}, p.Some)
+	if assert.NotNil(t, p.Full) {
+		assert.Equal(t, Stall{Avg10: 0.5, Avg60: 0.2, Avg300: 0.05, TotalMicros: 1234567}, *p.Full)
+	}
+
+	p, err = ReadFile("testdata/cpu")
+	require.NoError(t, err)
+	assert.Equal(t, 12.4, p.Some.Avg10)
+	assert.Nil(t, p.Full)
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, content := range []string{
+		"",
+		"full avg10=0.00 avg60=0.00 avg300=0.00 total=0\n",
+		"some avg10=abc avg60=0.00 avg300=0.00 total=0\n",
+		"some avg10\n",
+		"other avg10=0.00\n",
+	} {
+		_, err := Parse([]byte(content))
+		assert.Error(t, err, content)
+	}
+}
+
+func TestGetPressureEvent(t *testing.T) {
+	p, err := ReadFile("testdata/memory")
+	require.NoError(t, err)
+
+	event := GetPressureEvent(p)
+	assert.Equal(t, common.MapStr{
+		"10":    common.MapStr{"pct": 0.0153},
+		"60":    common.MapStr{"pct": 0.0087},
+		"300":   common.MapStr{"pct": 0.0025},
+		"total": common.MapStr{"us": uint64(9872134)},
+	}, event["some"])
+	assert.Contains(t, event, "full")
+
+	p, err = ReadFile("testdata/cpu")
+	require.NoError(t, err)
+	assert.NotContains(t, GetPressureEvent(p), "full")
+}
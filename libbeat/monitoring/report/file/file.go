@@ -0,0 +1,164 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package file
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/common/file"
+	"github.com/elastic/beats/v7/libbeat/logp"
+	"github.com/elastic/beats/v7/libbeat/monitoring"
+	"github.com/elastic/beats/v7/libbeat/monitoring/report"
+	"github.com/elastic/beats/v7/libbeat/paths"
+)
+
+type reporter struct {
+	wg       sync.WaitGroup
+	done     chan struct{}
+	period   time.Duration
+	beatMeta common.MapStr
+	rotator  *file.Rotator
+	logger   *logp.Logger
+}
+
+func init() {
+	report.RegisterReporterFactory("file", makeReporter)
+}
+
+// makeReporter returns a new Reporter that periodically writes snapshots of
+// the stats and state registries to a rotating file, one JSON document per
+// line.
+func makeReporter(beat beat.Info, _ report.Settings, cfg *common.Config) (report.Reporter, error) {
+	config := defaultConfig
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	path := config.Path
+	if path == "" {
+		path = paths.Resolve(paths.Logs, "")
+	}
+	filename := config.Filename
+	if filename == "" {
+		filename = beat.Beat + "-monitoring.ndjson"
+	}
+	path = filepath.Join(path, filename)
+
+	log := logp.NewLogger("monitoring")
+	rotator, err := file.NewFileRotator(
+		path,
+		file.MaxSizeBytes(config.RotateEveryKb*1024),
+		file.MaxBackups(config.NumberOfFiles),
+		file.Permissions(os.FileMode(config.Permissions)),
+		file.WithLogger(logp.NewLogger("rotator").With(logp.Namespace("rotator"))),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Writing monitoring snapshots to file. path=%v period=%v max_size_bytes=%v max_backups=%v",
+		path, config.Period, config.RotateEveryKb*1024, config.NumberOfFiles)
+
+	r := &reporter{
+		done:     make(chan struct{}),
+		period:   config.Period,
+		beatMeta: makeMeta(beat),
+		rotator:  rotator,
+		logger:   log,
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.snapshotLoop()
+	}()
+	return r, nil
+}
+
+func (r *reporter) Stop() {
+	close(r.done)
+	r.wg.Wait()
+	if err := r.rotator.Close(); err != nil {
+		r.logger.Warnf("Failed to close monitoring file: %v", err)
+	}
+}
+
+func (r *reporter) snapshotLoop() {
+	ticker := time.NewTicker(r.period)
+	defer ticker.Stop()
+
+	r.logger.Infof("Start monitoring file snapshot loop with period %s.", r.period)
+	defer r.logger.Info("Stop monitoring file snapshot loop.")
+
+	for {
+		var ts time.Time
+
+		select {
+		case <-r.done:
+			return
+		case ts = <-ticker.C:
+		}
+
+		if err := r.writeSnapshot(ts); err != nil {
+			r.logger.Errorf("Failed to write monitoring snapshot: %v", err)
+		}
+	}
+}
+
+func (r *reporter) writeSnapshot(ts time.Time) error {
+	doc := common.MapStr{
+		"@timestamp":  common.Time(ts),
+		"beat":        r.beatMeta,
+		"interval_ms": int64(r.period / time.Millisecond),
+	}
+	// For consistency with the elasticsearch reporter stats are named metrics.
+	if snapshot := makeSnapshot("stats"); len(snapshot) > 0 {
+		doc["metrics"] = snapshot
+	}
+	if snapshot := makeSnapshot("state"); len(snapshot) > 0 {
+		doc["state"] = snapshot
+	}
+
+	line, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	_, err = r.rotator.Write(append(line, '\n'))
+	return err
+}
+
+func makeSnapshot(namespace string) common.MapStr {
+	registry := monitoring.GetNamespace(namespace).GetRegistry()
+	return common.MapStr(monitoring.CollectStructSnapshot(registry, monitoring.Full, false))
+}
+
+func makeMeta(beat beat.Info) common.MapStr {
+	return common.MapStr{
+		"type":    beat.Beat,
+		"version": beat.Version,
+		"name":    beat.Name,
+		"host":    beat.Hostname,
+		"uuid":    beat.ID,
+	}
+}
@@ -0,0 +1,131 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package file
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/monitoring"
+	"github.com/elastic/beats/v7/libbeat/monitoring/report"
+)
+
+func TestFileReporter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "monitoring-file")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	registry := monitoring.GetNamespace("stats").GetRegistry().NewRegistry("filereportertest")
+	defer monitoring.GetNamespace("stats").GetRegistry().Remove("filereportertest")
+	monitoring.NewInt(registry, "events").Set(42)
+
+	cfg := common.MustNewConfigFrom(map[string]interface{}{
+		"path":   dir,
+		"period": "10ms",
+	})
+	r, err := makeReporter(beat.Info{Beat: "testbeat", Version: "1.2.3"}, report.Settings{}, cfg)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "testbeat-monitoring.ndjson")
+	require.Eventually(t, func() bool {
+		info, err := os.Stat(path)
+		return err == nil && info.Size() > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	r.Stop()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	require.True(t, sc.Scan())
+
+	var doc struct {
+		Timestamp  string                 `json:"@timestamp"`
+		Beat       map[string]interface{} `json:"beat"`
+		IntervalMs int64                  `json:"interval_ms"`
+		Metrics    map[string]interface{} `json:"metrics"`
+	}
+	require.NoError(t, json.Unmarshal(sc.Bytes(), &doc))
+
+	assert.NotEmpty(t, doc.Timestamp)
+	assert.Equal(t, "testbeat", doc.Beat["type"])
+	assert.Equal(t, "1.2.3", doc.Beat["version"])
+	assert.Equal(t, int64(10), doc.IntervalMs)
+	assert.Equal(t, map[string]interface{}{"events": float64(42)}, doc.Metrics["filereportertest"])
+}
+
+func TestFileOutputIsNotReporter(t *testing.T) {
+	outDir, err := ioutil.TempDir("", "output-file")
+	require.NoError(t, err)
+	defer os.RemoveAll(outDir)
+	monDir, err := ioutil.TempDir("", "monitoring-file")
+	require.NoError(t, err)
+	defer os.RemoveAll(monDir)
+
+	var outputs common.ConfigNamespace
+	require.NoError(t, common.MustNewConfigFrom(map[string]interface{}{
+		"file": map[string]interface{}{"path": outDir, "filename": "events"},
+	}).Unpack(&outputs))
+	info := beat.Info{Beat: "testbeat", Version: "1.2.3"}
+
+	// output.file is not used as monitoring reporter.
+	_, err = report.New(info, report.Settings{}, common.MustNewConfigFrom(map[string]interface{}{
+		"enabled": true,
+	}), outputs)
+	assert.Error(t, err)
+
+	// The settings of output.file are not merged in the file reporter.
+	r, err := report.New(info, report.Settings{}, common.MustNewConfigFrom(map[string]interface{}{
+		"enabled": true,
+		"file": map[string]interface{}{
+			"path":   monDir,
+			"period": "10ms",
+		},
+	}), outputs)
+	require.NoError(t, err)
+
+	path := filepath.Join(monDir, "testbeat-monitoring.ndjson")
+	require.Eventually(t, func() bool {
+		info, err := os.Stat(path)
+		return err == nil && info.Size() > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	r.Stop()
+
+	files, err := ioutil.ReadDir(outDir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestConfigValidation(t *testing.T) {
+	cfg := common.MustNewConfigFrom(map[string]interface{}{
+		"number_of_files": 1,
+	})
+	config := defaultConfig
+	assert.Error(t, cfg.Unpack(&config))
+}
@@ -0,0 +1,38 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/common/transport/tlscommon"
+)
+
+type config struct {
+	Endpoint string            `config:"endpoint" validate:"required"`
+	Headers  map[string]string `config:"headers"`
+	Timeout  time.Duration     `config:"timeout" validate:"positive"`
+	TLS      *tlscommon.Config `config:"ssl"`
+	Period   time.Duration     `config:"period" validate:"positive,nonzero"`
+}
+
+var defaultConfig = config{
+	Endpoint: "http://localhost:4318/v1/metrics",
+	Timeout:  30 * time.Second,
+	Period:   10 * time.Second,
+}
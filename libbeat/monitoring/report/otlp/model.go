@@ -0,0 +1,158 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/monitoring"
+)
+
+// The types in this file are the subset of the OTLP metrics data model needed
+// to export a registry snapshot, encoded as described by the OTLP/HTTP JSON
+// protobuf encoding.
+
+type exportMetricsServiceRequest struct {
+	ResourceMetrics []resourceMetrics `json:"resourceMetrics"`
+}
+
+type resourceMetrics struct {
+	Resource     resource       `json:"resource"`
+	ScopeMetrics []scopeMetrics `json:"scopeMetrics"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeMetrics struct {
+	Scope   scope    `json:"scope"`
+	Metrics []metric `json:"metrics"`
+}
+
+type scope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type metric struct {
+	Name  string `json:"name"`
+	Gauge *gauge `json:"gauge,omitempty"`
+	Sum   *sum   `json:"sum,omitempty"`
+}
+
+type gauge struct {
+	DataPoints []numberDataPoint `json:"dataPoints"`
+}
+
+type sum struct {
+	DataPoints             []numberDataPoint `json:"dataPoints"`
+	AggregationTemporality int               `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+}
+
+type numberDataPoint struct {
+	StartTimeUnixNano jsonUint64 `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      jsonUint64 `json:"timeUnixNano"`
+	AsDouble          *float64   `json:"asDouble,omitempty"`
+	AsInt             *jsonInt64 `json:"asInt,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// aggregationTemporalityCumulative is the value of
+// AGGREGATION_TEMPORALITY_CUMULATIVE in the OTLP protocol.
+const aggregationTemporalityCumulative = 2
+
+// jsonInt64 and jsonUint64 are encoded as strings, as the protobuf JSON
+// mapping does with 64 bits integers.
+type jsonInt64 int64
+
+func (i jsonInt64) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(strconv.FormatInt(int64(i), 10))), nil
+}
+
+type jsonUint64 uint64
+
+func (i jsonUint64) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(strconv.FormatUint(uint64(i), 10))), nil
+}
+
+// makeRequest converts a snapshot of the registry into an export request.
+// Integer metrics known to only increase are reported as cumulative sums, and
+// the other numeric metrics as gauges. Other values are not reported.
+func makeRequest(info beat.Info, snapshot monitoring.FlatSnapshot, start, ts time.Time) exportMetricsServiceRequest {
+	now := jsonUint64(ts.UnixNano())
+
+	var metrics []metric
+	for name, value := range snapshot.Ints {
+		v := jsonInt64(value)
+		dp := numberDataPoint{TimeUnixNano: now, AsInt: &v}
+		if !monitoring.IsCounter(name) {
+			metrics = append(metrics, metric{Name: name, Gauge: &gauge{DataPoints: []numberDataPoint{dp}}})
+			continue
+		}
+		dp.StartTimeUnixNano = jsonUint64(start.UnixNano())
+		metrics = append(metrics, metric{Name: name, Sum: &sum{
+			DataPoints:             []numberDataPoint{dp},
+			AggregationTemporality: aggregationTemporalityCumulative,
+			IsMonotonic:            true,
+		}})
+	}
+	for name, value := range snapshot.Floats {
+		v := value
+		dp := numberDataPoint{TimeUnixNano: now, AsDouble: &v}
+		metrics = append(metrics, metric{Name: name, Gauge: &gauge{DataPoints: []numberDataPoint{dp}}})
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Name < metrics[j].Name })
+
+	return exportMetricsServiceRequest{
+		ResourceMetrics: []resourceMetrics{{
+			Resource: resource{Attributes: makeResourceAttributes(info)},
+			ScopeMetrics: []scopeMetrics{{
+				Scope:   scope{Name: "libbeat", Version: info.Version},
+				Metrics: metrics,
+			}},
+		}},
+	}
+}
+
+func makeResourceAttributes(info beat.Info) []keyValue {
+	attributes := []keyValue{
+		{Key: "service.name", Value: anyValue{StringValue: info.Beat}},
+		{Key: "service.version", Value: anyValue{StringValue: info.Version}},
+		{Key: "service.instance.id", Value: anyValue{StringValue: info.ID.String()}},
+	}
+	if info.Hostname != "" {
+		attributes = append(attributes, keyValue{Key: "host.name", Value: anyValue{StringValue: info.Hostname}})
+	}
+	if info.Name != "" {
+		attributes = append(attributes, keyValue{Key: "beat.name", Value: anyValue{StringValue: info.Name}})
+	}
+	return attributes
+}
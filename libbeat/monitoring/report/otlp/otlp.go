@@ -0,0 +1,143 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/common/transport/tlscommon"
+	"github.com/elastic/beats/v7/libbeat/logp"
+	"github.com/elastic/beats/v7/libbeat/monitoring"
+	"github.com/elastic/beats/v7/libbeat/monitoring/report"
+	"github.com/elastic/beats/v7/libbeat/monitoring/report/log"
+)
+
+type reporter struct {
+	wg       sync.WaitGroup
+	done     chan struct{}
+	config   config
+	beatInfo beat.Info
+	client   *http.Client
+	logger   *logp.Logger
+}
+
+func init() {
+	report.RegisterReporterFactory("otlp", makeReporter)
+}
+
+// makeReporter returns a new Reporter that periodically pushes snapshots of
+// the stats registry as OTLP metrics over HTTP.
+func makeReporter(beat beat.Info, _ report.Settings, cfg *common.Config) (report.Reporter, error) {
+	config := defaultConfig
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := tlscommon.LoadTLSConfig(config.TLS)
+	if err != nil {
+		return nil, err
+	}
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig.ToConfig()
+	}
+
+	r := &reporter{
+		done:     make(chan struct{}),
+		config:   config,
+		beatInfo: beat,
+		client: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: transport,
+		},
+		logger: logp.NewLogger("monitoring"),
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.snapshotLoop()
+	}()
+	return r, nil
+}
+
+func (r *reporter) Stop() {
+	close(r.done)
+	r.wg.Wait()
+}
+
+func (r *reporter) snapshotLoop() {
+	ticker := time.NewTicker(r.config.Period)
+	defer ticker.Stop()
+
+	r.logger.Infof("Start monitoring OTLP snapshot loop with period %s, sending to %s.", r.config.Period, r.config.Endpoint)
+	defer r.logger.Info("Stop monitoring OTLP snapshot loop.")
+
+	for {
+		var ts time.Time
+
+		select {
+		case <-r.done:
+			return
+		case ts = <-ticker.C:
+		}
+
+		snapshot := monitoring.CollectFlatSnapshot(monitoring.GetNamespace("stats").GetRegistry(), monitoring.Full, false)
+		if err := r.send(makeRequest(r.beatInfo, snapshot, log.StartTime, ts)); err != nil {
+			r.logger.Errorf("Failed to send monitoring metrics to %s: %v", r.config.Endpoint, err)
+		}
+	}
+}
+
+func (r *reporter) send(request exportMetricsServiceRequest) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, r.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range r.config.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,134 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/monitoring"
+	"github.com/elastic/beats/v7/libbeat/monitoring/report"
+)
+
+func TestMakeRequest(t *testing.T) {
+	snapshot := monitoring.MakeFlatSnapshot()
+	snapshot.Ints["libbeat.output.events.acked"] = 10
+	snapshot.Ints["libbeat.pipeline.clients"] = 2
+	snapshot.Ints["libbeat.pipeline.queue.max_events"] = 4096
+	snapshot.Ints["libbeat.output.write.bytes"] = 1024
+	snapshot.Floats["system.load.1"] = 0.5
+	snapshot.Strings["beat.info.ephemeral_id"] = "abc"
+	snapshot.Bools["libbeat.config.reloads"] = true
+
+	start := time.Unix(100, 0)
+	ts := time.Unix(200, 0)
+	info := beat.Info{Beat: "testbeat", Version: "1.2.3", Hostname: "host1"}
+	data, err := json.Marshal(makeRequest(info, snapshot, start, ts))
+	require.NoError(t, err)
+
+	expected := `{"resourceMetrics":[{
+		"resource":{"attributes":[
+			{"key":"service.name","value":{"stringValue":"testbeat"}},
+			{"key":"service.version","value":{"stringValue":"1.2.3"}},
+			{"key":"service.instance.id","value":{"stringValue":"00000000-0000-0000-0000-000000000000"}},
+			{"key":"host.name","value":{"stringValue":"host1"}}
+		]},
+		"scopeMetrics":[{
+			"scope":{"name":"libbeat","version":"1.2.3"},
+			"metrics":[
+				{"name":"libbeat.output.events.acked","sum":{
+					"dataPoints":[{"startTimeUnixNano":"100000000000","timeUnixNano":"200000000000","asInt":"10"}],
+					"aggregationTemporality":2,"isMonotonic":true}},
+				{"name":"libbeat.output.write.bytes","sum":{
+					"dataPoints":[{"startTimeUnixNano":"100000000000","timeUnixNano":"200000000000","asInt":"1024"}],
+					"aggregationTemporality":2,"isMonotonic":true}},
+				{"name":"libbeat.pipeline.clients","gauge":{
+					"dataPoints":[{"timeUnixNano":"200000000000","asInt":"2"}]}},
+				{"name":"libbeat.pipeline.queue.max_events","gauge":{
+					"dataPoints":[{"timeUnixNano":"200000000000","asInt":"4096"}]}},
+				{"name":"system.load.1","gauge":{
+					"dataPoints":[{"timeUnixNano":"200000000000","asDouble":0.5}]}}
+			]
+		}]
+	}]}`
+	assert.JSONEq(t, expected, string(data))
+}
+
+func TestReporter(t *testing.T) {
+	registry := monitoring.GetNamespace("stats").GetRegistry().NewRegistry("otlpreportertest")
+	defer monitoring.GetNamespace("stats").GetRegistry().Remove("otlpreportertest")
+	monitoring.NewInt(registry, "events").Set(42)
+
+	type receivedRequest struct {
+		ResourceMetrics []struct {
+			ScopeMetrics []struct {
+				Metrics []struct {
+					Name string          `json:"name"`
+					Sum  json.RawMessage `json:"sum"`
+				} `json:"metrics"`
+			} `json:"scopeMetrics"`
+		} `json:"resourceMetrics"`
+	}
+	requests := make(chan receivedRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/metrics", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+
+		var request receivedRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		select {
+		case requests <- request:
+		default:
+		}
+	}))
+	defer server.Close()
+
+	cfg := common.MustNewConfigFrom(map[string]interface{}{
+		"endpoint": server.URL + "/v1/metrics",
+		"headers":  map[string]string{"Authorization": "secret"},
+		"period":   "10ms",
+	})
+	r, err := makeReporter(beat.Info{Beat: "testbeat"}, report.Settings{}, cfg)
+	require.NoError(t, err)
+	defer r.Stop()
+
+	select {
+	case request := <-requests:
+		require.Len(t, request.ResourceMetrics, 1)
+		require.Len(t, request.ResourceMetrics[0].ScopeMetrics, 1)
+		found := false
+		for _, m := range request.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+			if m.Name == "otlpreportertest.events" {
+				found = true
+				assert.NotEmpty(t, m.Sum)
+			}
+		}
+		assert.True(t, found, "metric otlpreportertest.events not found")
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for metrics")
+	}
+}
@@ -69,6 +69,13 @@ var (
 	defaultConfig = config{}
 
 	reportFactories = map[string]ReporterFactory{}
+
+	// outputReporters are the reporters that send the data like the output of
+	// the same name, and can use its settings. Other reporters, like the file
+	// reporter, are unrelated to the outputs sharing their names.
+	outputReporters = map[string]bool{
+		"elasticsearch": true,
+	}
 )
 
 func RegisterReporterFactory(name string, f ReporterFactory) {
@@ -115,7 +122,7 @@ func getReporterConfig(
 		rc := config.Reporter.Config()
 
 		// merge reporter config with output config if both are present
-		if outCfg := outputs.Config(); outputs.Name() == name && outCfg != nil {
+		if outCfg := outputs.Config(); outputs.Name() == name && outputReporters[name] && outCfg != nil {
 			// require monitoring to not configure any hosts if output is configured:
 			hosts := hostsCfg{}
 			rc.Unpack(&hosts)
@@ -147,7 +154,7 @@ func getReporterConfig(
 	// find output also available for reporting telemetry.
 	if outputs.IsSet() {
 		name := outputs.Name()
-		if reportFactories[name] != nil {
+		if reportFactories[name] != nil && outputReporters[name] {
 			return name, outputs.Config(), nil
 		}
 	}
@@ -76,3 +76,24 @@ func newConfigWithHosts(hosts ...string) *common.Config {
 	}
 	return common.MustNewConfigFrom(map[string][]string{"hosts": hosts})
 }
+
+func TestGetReporterConfigMergesOutput(t *testing.T) {
+	var outputs common.ConfigNamespace
+	require.NoError(t, common.MustNewConfigFrom(map[string]interface{}{
+		"elasticsearch": map[string]interface{}{"hosts": []string{"o1"}, "username": "out"},
+	}).Unpack(&outputs))
+
+	name, cfg, err := getReporterConfig(common.MustNewConfigFrom(map[string]interface{}{
+		"elasticsearch": map[string]interface{}{"username": "monitoring"},
+	}), Settings{Format: FormatBulk}, outputs)
+	require.NoError(t, err)
+	require.Equal(t, "elasticsearch", name)
+
+	var settings struct {
+		Hosts    []string `config:"hosts"`
+		Username string   `config:"username"`
+	}
+	require.NoError(t, cfg.Unpack(&settings))
+	require.Equal(t, []string{"o1"}, settings.Hosts)
+	require.Equal(t, "monitoring", settings.Username)
+}
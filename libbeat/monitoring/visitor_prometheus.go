@@ -0,0 +1,387 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package monitoring
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Prometheus metric types.
+const (
+	PrometheusCounter = "counter"
+	PrometheusGauge   = "gauge"
+)
+
+// prometheusLabelKeys lists the string metrics that are reported as labels of
+// the numeric metrics in the same registry (and its children), like the type of
+// the output or the name of the queue. Other string metrics are ignored.
+var prometheusLabelKeys = map[string]bool{
+	"type":      true,
+	"name":      true,
+	"input":     true,
+	"module":    true,
+	"metricset": true,
+	"host":      true,
+	"id":        true,
+}
+
+// prometheusCounterKeys lists the last name segments of the metrics that only
+// increase. Keys that are ambiguous on their own are qualified by their parent
+// segment. All other numeric metrics are reported as gauges.
+var prometheusCounterKeys = map[string]bool{
+	"total":        true,
+	"acked":        true,
+	"added":        true,
+	"batches":      true,
+	"closed":       true,
+	"done":         true,
+	"dropped":      true,
+	"duplicates":   true,
+	"errors":       true,
+	"events":       true,
+	"failed":       true,
+	"failures":     true,
+	"filtered":     true,
+	"memory_total": true,
+	"published":    true,
+	"reloads":      true,
+	"renamed":      true,
+	"retry":        true,
+	"scans":        true,
+	"skipped":      true,
+	"started":      true,
+	"starts":       true,
+	"stops":        true,
+	"success":      true,
+	"ticks":        true,
+	"toomany":      true,
+	"truncated":    true,
+	"read.bytes":   true,
+	"write.bytes":  true,
+	"time.ms":      true,
+	"total.value":  true,
+	"uptime.ms":    true,
+}
+
+// PrometheusSnapshot collects metrics from registries as Prometheus metric
+// families, to be rendered in the Prometheus text exposition format or in the
+// OpenMetrics format.
+type PrometheusSnapshot struct {
+	families map[string]*prometheusFamily
+}
+
+type prometheusFamily struct {
+	name    string
+	typ     string
+	samples []prometheusSample
+}
+
+type prometheusSample struct {
+	labels map[string]string
+	value  float64
+}
+
+type prometheusVisitor struct {
+	snapshot *PrometheusSnapshot
+	prefix   string
+	idLabel  string
+
+	key    keyStack
+	frames []*prometheusFrame
+}
+
+// prometheusFrame holds the labels and metrics of a registry being visited.
+// Labels are only known once all the entries of the registry are visited, so
+// metrics are added to the snapshot when the root registry is finished.
+type prometheusFrame struct {
+	name    []string
+	labels  map[string]string
+	metrics []prometheusMetric
+}
+
+type prometheusMetric struct {
+	name   []string
+	typ    string
+	labels map[string]string
+	value  float64
+}
+
+// NewPrometheusSnapshot creates an empty snapshot.
+func NewPrometheusSnapshot() *PrometheusSnapshot {
+	return &PrometheusSnapshot{families: map[string]*prometheusFamily{}}
+}
+
+// Collect adds the metrics of the registry to the snapshot. Metric names are
+// the path of the metric in the registry, prefixed with prefix unless the path
+// already starts with it (e.g. beat.memstats.rss is reported as
+// beat_memstats_rss, not beat_beat_memstats_rss). If idLabel is
+// set, the keys of the registry are considered identifiers (e.g. of inputs)
+// and reported in this label instead of being part of the metric names.
+func (s *PrometheusSnapshot) Collect(r *Registry, mode Mode, prefix, idLabel string) {
+	if r == nil {
+		r = Default
+	}
+
+	vs := &prometheusVisitor{snapshot: s, prefix: prefix, idLabel: idLabel}
+	vs.key.stack = vs.key.stack0[:0]
+	r.Visit(mode, vs)
+}
+
+// Add adds a single sample to the snapshot.
+func (s *PrometheusSnapshot) Add(name, typ string, labels map[string]string, value float64) {
+	name = prometheusName(name)
+	f := s.families[name]
+	if f == nil {
+		f = &prometheusFamily{name: name, typ: typ}
+		s.families[name] = f
+	}
+	f.samples = append(f.samples, prometheusSample{labels: labels, value: value})
+}
+
+// WriteText writes the snapshot in the Prometheus text exposition format, or
+// in the OpenMetrics text format if openMetrics is set.
+func (s *PrometheusSnapshot) WriteText(w io.Writer, openMetrics bool) error {
+	names := make([]string, 0, len(s.families))
+	for name := range s.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		f := s.families[name]
+
+		// Counter samples have the _total suffix, that is not part of the
+		// family name in OpenMetrics.
+		sampleName := name
+		if f.typ == PrometheusCounter {
+			if openMetrics {
+				name = strings.TrimSuffix(name, "_total")
+			}
+			if !strings.HasSuffix(sampleName, "_total") {
+				sampleName += "_total"
+			}
+		}
+		if !openMetrics {
+			name = sampleName
+		}
+
+		bw.WriteString("# TYPE " + name + " " + f.typ + "\n")
+
+		sort.SliceStable(f.samples, func(i, j int) bool {
+			return formatPrometheusLabels(f.samples[i].labels) < formatPrometheusLabels(f.samples[j].labels)
+		})
+		for _, sample := range f.samples {
+			bw.WriteString(sampleName)
+			bw.WriteString(formatPrometheusLabels(sample.labels))
+			bw.WriteString(" ")
+			bw.WriteString(formatPrometheusValue(sample.value))
+			bw.WriteString("\n")
+		}
+	}
+	if openMetrics {
+		bw.WriteString("# EOF\n")
+	}
+	return bw.Flush()
+}
+
+func (vs *prometheusVisitor) OnRegistryStart() {
+	frame := &prometheusFrame{labels: map[string]string{}}
+	if depth := len(vs.frames); depth > 0 {
+		parent := vs.frames[depth-1]
+		key := vs.key.current
+		if depth == 1 && vs.idLabel != "" {
+			frame.labels[vs.idLabel] = key
+			frame.name = parent.name
+		} else {
+			frame.name = appendName(parent.name, key)
+		}
+	}
+	vs.frames = append(vs.frames, frame)
+}
+
+func (vs *prometheusVisitor) OnRegistryFinished() {
+	last := len(vs.frames) - 1
+	frame := vs.frames[last]
+	vs.frames = vs.frames[:last]
+
+	for i := range frame.metrics {
+		m := &frame.metrics[i]
+		for k, v := range frame.labels {
+			if _, exists := m.labels[k]; !exists {
+				m.labels[k] = v
+			}
+		}
+	}
+
+	if last == 0 {
+		for _, m := range frame.metrics {
+			name := strings.Join(m.name, "_")
+			if vs.prefix != "" && (len(m.name) == 0 || m.name[0] != vs.prefix) {
+				name = vs.prefix + "_" + name
+			}
+			vs.snapshot.Add(name, m.typ, m.labels, m.value)
+		}
+		return
+	}
+
+	parent := vs.frames[last-1]
+	parent.metrics = append(parent.metrics, frame.metrics...)
+	vs.key.pop()
+}
+
+func (vs *prometheusVisitor) OnKey(key string) {
+	vs.key.push(key)
+}
+
+func (vs *prometheusVisitor) OnString(s string) {
+	key := vs.key.current
+	vs.key.pop()
+
+	if prometheusLabelKeys[key] && len(vs.frames) > 0 {
+		vs.frames[len(vs.frames)-1].labels[key] = s
+	}
+}
+
+func (vs *prometheusVisitor) OnBool(b bool) {
+	v := 0.0
+	if b {
+		v = 1
+	}
+	vs.addMetric(PrometheusGauge, v)
+}
+
+func (vs *prometheusVisitor) OnInt(i int64) {
+	vs.addMetric(vs.metricType(), float64(i))
+}
+
+func (vs *prometheusVisitor) OnFloat(f float64) {
+	vs.addMetric(vs.metricType(), f)
+}
+
+func (vs *prometheusVisitor) OnStringSlice(f []string) {
+	vs.key.pop()
+}
+
+func (vs *prometheusVisitor) metricType() string {
+	var parent string
+	if n := len(vs.key.stack); n > 0 {
+		parent = vs.key.stack[n-1]
+	}
+	if isCounterKey(parent, vs.key.current) {
+		return PrometheusCounter
+	}
+	return PrometheusGauge
+}
+
+// IsCounter returns true if the metric with the given dotted name only
+// increases, based on the same keys used to report Prometheus counters.
+func IsCounter(name string) bool {
+	var parent string
+	key := name
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		key = name[i+1:]
+		parent = name[:i]
+		if j := strings.LastIndexByte(parent, '.'); j >= 0 {
+			parent = parent[j+1:]
+		}
+	}
+	return isCounterKey(parent, key)
+}
+
+func isCounterKey(parent, key string) bool {
+	return prometheusCounterKeys[key] || (parent != "" && prometheusCounterKeys[parent+"."+key])
+}
+
+func (vs *prometheusVisitor) addMetric(typ string, value float64) {
+	key := vs.key.current
+	vs.key.pop()
+
+	if len(vs.frames) == 0 {
+		return
+	}
+	frame := vs.frames[len(vs.frames)-1]
+	frame.metrics = append(frame.metrics, prometheusMetric{
+		name:   appendName(frame.name, key),
+		typ:    typ,
+		labels: map[string]string{},
+		value:  value,
+	})
+}
+
+func appendName(name []string, key string) []string {
+	n := make([]string, len(name), len(name)+1)
+	copy(n, name)
+	return append(n, key)
+}
+
+// prometheusName replaces the characters that are not valid in Prometheus
+// metric and label names.
+func prometheusName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == ':' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+var prometheusLabelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func formatPrometheusLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("{")
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(prometheusName(k))
+		b.WriteString(`="`)
+		b.WriteString(prometheusLabelValueReplacer.Replace(labels[k]))
+		b.WriteString(`"`)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func formatPrometheusValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,96 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+
+package monitoring
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheusSnapshot(t *testing.T) {
+	stats := NewRegistry()
+	NewUint(stats, "libbeat.pipeline.events.total").Set(10)
+	NewUint(stats, "libbeat.pipeline.events.active").Set(2)
+	NewString(stats, "libbeat.output.type").Set("elasticsearch")
+	NewUint(stats, "libbeat.output.events.acked").Set(8)
+	NewUint(stats, "libbeat.output.write.bytes").Set(1024)
+	NewFloat(stats, "system.load.1").Set(0.5)
+	NewUint(stats, "beat.cgroup.memory.mem.usage.bytes").Set(4096)
+	NewBool(stats, "management.enabled").Set(true)
+	NewString(stats, "beat.info.ephemeral_id").Set("ignored")
+
+	dataset := NewRegistry()
+	input := dataset.NewRegistry("input-1")
+	NewString(input, "input").Set("tcp")
+	NewString(input, "start_time").Set("ignored")
+	NewUint(input, "bytes").Set(42)
+	NewString(dataset, "input-2.name").Set("/var/log/syslog\"")
+	NewInt(dataset, "input-2.read_offset").Set(7)
+
+	snapshot := NewPrometheusSnapshot()
+	snapshot.Add("beat_info", PrometheusGauge, map[string]string{"beat": "testbeat"}, 1)
+	snapshot.Collect(stats, Full, "beat", "")
+	snapshot.Collect(dataset, Full, "beat_dataset", "id")
+
+	var buf bytes.Buffer
+	if err := snapshot.WriteText(&buf, false); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `# TYPE beat_cgroup_memory_mem_usage_bytes gauge
+beat_cgroup_memory_mem_usage_bytes 4096
+# TYPE beat_dataset_bytes gauge
+beat_dataset_bytes{id="input-1",input="tcp"} 42
+# TYPE beat_dataset_read_offset gauge
+beat_dataset_read_offset{id="input-2",name="/var/log/syslog\""} 7
+# TYPE beat_info gauge
+beat_info{beat="testbeat"} 1
+# TYPE beat_libbeat_output_events_acked_total counter
+beat_libbeat_output_events_acked_total{type="elasticsearch"} 8
+# TYPE beat_libbeat_output_write_bytes_total counter
+beat_libbeat_output_write_bytes_total{type="elasticsearch"} 1024
+# TYPE beat_libbeat_pipeline_events_active gauge
+beat_libbeat_pipeline_events_active 2
+# TYPE beat_libbeat_pipeline_events_total counter
+beat_libbeat_pipeline_events_total 10
+# TYPE beat_management_enabled gauge
+beat_management_enabled 1
+# TYPE beat_system_load_1 gauge
+beat_system_load_1 0.5
+`, buf.String())
+
+	buf.Reset()
+	if err := snapshot.WriteText(&buf, true); err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, buf.String(), "# TYPE beat_libbeat_output_events_acked counter\nbeat_libbeat_output_events_acked_total{type=\"elasticsearch\"} 8\n")
+	assert.Contains(t, buf.String(), "# TYPE beat_libbeat_pipeline_events counter\nbeat_libbeat_pipeline_events_total 10\n")
+	assert.True(t, bytes.HasSuffix(buf.Bytes(), []byte("# EOF\n")))
+}
+
+func TestIsCounter(t *testing.T) {
+	assert.True(t, IsCounter("libbeat.output.events.acked"))
+	assert.True(t, IsCounter("libbeat.output.write.bytes"))
+	assert.True(t, IsCounter("total"))
+	assert.False(t, IsCounter("libbeat.output.bytes"))
+	assert.False(t, IsCounter("libbeat.pipeline.clients"))
+	assert.False(t, IsCounter("beat.memstats.memory_alloc"))
+}
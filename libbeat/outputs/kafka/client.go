@@ -45,6 +45,7 @@ type client struct {
 	hosts    []string
 	topic    outil.Selector
 	key      *fmtstr.EventFormatString
+	headers  []headerConfig
 	index    string
 	codec    codec.Codec
 	config   sarama.Config
@@ -75,6 +76,7 @@ func newKafkaClient(
 	hosts []string,
 	index string,
 	key *fmtstr.EventFormatString,
+	headers []headerConfig,
 	topic outil.Selector,
 	writer codec.Codec,
 	cfg *sarama.Config,
@@ -85,6 +87,7 @@ func newKafkaClient(
 		hosts:    hosts,
 		topic:    topic,
 		key:      key,
+		headers:  headers,
 		index:    strings.ToLower(index),
 		codec:    writer,
 		config:   *cfg,
@@ -228,6 +231,17 @@ func (c *client) getEventMessage(data *publisher.Event) (*message, error) {
 		}
 	}
 
+	for _, h := range c.headers {
+		value, err := h.Value.RunBytes(event)
+		if err != nil {
+			if c.log.IsDebug() {
+				c.log.Debugf("skipping kafka header %v: %v", h.Key, err)
+			}
+			continue
+		}
+		msg.headers = append(msg.headers, sarama.RecordHeader{Key: []byte(h.Key), Value: value})
+	}
+
 	return msg, nil
 }
 
@@ -0,0 +1,66 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	_ "github.com/elastic/beats/v7/libbeat/outputs/codec/json"
+	"github.com/elastic/beats/v7/libbeat/publisher"
+)
+
+func TestEventMessageHeaders(t *testing.T) {
+	cfg := common.MustNewConfigFrom(map[string]interface{}{
+		"hosts": []string{"localhost:9092"},
+		"topic": "test",
+		"headers": []map[string]interface{}{
+			{"key": "tenant", "value": "acme"},
+			{"key": "dataset", "value": "%{[event.dataset]}"},
+			{"key": "missing", "value": "%{[not.existing]}"},
+		},
+	})
+	grp, err := makeKafka(nil, beat.Info{Beat: "libbeat"}, outputs.NewNilObserver(), cfg)
+	require.NoError(t, err)
+	client := grp.Clients[0].(*client)
+
+	event := publisher.Event{Content: beat.Event{
+		Timestamp: time.Now(),
+		Fields: common.MapStr{
+			"event":   common.MapStr{"dataset": "nginx.access"},
+			"message": "hello",
+		},
+	}}
+	msg, err := client.getEventMessage(&event)
+	require.NoError(t, err)
+
+	assert.Equal(t, []sarama.RecordHeader{
+		{Key: []byte("tenant"), Value: []byte("acme")},
+		{Key: []byte("dataset"), Value: []byte("nginx.access")},
+	}, msg.headers)
+
+	msg.initProducerMessage()
+	assert.Equal(t, msg.headers, msg.msg.Headers)
+}
@@ -51,6 +51,7 @@ type kafkaConfig struct {
 	Timeout            time.Duration             `config:"timeout"             validate:"min=1"`
 	Metadata           metaConfig                `config:"metadata"`
 	Key                *fmtstr.EventFormatString `config:"key"`
+	Headers            []headerConfig            `config:"headers"`
 	Partition          map[string]*common.Config `config:"partition"`
 	KeepAlive          time.Duration             `config:"keep_alive"          validate:"min=0"`
 	MaxMessageBytes    *int                      `config:"max_message_bytes"   validate:"min=1"`
@@ -62,6 +63,7 @@ type kafkaConfig struct {
 	BulkMaxSize        int                       `config:"bulk_max_size"`
 	BulkFlushFrequency time.Duration             `config:"bulk_flush_frequency"`
 	MaxRetries         int                       `config:"max_retries"         validate:"min=-1,nonzero"`
+	Idempotent         bool                      `config:"idempotent"`
 	Backoff            backoffConfig             `config:"backoff"`
 	ClientID           string                    `config:"client_id"`
 	ChanBufferSize     int                       `config:"channel_buffer_size" validate:"min=1"`
@@ -72,6 +74,11 @@ type kafkaConfig struct {
 	EnableFAST         bool                      `config:"enable_krb5_fast"`
 }
 
+type headerConfig struct {
+	Key   string                    `config:"key"   validate:"required"`
+	Value *fmtstr.EventFormatString `config:"value" validate:"required"`
+}
+
 type saslConfig struct {
 	SaslMechanism string `config:"mechanism"`
 }
@@ -198,6 +205,22 @@ func (c *kafkaConfig) Validate() error {
 			return fmt.Errorf("compression_level must be between 0 and 9")
 		}
 	}
+
+	version, _ := c.Version.Get()
+	if len(c.Headers) > 0 && !version.IsAtLeast(sarama.V0_11_0_0) {
+		return fmt.Errorf("headers require kafka version 0.11 or newer, found %v", c.Version)
+	}
+
+	if c.Idempotent {
+		if !version.IsAtLeast(sarama.V0_11_0_0) {
+			return fmt.Errorf("idempotent producer requires kafka version 0.11 or newer, found %v", c.Version)
+		}
+		// Producer ids and sequence numbers are only checked by the brokers
+		// if all the in-sync replicas acknowledge the messages.
+		if c.RequiredACKs != nil && sarama.RequiredAcks(*c.RequiredACKs) != sarama.WaitForAll {
+			return fmt.Errorf("idempotent producer requires required_acks to be -1, found %v", *c.RequiredACKs)
+		}
+	}
 	return nil
 }
 
@@ -269,6 +292,13 @@ func newSaramaConfig(log *logp.Logger, config *kafkaConfig) (*sarama.Config, err
 	if config.RequiredACKs != nil {
 		k.Producer.RequiredAcks = sarama.RequiredAcks(*config.RequiredACKs)
 	}
+	if config.Idempotent {
+		// Sequence numbers are assigned per partition, only one in-flight
+		// request per broker guarantees that retried batches keep the order.
+		k.Producer.Idempotent = true
+		k.Producer.RequiredAcks = sarama.WaitForAll
+		k.Net.MaxOpenRequests = 1
+	}
 
 	compressionMode, ok := compressionModes[strings.ToLower(config.Compression)]
 	if !ok {
@@ -23,6 +23,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/common"
 	"github.com/elastic/beats/v7/libbeat/internal/testutil"
@@ -50,6 +53,19 @@ func TestConfigAcceptValid(t *testing.T) {
 				"realm":        "ELASTIC",
 			},
 		},
+		"headers": common.MapStr{
+			"headers": []common.MapStr{
+				{"key": "tenant", "value": "acme"},
+				{"key": "dataset", "value": "%{[event.dataset]}"},
+			},
+		},
+		"idempotent producer": common.MapStr{
+			"idempotent": true,
+		},
+		"idempotent producer with required_acks -1": common.MapStr{
+			"idempotent":    true,
+			"required_acks": -1,
+		},
 		"Kerberos with user and password pair": common.MapStr{
 			"kerberos": common.MapStr{
 				"auth_type":    "password",
@@ -88,6 +104,24 @@ func TestConfigInvalid(t *testing.T) {
 				"realm":        "ELASTIC",
 			},
 		},
+		"headers with kafka version before 0.11": common.MapStr{
+			"version": "0.10.2",
+			"headers": []common.MapStr{{"key": "tenant", "value": "acme"}},
+		},
+		"header without key": common.MapStr{
+			"headers": []common.MapStr{{"value": "acme"}},
+		},
+		"header without value": common.MapStr{
+			"headers": []common.MapStr{{"key": "tenant"}},
+		},
+		"idempotent producer with kafka version before 0.11": common.MapStr{
+			"idempotent": true,
+			"version":    "0.10.2",
+		},
+		"idempotent producer without acks from all replicas": common.MapStr{
+			"idempotent":    true,
+			"required_acks": 1,
+		},
 	}
 
 	for name, test := range tests {
@@ -103,6 +137,26 @@ func TestConfigInvalid(t *testing.T) {
 	}
 }
 
+func TestIdempotentProducerConfig(t *testing.T) {
+	c := common.MustNewConfigFrom(common.MapStr{
+		"hosts":      []string{"localhost"},
+		"idempotent": true,
+	})
+	cfg, err := readConfig(c)
+	if err != nil {
+		t.Fatalf("Can not create test configuration: %v", err)
+	}
+	k, err := newSaramaConfig(logp.L(), cfg)
+	if err != nil {
+		t.Fatalf("Failure creating sarama config: %v", err)
+	}
+
+	assert.True(t, k.Producer.Idempotent)
+	assert.Equal(t, sarama.WaitForAll, k.Producer.RequiredAcks)
+	assert.Equal(t, 1, k.Net.MaxOpenRequests)
+	assert.True(t, k.Producer.Retry.Max >= 1)
+}
+
 func TestBackoffFunc(t *testing.T) {
 	testutil.SeedPRNG(t)
 	tests := map[int]backoffConfig{
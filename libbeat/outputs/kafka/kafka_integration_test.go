@@ -182,6 +182,31 @@ func TestKafkaPublish(t *testing.T) {
 				"type": "log",
 			}),
 		},
+		{
+			"batch publish with static and formatted headers",
+			map[string]interface{}{
+				"headers": []map[string]interface{}{
+					{"key": "tenant", "value": "acme"},
+					{"key": "type", "value": "%{[type]}"},
+				},
+			},
+			testTopic,
+			randMulti(5, 100, common.MapStr{
+				"host": "test-host",
+				"type": "log",
+			}),
+		},
+		{
+			"batch publish with idempotent producer",
+			map[string]interface{}{
+				"idempotent": true,
+			},
+			testTopic,
+			randMulti(5, 100, common.MapStr{
+				"host": "test-host",
+				"type": "log",
+			}),
+		},
 	}
 
 	defaultConfig := map[string]interface{}{
@@ -248,6 +273,9 @@ func TestKafkaPublish(t *testing.T) {
 			for _, s := range stored {
 				msg := validate(t, s.Value, expected)
 				seenMsgs[msg] = struct{}{}
+				if headers, exists := test.config["headers"]; exists {
+					validateHeaders(t, s, headers.([]map[string]interface{}), findEvent(expected, msg))
+				}
 			}
 			assert.Equal(t, len(expected), len(seenMsgs))
 		})
@@ -274,6 +302,27 @@ func validateJSON(t *testing.T, value []byte, events []beat.Event) string {
 	return msg
 }
 
+func validateHeaders(t *testing.T, msg *sarama.ConsumerMessage, headers []map[string]interface{}, event *beat.Event) {
+	if event == nil {
+		return
+	}
+
+	expected := map[string]string{}
+	for _, h := range headers {
+		value, err := fmtstr.MustCompileEvent(h["value"].(string)).Run(event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected[h["key"].(string)] = value
+	}
+
+	actual := map[string]string{}
+	for _, h := range msg.Headers {
+		actual[string(h.Key)] = string(h.Value)
+	}
+	assert.Equal(t, expected, actual)
+}
+
 func makeValidateFmtStr(fmt string) func(*testing.T, []byte, []beat.Event) string {
 	fmtString := fmtstr.MustCompileEvent(fmt)
 	return func(t *testing.T, value []byte, events []beat.Event) string {
@@ -331,7 +380,10 @@ func makeConfig(t *testing.T, in map[string]interface{}) *common.Config {
 
 func newTestConsumer(t *testing.T) sarama.Consumer {
 	hosts := []string{getTestKafkaHost()}
-	consumer, err := sarama.NewConsumer(hosts, nil)
+	config := sarama.NewConfig()
+	// Record headers are only returned since kafka 0.11.
+	config.Version = sarama.V1_0_0_0
+	consumer, err := sarama.NewConsumer(hosts, config)
 	if err != nil {
 		t.Fatal(err)
 	}
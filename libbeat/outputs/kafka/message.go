@@ -28,11 +28,12 @@ import (
 type message struct {
 	msg sarama.ProducerMessage
 
-	topic string
-	key   []byte
-	value []byte
-	ref   *msgRef
-	ts    time.Time
+	topic   string
+	key     []byte
+	value   []byte
+	headers []sarama.RecordHeader
+	ref     *msgRef
+	ts      time.Time
 
 	hash      uint32
 	partition int32
@@ -48,6 +49,7 @@ func (m *message) initProducerMessage() {
 		Topic:     m.topic,
 		Key:       sarama.ByteEncoder(m.key),
 		Value:     sarama.ByteEncoder(m.value),
+		Headers:   m.headers,
 		Timestamp: m.ts,
 	}
 }
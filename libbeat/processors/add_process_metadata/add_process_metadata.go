@@ -264,6 +264,27 @@ func (p *addProcessMetadata) getContainerID(pid int) (string, error) {
 	return cid, nil
 }
 
+// GetProcessMetadata returns the default process fields for the given PID,
+// as the processor adds them under the process key. Lookups share the
+// process cache of the processors.
+func GetProcessMetadata(pid int) (common.MapStr, error) {
+	meta, err := procCache.GetProcessMetadata(pid)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		return nil, ErrNoProcess
+	}
+
+	process := common.MapStr{}
+	for key := range defaultFields["process"].(common.MapStr) {
+		if value, err := meta.fields.GetValue("process." + key); err == nil {
+			process[key] = value
+		}
+	}
+	return process, nil
+}
+
 type addProcessMetadataCloser struct {
 	addProcessMetadata
 }
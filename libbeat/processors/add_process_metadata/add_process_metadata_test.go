@@ -824,3 +824,17 @@ func TestBadProcess(t *testing.T) {
 	assert.NotNil(t, result.Fields)
 	assert.Equal(t, ev.Fields, result.Fields)
 }
+
+func TestGetProcessMetadata(t *testing.T) {
+	selfPID := os.Getpid()
+	process, err := GetProcessMetadata(selfPID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, selfPID, process["pid"])
+	assert.NotEmpty(t, process["executable"])
+	assert.NotContains(t, process, "env")
+
+	_, err = GetProcessMetadata(0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,193 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package logpublish publishes the log records of the Beat as events through
+// the publisher pipeline, so they are shipped to the configured output.
+package logpublish
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/logp"
+	"github.com/elastic/beats/v7/libbeat/monitoring"
+)
+
+const logSelector = "log_publisher"
+
+// internalLoggers are the loggers of the publishing path. Their records are
+// never published, as errors while publishing would generate new records
+// to publish in a loop.
+var internalLoggers = []string{
+	logSelector,
+	"publisher",
+	"publish",
+	"publisher_pipeline_output",
+	"publisher_processing",
+	"memqueue",
+	"diskqueue",
+	"spool",
+	"transport",
+	"esclientleg",
+	"elasticsearch",
+	"logstash",
+	"kafka",
+	"redis",
+	"file",
+	"console",
+	"rotator",
+}
+
+var (
+	publishedRecords = monitoring.NewInt(nil, "libbeat.logging.publish.published")
+	droppedRecords   = monitoring.NewInt(nil, "libbeat.logging.publish.dropped")
+	skippedRecords   = monitoring.NewInt(nil, "libbeat.logging.publish.skipped")
+)
+
+// Publisher reads the records captured by logp and publishes them as ECS
+// events.
+type Publisher struct {
+	log      *logp.Logger
+	queue    *logp.RecordQueue
+	client   beat.Client
+	excluded map[string]struct{}
+	dataset  string
+
+	done    chan struct{}
+	wg      sync.WaitGroup
+	dropped uint64
+}
+
+// New connects to the pipeline and starts publishing the records of the
+// queue. Records of the internal loggers of the publishing path and of the
+// excluded loggers are skipped.
+func New(info beat.Info, pipeline beat.Pipeline, queue *logp.RecordQueue, excluded ...string) (*Publisher, error) {
+	client, err := pipeline.ConnectWith(beat.ClientConfig{
+		// Never block on a full pipeline, records are dropped instead.
+		PublishMode: beat.DropIfFull,
+		Events:      eventer{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Publisher{
+		log:      logp.NewLogger(logSelector),
+		queue:    queue,
+		client:   client,
+		excluded: map[string]struct{}{},
+		dataset:  info.Beat + ".log",
+		done:     make(chan struct{}),
+	}
+	for _, name := range append(internalLoggers, excluded...) {
+		p.excluded[name] = struct{}{}
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.run()
+	}()
+	return p, nil
+}
+
+// Stop stops publishing records and closes the pipeline client.
+func (p *Publisher) Stop() {
+	close(p.done)
+	p.wg.Wait()
+	p.client.Close()
+}
+
+func (p *Publisher) run() {
+	p.log.Info("Publishing log records as events.")
+	for {
+		select {
+		case <-p.done:
+			return
+		case r := <-p.queue.Records():
+			p.updateDropped()
+			if p.isExcluded(r.LoggerName) {
+				skippedRecords.Inc()
+				continue
+			}
+			p.client.Publish(p.makeEvent(r))
+		}
+	}
+}
+
+func (p *Publisher) updateDropped() {
+	dropped := p.queue.Dropped()
+	if dropped > p.dropped {
+		droppedRecords.Add(int64(dropped - p.dropped))
+		p.dropped = dropped
+	}
+}
+
+// isExcluded returns true if the logger or any of its parents is excluded.
+func (p *Publisher) isExcluded(name string) bool {
+	for {
+		if _, found := p.excluded[name]; found {
+			return true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return false
+		}
+		name = name[:i]
+	}
+}
+
+func (p *Publisher) makeEvent(r logp.Record) beat.Event {
+	fields := common.MapStr{}
+	for k, v := range r.Fields {
+		// Errors are logged as strings, in ECS error is an object.
+		if msg, ok := v.(string); ok && k == "error" {
+			k, v = "error.message", msg
+		}
+		fields.Put(k, v)
+	}
+
+	fields.Put("message", r.Message)
+	fields.Put("log.level", r.Level.String())
+	fields.Put("event.dataset", p.dataset)
+	if r.LoggerName != "" {
+		fields.Put("log.logger", r.LoggerName)
+	}
+	if r.Caller.Defined {
+		fields.Put("log.origin.file.name", r.Caller.TrimmedPath())
+		fields.Put("log.origin.file.line", r.Caller.Line)
+	}
+	if r.Stack != "" {
+		fields.Put("error.stack_trace", r.Stack)
+	}
+
+	return beat.Event{
+		Timestamp: r.Time,
+		Fields:    fields,
+	}
+}
+
+// eventer counts the events published to the pipeline.
+type eventer struct{}
+
+func (eventer) Closing()                    {}
+func (eventer) Closed()                     {}
+func (eventer) Published()                  { publishedRecords.Inc() }
+func (eventer) FilteredOut(beat.Event)      {}
+func (eventer) DroppedOnPublish(beat.Event) { droppedRecords.Inc() }
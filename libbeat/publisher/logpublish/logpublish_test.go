@@ -0,0 +1,88 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logpublish
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/logp"
+	pubtest "github.com/elastic/beats/v7/libbeat/publisher/testing"
+)
+
+func TestPublisher(t *testing.T) {
+	err := logp.DevelopmentSetup(logp.ToObserverOutput(), logp.WithPublish(logp.PublishConfig{
+		Enabled:    true,
+		Level:      logp.WarnLevel,
+		BufferSize: 10,
+	}))
+	require.NoError(t, err)
+	defer logp.DevelopmentSetup(logp.ToObserverOutput())
+
+	client := pubtest.NewChanClient(10)
+	p, err := New(beat.Info{Beat: "testbeat"}, pubtest.PublisherWithClient(client), logp.PublishQueue(), "custom")
+	require.NoError(t, err)
+	defer p.Stop()
+
+	logp.NewLogger("elasticsearch").Error("failed to publish events")
+	logp.NewLogger("publisher").Named("output").Error("failed to publish events")
+	logp.NewLogger("custom").Error("excluded logger")
+	logp.NewLogger("foo").Errorw("something failed", "error", errors.New("boom"), "service.id", "abc")
+
+	select {
+	case event := <-client.Channel:
+		assert.Equal(t, "something failed", mustGet(t, event, "message"))
+		assert.Equal(t, "error", mustGet(t, event, "log.level"))
+		assert.Equal(t, "foo", mustGet(t, event, "log.logger"))
+		assert.Equal(t, "testbeat.log", mustGet(t, event, "event.dataset"))
+		assert.Equal(t, "boom", mustGet(t, event, "error.message"))
+		assert.Equal(t, "abc", mustGet(t, event, "service.id"))
+		assert.Contains(t, mustGet(t, event, "log.origin.file.name"), "logpublish_test.go")
+		assert.False(t, event.Timestamp.IsZero())
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for event")
+	}
+
+	select {
+	case event := <-client.Channel:
+		t.Fatalf("unexpected event: %v", event.Fields)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestIsExcluded(t *testing.T) {
+	p := Publisher{excluded: map[string]struct{}{"publisher": {}}}
+
+	assert.True(t, p.isExcluded("publisher"))
+	assert.True(t, p.isExcluded("publisher.output"))
+	assert.False(t, p.isExcluded("publisher_other"))
+	assert.False(t, p.isExcluded(""))
+}
+
+func mustGet(t *testing.T, event beat.Event, key string) interface{} {
+	t.Helper()
+	v, err := common.MapStr(event.Fields).GetValue(key)
+	require.NoError(t, err, key)
+	return v
+}
@@ -22,16 +22,45 @@ package socket
 import (
 	"os"
 	"sync/atomic"
+	"syscall"
 
 	"github.com/pkg/errors"
 
+	"github.com/elastic/gosigar/sys"
 	"github.com/elastic/gosigar/sys/linux"
 )
 
+const (
+	// inetDiagInfo is the attribute of the tcp_info struct in inet_diag
+	// responses. It is requested by setting its bit in the extensions field.
+	inetDiagInfo    = 2
+	inetDiagInfoExt = 1 << (inetDiagInfo - 1)
+
+	// Offsets in the request and in the responses of inet_diag.
+	inetDiagReqV2ExtOffset = 2
+	sizeofInetDiagMsg      = 72
+
+	// Offsets of tcpi_bytes_acked and tcpi_bytes_received in tcp_info. They
+	// are available since Linux 4.2.
+	tcpInfoBytesAckedOffset    = 120
+	tcpInfoBytesReceivedOffset = 128
+
+	tcpInfoReadBufferSize = 32 * 1024
+)
+
+var byteOrder = sys.GetEndian()
+
+// TCPBytes contains the byte counters of a TCP socket.
+type TCPBytes struct {
+	Sent     uint64 // Bytes sent and acknowledged by the peer.
+	Received uint64 // Bytes received.
+}
+
 // NetlinkSession communicates with the kernel's netlink subsystem.
 type NetlinkSession struct {
-	readBuffer []byte
-	seq        uint32
+	readBuffer        []byte
+	tcpInfoReadBuffer []byte
+	seq               uint32
 }
 
 // NewNetlinkSession creates a new netlink session.
@@ -52,3 +81,117 @@ func (session *NetlinkSession) GetSocketList() ([]*linux.InetDiagMsg, error) {
 	}
 	return sockets, nil
 }
+
+// GetTCPBytes retrieves the byte counters of the TCP sockets from the kernel,
+// indexed by socket inode. Sockets are not included if the kernel doesn't
+// provide these counters.
+func (session *NetlinkSession) GetTCPBytes() (map[uint32]TCPBytes, error) {
+	s, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW, syscall.NETLINK_INET_DIAG)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open netlink socket")
+	}
+	defer syscall.Close(s)
+
+	if session.tcpInfoReadBuffer == nil {
+		session.tcpInfoReadBuffer = make([]byte, tcpInfoReadBufferSize)
+	}
+
+	counters := map[uint32]TCPBytes{}
+	for _, af := range []linux.AddressFamily{linux.AF_INET, linux.AF_INET6} {
+		req := linux.NewInetDiagReqV2(af)
+		req.Header.Seq = atomic.AddUint32(&session.seq, 1)
+		req.Data[inetDiagReqV2ExtOffset] = inetDiagInfoExt
+
+		lsa := &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}
+		if err := syscall.Sendto(s, serializeNetlinkMessage(req), 0, lsa); err != nil {
+			return nil, errors.Wrap(err, "failed requesting socket dump")
+		}
+
+		if err := session.receiveTCPBytes(s, counters); err != nil {
+			return nil, err
+		}
+	}
+	return counters, nil
+}
+
+// receiveTCPBytes reads the responses of a inet_diag dump request and adds
+// the byte counters of the sockets to counters.
+func (session *NetlinkSession) receiveTCPBytes(s int, counters map[uint32]TCPBytes) error {
+	for {
+		nr, _, err := syscall.Recvfrom(s, session.tcpInfoReadBuffer, 0)
+		if err != nil {
+			return errors.Wrap(err, "failed reading socket dump")
+		}
+		if nr < syscall.NLMSG_HDRLEN {
+			return syscall.EINVAL
+		}
+
+		msgs, err := syscall.ParseNetlinkMessage(session.tcpInfoReadBuffer[:nr])
+		if err != nil {
+			return err
+		}
+
+		for _, m := range msgs {
+			switch m.Header.Type {
+			case syscall.NLMSG_DONE:
+				return nil
+			case syscall.NLMSG_ERROR:
+				return linux.ParseNetlinkError(m.Data)
+			}
+
+			diag, err := linux.ParseInetDiagMsg(m.Data)
+			if err != nil {
+				return err
+			}
+			if diag.Inode == 0 || len(m.Data) < sizeofInetDiagMsg {
+				continue
+			}
+			if bytes, found := parseTCPBytes(m.Data[sizeofInetDiagMsg:]); found {
+				counters[diag.Inode] = bytes
+			}
+		}
+	}
+}
+
+// parseTCPBytes looks for the tcp_info attribute in the attributes of a
+// inet_diag response, and returns the byte counters it contains.
+func parseTCPBytes(attrs []byte) (TCPBytes, bool) {
+	for len(attrs) >= syscall.SizeofRtAttr {
+		length := int(byteOrder.Uint16(attrs[0:2]))
+		attrType := byteOrder.Uint16(attrs[2:4])
+		if length < syscall.SizeofRtAttr || length > len(attrs) {
+			break
+		}
+
+		if attrType == inetDiagInfo {
+			info := attrs[syscall.SizeofRtAttr:length]
+			if len(info) < tcpInfoBytesReceivedOffset+8 {
+				return TCPBytes{}, false
+			}
+			return TCPBytes{
+				Sent:     byteOrder.Uint64(info[tcpInfoBytesAckedOffset:]),
+				Received: byteOrder.Uint64(info[tcpInfoBytesReceivedOffset:]),
+			}, true
+		}
+
+		// Attributes are aligned to 4 bytes.
+		aligned := (length + syscall.RTA_ALIGNTO - 1) &^ (syscall.RTA_ALIGNTO - 1)
+		if aligned >= len(attrs) {
+			break
+		}
+		attrs = attrs[aligned:]
+	}
+	return TCPBytes{}, false
+}
+
+func serializeNetlinkMessage(msg syscall.NetlinkMessage) []byte {
+	msg.Header.Len = uint32(syscall.SizeofNlMsghdr + len(msg.Data))
+	b := make([]byte, msg.Header.Len)
+	byteOrder.PutUint32(b[0:4], msg.Header.Len)
+	byteOrder.PutUint16(b[4:6], msg.Header.Type)
+	byteOrder.PutUint16(b[6:8], msg.Header.Flags)
+	byteOrder.PutUint32(b[8:12], msg.Header.Seq)
+	byteOrder.PutUint32(b[12:16], msg.Header.Pid)
+	copy(b[16:], msg.Data)
+	return b
+}
@@ -0,0 +1,96 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package socket
+
+import (
+	"github.com/elastic/beats/v7/libbeat/common"
+)
+
+// tcpStates are the names of the TCP states, as reported in /proc/net/tcp.
+var tcpStates = map[uint8]string{
+	0x01: "established",
+	0x02: "syn_sent",
+	0x03: "syn_recv",
+	0x04: "fin_wait1",
+	0x05: "fin_wait2",
+	0x06: "time_wait",
+	0x07: "close",
+	0x08: "close_wait",
+	0x09: "last_ack",
+	0x0A: "listen",
+	0x0B: "closing",
+}
+
+// NetworkStats contains the number of sockets and the TCP byte counters of
+// one or more processes.
+type NetworkStats struct {
+	TCP       int            // Number of TCP sockets.
+	TCPStates map[string]int // Number of TCP sockets by state.
+	UDP       int            // Number of UDP sockets.
+
+	// TCP byte counters, they are only set if HasTCPBytes is true.
+	HasTCPBytes      bool
+	TCPBytesSent     uint64
+	TCPBytesReceived uint64
+}
+
+// Add adds the sockets and counters of other to s.
+func (s *NetworkStats) Add(other *NetworkStats) {
+	s.TCP += other.TCP
+	s.UDP += other.UDP
+	for state, count := range other.TCPStates {
+		if s.TCPStates == nil {
+			s.TCPStates = map[string]int{}
+		}
+		s.TCPStates[state] += count
+	}
+	if other.HasTCPBytes {
+		s.HasTCPBytes = true
+		s.TCPBytesSent += other.TCPBytesSent
+		s.TCPBytesReceived += other.TCPBytesReceived
+	}
+}
+
+// ToMapStr returns the event fields of the stats.
+func (s *NetworkStats) ToMapStr() common.MapStr {
+	tcpSockets := common.MapStr{
+		"total": s.TCP,
+	}
+	for state, count := range s.TCPStates {
+		tcpSockets[state] = count
+	}
+
+	tcp := common.MapStr{
+		"sockets": tcpSockets,
+	}
+	if s.HasTCPBytes {
+		tcp["bytes"] = common.MapStr{
+			"sent":     s.TCPBytesSent,
+			"received": s.TCPBytesReceived,
+		}
+	}
+
+	return common.MapStr{
+		"tcp": tcp,
+		"udp": common.MapStr{
+			"sockets": common.MapStr{
+				"total": s.UDP,
+			},
+		},
+	}
+}
@@ -0,0 +1,238 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build linux
+
+package socket
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/v7/libbeat/logp"
+)
+
+// socketEntry is a socket found in the socket tables of /proc/<pid>/net.
+type socketEntry struct {
+	protocol string
+	state    uint8
+}
+
+// ProcessSocketsTable collects the network usage of processes, by mapping the
+// socket inodes found in /proc/<pid>/fd to the entries of the TCP and UDP
+// socket tables. Socket tables are read once per network namespace between
+// refreshes, so they can be shared by all the processes in the namespace.
+type ProcessSocketsTable struct {
+	procPath string
+	netlink  *NetlinkSession
+	log      *logp.Logger
+
+	namespaces map[string]map[uint32]socketEntry
+	tcpBytes   map[uint32]TCPBytes
+}
+
+// NewProcessSocketsTable returns a new ProcessSocketsTable that reads data from
+// the given proc filesystem mountpoint. If tcpBytes is true, the TCP byte
+// counters are requested to the kernel using netlink, in this case they are
+// only available for the sockets in the network namespace of this process.
+func NewProcessSocketsTable(procPath string, tcpBytes bool) (*ProcessSocketsTable, error) {
+	if procPath == "" {
+		procPath = "/proc"
+	}
+	if _, err := os.Stat(procPath); err != nil {
+		return nil, err
+	}
+
+	t := &ProcessSocketsTable{
+		procPath:   procPath,
+		log:        logp.NewLogger("socket"),
+		namespaces: map[string]map[uint32]socketEntry{},
+	}
+	if tcpBytes {
+		t.netlink = NewNetlinkSession()
+	}
+	return t, nil
+}
+
+// Refresh discards the cached socket tables and updates the TCP byte counters.
+// It should be called once before collecting the data of all processes.
+func (t *ProcessSocketsTable) Refresh() error {
+	t.namespaces = map[string]map[uint32]socketEntry{}
+	t.tcpBytes = nil
+
+	if t.netlink == nil {
+		return nil
+	}
+	tcpBytes, err := t.netlink.GetTCPBytes()
+	if err != nil {
+		return errors.Wrap(err, "failed to get TCP byte counters")
+	}
+	t.tcpBytes = tcpBytes
+	return nil
+}
+
+// ProcessNetwork returns the network stats of the process with the given pid.
+func (t *ProcessSocketsTable) ProcessNetwork(pid int) (*NetworkStats, error) {
+	procDir := filepath.Join(t.procPath, strconv.Itoa(pid))
+
+	inodes, err := processSocketInodes(procDir)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &NetworkStats{}
+	if len(inodes) == 0 {
+		return stats, nil
+	}
+
+	sockets, err := t.socketTable(procDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, inode := range inodes {
+		entry, found := sockets[inode]
+		if !found {
+			// Other kinds of sockets, such as unix sockets.
+			continue
+		}
+
+		switch entry.protocol {
+		case "tcp":
+			stats.TCP++
+			if state, found := tcpStates[entry.state]; found {
+				if stats.TCPStates == nil {
+					stats.TCPStates = map[string]int{}
+				}
+				stats.TCPStates[state]++
+			}
+			if bytes, found := t.tcpBytes[inode]; found {
+				stats.HasTCPBytes = true
+				stats.TCPBytesSent += bytes.Sent
+				stats.TCPBytesReceived += bytes.Received
+			}
+		case "udp":
+			stats.UDP++
+		}
+	}
+
+	return stats, nil
+}
+
+// socketTable returns the sockets of the network namespace of a process,
+// reading them from its net directory if they are not cached yet.
+func (t *ProcessSocketsTable) socketTable(procDir string) (map[uint32]socketEntry, error) {
+	// The namespace is identified by the inode in the link, like net:[4026531992].
+	// If it cannot be read, sockets are read and cached for this process.
+	namespace, err := os.Readlink(filepath.Join(procDir, "ns", "net"))
+	if err != nil {
+		namespace = procDir
+	}
+	if sockets, found := t.namespaces[namespace]; found {
+		return sockets, nil
+	}
+
+	sockets := map[uint32]socketEntry{}
+	for _, file := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		protocol := strings.TrimSuffix(file, "6")
+		err := readSocketTable(filepath.Join(procDir, "net", file), protocol, sockets)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrapf(err, "failed to read %s socket table", file)
+		}
+	}
+
+	t.namespaces[namespace] = sockets
+	return sockets, nil
+}
+
+// processSocketInodes returns the inodes of the sockets opened by a process.
+func processSocketInodes(procDir string) ([]uint32, error) {
+	fdDir := filepath.Join(procDir, "fd")
+	d, err := os.Open(fdDir)
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+
+	names, err := d.Readdirnames(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	var inodes []uint32
+	for _, name := range names {
+		target, err := os.Readlink(filepath.Join(fdDir, name))
+		if err != nil {
+			// The file descriptor may have been closed.
+			continue
+		}
+		if !strings.HasPrefix(target, "socket:[") || !strings.HasSuffix(target, "]") {
+			continue
+		}
+		inode, err := strconv.ParseUint(target[8:len(target)-1], 10, 32)
+		if err != nil {
+			continue
+		}
+		inodes = append(inodes, uint32(inode))
+	}
+	return inodes, nil
+}
+
+// readSocketTable reads a socket table such as /proc/net/tcp, and adds its
+// entries to sockets.
+func readSocketTable(path, protocol string, sockets map[uint32]socketEntry) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		// Format:
+		//   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+		//    0: 0100007F:0CEA 00000000:0000 0A 00000000:00000000 00:00000000 00000000   109        0 23456 ...
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 10 || fields[0] == "sl" {
+			continue
+		}
+
+		state, err := strconv.ParseUint(fields[3], 16, 8)
+		if err != nil {
+			return errors.Wrapf(err, "invalid state '%s'", fields[3])
+		}
+		inode, err := strconv.ParseUint(fields[9], 10, 32)
+		if err != nil {
+			return errors.Wrapf(err, "invalid inode '%s'", fields[9])
+		}
+		if inode == 0 {
+			// Sockets in TIME_WAIT state don't belong to any process.
+			continue
+		}
+
+		sockets[uint32(inode)] = socketEntry{
+			protocol: protocol,
+			state:    uint8(state),
+		}
+	}
+	return sc.Err()
+}
@@ -0,0 +1,44 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !linux
+
+package socket
+
+import (
+	"github.com/pkg/errors"
+)
+
+// ProcessSocketsTable collects the network usage of processes. It is only
+// supported on Linux.
+type ProcessSocketsTable struct{}
+
+// NewProcessSocketsTable returns an error as the network usage of processes
+// is only available on Linux.
+func NewProcessSocketsTable(procPath string, tcpBytes bool) (*ProcessSocketsTable, error) {
+	return nil, errors.New("network usage of processes is only available on Linux")
+}
+
+// Refresh is not supported on this platform.
+func (t *ProcessSocketsTable) Refresh() error {
+	return nil
+}
+
+// ProcessNetwork is not supported on this platform.
+func (t *ProcessSocketsTable) ProcessNetwork(pid int) (*NetworkStats, error) {
+	return nil, errors.New("network usage of processes is only available on Linux")
+}
@@ -0,0 +1,141 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build linux
+
+package socket
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+)
+
+func TestProcessNetwork(t *testing.T) {
+	table, err := NewProcessSocketsTable("testdata/proc", false)
+	require.NoError(t, err)
+	require.NoError(t, table.Refresh())
+
+	stats, err := table.ProcessNetwork(100)
+	require.NoError(t, err)
+	assert.Equal(t, &NetworkStats{
+		TCP:       2,
+		TCPStates: map[string]int{"listen": 1, "established": 1},
+		UDP:       2,
+	}, stats)
+
+	// Process 101 has no net directory, but it is in the same network
+	// namespace as process 100, so its socket table is reused.
+	stats, err = table.ProcessNetwork(101)
+	require.NoError(t, err)
+	assert.Equal(t, &NetworkStats{
+		TCP:       1,
+		TCPStates: map[string]int{"established": 1},
+	}, stats)
+
+	stats, err = table.ProcessNetwork(200)
+	require.NoError(t, err)
+	assert.Equal(t, &NetworkStats{
+		TCP:       2,
+		TCPStates: map[string]int{"established": 1, "close_wait": 1},
+	}, stats)
+
+	_, err = table.ProcessNetwork(300)
+	assert.Error(t, err)
+
+	// Cached tables are discarded on refresh.
+	require.NoError(t, table.Refresh())
+	stats, err = table.ProcessNetwork(101)
+	require.NoError(t, err)
+	assert.Equal(t, &NetworkStats{}, stats)
+}
+
+func TestProcessNetworkTCPBytes(t *testing.T) {
+	table, err := NewProcessSocketsTable("testdata/proc", false)
+	require.NoError(t, err)
+	require.NoError(t, table.Refresh())
+	table.tcpBytes = map[uint32]TCPBytes{
+		1002: {Sent: 100, Received: 2000},
+		2001: {Sent: 5, Received: 7},
+	}
+
+	stats, err := table.ProcessNetwork(100)
+	require.NoError(t, err)
+	assert.True(t, stats.HasTCPBytes)
+	assert.Equal(t, uint64(100), stats.TCPBytesSent)
+	assert.Equal(t, uint64(2000), stats.TCPBytesReceived)
+
+	total := &NetworkStats{}
+	total.Add(stats)
+	stats, err = table.ProcessNetwork(200)
+	require.NoError(t, err)
+	total.Add(stats)
+
+	assert.Equal(t, common.MapStr{
+		"tcp": common.MapStr{
+			"sockets": common.MapStr{
+				"total":       4,
+				"listen":      1,
+				"established": 2,
+				"close_wait":  1,
+			},
+			"bytes": common.MapStr{
+				"sent":     uint64(105),
+				"received": uint64(2007),
+			},
+		},
+		"udp": common.MapStr{
+			"sockets": common.MapStr{
+				"total": 2,
+			},
+		},
+	}, total.ToMapStr())
+}
+
+func TestParseTCPBytes(t *testing.T) {
+	attr := func(attrType uint16, payload []byte) []byte {
+		length := syscall.SizeofRtAttr + len(payload)
+		b := make([]byte, (length+3)&^3)
+		byteOrder.PutUint16(b[0:2], uint16(length))
+		byteOrder.PutUint16(b[2:4], attrType)
+		copy(b[4:], payload)
+		return b
+	}
+
+	info := make([]byte, 232)
+	byteOrder.PutUint64(info[tcpInfoBytesAckedOffset:], 1234)
+	byteOrder.PutUint64(info[tcpInfoBytesReceivedOffset:], 5678)
+
+	attrs := append(attr(8, []byte{1, 2, 3}), attr(inetDiagInfo, info)...)
+	bytes, found := parseTCPBytes(attrs)
+	assert.True(t, found)
+	assert.Equal(t, TCPBytes{Sent: 1234, Received: 5678}, bytes)
+
+	// tcp_info of old kernels without byte counters.
+	_, found = parseTCPBytes(attr(inetDiagInfo, make([]byte, 104)))
+	assert.False(t, found)
+
+	_, found = parseTCPBytes(attr(8, []byte{1}))
+	assert.False(t, found)
+
+	_, found = parseTCPBytes([]byte{0xff, 0xff})
+	assert.False(t, found)
+}
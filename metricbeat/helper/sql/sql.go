@@ -59,25 +59,50 @@ func NewDBClient(driver, uri string, l *logp.Logger) (*DbClient, error) {
 }
 
 // fetchTableMode scan the rows and publishes the event for querys that return the response in a table format.
-func (d *DbClient) FetchTableMode(ctx context.Context, q string) ([]common.MapStr, error) {
-	rows, err := d.QueryContext(ctx, q)
+// Optional args are bound to the placeholders of the query.
+func (d *DbClient) FetchTableMode(ctx context.Context, q string, args ...interface{}) ([]common.MapStr, error) {
+	rows, err := d.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 	return d.fetchTableMode(rows)
 }
 
+// FetchTableModeWithRaw is like FetchTableMode, and also returns for each row the value of the
+// given column as returned by the driver, before its conversion to a number. Integers above 2^53
+// lose precision when converted to float64.
+func (d *DbClient) FetchTableModeWithRaw(ctx context.Context, q string, column string, args ...interface{}) ([]common.MapStr, []interface{}, error) {
+	rows, err := d.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	return d.fetchTableModeWithRaw(rows, column)
+}
+
 // fetchTableMode scan the rows and publishes the event for querys that return the response in a table format.
 func (d *DbClient) fetchTableMode(rows sqlRow) ([]common.MapStr, error) {
+	rr, _, err := d.fetchTableModeWithRaw(rows, "")
+	return rr, err
+}
+
+// fetchTableModeWithRaw scans the rows like fetchTableMode, and keeps the driver values of the
+// given column. The raw value is nil for rows without this column.
+func (d *DbClient) fetchTableModeWithRaw(rows sqlRow, column string) ([]common.MapStr, []interface{}, error) {
 	// Extracted from
 	// https://stackoverflow.com/questions/23507531/is-golangs-sql-package-incapable-of-ad-hoc-exploratory-queries/23507765#23507765
 	cols, err := rows.Columns()
 	if err != nil {
-		return nil, errors.Wrap(err, "error getting columns")
+		return nil, nil, errors.Wrap(err, "error getting columns")
 	}
 
+	rawIdx := -1
 	for k, v := range cols {
 		cols[k] = strings.ToLower(v)
+		if column != "" && cols[k] == strings.ToLower(column) {
+			rawIdx = k
+		}
 	}
 
 	vals := make([]interface{}, len(cols))
@@ -86,6 +111,7 @@ func (d *DbClient) fetchTableMode(rows sqlRow) ([]common.MapStr, error) {
 	}
 
 	rr := make([]common.MapStr, 0)
+	var raw []interface{}
 	for rows.Next() {
 		err = rows.Scan(vals...)
 		if err != nil {
@@ -101,21 +127,30 @@ func (d *DbClient) fetchTableMode(rows sqlRow) ([]common.MapStr, error) {
 		}
 
 		rr = append(rr, r)
+		if column != "" {
+			var v interface{}
+			if rawIdx >= 0 {
+				v = *vals[rawIdx].(*interface{})
+			}
+			raw = append(raw, v)
+		}
 	}
 
 	if err = rows.Err(); err != nil {
 		d.logger.Debug(errors.Wrap(err, "error trying to read rows"))
 	}
 
-	return rr, nil
+	return rr, raw, nil
 }
 
 // fetchTableMode scan the rows and publishes the event for querys that return the response in a table format.
-func (d *DbClient) FetchVariableMode(ctx context.Context, q string) (common.MapStr, error) {
-	rows, err := d.QueryContext(ctx, q)
+// Optional args are bound to the placeholders of the query.
+func (d *DbClient) FetchVariableMode(ctx context.Context, q string, args ...interface{}) (common.MapStr, error) {
+	rows, err := d.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 	return d.fetchVariableMode(rows)
 }
 
@@ -186,6 +221,21 @@ func getValue(pval *interface{}) interface{} {
 	}
 }
 
+// Placeholder returns the bind parameter placeholder used by the driver for the
+// n-th (starting at 1) argument of a query.
+func Placeholder(driver string, n int) string {
+	switch switchDriverName(driver) {
+	case "postgres":
+		return "$" + strconv.Itoa(n)
+	case "godror":
+		return ":" + strconv.Itoa(n)
+	case "mssql", "sqlserver":
+		return "@p" + strconv.Itoa(n)
+	}
+
+	return "?"
+}
+
 // switchDriverName switches between driver name and a pretty name for a driver. For example, 'oracle' driver is called
 // 'godror' so this detail implementation must be hidden to the user, that should only choose and see 'oracle' as driver
 func switchDriverName(d string) string {
@@ -129,6 +129,24 @@ func TestFetchTableMode(t *testing.T) {
 	}
 }
 
+func TestFetchTableModeWithRaw(t *testing.T) {
+	db := DbClient{}
+
+	mss, raw, err := db.fetchTableModeWithRaw(&mockTableMode{results: results}, "Byte_Array")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(raw) != len(mss) {
+		t.Fatalf("expected %d raw values, got %d", len(mss), len(raw))
+	}
+	for i, ms := range mss {
+		if string(raw[i].([]byte)) != "byte_array" || ms["byte_array"] != "byte_array" {
+			t.Fail()
+		}
+	}
+}
+
 func checkValue(t *testing.T, res kv, ms common.MapStr) {
 	switch v := res.v.(type) {
 	case string, bool:
@@ -186,3 +204,20 @@ func TestToDotKeys(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestPlaceholder(t *testing.T) {
+	tests := map[string]string{
+		"mysql":      "?",
+		"postgres":   "$2",
+		"cockroach":  "$2",
+		"oracle":     ":2",
+		"mssql":      "@p2",
+		"unknown_db": "?",
+	}
+
+	for driver, expected := range tests {
+		if p := Placeholder(driver, 2); p != expected {
+			t.Errorf("driver %s: expected placeholder %s, got %s", driver, expected, p)
+		}
+	}
+}
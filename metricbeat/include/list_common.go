@@ -82,6 +82,8 @@ import (
 	_ "github.com/elastic/beats/v7/metricbeat/module/http"
 	_ "github.com/elastic/beats/v7/metricbeat/module/http/json"
 	_ "github.com/elastic/beats/v7/metricbeat/module/http/server"
+	_ "github.com/elastic/beats/v7/metricbeat/module/influxdb"
+	_ "github.com/elastic/beats/v7/metricbeat/module/influxdb/server"
 	_ "github.com/elastic/beats/v7/metricbeat/module/jolokia"
 	_ "github.com/elastic/beats/v7/metricbeat/module/jolokia/jmx"
 	_ "github.com/elastic/beats/v7/metricbeat/module/kafka"
@@ -157,6 +159,7 @@ import (
 	_ "github.com/elastic/beats/v7/metricbeat/module/system/memory"
 	_ "github.com/elastic/beats/v7/metricbeat/module/system/network"
 	_ "github.com/elastic/beats/v7/metricbeat/module/system/network_summary"
+	_ "github.com/elastic/beats/v7/metricbeat/module/system/pressure"
 	_ "github.com/elastic/beats/v7/metricbeat/module/system/process"
 	_ "github.com/elastic/beats/v7/metricbeat/module/system/process_summary"
 	_ "github.com/elastic/beats/v7/metricbeat/module/system/raid"
@@ -0,0 +1,36 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Code generated by beats/dev-tools/cmd/asset/asset.go - DO NOT EDIT.
+
+package influxdb
+
+import (
+	"github.com/elastic/beats/v7/libbeat/asset"
+)
+
+func init() {
+	if err := asset.SetFields("metricbeat", "influxdb", asset.ModuleFieldsPri, AssetInfluxdb); err != nil {
+		panic(err)
+	}
+}
+
+// AssetInfluxdb returns asset data.
+// This is the base64 encoded gzipped contents of module/influxdb.
+func AssetInfluxdb() string {
+	return "eJykVMFy0zAUvPsrdnpkSD7ABw5MhxkOcOLeka2NEZUlzXtyIH/PyK6pnKQ0UFun1WrfSm+lHR55auHCwU+/bNcA2WXPFnefZ+j+410DWGovLmUXQ4sPDQCs0xijnTwbQOhplC06ZtMAB0dvtZ3ZOwQzclOnwPmU2GKQOKUn5EqlMr4wi+sVwp7uSAsXnh14F4gkMcc++v3Tmrp67WCk0Uk4MuQ/c6uRR55+RrEV/oKdMr6akYgH5O+sRfcXFbMZdP+uWrsUi90P9rWHBXj4DyvfzABlXt2k6ELWPe5j1nJQBctmKJ1WGCGEyZueFt1pozMFS9E+CvVyG8uB/uNGHpIRM1ZtKGNXM1r4GIbN/FZhNCm5MLxEPhOzcermNN4md4V+JtjF6GnCzYrX+LvXevt3Sc3iwnBbFD6VLuFo/ETd5uH90t45r+E5u06up7f8a4AqwnyPFSbY5X6/MVRKOVIq/uWL8MqGLxTOn6H1Ozh6q23zewAZQGxp"
+}
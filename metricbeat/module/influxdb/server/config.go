@@ -0,0 +1,66 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package server
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type InfluxDBServerConfig struct {
+	Protocol  string `config:"protocol"`
+	Precision string `config:"precision"`
+}
+
+func DefaultInfluxDBServerConfig() InfluxDBServerConfig {
+	return InfluxDBServerConfig{
+		Protocol:  "udp",
+		Precision: "ns",
+	}
+}
+
+func (c InfluxDBServerConfig) Validate() error {
+	if c.Protocol != "udp" && c.Protocol != "tcp" && c.Protocol != "http" {
+		return errors.New("`protocol` can only be udp, tcp or http")
+	}
+	if _, err := parsePrecision(c.Precision); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parsePrecision returns the unit of the timestamps for the given precision,
+// as accepted by the `precision` parameter of InfluxDB write requests.
+func parsePrecision(precision string) (time.Duration, error) {
+	switch precision {
+	case "ns", "n":
+		return time.Nanosecond, nil
+	case "us", "u":
+		return time.Microsecond, nil
+	case "ms":
+		return time.Millisecond, nil
+	case "s":
+		return time.Second, nil
+	case "m":
+		return time.Minute, nil
+	case "h":
+		return time.Hour, nil
+	}
+	return 0, errors.Errorf("invalid precision '%s', expected one of ns, us, ms, s, m or h", precision)
+}
@@ -0,0 +1,91 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package server
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joeshaw/multierror"
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/metricbeat/mb"
+)
+
+// eventsFromLines parses a batch of lines in InfluxDB line protocol and
+// converts them to events. Points with the same measurement, tag set and
+// timestamp are grouped in the same event. Invalid lines are skipped and
+// returned as errors, along with the events of the valid ones.
+func eventsFromLines(data string, precision time.Duration) ([]mb.Event, error) {
+	var events []mb.Event
+	var errs multierror.Errors
+	series := map[string]common.MapStr{}
+
+	now := time.Now().UTC()
+	for n, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		p, err := parseLine(line, precision, now)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "invalid line %d", n+1))
+			continue
+		}
+
+		key := p.seriesKey() + " " + strconv.FormatInt(p.timestamp.UnixNano(), 10)
+		fields, found := series[key]
+		if !found {
+			fields = common.MapStr{}
+			series[key] = fields
+			events = append(events, newEvent(p, fields))
+		}
+
+		for k, v := range p.fields {
+			fields[common.DeDot(k)] = v
+		}
+	}
+
+	return events, errs.Err()
+}
+
+// newEvent creates the event for the measurement and tag set of the point,
+// values are stored in fields.
+func newEvent(p *point, fields common.MapStr) mb.Event {
+	moduleFields := common.MapStr{
+		"measurement": p.measurement,
+		"fields": common.MapStr{
+			common.DeDot(p.measurement): fields,
+		},
+	}
+	if len(p.tags) > 0 {
+		tags := make(common.MapStr, len(p.tags))
+		for k, v := range p.tags {
+			tags[common.DeDot(k)] = v
+		}
+		moduleFields["tags"] = tags
+	}
+
+	return mb.Event{
+		Timestamp:    p.timestamp,
+		ModuleFields: moduleFields,
+	}
+}
@@ -0,0 +1,69 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+)
+
+func TestEventsFromLines(t *testing.T) {
+	data := `
+# Comment
+cpu,host=a usage_user=1.5 1600000000000000000
+cpu,host=a usage_system=0.5 1600000000000000000
+cpu,host=b usage_user=2 1600000000000000000
+cpu,host=a usage_user=3 1600000010000000000
+disk.io,host=a,dev.name=sda reads=10i 1600000000000000000
+invalid line
+`
+
+	events, err := eventsFromLines(data, time.Nanosecond)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid line 8")
+	require.Len(t, events, 4)
+
+	assert.Equal(t, time.Unix(1600000000, 0).UTC(), events[0].Timestamp)
+	assert.Equal(t, common.MapStr{
+		"measurement": "cpu",
+		"tags":        common.MapStr{"host": "a"},
+		"fields": common.MapStr{
+			"cpu": common.MapStr{"usage_user": 1.5, "usage_system": 0.5},
+		},
+	}, events[0].ModuleFields)
+
+	assert.Equal(t, common.MapStr{"host": "b"}, events[1].ModuleFields["tags"])
+
+	assert.Equal(t, time.Unix(1600000010, 0).UTC(), events[2].Timestamp)
+	assert.Equal(t, common.MapStr{"host": "a"}, events[2].ModuleFields["tags"])
+
+	assert.Equal(t, common.MapStr{
+		"measurement": "disk.io",
+		"tags":        common.MapStr{"host": "a", "dev_name": "sda"},
+		"fields": common.MapStr{
+			"disk_io": common.MapStr{"reads": int64(10)},
+		},
+	}, events[3].ModuleFields)
+}
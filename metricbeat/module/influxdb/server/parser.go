@@ -0,0 +1,226 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package server
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// point is a single line of the InfluxDB line protocol:
+//
+//   measurement[,tag_key=tag_value...] field_key=field_value[,field_key=field_value...] [timestamp]
+type point struct {
+	measurement string
+	tags        map[string]string
+	fields      map[string]interface{}
+	timestamp   time.Time
+}
+
+// parseLine parses a line in InfluxDB line protocol. Timestamps are
+// interpreted with the given precision, points without timestamp get the
+// given default time.
+func parseLine(line string, precision time.Duration, now time.Time) (*point, error) {
+	series, rest := splitSection(line, false)
+	if series == "" {
+		return nil, errors.New("missing measurement")
+	}
+	fieldSet, rest := splitSection(rest, true)
+	if fieldSet == "" {
+		return nil, errors.New("missing fields")
+	}
+	ts := strings.TrimSpace(rest)
+
+	p := &point{timestamp: now}
+
+	parts := splitUnescaped(series, ',', false)
+	p.measurement = unescape(parts[0], ", ")
+	if p.measurement == "" {
+		return nil, errors.New("missing measurement")
+	}
+	if len(parts) > 1 {
+		p.tags = make(map[string]string, len(parts)-1)
+		for _, tag := range parts[1:] {
+			key, value, err := splitKeyValue(tag)
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid tag")
+			}
+			if value == "" {
+				return nil, errors.Errorf("missing value for tag '%s'", key)
+			}
+			p.tags[key] = unescape(value, ",= ")
+		}
+	}
+
+	fields := splitUnescaped(fieldSet, ',', true)
+	p.fields = make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		key, value, err := splitKeyValue(field)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid field")
+		}
+		v, err := parseFieldValue(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value for field '%s'", key)
+		}
+		p.fields[key] = v
+	}
+
+	if ts != "" {
+		n, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid timestamp '%s'", ts)
+		}
+		if n > math.MaxInt64/int64(precision) || n < math.MinInt64/int64(precision) {
+			return nil, errors.Errorf("timestamp '%s' out of range", ts)
+		}
+		p.timestamp = time.Unix(0, n*int64(precision)).UTC()
+	}
+
+	return p, nil
+}
+
+// seriesKey identifies the measurement and tag set of the point. Each part is
+// prefixed by its length, so keys and values containing separators cannot
+// produce the same key as a different tag set.
+func (p *point) seriesKey() string {
+	keys := make([]string, 0, len(p.tags))
+	for k := range p.tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	writePart := func(s string) {
+		b.WriteString(strconv.Itoa(len(s)))
+		b.WriteByte(':')
+		b.WriteString(s)
+	}
+	writePart(p.measurement)
+	for _, k := range keys {
+		writePart(k)
+		writePart(p.tags[k])
+	}
+	return b.String()
+}
+
+// parseFieldValue parses a field value, its type depends on its format:
+// integers end with `i`, unsigned integers with `u`, strings are quoted,
+// booleans are one of t, T, true, True, TRUE, f, F, false, False or FALSE,
+// and any other value is a float.
+func parseFieldValue(value string) (interface{}, error) {
+	if value == "" {
+		return nil, errors.New("empty value")
+	}
+
+	switch value {
+	case "t", "T", "true", "True", "TRUE":
+		return true, nil
+	case "f", "F", "false", "False", "FALSE":
+		return false, nil
+	}
+
+	switch value[len(value)-1] {
+	case '"':
+		if len(value) < 2 || value[0] != '"' {
+			return nil, errors.Errorf("unterminated string %s", value)
+		}
+		return unescape(value[1:len(value)-1], `"\`), nil
+	case 'i':
+		return strconv.ParseInt(value[:len(value)-1], 10, 64)
+	case 'u':
+		return strconv.ParseUint(value[:len(value)-1], 10, 64)
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// splitKeyValue splits a tag or a field in its key and its value.
+func splitKeyValue(s string) (string, string, error) {
+	parts := splitUnescaped(s, '=', false)
+	if len(parts) < 2 || parts[0] == "" {
+		return "", "", errors.Errorf("expected key=value, found '%s'", s)
+	}
+	// Only the first unescaped equal sign separates the key from the value.
+	value := s[len(parts[0])+1:]
+	return unescape(parts[0], ",= "), value, nil
+}
+
+// splitSection returns the first space-separated section of the line and the
+// rest of the line. Escaped spaces, and spaces in quoted strings if quotes is
+// true, don't separate sections.
+func splitSection(line string, quotes bool) (string, string) {
+	line = strings.TrimLeft(line, " ")
+	end := indexUnescaped(line, ' ', quotes)
+	if end < 0 {
+		return line, ""
+	}
+	return line[:end], line[end+1:]
+}
+
+// splitUnescaped splits s on the unescaped occurrences of sep.
+func splitUnescaped(s string, sep byte, quotes bool) []string {
+	var parts []string
+	for {
+		i := indexUnescaped(s, sep, quotes)
+		if i < 0 {
+			return append(parts, s)
+		}
+		parts = append(parts, s[:i])
+		s = s[i+1:]
+	}
+}
+
+// indexUnescaped returns the index of the first occurrence of c in s that is
+// not escaped with a backslash, and is not in a quoted string if quotes is
+// true, or -1 if there is none.
+func indexUnescaped(s string, c byte, quotes bool) int {
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case quotes && s[i] == '"':
+			quoted = !quoted
+		case !quoted && s[i] == c:
+			return i
+		}
+	}
+	return -1
+}
+
+// unescape removes the backslashes before the escaped characters in s. Other
+// backslashes are kept.
+func unescape(s string, escaped string) string {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(escaped, s[i+1]) >= 0 {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,140 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	now := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		line      string
+		precision time.Duration
+		expected  point
+	}{
+		{
+			line:      "cpu value=1",
+			precision: time.Nanosecond,
+			expected: point{
+				measurement: "cpu",
+				fields:      map[string]interface{}{"value": float64(1)},
+				timestamp:   now,
+			},
+		},
+		{
+			line:      "cpu,host=a,region=eu usage=0.5,cores=4i,ctx=18446744073709551615u,up=t,down=FALSE,state=\"ok\" 1600000000000000000",
+			precision: time.Nanosecond,
+			expected: point{
+				measurement: "cpu",
+				tags:        map[string]string{"host": "a", "region": "eu"},
+				fields: map[string]interface{}{
+					"usage": 0.5,
+					"cores": int64(4),
+					"ctx":   uint64(18446744073709551615),
+					"up":    true,
+					"down":  false,
+					"state": "ok",
+				},
+				timestamp: time.Unix(1600000000, 0).UTC(),
+			},
+		},
+		{
+			line:      `my\ measurement,my\,tag=a\ b\=c msg="say \"hi\", x=1",f\=1=2 1600000000`,
+			precision: time.Second,
+			expected: point{
+				measurement: "my measurement",
+				tags:        map[string]string{"my,tag": "a b=c"},
+				fields: map[string]interface{}{
+					"msg": `say "hi", x=1`,
+					"f=1": float64(2),
+				},
+				timestamp: time.Unix(1600000000, 0).UTC(),
+			},
+		},
+		{
+			line:      "mem free=1.5e3 1600000000123",
+			precision: time.Millisecond,
+			expected: point{
+				measurement: "mem",
+				fields:      map[string]interface{}{"free": 1500.0},
+				timestamp:   time.Unix(1600000000, 123000000).UTC(),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.line, func(t *testing.T) {
+			p, err := parseLine(c.line, c.precision, now)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, *p)
+		})
+	}
+}
+
+func TestParseLineErrors(t *testing.T) {
+	lines := []string{
+		"cpu",
+		",host=a value=1",
+		"cpu,host value=1",
+		"cpu,host= value=1",
+		"cpu value",
+		"cpu value=",
+		"cpu value=abc",
+		"cpu value=1x",
+		"cpu value=\"unterminated",
+		"cpu value=1 notatimestamp",
+	}
+
+	for _, line := range lines {
+		t.Run(line, func(t *testing.T) {
+			_, err := parseLine(line, time.Nanosecond, time.Now())
+			assert.Error(t, err)
+		})
+	}
+
+	// Timestamps that overflow when converted to nanoseconds.
+	for _, line := range []string{"cpu value=1 9223372036854776", "cpu value=1 -9223372036854776"} {
+		t.Run(line, func(t *testing.T) {
+			_, err := parseLine(line, time.Second, time.Now())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSeriesKey(t *testing.T) {
+	p1, err := parseLine("cpu,b=2,a=1 value=1", time.Nanosecond, time.Now())
+	require.NoError(t, err)
+	p2, err := parseLine("cpu,a=1,b=2 other=1", time.Nanosecond, time.Now())
+	require.NoError(t, err)
+
+	assert.Equal(t, p1.seriesKey(), p2.seriesKey())
+
+	// Escaped separators in tag values do not make different tag sets equal.
+	p3, err := parseLine(`cpu,a=1\,b\=2 value=1`, time.Nanosecond, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"a": "1,b=2"}, p3.tags)
+	assert.NotEqual(t, p1.seriesKey(), p3.seriesKey())
+}
@@ -0,0 +1,189 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	serverhelper "github.com/elastic/beats/v7/metricbeat/helper/server"
+	httpserver "github.com/elastic/beats/v7/metricbeat/helper/server/http"
+	"github.com/elastic/beats/v7/metricbeat/helper/server/tcp"
+	"github.com/elastic/beats/v7/metricbeat/helper/server/udp"
+	"github.com/elastic/beats/v7/metricbeat/mb"
+	"github.com/elastic/beats/v7/metricbeat/mb/parse"
+)
+
+// init registers the MetricSet with the central registry.
+// The New method will be called after the setup of the module and before starting to fetch data
+func init() {
+	mb.Registry.MustAddMetricSet("influxdb", "server", New,
+		mb.WithHostParser(parse.EmptyHostParser),
+		mb.DefaultMetricSet(),
+	)
+}
+
+// MetricSet receives metrics in InfluxDB line protocol, over UDP, TCP, or
+// HTTP with the `/write` endpoint of the InfluxDB API.
+type MetricSet struct {
+	mb.BaseMetricSet
+	server    serverhelper.Server
+	precision time.Duration
+	events    chan mb.Event
+}
+
+// New create a new instance of the MetricSet
+// Part of new is also setting up the configuration by processing additional
+// configuration entries if needed.
+func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	config := DefaultInfluxDBServerConfig()
+	if err := base.Module().UnpackConfig(&config); err != nil {
+		return nil, err
+	}
+
+	precision, err := parsePrecision(config.Precision)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &MetricSet{
+		BaseMetricSet: base,
+		precision:     precision,
+		events:        make(chan mb.Event),
+	}
+
+	switch config.Protocol {
+	case "http":
+		m.server, err = httpserver.NewHttpServerWithHandler(base, m.handleFunc)
+	case "tcp":
+		m.server, err = tcp.NewTcpServer(base)
+	default:
+		m.server, err = udp.NewUdpServer(base)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Run method provides the InfluxDB server with a reporter with which events can be reported.
+func (m *MetricSet) Run(reporter mb.PushReporterV2) {
+	if err := m.server.Start(); err != nil {
+		err = errors.Wrap(err, "failed to start influxdb server")
+		m.Logger().Error(err)
+		reporter.Error(err)
+		return
+	}
+
+	for {
+		select {
+		case <-reporter.Done():
+			m.server.Stop()
+			return
+		case e := <-m.events:
+			reporter.Event(e)
+		case msg := <-m.server.GetEvents():
+			if msg == nil {
+				continue
+			}
+			data, ok := msg.GetEvent()[serverhelper.EventDataKey].([]byte)
+			if !ok || len(data) == 0 {
+				continue
+			}
+
+			events, err := eventsFromLines(string(data), m.precision)
+			if err != nil {
+				reporter.Error(err)
+			}
+			for _, e := range events {
+				reporter.Event(e)
+			}
+		}
+	}
+}
+
+// handleFunc implements the write endpoints of the InfluxDB 1.x and 2.x
+// APIs, and the ping endpoint used by clients to check the server.
+func (m *MetricSet) handleFunc(writer http.ResponseWriter, req *http.Request) {
+	switch req.URL.Path {
+	case "/ping", "/health":
+		writer.WriteHeader(http.StatusNoContent)
+		return
+	case "/write", "/api/v2/write":
+	default:
+		http.NotFound(writer, req)
+		return
+	}
+
+	if req.Method != http.MethodPost {
+		writer.Header().Set("Allow", http.MethodPost)
+		http.Error(writer, "only POST requests are supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	precision := m.precision
+	if p := req.URL.Query().Get("precision"); p != "" {
+		var err error
+		precision, err = parsePrecision(p)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var body io.Reader = req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(req.Body)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		m.Logger().Errorf("Read error %v", err)
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, parseErr := eventsFromLines(string(data), precision)
+	for _, e := range events {
+		select {
+		case <-req.Context().Done():
+			return
+		case m.events <- e:
+		}
+	}
+
+	if parseErr != nil {
+		// As in InfluxDB, valid points are written even if others are invalid.
+		m.Logger().Debugf("Partial write: %v", parseErr)
+		http.Error(writer, "partial write: "+parseErr.Error(), http.StatusBadRequest)
+		return
+	}
+	writer.WriteHeader(http.StatusNoContent)
+}
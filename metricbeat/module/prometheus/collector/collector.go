@@ -19,6 +19,7 @@ package collector
 
 import (
 	"regexp"
+	"sort"
 
 	"github.com/pkg/errors"
 	dto "github.com/prometheus/client_model/go"
@@ -82,6 +83,18 @@ type MetricSet struct {
 	promEventsGen   PromEventsGenerator
 	host            string
 	eventGenStarted bool
+
+	// series seen in the last fetch, by labels hash, only tracked when
+	// staleness markers are enabled
+	stalenessMarkers bool
+	lastSeries       map[string]*series
+}
+
+// series holds the labels of an event, and the names of the metric families
+// reported with them.
+type series struct {
+	labels common.MapStr
+	names  map[string]struct{}
 }
 
 // MetricSetBuilder returns a builder function for a new Prometheus metricset using
@@ -108,6 +121,8 @@ func MetricSetBuilder(namespace string, genFactory PromEventsGeneratorFactory) f
 			namespace:       namespace,
 			promEventsGen:   promEventsGen,
 			eventGenStarted: false,
+
+			stalenessMarkers: config.StalenessMarkers,
 		}
 		// store host here to use it as a pointer when building `up` metric
 		ms.host = ms.Host()
@@ -133,6 +148,7 @@ func (m *MetricSet) Fetch(reporter mb.ReporterV2) error {
 
 	families, err := m.prometheus.GetFamilies()
 	eventList := map[string]common.MapStr{}
+	seriesList := map[string]*series{}
 	if err != nil {
 		// send up event only
 		families = append(families, m.upMetricFamily(0.0))
@@ -171,7 +187,25 @@ func (m *MetricSet) Fetch(reporter mb.ReporterV2) error {
 
 			// Accumulate metrics in the event
 			eventList[labelsHash].DeepUpdate(promEvent.Data)
+
+			if m.stalenessMarkers {
+				s, ok := seriesList[labelsHash]
+				if !ok {
+					s = &series{labels: promEvent.Labels, names: map[string]struct{}{}}
+					seriesList[labelsHash] = s
+				}
+				s.names[family.GetName()] = struct{}{}
+			}
+		}
+	}
+
+	if m.stalenessMarkers {
+		for _, e := range m.staleEvents(seriesList) {
+			if !reporter.Event(e) {
+				return err
+			}
 		}
+		m.lastSeries = seriesList
 	}
 
 	// Report events
@@ -187,6 +221,36 @@ func (m *MetricSet) Fetch(reporter mb.ReporterV2) error {
 	return err
 }
 
+// staleEvents returns a staleness marker event for each set of labels with
+// metrics that were reported in the previous fetch, but not in this one.
+func (m *MetricSet) staleEvents(current map[string]*series) []mb.Event {
+	var events []mb.Event
+	for hash, last := range m.lastSeries {
+		var stale []string
+		for name := range last.names {
+			if s, found := current[hash]; found {
+				if _, found := s.names[name]; found {
+					continue
+				}
+			}
+			stale = append(stale, name)
+		}
+		if len(stale) == 0 {
+			continue
+		}
+		sort.Strings(stale)
+
+		events = append(events, mb.Event{
+			RootFields: common.MapStr{m.namespace: common.MapStr{
+				"labels":        last.labels,
+				"stale":         true,
+				"stale_metrics": stale,
+			}},
+		})
+	}
+	return events
+}
+
 // Close stops the metricset
 func (m *MetricSet) Close() error {
 	if m.eventGenStarted {
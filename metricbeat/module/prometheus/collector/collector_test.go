@@ -20,7 +20,9 @@
 package collector
 
 import (
+	"math"
 	"testing"
+	"time"
 
 	"github.com/elastic/beats/v7/metricbeat/mb"
 
@@ -379,3 +381,198 @@ func TestSkipMetricFamily(t *testing.T) {
 func TestData(t *testing.T) {
 	mbtest.TestDataFiles(t, "prometheus", "collector")
 }
+
+func TestGetPromEventsNativeTypes(t *testing.T) {
+	labels := common.MapStr{
+		"handler": "query",
+	}
+	labelPairs := []*dto.LabelPair{
+		{
+			Name:  proto.String("handler"),
+			Value: proto.String("query"),
+		},
+	}
+
+	histogram := &dto.MetricFamily{
+		Name: proto.String("http_request_duration_seconds"),
+		Type: dto.MetricType_HISTOGRAM.Enum(),
+		Metric: []*dto.Metric{
+			{
+				Label: labelPairs,
+				Histogram: &dto.Histogram{
+					SampleCount: proto.Uint64(10),
+					SampleSum:   proto.Float64(4.5),
+					Bucket: []*dto.Bucket{
+						{UpperBound: proto.Float64(0.1), CumulativeCount: proto.Uint64(2)},
+						{UpperBound: proto.Float64(1), CumulativeCount: proto.Uint64(8)},
+						{UpperBound: proto.Float64(math.Inf(1)), CumulativeCount: proto.Uint64(10)},
+					},
+				},
+			},
+		},
+	}
+
+	summary := &dto.MetricFamily{
+		Name: proto.String("rpc_duration_seconds"),
+		Type: dto.MetricType_SUMMARY.Enum(),
+		Metric: []*dto.Metric{
+			{
+				Label: labelPairs,
+				Summary: &dto.Summary{
+					SampleCount: proto.Uint64(5),
+					SampleSum:   proto.Float64(1.5),
+					Quantile: []*dto.Quantile{
+						{Quantile: proto.Float64(0.5), Value: proto.Float64(0.2)},
+						{Quantile: proto.Float64(0.999), Value: proto.Float64(0.9)},
+						{Quantile: proto.Float64(0.99), Value: proto.Float64(math.NaN())},
+					},
+				},
+			},
+		},
+	}
+
+	p := promEventGenerator{nativeTypes: true}
+	assert.Equal(t, []PromEvent{
+		{
+			Data: common.MapStr{
+				"metrics": common.MapStr{
+					"http_request_duration_seconds": common.MapStr{
+						"count": uint64(10),
+						"sum":   4.5,
+						"buckets": common.MapStr{
+							"le":    []float64{0.1, 1},
+							"count": []uint64{2, 8},
+						},
+					},
+				},
+			},
+			Labels: labels,
+		},
+	}, p.GeneratePromEvents(histogram))
+
+	assert.Equal(t, []PromEvent{
+		{
+			Data: common.MapStr{
+				"metrics": common.MapStr{
+					"rpc_duration_seconds": common.MapStr{
+						"count": uint64(5),
+						"sum":   1.5,
+						"quantiles": common.MapStr{
+							"p50":   0.2,
+							"p99_9": 0.9,
+						},
+					},
+				},
+			},
+			Labels: labels,
+		},
+	}, p.GeneratePromEvents(summary))
+}
+
+func TestGetPromEventsRateCounters(t *testing.T) {
+	counter := func(value float64) *dto.MetricFamily {
+		return &dto.MetricFamily{
+			Name: proto.String("http_requests_total"),
+			Type: dto.MetricType_COUNTER.Enum(),
+			Metric: []*dto.Metric{
+				{
+					Counter: &dto.Counter{
+						Value: proto.Float64(value),
+					},
+				},
+			},
+		}
+	}
+	histogram := func(counts ...uint64) *dto.MetricFamily {
+		var buckets []*dto.Bucket
+		for i, c := range counts {
+			buckets = append(buckets, &dto.Bucket{UpperBound: proto.Float64(float64(i + 1)), CumulativeCount: proto.Uint64(c)})
+		}
+		return &dto.MetricFamily{
+			Name: proto.String("latency_seconds"),
+			Type: dto.MetricType_HISTOGRAM.Enum(),
+			Metric: []*dto.Metric{
+				{
+					Histogram: &dto.Histogram{
+						SampleCount: proto.Uint64(counts[len(counts)-1]),
+						Bucket:      buckets,
+					},
+				},
+			},
+		}
+	}
+
+	p := promEventGenerator{
+		nativeTypes: true,
+		counters:    common.NewCache(time.Minute, 0),
+		timeout:     time.Minute,
+	}
+
+	// No rate on the first fetch
+	events := p.GeneratePromEvents(counter(10))
+	assert.Equal(t, common.MapStr{"metrics": common.MapStr{"http_requests_total": float64(10)}}, events[0].Data)
+	p.GeneratePromEvents(histogram(1, 3))
+
+	events = p.GeneratePromEvents(counter(15))
+	assert.Equal(t, common.MapStr{"http_requests_total": float64(5)}, events[0].Data["rates"])
+	events = p.GeneratePromEvents(histogram(2, 5))
+	assert.Equal(t, common.MapStr{
+		"latency_seconds": common.MapStr{
+			"count":   float64(2),
+			"sum":     float64(0),
+			"buckets": common.MapStr{"count": []float64{1, 2}},
+		},
+	}, events[0].Data["rates"])
+
+	// Counter reset
+	events = p.GeneratePromEvents(counter(3))
+	assert.Equal(t, common.MapStr{"http_requests_total": float64(3)}, events[0].Data["rates"])
+	events = p.GeneratePromEvents(histogram(1, 4))
+	assert.Equal(t, common.MapStr{
+		"latency_seconds": common.MapStr{
+			"count":   float64(4),
+			"sum":     float64(0),
+			"buckets": common.MapStr{"count": []float64{1, 4}},
+		},
+	}, events[0].Data["rates"])
+}
+
+func TestQuantileKey(t *testing.T) {
+	for quantile, expected := range map[float64]string{
+		0:     "p0",
+		0.05:  "p5",
+		0.5:   "p50",
+		0.99:  "p99",
+		0.999: "p99_9",
+		1:     "p100",
+	} {
+		assert.Equal(t, expected, quantileKey(quantile))
+	}
+}
+
+func TestStaleEvents(t *testing.T) {
+	labels := common.MapStr{"instance": "localhost:9090", "job": "prometheus"}
+	ms := &MetricSet{
+		namespace: "prometheus",
+		lastSeries: map[string]*series{
+			"a": {labels: labels, names: map[string]struct{}{"up": {}, "go_goroutines": {}, "go_threads": {}}},
+			"b": {labels: common.MapStr{"handler": "query"}, names: map[string]struct{}{"http_requests_total": {}}},
+		},
+	}
+
+	events := ms.staleEvents(map[string]*series{
+		"a": {labels: labels, names: map[string]struct{}{"up": {}}},
+		"b": {labels: common.MapStr{"handler": "query"}, names: map[string]struct{}{"http_requests_total": {}}},
+	})
+	assert.Equal(t, []mb.Event{
+		{
+			RootFields: common.MapStr{"prometheus": common.MapStr{
+				"labels":        labels,
+				"stale":         true,
+				"stale_metrics": []string{"go_goroutines", "go_threads"},
+			}},
+		},
+	}, events)
+
+	assert.Empty(t, ms.staleEvents(ms.lastSeries))
+}
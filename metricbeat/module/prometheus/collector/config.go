@@ -18,7 +18,10 @@
 package collector
 
 type metricsetConfig struct {
-	MetricsFilters MetricFilters `config:"metrics_filters" yaml:"metrics_filters,omitempty"`
+	MetricsFilters   MetricFilters `config:"metrics_filters" yaml:"metrics_filters,omitempty"`
+	NativeTypes      bool          `config:"native_types" yaml:"native_types,omitempty"`
+	RateCounters     bool          `config:"rate_counters" yaml:"rate_counters,omitempty"`
+	StalenessMarkers bool          `config:"staleness_markers" yaml:"staleness_markers,omitempty"`
 }
 
 type MetricFilters struct {
@@ -20,6 +20,8 @@ package collector
 import (
 	"math"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/elastic/beats/v7/libbeat/common"
 	"github.com/elastic/beats/v7/metricbeat/helper/labelhash"
@@ -41,13 +43,45 @@ func (p *PromEvent) LabelsHash() string {
 
 // DefaultPromEventsGeneratorFactory returns the default prometheus events generator
 func DefaultPromEventsGeneratorFactory(ms mb.BaseMetricSet) (PromEventsGenerator, error) {
-	return &promEventGenerator{}, nil
+	config := defaultConfig
+	if err := ms.Module().UnpackConfig(&config); err != nil {
+		return nil, err
+	}
+
+	g := &promEventGenerator{
+		nativeTypes: config.NativeTypes,
+	}
+	if config.RateCounters {
+		// use a counter cache with a timeout of 5x the period, as a safe value
+		// to make sure that all counters are available between fetches
+		g.timeout = ms.Module().Config().Period * 5
+		g.counters = common.NewCache(g.timeout, 0)
+	}
+	return g, nil
 }
 
-type promEventGenerator struct{}
+type promEventGenerator struct {
+	// nativeTypes enables storing each histogram and summary in a single
+	// event, instead of one event per bucket or quantile.
+	nativeTypes bool
+
+	// counters keeps the last value of the counters when rates are
+	// calculated, it is nil otherwise.
+	counters *common.Cache
+	timeout  time.Duration
+}
 
-func (p *promEventGenerator) Start() {}
-func (p *promEventGenerator) Stop()  {}
+func (p *promEventGenerator) Start() {
+	if p.counters != nil {
+		p.counters.StartJanitor(p.timeout)
+	}
+}
+
+func (p *promEventGenerator) Stop() {
+	if p.counters != nil {
+		p.counters.StopJanitor()
+	}
+}
 
 // DefaultPromEventsGenerator stores all Prometheus metrics using
 // only double field type in Elasticsearch.
@@ -70,12 +104,14 @@ func (p *promEventGenerator) GeneratePromEvents(mf *dto.MetricFamily) []PromEven
 		counter := metric.GetCounter()
 		if counter != nil {
 			if !math.IsNaN(counter.GetValue()) && !math.IsInf(counter.GetValue(), 0) {
-				events = append(events, PromEvent{
-					Data: common.MapStr{
-						"metrics": common.MapStr{
-							name: counter.GetValue(),
-						},
+				data := common.MapStr{
+					"metrics": common.MapStr{
+						name: counter.GetValue(),
 					},
+				}
+				p.putRate(data, name, labels, counter.GetValue())
+				events = append(events, PromEvent{
+					Data:   data,
 					Labels: labels,
 				})
 			}
@@ -96,15 +132,23 @@ func (p *promEventGenerator) GeneratePromEvents(mf *dto.MetricFamily) []PromEven
 		}
 
 		summary := metric.GetSummary()
-		if summary != nil {
+		if summary != nil && p.nativeTypes {
+			events = append(events, PromEvent{
+				Data:   p.summaryData(name, labels, summary),
+				Labels: labels,
+			})
+		} else if summary != nil {
 			if !math.IsNaN(summary.GetSampleSum()) && !math.IsInf(summary.GetSampleSum(), 0) {
-				events = append(events, PromEvent{
-					Data: common.MapStr{
-						"metrics": common.MapStr{
-							name + "_sum":   summary.GetSampleSum(),
-							name + "_count": summary.GetSampleCount(),
-						},
+				data := common.MapStr{
+					"metrics": common.MapStr{
+						name + "_sum":   summary.GetSampleSum(),
+						name + "_count": summary.GetSampleCount(),
 					},
+				}
+				p.putRate(data, name+"_sum", labels, summary.GetSampleSum())
+				p.putRate(data, name+"_count", labels, float64(summary.GetSampleCount()))
+				events = append(events, PromEvent{
+					Data:   data,
 					Labels: labels,
 				})
 			}
@@ -128,15 +172,23 @@ func (p *promEventGenerator) GeneratePromEvents(mf *dto.MetricFamily) []PromEven
 		}
 
 		histogram := metric.GetHistogram()
-		if histogram != nil {
+		if histogram != nil && p.nativeTypes {
+			events = append(events, PromEvent{
+				Data:   p.histogramData(name, labels, histogram),
+				Labels: labels,
+			})
+		} else if histogram != nil {
 			if !math.IsNaN(histogram.GetSampleSum()) && !math.IsInf(histogram.GetSampleSum(), 0) {
-				events = append(events, PromEvent{
-					Data: common.MapStr{
-						"metrics": common.MapStr{
-							name + "_sum":   histogram.GetSampleSum(),
-							name + "_count": histogram.GetSampleCount(),
-						},
+				data := common.MapStr{
+					"metrics": common.MapStr{
+						name + "_sum":   histogram.GetSampleSum(),
+						name + "_count": histogram.GetSampleCount(),
 					},
+				}
+				p.putRate(data, name+"_sum", labels, histogram.GetSampleSum())
+				p.putRate(data, name+"_count", labels, float64(histogram.GetSampleCount()))
+				events = append(events, PromEvent{
+					Data:   data,
 					Labels: labels,
 				})
 			}
@@ -149,12 +201,14 @@ func (p *promEventGenerator) GeneratePromEvents(mf *dto.MetricFamily) []PromEven
 				bucketLabels := labels.Clone()
 				bucketLabels["le"] = strconv.FormatFloat(bucket.GetUpperBound(), 'f', -1, 64)
 
-				events = append(events, PromEvent{
-					Data: common.MapStr{
-						"metrics": common.MapStr{
-							name + "_bucket": bucket.GetCumulativeCount(),
-						},
+				data := common.MapStr{
+					"metrics": common.MapStr{
+						name + "_bucket": bucket.GetCumulativeCount(),
 					},
+				}
+				p.putRate(data, name+"_bucket", bucketLabels, float64(bucket.GetCumulativeCount()))
+				events = append(events, PromEvent{
+					Data:   data,
 					Labels: bucketLabels,
 				})
 			}
@@ -176,3 +230,162 @@ func (p *promEventGenerator) GeneratePromEvents(mf *dto.MetricFamily) []PromEven
 	}
 	return events
 }
+
+// summaryData returns the data of a summary with its quantiles in a map,
+// keyed by percentile, for example `p99_9` for the 0.999 quantile.
+func (p *promEventGenerator) summaryData(name string, labels common.MapStr, summary *dto.Summary) common.MapStr {
+	metric := common.MapStr{
+		"count": summary.GetSampleCount(),
+	}
+	if !math.IsNaN(summary.GetSampleSum()) && !math.IsInf(summary.GetSampleSum(), 0) {
+		metric["sum"] = summary.GetSampleSum()
+	}
+
+	quantiles := common.MapStr{}
+	for _, quantile := range summary.GetQuantile() {
+		if math.IsNaN(quantile.GetValue()) || math.IsInf(quantile.GetValue(), 0) {
+			continue
+		}
+		quantiles[quantileKey(quantile.GetQuantile())] = quantile.GetValue()
+	}
+	if len(quantiles) > 0 {
+		metric["quantiles"] = quantiles
+	}
+
+	data := common.MapStr{
+		"metrics": common.MapStr{name: metric},
+	}
+	if rate, ok := p.rate(name+"_count", labels, float64(summary.GetSampleCount())); ok {
+		data.Put("rates."+name+".count", rate)
+	}
+	if sum, ok := metric["sum"].(float64); ok {
+		if rate, ok := p.rate(name+"_sum", labels, sum); ok {
+			data.Put("rates."+name+".sum", rate)
+		}
+	}
+	return data
+}
+
+// histogramData returns the data of a histogram with the upper bounds and the
+// cumulative counts of its buckets in two arrays. The +Inf bucket is not
+// included, its count is the count of the histogram.
+func (p *promEventGenerator) histogramData(name string, labels common.MapStr, histogram *dto.Histogram) common.MapStr {
+	metric := common.MapStr{
+		"count": histogram.GetSampleCount(),
+	}
+	if !math.IsNaN(histogram.GetSampleSum()) && !math.IsInf(histogram.GetSampleSum(), 0) {
+		metric["sum"] = histogram.GetSampleSum()
+	}
+
+	var bounds []float64
+	var counts []uint64
+	for _, bucket := range histogram.GetBucket() {
+		if math.IsInf(bucket.GetUpperBound(), 0) || math.IsNaN(bucket.GetUpperBound()) {
+			continue
+		}
+		bounds = append(bounds, bucket.GetUpperBound())
+		counts = append(counts, bucket.GetCumulativeCount())
+	}
+	if len(bounds) > 0 {
+		metric["buckets"] = common.MapStr{
+			"le":    bounds,
+			"count": counts,
+		}
+	}
+
+	data := common.MapStr{
+		"metrics": common.MapStr{name: metric},
+	}
+	if p.counters == nil {
+		return data
+	}
+
+	// The whole histogram is considered reset if any of its counts decreased.
+	values := make([]float64, 0, len(counts)+2)
+	values = append(values, float64(histogram.GetSampleCount()))
+	if sum, ok := metric["sum"].(float64); ok {
+		values = append(values, sum)
+	}
+	for _, c := range counts {
+		values = append(values, float64(c))
+	}
+	if rates, ok := p.rates(name, labels, values); ok {
+		data.Put("rates."+name+".count", rates[0])
+		rates = rates[1:]
+		if _, ok := metric["sum"]; ok {
+			data.Put("rates."+name+".sum", rates[0])
+			rates = rates[1:]
+		}
+		if len(rates) > 0 {
+			data.Put("rates."+name+".buckets.count", rates)
+		}
+	}
+	return data
+}
+
+// putRate adds the rate of a counter to the data, if rates are enabled and
+// there is a previous value of the counter.
+func (p *promEventGenerator) putRate(data common.MapStr, name string, labels common.MapStr, value float64) {
+	if rate, ok := p.rate(name, labels, value); ok {
+		data.Put("rates."+name, rate)
+	}
+}
+
+// rate returns the increase of a counter since the previous fetch, and false
+// if rates are disabled or this is the first value of the counter. If the
+// counter decreased, it was reset, and the increase is its current value.
+func (p *promEventGenerator) rate(name string, labels common.MapStr, value float64) (float64, bool) {
+	rates, ok := p.rates(name, labels, []float64{value})
+	if !ok {
+		return 0, false
+	}
+	return rates[0], true
+}
+
+// rates returns the increases of a group of counters that are reset together,
+// like the counts of a histogram.
+func (p *promEventGenerator) rates(name string, labels common.MapStr, values []float64) ([]float64, bool) {
+	if p.counters == nil {
+		return nil, false
+	}
+
+	key := name + labelhash.LabelHash(labels)
+	prev, _ := p.counters.PutWithTimeout(key, values, p.timeout).([]float64)
+	if prev == nil || len(prev) != len(values) {
+		// First value, or the buckets of the histogram changed.
+		return nil, false
+	}
+
+	rates := make([]float64, len(values))
+	for i := range values {
+		if values[i] < prev[i] {
+			// Counter reset
+			return values, true
+		}
+		rates[i] = values[i] - prev[i]
+	}
+	return rates, true
+}
+
+// quantileKey returns the percentile of a quantile as a field name that does
+// not contain dots, 0.5 is stored as p50 and 0.999 as p99_9.
+func quantileKey(quantile float64) string {
+	s := strconv.FormatFloat(quantile, 'f', -1, 64)
+	parts := strings.SplitN(s, ".", 2)
+	integer, decimals := parts[0], ""
+	if len(parts) == 2 {
+		decimals = parts[1]
+	}
+	for len(decimals) < 2 {
+		decimals += "0"
+	}
+
+	percentile := strings.TrimLeft(integer+decimals[:2], "0")
+	if percentile == "" {
+		percentile = "0"
+	}
+	if rest := decimals[2:]; rest != "" {
+		percentile += "_" + rest
+	}
+	return "p" + percentile
+}
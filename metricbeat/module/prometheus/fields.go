@@ -32,5 +32,5 @@ func init() {
 // AssetPrometheus returns asset data.
 // This is the base64 encoded gzipped contents of module/prometheus.
 func AssetPrometheus() string {
-	return "eJzMkk1u20AMhfc6xYO6C5wcQIueoEBbdFkUxlh6sqaZv5JUDN++kGU5im2gf5tyyTckP77hI555bFAkR9rAUSvAvAU2qD9dknUFdNRWfDGfU4P3FQB8MWcKbcUVduglRzi8VoGpK9kne6oAHbLYts2p9/sGvQvKChAGOmWDvZve0MynvTb4WquGeoN6MCv1twroPUOnzWnuI5KLvKKewo5l6iV5LOfMumyKd/goHQVe4WPJYi4ZBgo3CG7HoDj4EBCdtQN6L2ob2EAI1eCE6PK4C7z0W1Dm4qeHi7DA5N13trZKz4ntrD7zeMjSreQ7Ni+xcjbSxLfnqTcws/rnNFe7vVG30ZXi0/78tH6o/xL6hvbHSDn+b6wvLoynXx+DLbc9yZ8/rPjfXu+drW52Wp/mL2BODZav5NqHe2NvL30BEcZs3B7EG/+FZ+6DU58F69WXs21KeaH8NuvPAAAA///rUkpn"
+	return "eJzMlE9v2zoQxO/+FAO9W+DkA+jw7g946B/kWBQOLY0sNhSp7q7s+tsXtCRXid02TS8Bb1zu8jeDwd7ikccSvaSO1nLQFWDeAksUH86XxQqoqZX43nyKJf5dAcC9OVNoJa5njUZSB4cfXWCs++Sj3a0AbZPYpkqx8bsSjQvKFSAMdMoSO5ff0MzHnZb4VKiGYo2iNeuLzyug8Qy1lqd/bxFdx2fUuWDHPs+SNPTTzbItn3/wXmoKvMJ3fRJz0dBSuEZwWwbFwYeAzlnVovGitoa1hFANTog6DdvA87wZZWy+uzkXZpi0/cLKFtfjxWasPvJ4SFIvyldsns/C2Y4mvpp+vYAZq39O80zbk+qmc33v4256WtwUr4S+oBVnfBus/8VKchyRmiV3lYZoFIX6WPEUh16492lQNLSqXePQMuIhK9nMrx9yxhjdNrC+0KzmnsBn6hLblAJdfBnsPQ0+jpMiVdE5eaSAe0bTNYQ53qxHtikSOHhrswLlFJ5TqGMy8FuflDVcPHZJeB15M81ZkLwiyO9cR80mZytnMmud/Qxm/QulF6BfB8rxrWV/78Jw2iJDsHlX5oh9/P/ccftsG15RdaFpuep+A3MaMLvNpQ/Xvr3cnDOIsEvGzUG88W94xjk4zTmH4OzLZJtS9pQXs34fAIUP/8Y="
 }
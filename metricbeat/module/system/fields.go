@@ -32,5 +32,5 @@ func init() {
 // AssetSystem returns asset data.
 // This is the base64 encoded gzipped contents of module/system.
 func AssetSystem() string {
-	return "eJzsfXuPG7ey5//5FIQXBxnfnZE9Pjm5584fCzj2ze4AdjzwA/cCi4VMdZcknmGTHZItWfn0CxbZb7a6W2pp5CCDg5xkRiJ/9WCxqlgs3pBH2N0RvdMGkh8IMcxwuCPPPuEvnv1ASAw6Uiw1TIo78r9+IIQQ90eiDTWZJgkYxSJ9TTh7BPLm4QuhIiYJJFLtSKbpCq6JWVNDqAISSc4hMhCTpZIJMWsgMgVFDRMrj2L2AyF6LZWZR1Is2eqOGJXBD4Qo4EA13JEV/YGQJQMe6zsEdEMETeCOpEpGoDX+jhCzS+2HlcxS/5sALfbnwX0tp2Tm/1CdoTqLpRuK3+bzPMJuK1Vc+X3HbPbn8xpysG64GflVKgLfaJIi/1UmBBOrZ7PW7FGazdLItObXEeUQz5dc0uofl1Il1NyRFFQEwoyA575AV0DkEsVqWAJEpyAMWexQdAUJTESAv+FUGwIbEGbWGJFpsqE8A8I0ERYUZ39AnI8ksmQBKp8pkgo0qhEzRFGxAl0bDXXnJTGS3IYZpA1VZm4Bt/gU14XXwwWkebsGUaN3S1FsykDcnt9p/hPIyC+5KlAZRVnKICZMkITaf7jPXH18/f75rLZ2ChNAxiydr+5rX0kkhaFMaMJlRLkfbeiKsvJuMas6ew8vPIobO04FilUlj8DymFCrqCsOOJ/lGCVJxg3D71WsT/5TNziFtBpEVAlhce3XOSlcilXjD3uosT8W+huLyi2MElXtk/+DPBQaoIOAMg2qoYqkTx3JXpUcAL7XfDCBwIhOaQQdtNUoMCx61NOw1oKjicyEORKY15dLZO4jKAF8DBUTMriXwyPQCRbB5XFYCsLl9iZVTCpmdrm1BT2EmrNx+lCULOYXyHNENQD4+RR5ACC5pcxcIC8FscDIlRQkZvrx+TA6zmkjxuFTv18ekzWoDYtsWGP92DUVMbf/saYq3tpIiAkDSmWp6V2P6vfzsX4y1FouzfckF4v3MAqfWjYHIDdA+eVJhgnCxEbyTBiqds4E+PBww5TJKMdvbNeMu2BzvUstS7RUrckwQqvwS5o1qHwLlGrW+sLrDWWcLjgQKfjObp5fBPs2iJHntIuXy6BK+H1UKBelWSuatFRpQ6uKfUh0hmmFCQUVSlqkCrT3vlACUpuZ+7AUN2XeozVeuTI02TLOyZpuwAao9BtLssTnTuSSfL19+fJv5N/cdF9x7NZglfxKdVzKFdB4Rwx9tPpRZmSEkYRGEaqdsy2b9qABLBbKnzo0JR9EO0Wgr1vD7mRGIiqc0KosLxKfKwXUgLK/EI5v1YzfNWFL8vfWsD4PpoBQQ35++TcL7drqlVMun/aYRWk2y7n51WnPAsjtPzuF8+cKYf9cQeL3G379WaKd78hr/csvD1D4l3c7jXdrpLlQRuKRmSaObNxR72MOqDj3H/7LWqEup+S30jMa5J9YT+oiWTA2TX2xhIzd6C+TkKN2+8skafiWf6H4D9j3L5OSyTf/74rMQz2AyyTye3UDLo2bQ7yA6zwRokOFJhhcB2hveAyfW9m97+Vk+pLPdL+PU9ALPEy86EO4pz4KOXxHfGrkh25yf509VHli9ZTJH5qsGHP8YIeonD/Y/yT3H4oysoH1q/nP+DMK+8+gPNv1pfanqBjVMb0dL24kz07Zp2ygGOVzt3mOgDcQwo/az5CXu7l60YTuiJCGLLCgccNit41Tzkumt8b0OfoeghTQeIYHHhMuHvSUKh6GncSqjJWQVRmdRVbDlxnnux58W8UMnBwgznIgQuTgYmeGn6jlrmDoSweAx2EQRh02+SDIOyayb+6IizWnIg0/UENkpPIj4WFPypnXNEGo1lliOYOfIpr9gX7oP25fDZLg0zPI4jAgpuFRPthANrVG7WcbqlWjcHsv0w5gTMK4jQkiKWJdFrRbs4IrdpBgnwyiW7O9zuKpAYYxxtLug/cvPuj6Jt4FUqZTOi9NjBaHdVxSJVcKdD/TbEQ5Qw1U8HsG2swSUCvQ8xTUXEMUxBqKenvANssH0PT4KTXBOfHknjjuulPkLSggv2eQQUyMxAUaw4b1xlueLKe256UL5zw1YTV5nVVQJXqmdQt9hc4DBHReyUxLCUrEE7BnB5yAjF9KH6Dwx1uYm/FEeJvtJYjamGdaQugGFF1BNc5aStXQsqBEjLResQ2iqleQ+tXrjFJxKnZKsTiSzieXxqKZSDD5iqeb1dz6TachBT2yKyYce59bMVnUAy3AMErQhp+YDpyDcBArsz4JEedc5tMqkkupQPPa44QE+BkcIVaZqi7gcyTq/sWHaeWxyPRuOmoewqcJcaas47pds2hdJ6F7U7xaUBFvWWzWJDOMsz+onRaZUH7q+Yy8dR/X1GTKfURGUWaDKVfHV70YG3GpUfT1ysqcJSCMkmmVHaMTXGUqzV/RbI85PmlF80HnC2YmTUcWaO3AVmRtuJXr309+PFXi9TivLTepYRvItSeVkhdphJ9e/sfPLSkvGYfabVxyUCazHKZVT13+aYqy6oLoM+U5MGmJJ00VfhtJqCCZSBXbMA42zsDzsnzHmwWhu0U6H5l0HZVYrV/s//oihs0L+9fbr0FEdt4TQLFjNKHAN/NTGAQeAsxTyTqyjwdjwYGtpcWxW7wJo0FtPWGawI5PhIwBkwV2jeJv2tn8CiQFT6rt+7XaoptPzbUKvxTAIUxDvp+Ja07GFd7t51im4bzJbDvhSHhPv7s1QO+rnShUUdsN5qh9zKuUG6mylVU2sfx0jq5WCla0OJ6jnDuT07hwU3716BtFhx7Q/FY3Px4NWcqsGRnXls8Ry/pzwOzpGflNYpHEfzERy22H/rmpA0HdvlUQlnSbEaBRXiUXSCwj3coOBKRA9lvkvazpQ9/CGYomiGcqWsTGmmgCtIvnyQDiyu0BGDLP50PozOAVAk15ppGnz9shEJc0Psac2JDPjpHHtEcagGe3z8YaZfsnJlbzJY2MVHc21BtnmN9V4BfhJjZeSpjIDITX8LN/XBLSf3isHQbn2e1Fob0NwA3jxjLJp9KJgC6QmBV1E8PKH9vkPJUowgozBUVPpl0dWnUkTfihMEWnvdjcss6uvdhR7p4bopWy8I3LJkhXnC0MwX3Nd4PbL8Gzhh9f7BY7CNYZo1wXr5XVh+hReZkXsVG1i2AsQWNxGBMRz+Liw5EUrhJlscvdyYhGa9dOsDX1IlsuQWlypaGIXT1raGQyymcNN+Tiw7NBgnW0Heavt5G8xtHKjp8QY22r5VyfF7/XWw6uCHIGj9QTVOFnRQfvDVHgjaF2mX5mlQhEBGQBZgv+dr5XaaxyqOZuvISCjRvsT/OTJIYURKxzy/vhk8ubJVIBicFQxvU1SdEMkmgN0WMRM1d0+GuHSpCnj6E8u8NL/t7guQjlUcYxsF9QK5YKL+qlbM465D0Q3kNSHnhgSuBFqmT0IoGEiaW8bvPC/khVnRC/VgWH4UlpVAojwpb10V2nU1MKtB172Z8Pgnz49N+EIaGU6CxpGsBch5igER4l5Cr0oYjbr/334ff2wvZSlIVa+K8PVYsO80aGmDjSa+bIwCixfdbSWqV9Rcxb2rRs3TYvVbBk3+7Is/+LZP2/pntVT61YzcNRSrfFeipMGxZpdwRUnh9aHLX+yLk2h5Kn/ZmPJ47bS2KGqtJTmXV0fMbhfSqLWB7SjoIrMzNLWxfaB2CuYYpyJwyHQgipNbmZ6UfAxOkAMNE/vwIa0zXWnx0NA3lfDEjqAw5AgFvE6JzfPgg4IllXz9i/N6udDVuExZE+XcEcg77DvNV8y8bqlcIij7Sw6UpHVMwfLewDFSvnZvAGTQu11/uICuEiGTd1H8CYKYgOtADHAHTz8maZThUfBgNnA2ZnK5IprVKKFu8MUH5G6ZbZFYdWQcQpS4ZKGtGeT9TdaHvF7j4wh+WSRQxE1GyKX0c7rT1yc+doSYmhYo9m5DXhcguqaqOYiFmEF8tL5bGetTYqW63wsqaRxbhNI9ZkgRPn07DAzX12FgTt+DpbQUhZz+6AWyBek5/a9x62/sIba3l8XCHIF2KkUvJL98XfV5JFTBDKuYxQRCU5U0SmPQQc5dvUS0lretVZsUsmii2mUZ0y03SwEilwFcpPS0iOgiwy41IuAX0aSZnOVMqzE2+ufYTJDahIJgkbvTRiWNKMm1DRxmAajljfb930rtB1KdU48HbjmlXjzSbw0IbRQlYRfSiGrdDbYeVJIxAJ8YL0MbMFawiiipWgnC9o9DjJ1G/ywLrCGqzRTzKN1+x1ypn9lyV2u93StAqvaFEAZitVFdH4Uz4/RuWYz/+m2myh9mZP/ndskLFsVLKcr88CmPXIg188UG2CH9J0QWbmrDWJzZvjuvaKVxAiE0+KUEEErP9+jEuLRY8w6d2EamCEYw9k2OmQqALJQMYwMQOlpDoNW9zQviWMQ8TEaoCszoVJg4j7ETExi5W0xvokiJiIZIIl8V525aUpP+0Ajp0SoMzMSu4H2Hjej/It3bU3y5c21npL1dY6/CImv3x6SxYQ0UyDP72yrpuCVCpTpm+62+s09qO5zpKEDqg+KTaLBRg6bL9673ckd5nHxb8rLheUF6Ydj+aY2Q3cf1g6+7eguOTiX9CKaHoEdv/gcuagwp3qTDTlbJ/f9EyXxVNO9+Vt/3RzzgxMPOc7ZmD/xCxKJpXim/cBSgsHtPa2KTnI6/JjVLyutHz6lMbU0Ovqo4nX1ZdcG085kmm9LsoZbVqMlJp1Qfcs8NWErdyNyuKJ2PaMzcdayfGXmEY+3FpFk3Y8ENlPfvubQ6hPj5jwwBlXh8/Y/uqQGaMk5kxMLONlxjmxkTcV8Y0d3qWqjHTvvlYfWb32JWi4LQRqeqhaZQkWC2lIqaJ+bwtW47OVkArmdCE3cEdevfzpn2GLp0EdsJRcR/PD1lG0PVSsdndkYuWPLOrloUNnB7EZbmbdL+dHagCIDVNSWMmRDVWMLrjP7QW1wD3yY01oqJsWrTQwJL8qgF8+vb12ZUvOyH74RP47bDLq7ymR6XLmbx6+3OgUIrZkUTVZnpa9GMemwzs74pJRp97dZ8mB9pS1d6j3tcptgnV9jdFpPRHa4p0kC9adNriXslF7vL3o4nUT6OUd5Y96EzxLY9wt700lUNAsYZwqXxgVnPZvdpaCkdUJYqZTTndlpGBkmpvsvEVouxtkmLkd3a2/Kw4HXl0vR57y9fWC6PIV9lq3iiaL97SjJme2C+E21U3ATidOidfVBu8V7x5+hh63r6OL207vGHTDH70n+y5/kOF1Ou48cOx+1Lff9e1XT3Q4UmpA3jo58HT/mupqga+rbm5Unr/BoyHyZk3VCsiVCVykKEamzl3Jozkq6AqUnYW4AyYsdcaEuw9hciTPi1cCfdbVXWJiul9TldZPdsJsmfwRNIvt0voEhnxif8CsYS0CfJdRlKXMHUsn1P7Dfebq4+v3z3slEmVK2Qm900s0uDOw644b/k1uXd4eNJpF3attTdVTLTecOw4Rk+nONhrhiOeAGzK/Mg7FZ6TyvmCeUXHbs9UUZLfLNDJdCRqWgSbq7s6D3aV9PDG1cZQpiKO2v0bXkDoPNI4/eM/jLGFmpuVydK3HUAWRS+NmySuCeqAX3lNwyFpUWBk7ooIsgERr61bFTY+OGkLFDvffPlasaSuonYoVduhTsaIytmUFtvNfAFE0f6NFSWk6AuHQwjt4SeYZfbuAEI8uW1W6mbBNKjaEw32V6kd3QScBbFHfGtF/q+hFoqA8y2g5U3bfdQPpNUuxBKo1oJDixrLDj4wM1FCbAPlXSy6gWRgbt7fybqQngzaAwcRr0/1bdDCsJklsyOKo0YRqLSOG6bAtM2u3nVo2h2OYe4z+sBmf+NEQmo96/9YlZXx77Hx0HA3pzi+DBUeliz2HtqRW/2HWp2OSHT2/HuT1qNk3zv9aZwsXT/2oXWsb10lrFMtwtnMwrZ27IuNKeLo5FqVZyQuiozXEGQeNMRXFTvfOT6X6saj88usoOOZr953cPkthlOTcW7atLHK3xVRKX5M3v35CA/Lxc3hQ+3dtqIgdmPydBb4jS8pUOZS3M6mS1l4wKSgPlFUjd7BRgPf+8/Axv3Wai7G4IrkFtlqbGfn4uQIjOK4Cyn0s2gClwejK29/BSDvoj5LyraW6AJDJ/p523nmTkhXbgLC+J5P7qieHVWsFDRoZsF5JUwPv3+Z5p6b27AXQYS4OghBeBPbn4RCz0TlayJzsJTJa6pkXWLBQkowuUNtDKs6DsvDPvyUsUjJ/fgBLDOWWKFhlnCq7K3YO5Vjyo87thJGoywq0zFQEmui1zHiMfgkUlaQjePJ7Jg09PUs+N0L9Tsa4hUx5+GIwQsrNJK2uUZWJfH1KAX5tkiuqSQxL5ty+bi5XlaOrg0KIexiqnZp3rwXW4q1A+ewGJkh8+gmswSsWEuKpGrzOQWvdSHOnscbWWeVcIJ8s9taxm5Np5pni3O+8WPMVsUrPVuuqN7qXvcpc8Hot1mU3fzvWK6Zhxi5UZWYqExhqXQIzMI0vxQq0Qe+DiUxm2q+5zoGZaIQo9UW8phvo4tpANrmGOw7GqdlU1r17U4PlshvKNRqd2oKxi6JuYrqNm13ayArgNN1/OaNNulkraQyH+OxMsLqiu6S6cG1GPDZyhUQyfd05bn4tYusOsK1tz4vvzBp2nkHf1jTDfoz4yNpyr12qmDur1TUJuXwAUwT3wqHmv8lxcfIttMiY523gXYf2KyaIoELWWtv7lVbIo8fBCMlpWMxEoz1Z4EFxk4+C8m7Lgdqt/Ocvb7r4eWJv2p9En8drrL6jXFH0WvssawKq8XOPvo9a464UaRpaC1qq4AvgWAqSyLjrtkUYnz+zPh/CK3c0/XwM1BRUOMNC9hdI5T+1QqnjNaugsmU9C7KlIECjNX60oWF7tm+m+1Vs7yE0GWc9/eVUnxZ2NbB/GdATGdDxhjKBZIYnaJ1ny2TICu07WRxBeLXVoz/cW+w601/lK0yjCU7ot8sheg1FVrDa+29qyt151yVSXaZe3CZT711nqc3rgm3c3r190mgNz32JRjtfjQc9Fc8900P3B8u9JWU8O30+pX7W6yOXRs2JO/a7asj0Odm2CojLHwXYRGk4wXp7abZhDXlPv7w4pGYosJsgNkRyLdD9Guocb8q1VTDrUu1Kq1LHbsZtZtWYsseROJZZF2+K8kySV7gm01DY+/MkUxsgvb0kE9RcbCjQzhGvWlJHYzXSKD1eqr/ii2FP5rY8Xr7f0mRBr/vSOep4zly8MZHLBn/2GYjuLOEhhuPxMl2Xptym9F3s2HMTpRdpKmo2wm4yn988lH2PW5WtYwi9VNNQtQlNigM2oic5dpj1RDZ9D3bCM6vJp5bB6OPSwZ5Gwa3LNBpNQXYfVo33L1zC0nUGn1Mhwy1ZBjNgQl15LaTYJTLTpQfqOthKQXwncw5UmxsFEQjDdze42q7effzSzSDOtKlduU3SpSZXep1A8jxUZj+ceTZKPzPzfmUcbhY0eiyL00vmvPv4pSD3AKqQ12em58FuEDjx1DJaM1BURWsWUT53rJpflmmspo2LSCyH7b2novFCxU4429d9cjsJu/T2MrlVRmSD+dY5ZJ2fh/Etf2Ph+7GkxasQVXNRW3ndAW5zRR7EqScwm92cChvUII8O0I4Em/ZdFsWf/FvijtobB5H4/8NHPrtN8bQ2B5u1Y9/HsxfJWBtBi9sV9ejUKLZagYLYfmJfAgyhj9SHf0k1/w7oRqA9hJNn7+2nnrn/1GRtVUiUd1d8MsC9u8J3eIfFyH3hr3u2Bpti4OWamFVvdwzUKD3vTLucoPAMe2Laf2L1may81sTcpby8Q9MBdHT1+jw1ITKrBGnHkrLvTu8gUs6xLfqjN7tCFBU6pXjuUjQhf35NhOzO+07ruCqt53bmi+Hab40umnJJaMHIIL/Glc5saXoxtH4qjj0OlF4mYMMig893XQpR7yvp2IgKIY27q+AfZhhEaU7lgj+ykA0fUS7zC5dRtW/vX1UyU1fJHFAk46oJL0Vjm2/PO8ODtmYJSrlsn3s00j/bv0Cliu3i65yc7DmsGcUmJs9TdVky4P7Fh7yzqRRY5m+57SrkLPmHE4512FBerfe1x9jQQ3IW7doNVPMb2oEGqsxwuCMP3r/8NLDD6h6m+CFqPb4rF6NBF+1Hgg8Nn/zB377OcA05lrARLtMtvJUbJ46wiZBUXj+o92sZhIXFrYKk44HYQUeh0BwgPQVL8oHHoTFTNlGugHHjjsLyh0wWbHoJuWFHIYmBTs+SGB/UC6Mg9+ZHTTagdiQTnD0C964OM+5Wug1LqcK3PpggWib+Lh3lRDOTeZPKDEnozgexYdIy8SjkthlcHk9dSVjl2sjaPUCHLYV5LH70PptRDDbW7isbknlEbROtaM07Gm12G98/+XsJfbyiSdHPz211XUuSmtbtvCPmzZt03zhRDEDAYQPhzePgzqJWFm7cOoAwB3YimlvYMqynB6F44wsR7eDEDX5NmMPy8fX9W0KVojt3rzLOREyFCfeej5l+zI/PJlpG1feJXM7WTbJn/lNu8DhDRUh4l4FpY8PmfZgwhp6eJThs3M+SJWV8sq2sMr8bt39+XF96THP047v2koRi0x5Ft4jC2dtw63YML6bVnEpaBQevKs1a8hjT8OT25aufbmz4k0PYB8+uzxM4JB6fd7A9RJdKVngjzM7bg7awT6AatmuyRxeqIULe58XNps+waWGFR2WbahNa2SQkjedHdZr/jNe/aUxqG9O+OY+eLt8LR0yZLY6nUmeLm3FEzrHRbXDOQJ/T1oR4UmJokuYTYrdc74thH7aZ75SUQ8HkuNt7qIjz+Ora+aj2f0aTLG13aSu6lX+DaB7J+Cg+fbr/32/+z7u3xI5TtibzCH/UrvFi+1GIqnfLjDupPF5mVXnZcdsXWtqzbkDEUs2xPWbT2I+aPc7fkhuOouhyEJy3t12ctzZFp7VWp7Kw1vY3WovSzJc9Bm+WDu/eV3YUr9+17JzZH/7sLy4dPn+tILRVPdCcHEsAZ3jadNSslYo7LCocLpjWa0GdOAalvPNHf3I967xZMSjvfe46E5d97ThVraAKv1R2Olz5A1M9yLqfcxsMLTht31OMXe/xHTarP3urzNn2raQFdkzY//nNgx9Flw6e29qOy6m6xzy6gtLuR0H8wpl1fb/rMZAgiCVNWKtP3lAE9nPHTM5lRPmMhRuStn5dPBB0+x+vZi9nr2a3RCry6uXL27uXb3/5593rX/7z7d0///H3n+/ubse59e8sDnL/QGgcK99nlRWNDKkg9w+bn+xk9w+bn4sPDaEtlSq8bwdUvKDv1atD4NupejApSKSBC2D4RwQyMcc9dWdhuSdgOM/XUo9x4Apg//7zzavb25vb23+/+fvPM7Gd+b/MItl6ab0H88Pnj0RBJFUc3PRVLpMZuceXBOXCUOxQt2GUKNiA0u3t+f6BcCkfOw8LG2wAw+N5yjM9l6Oemyrfjj2UfHyPaLmEyB8SpzcufRhLjAKu4PO7t89zz9jzwgrNVddKASSR7StanC6A194vu8YB7Gj/8xbD7mdLKWcLqmYryalYzaRazZ5Z/j6r/qJ14F88hWTHiMGASpjI37uxw5NIJuA7LlNBIFlAHENMIpnuiqQoNa0WS/iFtTHp3YsXabbgLNLZcsm+IY7BujzHV0APDUnayvmfdjj/oUVOpmutVcgENdCrG/GXVHoQdz/91rfHjX80bi8A/6jOgSACSZjDUEz9ztuvlTfeSG3ovTjg26FPGMI3iDIsJTqGH9g6abRKhL81fuLOlFrP1MuM8/kIVaj7wN2lCZ/w72To268jKhPk0j1RkPvPrKxH8AmCozzodjvWg3vZv0Y9FsJ51E0h9OYk9oblvktqX0AcrnyxwJCH3eiq7xpoA4ECiQmxFFOg89P5Vu6UcnGP5R4qm55uVt0MmeDZlPf1S/HVUDJP+FyXrcbL1EzRiNXXIGNprkuope4JvD9gRt5IpUCn2HTOyLzXlgY8039hLeYLvdMvBJgXLN389MJE6TyBZEY+dDx50F3iGG58fHQX+n7pkoEJIKnSNd1f494t6YFoEbFb615IflqIrcrnou3m714KumzI1ATk9qSf78PsygnwWWj77EwTHmjrETC9bh30nQBgeQZYmXYUNyMuNcy3tLNtyknQNhBaGzEvkcyDh2F13IYllwG7ADIEtd6JuQ4/5nVW0DmOoZgVRM0He58Es8UxBPOSCZRJMxV0dtAFkDGom/mfJ0P9aghqTrWZ0yh0AnNW0DmOIZitrTnLDtJv8phYhRAXQVo8qfv65e2fxH21hDyh+5rFl+i+7pcuGei+ntv560K951+K1ZE2HvAYnSX46ob4Wu/k4K9yiFWuKu5TPpdw5FGbb0o/S8LVDIGjgXz55F9t/JmJNDPz/EMJ45yFywcGFLN++JTTiq9alEO1S8UyDUr38v6AQrF3crWC+KZ49x20ZlI0E8j7eNyRTju4xLe8gObBBGfV0Ho464h5X4vq0QiXK2YtV3OKPXfdjqT57S+Z9lWc7oW5ARwIHMIeicJ+vagRqmhDhwBCtSLHyKBQvqGlKfXjiSCShZQcWvmBXiT2a4SJmEXOMtH8ZGgvR44pFQtLJO962yj624MhklNrRUUazkDHgVnKkn8atzarg+vJ14BvmpKHYTbByWg+8si1dwt9XTsW9GfSZbvYBqDyX/5/AAAA//8PrkhW"
+	return "eJzsXW1v3DiS/u5fQWSxGHvhaOxkJtjLhwMyycydcUkcxMntHg6HDltid3MtkRqScqfn1x+KL3ql1JL6TRlnHcwmtrv41MNisUhWkU/RPdm8RHIjFUnOEFJUxeQlenKnv/HkDKGIyFDQVFHOXqJ/P0MIIfNDJBVWmUQJUYKG8hLF9J6g1x8+I8wilJCEiw3KJF6SS6RWWCEsCAp5HJNQkQgtBE+QWhHEUyKwomxpUQRnCMkVF2oWcragy5dIiYycISRITLAkL9ESnyG0oCSO5EsN6CliOCEvUSp4SKTU30NIbVL4ZcGz1H7Howv8+WA+5jQJ7A/KLZRbAb1J/l3Xzj3ZrLmISt9vaQ3+fFoRB1bTSAL0GxeIfMVJqvkXGWOULZ8EjdbDNAvSUJXEmfZliGMSzRYxx+UfLrhIsHqJUiJCwtQAeOYDeEkQX+huVTQhSKaEKTTfIFVWgbKQ6O/EWCpEHghTBXL4+rSiEj3gOCOISsQAVEz/IJGTxLJkToRrKeSCSG1GVCGB2ZK4PrVKge1cIcXRtZ8gqbBQMwBc+pzhKap23hYWQARarwir6LvGutuEIlGzfWP5J+gjO+TKQHkYZiklEaIMJRj+Y37n/OOrdxdBZezkLmDQ0PliPvYFhZwpTJlEMQ9xbKX1HVHQ3w2yyq1v4cKieApySlDAlCwCtOACYTDUZQxeSGjGMEqyWFH9OQu56M+6w0HIr0RZEVoe/4UqMWfL2g86tIE/AP01oDIDo0BV+c2/oA+5BUgvoEwSUTPFrebYbZI9wG91H5RpYEimOCQtulU0UDS8l14dBlML4HDCM6Z2BGbtZYrk3hPBSDxEiz0SvJXhAegYDcn0zJczFPP101RQLqjaOG9LZB9tjsb0WJQ0iifIuUaVf6wd+PEMuQcgvsZUTZBLhgAYOucMRVTeX/TT43jUDsUnfp8eyZKIBxrCsgbi2BVmUQz/WGERrWElRJkiQmSp2joexe/Hs+q9oZZ8ob6lfgG84zQ8dd+MQK4IjqfXM5Qhyh54nDGFxca4ALs8fKBCZTjWn1ivaGwWm6tNCpRILhqNrbGs8MXVigg3BXIRND7w6gHTGM9jgjiLN4gz9JnRr72IPJoBTJogx0mYZjst5cI0a6wmgQfYJpG7rc5gmbfPjvJtWqSCSBt9aRPlUgXa9BlnT4t9j4a8YmRItKZxjFb4gSCMEvyVJlli9074An25vrr6K/qbXsPKL1p2Q1hpf6UsF8eC4GiDFL6HAVTsyDDFEQ5DbXbG7z+U1+Pmy4MFoBRdUvnEn2Npim5Zc4tAXjbEbniGQsxMpxXyZbHxuRQEKyLgG8zwVt7xu0R0gZ43xOo+1vumWKEXV38FaLCZarej7LZHADtejs0vxnrmBF3/vbVzaou/b3wJ++daJH67y68/y2rnT72aeARx+ffodj/RreJqokRCLEgkMmrrGfUmiok2nJvbf4AXysVW5P8FvS8io17xCURSUw9S8s971bBz/GQVGTrRT1ORnWb7ifZN7yl/ovhHzPvT1GTvk/83pebYCGCaSn6rYcDU2OwTBVy6RBPpSzTRi2uP7vlf4M9f0KfG7t63cjJ9zH3JobP40bDtNDEfj8Hec+3xII2YPo8Gbu8z4qmRj53kjoZ70vOW4wQOsynf6fgBRJTOH+Cf6OY2TyPrmb86/owC/uvtz2Z+KXzlGaMywtfDu1urB00WoL2oJBEUxzMzeQ6A1xPCD9oeKI7t9AynGlSiBG8Q4wrNdULjA43MNI7juCC9IdPu0W9RCA5CAn3g4dVm3ODRkVIpwoBGJAo57PCDycgshOPHRRbHmy341oIqcnCAupWRCEG5YL5RRPYF6EJB34dGgNdiNIwqbDizeUtZ9tUccdF6U6gWB0oSKi6sJH3Yk8bUWhpDWMosgb7Tv4Uk/UPHoT9fP+vVg6cnCPpYEbYfjpywnjQ1pG6nDXohqCVud5I2gpiExrAmCDmLpJ3erFuB1rdNvMABOR1E3fw2jJQfGqAfY8RhRr/58bYEsAskT+UBMQIOiGNTwZeCyO2kwb5yADYQCPJ7RqQKEiKWRM5SImaShF6svlXvFrD19AFoEtkmoaBGLM3JPQwnziI4NFZoTQRBv2ckIxFUPsAAjcgDDUk/tbTdHFkv3eahFav011E7qkBPpWygL+mZy+3So9pBx+2Z/Wqie8Qq0BEi7EGNX4oYII/HG5iDXtPsVoUwLIf3qwh+IAI2tkrrLKhTqVqZt0cUh6gYFlHlEqQuHYx5HbFXdIMH7RbdwhH7pTZo9tQxVl6AH5YziJsOowpIRueUGXovYNZRq5Iy3R6gnybahx9YD90GiglbqtVBlDjmMLew92RI4A1oSOplj3tUwLZgFAFjKoeAFzCC0c2Pt/vtj3kmN/vTpjj0r+xxRZmAwHW9ouGqqkIrenQ+xyxa00itUKZoTP/A0KwmofitiwC9Mb8uscpgy4IzXZUppCsrLRfGhjGXEMfWMisdJYQpwdPNLhtcxVaaLdFsyhy+aYWd0Nmcqn1G9LlgBIKhy5pwCxinP54q8Fqcl5CmjBV9IM56Us7jfBvhp6t/e3FWV2NBY1Kpxh3V0V8KMY186uJH+0irzpX2ku/peP9M35tyvWmpU19KfEMGM0MZSwV9oDGBBZQ+L3MzXuCFbgbpbOCma1+MILZW2P/lx4g8/AgaXH/xIoJ+PgAUEFuHQr6qn/wgdHXQLOWUqf1i0YLB02rZDW78aLS19rWtEdsEIB8xHhG9WQC+W3+nuZtfgiQI6YvoENbebdULQchs36yV+BKEjCFNb8/0RbQja7qtMnfdjGWSHHczGxocCO/0s1sNdIG1+ReHfCFhgjmrYx4yj1mTMpJKU1lpEnOnc3i5FGSJ8+M5HMfG5dQKboqP7jj1jT+geV91PxYNWvCsvjJ2bWmT3mFYf/K4PRmg91xnL/6DsoivW+zPNO1Z1HWNAn9PN4kgUvdXwQKKeHFlSFcvlCF6PHInNdvQb7FK92VIhcbrY6IOEAbPyQBC49sA+tzz8RBqcOhcA03jTGpOSzknDmXMcXS2zcg6WoUlH8hwa9odHcCT6ydnPro6nDL8iLLlbIHhTO0lLPXOBpH2tgQ/X27qi5cSyjJFAj/Sn6eE9GeLVbaAvZ4U2msPXD9uSDgMTmUTFcwGMIponjfRL/2xqc7PU1An74F9aHQ9CZWu96WT/qUnZz3d9qBYv7uw+awOxVxBtot//mJENLYs7MVle9iuONoyBNqxF64ViL2Qjrr8+AxTbC9YnpjqsOu1IvsQmrYgi7VR+RbBiBO4RxAS6sM4i/JfDjkzCTvzjQsnQxyuzHWCjabn2WJBhETnkrjoM7DU4BCSGoNaGOLlaUrLs14da3Tzwq0P1R5IXmlprgOADOBaB3BBXePauKz9uEapz5Y6jXCbIfZQpqRQic+SDd4oJIh1hnDCARtsYEQErsCcE7UmtjrfmrRO3yjv3dge8l7cAH/qv4kikhJIsbGe9/bO7JslcCNBRBSmsbxEqd61ReGKhPf5mrlkw1+C7aSfaA1l6fYP+Rulz0VwHGaxXtjPMXRLiYtqKpvxDu4OhHckKQ489JbAj5Bm/GNCEsoW/LLJBXxxUW5Qf6wMTi9PCqeSOxG6qEoH4OCh8g6tjwbzdcvQ7d0/EdWKYiSzpO4AnQ1RhkN9lOBM6DZft1/az5PfmwPb9iLPzcJ+vK9ZtLi3Xi5uu5vraSRNd4cbo7RFF6eHXOO0t89LBVnQry/Rk//Vnvv/npx1QNbzkpZShC0QqVCpYFtKHwEV54eAw3Wtvh/ZWbPtnlpLvlhmWzxzjGFr1+2FMn1Nqa3NQwPWgc8wvKfyiLnPGgaXZypI8XI4uxVMoQvCtCgNIQWXmxVDtxUBZYcDQFn+wdb2IXUKr3T+2c4woN1CIKrq1QOBniKifULQEtGqfMbeimOiXjvrNwidFkD6DFypPPPpsDVatW4WxJQ88kAPmy5liNnsHnpjpGE5Nr0VNA3U1u5DzBhEPBtkmt4GMKKChOr4AE278aYDHzjS4wGD1vLNlEYqRR2brqQ7Yu8Wuyu6MSRIGGOa9O1pjfZ4Xd2Odmu3m1+YkcWChpSwsH4p/iH9kWnboUUFhpI/CtArKA4movQ9RFlEQ329TGE8EJlLJbLlEvYAIY5zcutOrE6BsarTUGDaPjoFZz4eVtmS+Iz16AE4ALGWfOrYu9/480+sxfFxSSGbiJFyHrd0x2Ri8XelzSLKoLCJw6CLSur0VaFrQG1RwNr8OBWqqaSlbgClDry22I/p5BsT440I9sGgBuG0ijgUaJ4pvSnss6eBmslMwEbPaRXjD0SEPEno4KERkQXOYuVL2jjG+H5jmjeJrnCQNwg8TFxBeb3ZZ8JoICua9K5ht3v5MqS2SGsrmW2xcyeiot0FjuM5Du/30vRrt7AuUaNz9JNM6jJ7mcYU/rKAvWUgrQzPQWJErbm4P9vWKR1m8sXKKB3z2e+UL1uovNnjfq6v9ljUMlmGnwIOTPnNk1SJWg08+P208oDvc+kC7Ob4hmBrn3eN2z4gc7ejBSBZecXLC5GykyIUJCR0e30MEJni8J6o3kAHgbGyexJ2OCQiR9KTGMoCIgQXh6HFiLZXwhhElC23QIK+OhYmSVi0HRFlQSQ4OOuDIKIs5AksqVzfFUVTttkejB0SIM/UkncDrD3vh+M13tT7D6ErOOd4g8UaAn4WoV/u3qA5CXEmiT29gtBNkJQLVWzftF+v4wiwznUmsyTBPbJP8sliThQ+68XKOzsj6YWkXf8uYz7Hce7a9dEcVZue8w9Ng795u4vP/0VCNazDbj6YPXMipLcxFe6ztU+vtzSXRfts7vOb7c3NYqgE3m+bb6HYt7NhGib7VPTm9TuPpq4xSDmQ2W7vJH6wMtCdgtT4G2bmYyhbPP9wd5NXBevkLnSjkBI4vLfbQCu4WA1+oV5eSpktnFRY3tuKSQkNkEjfEwbjBUK51x8+X7oNeagUvQ16DMquMVR9aqSdkR7cQ56bYxhunyIILspBMWXao+VOCUv0BxEcnJO58lCin4Pr5ssNcGQPe07iUrs6cIpzae9R43FEhPt4mYM2fcs6S56Q4PrqiNuITbLg665sDoUJYIWgH5XG6TMIzuCeuOYLGvBlc28jvewt5Ule2RsDei0dgZ8Xj4qfF4P5eX71qAh6fjWYIZ1UEQzf/umhuMmbcFonNBTcgtvOgldglZkeGoJrm7IPiWP9WhKN4rLuzgK8oiRNslhhRngmzfNd4GXaDOJ6iD1otl48YrYG+RfN1vOrR0zXMG+j+Tq1t/Fz4pVnefJz0qJva3XATiGbS+1wUdujC6S84gwr+/F8334sNYaixxZOjeHo8URUndkNjy6o2qd3eRRx1T59zeMIrfbqef480VWvLKvK3fc7BVc3t484sEI3t4P2Fx5ZUDWUnscWUA3l5/EEU+Ut/1YFH0UgdXN7uRdv8iiCqJvb7wFU/wDq5vZ78FQLnsp+x2lnnxs62xYsdSjyxcooZbHZ78B7QTjCCl/CtphLaDNngXbfyn7Pewa6exYbjimu91mK1SrXO/B8NKFLc0PtS6RERrwtQhHVkMS5LXYAJ62OMy26difkE5ExRtnySeBFk9JopPrNT/bRPt2hwZEtLse3uBzVYpjAk2TE2+joPgbXC+8MJZhFT0G8zt2HShqpsFA6GLK4L+2VHpA2oDx3JGCxzBJ9+YIkKRbY5gp5bzejS8YFmeE5fyAv0bOrn/7uVRluih0xlOBjY8dRuI4Gtua6FbKN4HIlU+HU3FDv0zphD/3TVkwuy2xHCyDsgQrOoOfQAxYUyptluxUE+kPgQn2vExWVC5yh3wQhv9y9uTTXQBgne3uH/hnsnDQyqAbp9YfPT2VKQrqgYbn4KC3ethu6lG59YXQv06//ub9SH3Q/PVoHayIFnQR4ILRatgNrqrckhWtXtA+x/qKN6zrQ6ZVG512Qv/dU6QutaR7KZWmkZ8sbVUq8lDShMRb2oglvs3+FVnIiyw1EVKYx3hSZl4qnzmW7JxdtDuZWclteC/6mGCYPlXTu8lc13dU+MvxHwZzv/jRgkSokMGsrJNEXzVw1b/+vU2zzYKfgF/zP/tYBmwF3SLy6he7u7eATvIfv1YwCXdQMeoegA0z2tYeCxDXWIS+kFQZnPly7HJeb+sqh89G2+W7bfHWiYrPCAtxTtHaNVaZ7hWX5wiRzW1TtJq/XutQOvV5hsSTovHSLVz4ecsnYBK323wlmeEkEWmH9nGYCzyRGtoDJLmEckgvnOewdV/ZSSCrbeqXgV0jprbc5FskfiaQRDK07otAd/YMENW/h4R0eO0nhPU1Y42PqTjLQ+cdX7y629kiYCQEN2qAXSWJqCi+LK8I62ZreHDSYolb9JOwincgSdNuRT5ms8rZ6Ge8iGufLmih+g+od9ztc2FjQ7aiYahSwFG2R+pswTxeLhoXnUWq9eNBrB7ue2Ldz5ClhQ3urwkO1LGdR5UDCk8is95wX04SqAF4d3wlSh4HwhTKtuBsWtkDPoyevSKdQXXaIGdSthisIq6Ka+nBYg9lGz7/bqFhhER2IChB9KCpKsoEK/Tz6nCCBKSys5xskOK8FsbWyqX2Nyfe2Dkov2atLi0u0pmqlvyO5KXKrqJmr1BB6C/pUFvtmmOpgym2/BlaTgDDYVIi+wGCXRAUDByhUSVl8waiLPQaV9kMVVU82eiEmUuF5TOWuV47tjBtm5l/vPr365e3N3X/++sYrDsLR0oKhl4Jyw2bSPxEfWbu7/3k/u/v1/Se7Y52L7K2GIOHDNNT4+Ovr/x6lxoKyGRR3XZ9ej99u3s/+8erm0/VOijybjiLPRimi3w48vRKv397e/TpegZnn+c1TaaGtapQqsIE089/acWRF3r66+zR79fq/xqlBpSJsAkrc3H369f0oFWBUULY8vQ4wLm7e/8cgJfQiLRgz6e1hmfdLfgkIUivBs6WJ4CrKVneQfTfswxcO7xlfxyQqh+iECBnUAzzYCrHS8gubvCIlTopbVSCetS/kSVuqPydY2bDTRIw/Bc/gggxdkzuIfXeVxsl6wAHo2QuGU6+8bp7bOfUK2w/PcFXA8WLuz29KrPkGagtihzb0rYtGr5jcpRKw56CXh7J4Ld20BJhtaT1sRWJ5D0sbjBKSzMv0uv/ZT7k9D0jJyivXG/vPsFVpBMkVTWGwlbNOzBdkI8EK0krWa06Z77ToBrh/iQZmqB+Jadp+Jt1RXcboAva9VpQILMLVBhQ2TUn08OzS7rCaxvS9KNAijiU/a7dtygoR11UOyoZutmuGrhFp1Gqg/pPxnjYKa/mbN3rrGLZLuE58cmpgKXlIdaKDXUhTqa2haQHwdaP9gX62mv2gEHZSb96Y4/b5piJdb4Rrvd2zCV6peN5xvVGZIshWOBxJIN1dpG/Nvf7Csv22zObmpOwHaR6BNG/ODqJMt3YM0ppZCd2uZQBjYZoVXCAJd5JnkHoBp2U41K4ApgOwp3wqsMPdK/OV+YzbeePw0LXOuwNXuuZ5Vk7elJCX6PVvd3pv9eMnfwfAz6XCkPsHYEKepDFRJN6gBaaiEGXdYSo4uDXKGY5bJjvzTJg913EHg+59FteN+WMia0KXKxWgj59KMLxyBXFJi3VQEqYVjBL8lSZZ4j9DxaprT6+Yi60NA8n2RSP3Rj1GS/pAGBxLUN52wVe3M9vq0PqM14YF3rxxkUjdejoBtLiLURD8gwC+PoxxG63SfO6kU8lwIQPbYZns1LYlzBmiqm6nnF1cSeKH4bXiayTIMouxgMm7VZSh5Afp/ITi2pYFkTwTIdxiueJZHMEOvCD5nasDOPk94wofnpJPtUPcVmLypONWUbmbxM5gYIyKjLnxyRmxYxOdY4kisqAmzmwVWTGOtrfGfOzpQ7hDc/eK6Vsr4XDbnFuDE0M2sYCAw8sHknRp7c7htQotojU7+Bq0BqWML9eYC+BaxYZpZknRrxvn15rqpd+KLldE9KRXqAmPV0tRh4NqG69UjhioQgUCnu1PyCTIAF8NDRGpdPRBWcYzacdcq+C8osBrbWiFH0ibl+tJE2zsuIF8aJqKJa11NTBExQOOpXY6lQEDg6LqYlrF6qGtqSAxTmVvCzGqw/6EUjGJjk4C2Ips69U5BHw5NsjqwXDZpL9ED77cBeJr2HPh2re7ayrVimyMrZCvK5zpl8thWcAXnX6p5O5giFd6CKLmFaEC6bnwYiTj7NBkFzlWQDZMdvbeQMoQw8yN0IvSNFr0R6vU9n7qyUNepNxdddw76adf4s8A3gaUkVai5/Yyp3Kp05ariHqVErbT+eI7nRU6X+xG5/Or73xW+Hx+tRuhHbWJe3V9feoUWwlrFVsiskbYUDK6K6anZ1zNms4RttWs8uwyteurM680hMZaYHfl9SMn3ZD+4jCkP7/6znoH68+vDkP7lLxtG62tUkt0d9PaQY2jJUwzHIZqt/16u/sOO1Kwa+SpBv++izu1XVxb23acEZAfGlQXWO44ID+RyldZ282/p5pmbQkln/vSNdelDD4HDi2hhEdkED5bBXc8hOem2O1iCFRY86RZJ0JvybW39Hp3y8q1bKzac7U5QwSHK01IzcJaxerD0K0m1lnWNtB72ucDbdYEHCx+d6AHc6DDHWVCkkDX5LRWq/UaodtSqAYobnyqLVfS0GCAtx27nrvisovBCif463SUXpH8NDpXnUR711wPw0lqXRz5mUnGkOB0ROfFTSNwXtQqUr+7fmGLPt2kUGINYv/SjnEm+84PYDcLTOPs8Od41eoxu2Neq2LVHYnOa316gdYtOYA2HwLyIPorLNdT8w2QEpYlQIsrN7V8aJwIngHV7zWaOnc3hlrl7XNsyfXE/UoxwixnMBk3yao4nFbBu5M1eVfkTjCtwdVJ0xbXKu8gDkiup+SC6oNNd2irxPNGr2tnNdAp3U81XrHXaxwsbLmfftxSp2Br+NIqdTgzk3cmfFEzkS4H0Sp4lOO4n2bocn/A2AVkz6BIeIquwtKgoYF0XSEyzxYLIqTnrowhik7VNeQqk6ihscdHtMrcxXtqe/gW/IQlq85Tw2FsY2l0pJGzNU2nUe/I9iSp4fGF2bDUD8SSGWbc/2h2bwL2aCuvGGebBNLn8ghUr3XhONXgNcfaT6EIjal481TPwOdvP35uJyimUlUu8UzShUTncpWQ5MJ3cU9/8mCVfmTy4K6Zp/ACf3HdTUHO24+fc3VHaKW5PrI+H2CC0A3vu49cFRcNcTwzVM2m5RrL28Z5LmlRfKadYX6Vc8lPGN/XnjG4F7rkeppsFSuy3ry1iqzyOY43yr41T0qZx11URl6r2MaIzH9zCFMncJvtTPkdqpejEdaRYHiRfloaw015RQz21EBE9v8AqWx3xa1CR7GT4iWZLXAWq9G8jE3OBt+KXVBug00XVCpBl0siIJkapV0bYBr6QHv4Fxezb0DvBP+Liy2Koyfv4LeemH/CnZYpXPqWl3bbzQAcqgzqIvVtlIqftciEXbJIf07f2aVrzyNariruwS8wK2eUHY1W3aC2EqhuUNyOKnvrny6Pt2/oj9CDZ+okivCstEjbVZWuW0KP7fpap0V79AaeQWAmU6yv+VxlSwKMyItLxHhbX6BWbzluzhBSzqDlybBWGIkWBn/BOZFevgbpC90wGV3v8mOPkb2XMfJAQ7j8jkwtdNbOP8QMKop0jWwYY5qQaLCm+i5yGcR8PVa5wZZXmr5KM5bZ/LBqQDZmTBBVcsu5FXwqY5HLUuVrNOcZi7DYNG4A0hdcuPL8h2fD+FnR5eoUBNnZnfiye4uSplaZpeoyBCrYeSAnSR9pka8hIRGJ6oy1St2JyQR/PQWRwIA2pTqRWCI8h1lScbRsj2iKTGh/JkOnubVKHUoe58mUyLOblQUvrWJhjrbVwZQzCXdvxCTqJG2wZXGezO5pHB+NobbBCSCKC6Bub9+Z75RirfrXXmiYVMXi/7N3db9t40j83X8F0Ze9j0Spvb3uXt6SON01LpsETXrAPbm0xLhEZFIgJbfZv/4w/JBkifqWnSzgQ3FYxPbMb4bUaDicj+zD7H97LwlrmPB7LFrsXLTYoNFj3WL3usUGlR5LFx2li+b4mv6snT6O1YuqerFhwx0LGPdUwNig92MN475qGBsUfyxj7F3G2GiHrWZW4TPlwypxLkPuP6PF3bEAZ18FOO7ulbWy6D3+VoJhOkEty8VRuHTY94kIuPWJucqAVjcQYG5WalMFENerZI5q8kA7qYny3krqqYDF2R30CRfmlB0RAXYcLCBTNqG/4Kq1EMnmAJnYEwTKIx5Sv41RGGoR3HrILIR9OinPmoeKE4TXa0HWOB2blumhVUfrysP40QwNMUOz7sYHbhUPbHuyYI/6ib7YHGwegMpo1iGDCGTdj/9gxN8Fjckr6x4wxISNJcz4+ld097QAAZU+Fq+9/Q2KMQUafxkM5T0txF/BAwJDcJI+L+AAuReukmx/xYy1nO1FzoQleVGrlr+S7rBtcWwXuLg7Bt7HC7wv7o5B9xGD7ou7Y8C9S8B9cXcMtvcKtu/FCh4D6iqgvhebeAya66D54u4YMB8/YF5rR1Ot0EDuIzJmx4CZ/RHRQOYCY+2Tb46BrgMHukyzmFohup+Lhx1w6vK7xnpS/zDFxcyB2ZYG72JHF2oOFqJPlUTt+C3EuJnIPinKZrh4Mtls8E4HuZjGITlH96bI4qH8BedDWiOvIZHGrG3RWyapSBiDuLoZ6Q6j7rI1QtBWjWAJTPGk/sm04rnGH1YuWcNSFU/fGWwFl8oS3gyHEWwkJNnGtgrrgoUGIRkdCBDthEKGhET7UIkl3A1NzKFwbHwwmm4nLH/yzYqOv0KabCckAcHjqwSIVqFAi/gnibYEOlCwkD7DrDqVDE5jPRIQyvuwQKskBgcGDqF66gcOkaRxYoJeNEYb/GIqudyiJQzG57LRpcsEy83sgF5oMKEY+TDZC4YhqsKFWFCyBZdTQF2SQeSGaybITtq6SQ14rSU2PoadT6tK1szfqlbIeW1YHlWq6+e+Giqe4eARBv5W8BUElqSw7aqdLNcQ6v1Ptn28ck22TRWTgW+Fl0gop6HyGwleEzU8N9cPjxeXN4uH36/nveZ/yxe27DNAe2w5Hv53u3y4vn3sLYQg/vYtCPH5+uq/vYR4okwN95++thSfFrdqsv90kBiztyLGrJcYMBefvLYIMBP/uj98tQ5vQga1n3oJAqGbJfafX1uMm4uHx+XF1X/6CUFlTNiri7B4eLy+7SUAPA1lB//gEsBeWtz+1kkEdZfs9XnFNV1wt5DuEpgjYK7KHJO19iB3RM07mnDzi31wHkMSrJ3eBbK5axEhQpbCX+Cq7ugsHehu/DYnSXDJdD9RLE3AbUVwfKKjadoL/ODNwL0NcX29fVn30BuLbknwavq3AFqtQatsukYt72jUSWwcLSfBIb3oL/PaR9SbFOEJTIMhYabC77sHjeD/Jy51uKOnDdq4hRU2hyqdhOo5uYJ9SuR4fFXrHxq/nALhVghCsiXheACgZyKshaa7C8DJX74wfwmwORsPxZXpPg7EkSZ+gqjG8vliMUdYCPwC51FBAqifZzFyooMuLrZn3qTlw9KALfeUmEZNmkkNf9fzOlZAUzHPLRLcmEgqla2rw6QaZ4yEKacSRTYwPGrY64Lq8flrus381fMlvX9MWk+i2ZlA02tXQ6MrwCjwdwVQezfSiVIl/o+7czIlaeL5TfONh4HqvYWm72cfTuFFbCHUwYPnkwT7wsdZHqIKKcL9PcQQX5jfgNYilUQUbJf73ZS+cVYkxpNWoPNXIrpCxXKTB3hpgaVmuddUWdCMT8hxsFS7bQg3oGJioi14DmZn34UdWCar4VLKZHXaniN8cSkp8908gzKYEkO46Zcx3kSWIZyEjdD+N8zWxEOLHSjw4jPvHjhHmPukEx3xhYZZsURJBHTdTy/5Qfylz4NBenpY/Hb1+80cAR1dsJRD+JNEG0yZ9RidKBJGY92ecPia5dcL6Jan2JS5bgkLoCOeIJLEQ7gHRHWh64IiHalfoO22TSWuxtpYA4Sl5D5VFUPqgJFbiAIVlzHK44IR+apzinvgXaVpLyGEqWmKENodsFbJ2ZRq1neUb8/fNA5KIbgpWuYq6ctTLeYGcc3cMkWxw8JkWKpP79WbowzEHE7tPqtsS1W1IfKI6KGbyypuVeNxcqgi6J0aHw6X4deEjCfxUGhOtlU9GfN862TpxtU03MvxnBQZ6gjBpMihuENrXKh8bCZ18PSrzXM5Z63DAQEVpPpQikNaCtbA3MH0wfGqfr+ha12lcY5ikWRPlBPEE97Q8KUnAkA6hDk0rwo9WjQVQPYclf5MfuBNBKlD03/PvPfezJtCcGr2/v30/P388tfzi8vr+fmv//r54/l58dqqZnnh3w3gQIt7hIMA0ilNU08fM7SCChi0uN9+AGaL++3H9EspmRrZIi5ip3SOLZ7KN5v1gQ+ssg3pxCTIhsfkDSj8swIyssaNdAdRuRGgvc4hycGJyu3ApcB++Xg6m05Pp9NfTn/+6LHvnvnE8/nG64b5/vEzdLXkInC+9IVdEw8tYnDf+Qp6xJMAbSlGgmyJcFxCLu5RyPlzErVTA4nDYAlN6peckT766C0+nJvI0xNYXJVOGp3q8GHA1Sngb+TxZv536xkbXcCi6YmCnBG04aVoFArxioQe+sSFhQhHHIKA2j+n4Fagd0+ceyssvDUPMVt7XKy9d6Dfd/k/FIXRXjvkzQCNgMREbKjKYbTkkc+hp6U61mCGyGZFAqhG9HmUJuBA4k2RsPrBtziOzs/OomQVUl8mT0/0h8KRfrluEUEtSyIEFx1WsGFzXgM5s4QrK6butJmuidqBZrshM5km05sTsTnceRENnFir33HVv+z0irNkfL7ZYNYXhCMI0w/FJggpI+Mt26ckDJGRDe2QrsVBfpCemoC4QBI7eql20gcMmPU6bwn3r7ozrgypNbCGCo9lh61gmWrvtToV+0F9jhyfD83E5k8qhSH1n83lJBgQE44c5EHjUmdSN+IWG/lC7WPG4P3AS4EFF4g8kPpjOWUxWRPXvIAGUBaY0mE1ugwHjDcijoTwEbGkLJTzIycuGLEfjbkucALrvzYNI+yrFdJ09m6hsD92J2Hmj5I24HOCVhg+5ix3Y2b6+kKCNzTYV0X4JqAGHeSRpH8SD11xIYiMVNlVbCoxVPorBLTOwGKeyRd5xkh8RqPth7PYj2DUUylNw+bWeqhSieUnrX5VW+qneXXrVjgPkIvoGy6ehNuudEu08O8Ccq31lD4choYtZHP4kV3aav3WSlBlQ8YWwNqTZr23syt7wAfQ6uxMEV59CvTIALM7wBzbTtqszYfcG9oCQrARywzJErluOHZxw23Nm4CdAmmDuiap/KCgLY62mCtyyA+OGXC0wVyXMn5Q0CmQLqiL8Z9XQz1rg7omEfmgoC2ONpir03YPCtnAcCG2SJMgmrR1dBowgYPzZb6DYtLOuXmD7uuX+au6r0nwFt3XL/Mx3NdDO39VqGv+w0LVWRuTIr6iGmsQfdUkvu6MIbXNxdjabhX9LRNL8AYFCoIESHPmbWTbqwH7+NifFj6mLEripf3ShoYhdacPNKwMhHnvHqyslO2Q8iZFQSAOJBt13yNR7Iav1yQ4pQyedoEkkZJyVgwg1+mYBuOFFUErWasKA8bJVRIcj8f3guWvRkK+piwos6jpijFQ5vllIk0Wp4o5ttGA4xJ2IAr4ueWc3w1O9u5ckQEILiy71qkpFoq+tSkQ1EhWnIcEs65I4GeIsoDCkCW2RtjwqNeIwxUauCKme8lu+lYtBp+PvStyq6ENdODgYvmHBAdEtLW1LbgLzmN0384m6DVadrxybQAB2yF/LWjupNMJ20VAE4QQQgihyf8HAFFyXl4="
 }
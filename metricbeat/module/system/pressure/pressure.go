@@ -0,0 +1,91 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build linux
+
+package pressure
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/libbeat/metric/system/pressure"
+	"github.com/elastic/beats/v7/metricbeat/mb"
+	"github.com/elastic/beats/v7/metricbeat/mb/parse"
+	"github.com/elastic/beats/v7/metricbeat/module/system"
+)
+
+// resources are the resources tracked by the Pressure Stall Information.
+var resources = []string{"cpu", "memory", "io"}
+
+// init registers the MetricSet with the central registry as soon as the program
+// starts. The New function will be called later to instantiate an instance of
+// the MetricSet for each host defined in the module's configuration. After the
+// MetricSet has been created then Fetch will begin to be called periodically.
+func init() {
+	mb.Registry.MustAddMetricSet("system", "pressure", New,
+		mb.WithHostParser(parse.EmptyHostParser),
+	)
+}
+
+// MetricSet reads the host-wide Pressure Stall Information from
+// /proc/pressure.
+type MetricSet struct {
+	mb.BaseMetricSet
+	pressurePath string
+}
+
+// New creates a new instance of the MetricSet.
+func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	cfgwarn.Beta("The system pressure metricset is beta.")
+
+	systemModule, ok := base.Module().(*system.Module)
+	if !ok {
+		return nil, errors.New("unexpected module type")
+	}
+
+	return &MetricSet{
+		BaseMetricSet: base,
+		pressurePath:  filepath.Join(systemModule.HostFS, "/proc/pressure"),
+	}, nil
+}
+
+// Fetch reads the pressure of each resource and reports it in a single event.
+func (m *MetricSet) Fetch(report mb.ReporterV2) error {
+	event := common.MapStr{}
+	for _, resource := range resources {
+		p, err := pressure.ReadFile(filepath.Join(m.pressurePath, resource))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return errors.Wrapf(err, "pressure stall information not available, "+
+					"it requires a kernel 4.20 or later with PSI enabled")
+			}
+			return errors.Wrapf(err, "error getting %s pressure", resource)
+		}
+		event[resource] = pressure.GetPressureEvent(p)
+	}
+
+	report.Event(mb.Event{
+		MetricSetFields: event,
+	})
+
+	return nil
+}
@@ -0,0 +1,85 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+// +build linux
+
+package pressure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	mbtest "github.com/elastic/beats/v7/metricbeat/mb/testing"
+	"github.com/elastic/beats/v7/metricbeat/module/system"
+)
+
+func TestData(t *testing.T) {
+	testdata := "./_meta/testdata"
+	system.HostFS = &testdata
+	f := mbtest.NewReportingMetricSetV2Error(t, getConfig())
+	err := mbtest.WriteEventsReporterV2Error(f, t, ".")
+	if err != nil {
+		t.Fatal("write", err)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	testdata := "./_meta/testdata"
+	system.HostFS = &testdata
+	f := mbtest.NewReportingMetricSetV2Error(t, getConfig())
+	events, errs := mbtest.ReportingFetchV2Error(f)
+
+	assert.Empty(t, errs)
+	if !assert.Len(t, events, 1) {
+		t.FailNow()
+	}
+
+	fields := events[0].MetricSetFields
+	for key, expected := range map[string]interface{}{
+		"cpu.some.10.pct":      0.0427,
+		"cpu.some.total.us":    uint64(1048235412),
+		"memory.full.300.pct":  0.0001,
+		"io.some.60.pct":       0.0091,
+		"io.full.total.us":     uint64(80129876),
+		"memory.some.total.us": uint64(23490871),
+	} {
+		value, err := fields.GetValue(key)
+		if assert.NoError(t, err, key) {
+			assert.Equal(t, expected, value, key)
+		}
+	}
+}
+
+func TestFetchNotAvailable(t *testing.T) {
+	testdata := "./_meta/testdata/missing"
+	system.HostFS = &testdata
+	f := mbtest.NewReportingMetricSetV2Error(t, getConfig())
+	_, errs := mbtest.ReportingFetchV2Error(f)
+
+	if assert.Len(t, errs, 1) {
+		assert.Contains(t, errs[0].Error(), "pressure stall information not available")
+	}
+}
+
+func getConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"module":     "system",
+		"metricsets": []string{"pressure"},
+	}
+}
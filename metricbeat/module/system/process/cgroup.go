@@ -21,6 +21,8 @@ import (
 	"strconv"
 
 	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/metric/system/cgroupv2"
+	"github.com/elastic/beats/v7/libbeat/metric/system/pressure"
 	"github.com/elastic/gosigar/cgroup"
 )
 
@@ -221,3 +223,124 @@ func cgroupBlockIOToMapStr(blockIO *cgroup.BlockIOSubsystem) common.MapStr {
 		},
 	}
 }
+
+// cgroupV2StatsToMap returns a MapStr containing the data from the cgroups v2
+// stats object. Metrics that are also available in cgroups v1 are reported in
+// the same fields. If stats is nil then nil is returned.
+func cgroupV2StatsToMap(stats *cgroupv2.Stats) common.MapStr {
+	if stats == nil {
+		return nil
+	}
+
+	cgroup := common.MapStr{
+		"id":   stats.ID,
+		"path": stats.Path,
+	}
+
+	metadata := func() common.MapStr {
+		return common.MapStr{
+			"id":   stats.ID,
+			"path": stats.Path,
+		}
+	}
+
+	if cpu := stats.CPU; cpu != nil {
+		cpuMap := metadata()
+		cpuMap["stats"] = common.MapStr{
+			"periods": cpu.Periods,
+			"throttled": common.MapStr{
+				"periods": cpu.ThrottledPeriods,
+				"ns":      cpu.ThrottledMicros * 1000,
+			},
+		}
+		if cpu.PeriodMicros > 0 {
+			cfs := common.MapStr{
+				"period": common.MapStr{
+					"us": cpu.PeriodMicros,
+				},
+			}
+			if cpu.QuotaMicros != nil {
+				cfs.Put("quota.us", *cpu.QuotaMicros)
+			}
+			cpuMap["cfs"] = cfs
+		}
+		if cpu.Pressure != nil {
+			cpuMap["pressure"] = pressure.GetPressureEvent(cpu.Pressure)
+		}
+		cgroup["cpu"] = cpuMap
+
+		cpuacct := metadata()
+		cpuacct["total"] = common.MapStr{
+			"ns": cpu.UsageMicros * 1000,
+		}
+		cpuacct["stats"] = common.MapStr{
+			"system": common.MapStr{
+				"ns": cpu.SystemMicros * 1000,
+			},
+			"user": common.MapStr{
+				"ns": cpu.UserMicros * 1000,
+			},
+		}
+		cgroup["cpuacct"] = cpuacct
+	}
+
+	if memory := stats.Memory; memory != nil {
+		mem := common.MapStr{
+			"usage": common.MapStr{
+				"bytes": memory.UsageBytes,
+			},
+		}
+		if memory.LimitBytes != nil {
+			mem.Put("limit.bytes", *memory.LimitBytes)
+		}
+
+		memMap := metadata()
+		memMap["mem"] = mem
+		memMap["events"] = common.MapStr{
+			"low":      memory.Events.Low,
+			"high":     memory.Events.High,
+			"max":      memory.Events.Max,
+			"oom":      memory.Events.OOM,
+			"oom_kill": memory.Events.OOMKill,
+		}
+		if memory.Pressure != nil {
+			memMap["pressure"] = pressure.GetPressureEvent(memory.Pressure)
+		}
+		cgroup["memory"] = memMap
+	}
+
+	if io := stats.IO; io != nil {
+		ioMap := metadata()
+		ioMap["read"] = common.MapStr{
+			"bytes": io.ReadBytes,
+			"ios":   io.ReadIOs,
+		}
+		ioMap["write"] = common.MapStr{
+			"bytes": io.WriteBytes,
+			"ios":   io.WriteIOs,
+		}
+		ioMap["discard"] = common.MapStr{
+			"bytes": io.DiscardBytes,
+			"ios":   io.DiscardIOs,
+		}
+		ioMap["total"] = common.MapStr{
+			"bytes": io.ReadBytes + io.WriteBytes + io.DiscardBytes,
+			"ios":   io.ReadIOs + io.WriteIOs + io.DiscardIOs,
+		}
+		if io.Pressure != nil {
+			ioMap["pressure"] = pressure.GetPressureEvent(io.Pressure)
+		}
+		cgroup["io"] = ioMap
+	}
+
+	if pids := stats.Pids; pids != nil {
+		pidsMap := metadata()
+		pidsMap["current"] = pids.Current
+		if pids.Limit != nil {
+			pidsMap["limit"] = *pids.Limit
+		}
+		cgroup["pids"] = pidsMap
+	}
+
+	return cgroup
+}
@@ -0,0 +1,97 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+
+package process
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/metric/system/cgroupv2"
+	"github.com/elastic/beats/v7/libbeat/metric/system/pressure"
+)
+
+func TestCgroupV2StatsToMap(t *testing.T) {
+	quota := uint64(150000)
+	limit := uint64(536870912)
+	stats := &cgroupv2.Stats{
+		ID:   "docker-2c5c0ae9e1a6.scope",
+		Path: "/system.slice/docker-2c5c0ae9e1a6.scope",
+		CPU: &cgroupv2.CPUStats{
+			UsageMicros:      300,
+			UserMicros:       200,
+			SystemMicros:     100,
+			Periods:          10,
+			ThrottledPeriods: 2,
+			ThrottledMicros:  50,
+			QuotaMicros:      &quota,
+			PeriodMicros:     100000,
+			Pressure: &pressure.Pressure{
+				Some: pressure.Stall{Avg10: 2.5, TotalMicros: 42},
+			},
+		},
+		Memory: &cgroupv2.MemoryStats{
+			UsageBytes: 1024,
+			LimitBytes: &limit,
+			Events:     cgroupv2.MemoryEvents{Max: 3, OOMKill: 1},
+		},
+		IO: &cgroupv2.IOStats{
+			ReadBytes:  10,
+			WriteBytes: 20,
+			ReadIOs:    1,
+			WriteIOs:   2,
+		},
+		Pids: &cgroupv2.PidsStats{Current: 12},
+	}
+
+	event := cgroupV2StatsToMap(stats)
+
+	assert.Equal(t, "/system.slice/docker-2c5c0ae9e1a6.scope", event["path"])
+	assertValue(t, event, "cpu.cfs.quota.us", uint64(150000))
+	assertValue(t, event, "cpu.cfs.period.us", uint64(100000))
+	assertValue(t, event, "cpu.stats.throttled.ns", uint64(50000))
+	assertValue(t, event, "cpu.pressure.some.10.pct", 0.025)
+	assertValue(t, event, "cpu.pressure.some.total.us", uint64(42))
+	assertValue(t, event, "cpuacct.total.ns", uint64(300000))
+	assertValue(t, event, "cpuacct.stats.user.ns", uint64(200000))
+	assertValue(t, event, "memory.mem.usage.bytes", uint64(1024))
+	assertValue(t, event, "memory.mem.limit.bytes", uint64(536870912))
+	assertValue(t, event, "memory.events.max", uint64(3))
+	assertValue(t, event, "memory.events.oom_kill", uint64(1))
+	assertValue(t, event, "io.total.bytes", uint64(30))
+	assertValue(t, event, "io.total.ios", uint64(3))
+	assertValue(t, event, "pids.current", uint64(12))
+
+	for _, key := range []string{"memory.pressure", "io.pressure", "pids.limit", "blkio"} {
+		_, err := event.GetValue(key)
+		assert.Error(t, err, key)
+	}
+
+	assert.Nil(t, cgroupV2StatsToMap(nil))
+}
+
+func assertValue(t *testing.T, event common.MapStr, key string, expected interface{}) {
+	t.Helper()
+	value, err := event.GetValue(key)
+	if assert.NoError(t, err, key) {
+		assert.Equal(t, expected, value, key)
+	}
+}
@@ -31,6 +31,7 @@ type Config struct {
 	IncludeTop      process.IncludeTopConfig `config:"process.include_top_n"`
 	IncludeCPUTicks bool                     `config:"process.include_cpu_ticks"`
 	IncludePerCPU   bool                     `config:"process.include_per_cpu"`
+	Network         bool                     `config:"process.network.enabled"`
 	CPUTicks        *bool                    `config:"cpu_ticks"` // Deprecated
 }
 
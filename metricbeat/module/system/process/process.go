@@ -21,13 +21,16 @@ package process
 
 import (
 	"fmt"
+	"path/filepath"
 	"runtime"
 
 	"github.com/pkg/errors"
 
 	"github.com/elastic/beats/v7/libbeat/common"
 	"github.com/elastic/beats/v7/libbeat/logp"
+	"github.com/elastic/beats/v7/libbeat/metric/system/cgroupv2"
 	"github.com/elastic/beats/v7/libbeat/metric/system/process"
+	sock "github.com/elastic/beats/v7/metricbeat/helper/socket"
 	"github.com/elastic/beats/v7/metricbeat/mb"
 	"github.com/elastic/beats/v7/metricbeat/mb/parse"
 	"github.com/elastic/beats/v7/metricbeat/module/system"
@@ -46,10 +49,12 @@ func init() {
 // MetricSet that fetches process metrics.
 type MetricSet struct {
 	mb.BaseMetricSet
-	stats   *process.Stats
-	cgroup  *cgroup.Reader
-	perCPU  bool
-	IsAgent bool
+	stats    *process.Stats
+	cgroup   *cgroup.Reader
+	cgroupV2 *cgroupv2.Reader
+	network  *sock.ProcessSocketsTable
+	perCPU   bool
+	IsAgent  bool
 }
 
 // New creates and returns a new MetricSet.
@@ -92,6 +97,25 @@ func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
 					return nil, errors.Wrap(err, "error initializing cgroup reader")
 				}
 			}
+
+			// Hosts using the unified hierarchy don't have v1 controllers,
+			// their cgroups are read by the cgroups v2 reader.
+			m.cgroupV2, err = cgroupv2.NewReader(systemModule.HostFS, true)
+			if err != nil {
+				if err == cgroupv2.ErrCgroupsV2Missing {
+					debugf("cgroups v2 data collection will be disabled: %v", err)
+				} else {
+					return nil, errors.Wrap(err, "error initializing cgroups v2 reader")
+				}
+			}
+		}
+	}
+
+	if config.Network {
+		debugf("process network data collection is enabled, using hostfs='%v'", systemModule.HostFS)
+		m.network, err = sock.NewProcessSocketsTable(filepath.Join(systemModule.HostFS, "/proc"), true)
+		if err != nil {
+			return nil, errors.Wrap(err, "error initializing process network data collection")
 		}
 	}
 
@@ -106,25 +130,40 @@ func (m *MetricSet) Fetch(r mb.ReporterV2) error {
 		return errors.Wrap(err, "process stats")
 	}
 
-	if m.cgroup != nil {
+	if m.cgroup != nil || m.cgroupV2 != nil {
 		for _, proc := range procs {
 			pid, ok := proc["pid"].(int)
 			if !ok {
 				debugf("error converting pid to int for proc %+v", proc)
 				continue
 			}
-			stats, err := m.cgroup.GetStatsForProcess(pid)
-			if err != nil {
-				debugf("error getting cgroups stats for pid=%d, %v", pid, err)
-				continue
-			}
 
-			if statsMap := cgroupStatsToMap(stats, m.perCPU); statsMap != nil {
+			if statsMap := m.cgroupStats(pid); statsMap != nil {
 				proc["cgroup"] = statsMap
 			}
 		}
 	}
 
+	if m.network != nil {
+		// TCP byte counters are not reported if they cannot be refreshed,
+		// but sockets are still counted.
+		if err := m.network.Refresh(); err != nil {
+			debugf("error refreshing process network data, %v", err)
+		}
+		for _, proc := range procs {
+			pid, ok := proc["pid"].(int)
+			if !ok {
+				continue
+			}
+			stats, err := m.network.ProcessNetwork(pid)
+			if err != nil {
+				debugf("error getting network stats for pid=%d, %v", pid, err)
+				continue
+			}
+			proc["network"] = stats.ToMapStr()
+		}
+	}
+
 	for _, proc := range procs {
 		rootFields := common.MapStr{
 			"process": common.MapStr{
@@ -183,6 +222,32 @@ func (m *MetricSet) Fetch(r mb.ReporterV2) error {
 	return nil
 }
 
+// cgroupStats returns the cgroup data of a process, read from the cgroups v1
+// controllers or, if the process has none, from its cgroup v2.
+func (m *MetricSet) cgroupStats(pid int) common.MapStr {
+	if m.cgroup != nil {
+		stats, err := m.cgroup.GetStatsForProcess(pid)
+		if err != nil {
+			debugf("error getting cgroups stats for pid=%d, %v", pid, err)
+			return nil
+		}
+		if stats != nil {
+			return cgroupStatsToMap(stats, m.perCPU)
+		}
+	}
+
+	if m.cgroupV2 != nil {
+		stats, err := m.cgroupV2.GetStatsForProcess(pid)
+		if err != nil {
+			debugf("error getting cgroups v2 stats for pid=%d, %v", pid, err)
+			return nil
+		}
+		return cgroupV2StatsToMap(stats)
+	}
+
+	return nil
+}
+
 func getAndRemove(from common.MapStr, field string) interface{} {
 	if v, ok := from[field]; ok {
 		delete(from, field)
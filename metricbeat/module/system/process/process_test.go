@@ -20,10 +20,12 @@
 package process
 
 import (
+	"runtime"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	mbtest "github.com/elastic/beats/v7/metricbeat/mb/testing"
 )
@@ -53,6 +55,26 @@ func TestData(t *testing.T) {
 	}
 }
 
+func TestFetchNetwork(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process network data is only available on Linux")
+	}
+
+	config := getConfig()
+	config["process.network.enabled"] = true
+	f := mbtest.NewReportingMetricSetV2Error(t, config)
+	events, errs := mbtest.ReportingFetchV2Error(f)
+
+	require.Empty(t, errs)
+	require.NotEmpty(t, events)
+
+	for _, event := range events {
+		total, err := event.MetricSetFields.GetValue("network.tcp.sockets.total")
+		require.NoError(t, err)
+		assert.IsType(t, 0, total)
+	}
+}
+
 func getConfig() map[string]interface{} {
 	return map[string]interface{}{
 		"module":     "system",
@@ -20,6 +20,7 @@
 package process_summary
 
 import (
+	"path/filepath"
 	"runtime"
 
 	"github.com/pkg/errors"
@@ -27,8 +28,10 @@ import (
 	"github.com/elastic/beats/v7/libbeat/common"
 	"github.com/elastic/beats/v7/libbeat/logp"
 	"github.com/elastic/beats/v7/libbeat/metric/system/process"
+	sock "github.com/elastic/beats/v7/metricbeat/helper/socket"
 	"github.com/elastic/beats/v7/metricbeat/mb"
 	"github.com/elastic/beats/v7/metricbeat/mb/parse"
+	"github.com/elastic/beats/v7/metricbeat/module/system"
 	sigar "github.com/elastic/gosigar"
 )
 
@@ -47,15 +50,38 @@ func init() {
 // multiple fetch calls.
 type MetricSet struct {
 	mb.BaseMetricSet
+	network *sock.ProcessSocketsTable
 }
 
 // New create a new instance of the MetricSet
 // Part of new is also setting up the configuration by processing additional
 // configuration entries if needed.
 func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
-	return &MetricSet{
+	config := struct {
+		Network bool `config:"process.network.enabled"`
+	}{}
+	if err := base.Module().UnpackConfig(&config); err != nil {
+		return nil, err
+	}
+
+	m := &MetricSet{
 		BaseMetricSet: base,
-	}, nil
+	}
+
+	if config.Network {
+		systemModule, ok := base.Module().(*system.Module)
+		if !ok {
+			return nil, errors.New("unexpected module type")
+		}
+
+		var err error
+		m.network, err = sock.NewProcessSocketsTable(filepath.Join(systemModule.HostFS, "/proc"), true)
+		if err != nil {
+			return nil, errors.Wrap(err, "error initializing process network data collection")
+		}
+	}
+
+	return m, nil
 }
 
 // Fetch methods implements the data gathering and data conversion to the right format
@@ -127,6 +153,10 @@ func (m *MetricSet) Fetch(r mb.ReporterV2) error {
 		}
 	}
 
+	if m.network != nil {
+		event["network"] = m.networkSummary(pids).ToMapStr()
+	}
+
 	r.Event(mb.Event{
 		// change the name space to use . instead of _
 		Namespace:       "system.process.summary",
@@ -135,3 +165,21 @@ func (m *MetricSet) Fetch(r mb.ReporterV2) error {
 
 	return nil
 }
+
+// networkSummary aggregates the network stats of the given processes.
+func (m *MetricSet) networkSummary(pids []int) *sock.NetworkStats {
+	if err := m.network.Refresh(); err != nil {
+		m.Logger().Debugf("Error refreshing process network data: %v", err)
+	}
+
+	summary := &sock.NetworkStats{}
+	for _, pid := range pids {
+		stats, err := m.network.ProcessNetwork(pid)
+		if err != nil {
+			// The process may have exited, or it belongs to other user.
+			continue
+		}
+		summary.Add(stats)
+	}
+	return summary
+}
@@ -76,6 +76,25 @@ func TestFetch(t *testing.T) {
 	}
 }
 
+func TestFetchNetwork(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process network data is only available on Linux")
+	}
+
+	config := getConfig()
+	config["process.network.enabled"] = true
+	f := mbtest.NewReportingMetricSetV2Error(t, config)
+	events, errs := mbtest.ReportingFetchV2Error(f)
+
+	require.Empty(t, errs)
+	require.NotEmpty(t, events)
+
+	network, err := events[0].MetricSetFields.GetValue("network")
+	require.NoError(t, err)
+	assert.Contains(t, network, "tcp")
+	assert.Contains(t, network, "udp")
+}
+
 func getConfig() map[string]interface{} {
 	return map[string]interface{}{
 		"module":     "system",
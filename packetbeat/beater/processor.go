@@ -18,6 +18,7 @@
 package beater
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -36,21 +37,30 @@ import (
 	"github.com/elastic/beats/v7/packetbeat/sniffer"
 )
 
+// interfaceTrackerTimeout is the time a connection is remembered after its
+// last packet when tagging transactions with the capture interface. It must
+// outlive the transaction timeouts of the protocol analyzers.
+const interfaceTrackerTimeout = 2 * time.Minute
+
 type processor struct {
 	wg              sync.WaitGroup
 	publisher       *publish.TransactionPublisher
 	flows           *flows.Flows
-	sniffer         *sniffer.Sniffer
+	interfaces      *publish.InterfaceTracker
+	sniffers        []*sniffer.Sniffer
 	shutdownTimeout time.Duration
 	err             chan error
+	done            chan struct{}
 }
 
-func newProcessor(shutdownTimeout time.Duration, publisher *publish.TransactionPublisher, flows *flows.Flows, sniffer *sniffer.Sniffer, err chan error) *processor {
+func newProcessor(shutdownTimeout time.Duration, publisher *publish.TransactionPublisher, flows *flows.Flows, interfaces *publish.InterfaceTracker, sniffers []*sniffer.Sniffer, err chan error) *processor {
 	return &processor{
 		publisher:       publisher,
 		flows:           flows,
-		sniffer:         sniffer,
+		interfaces:      interfaces,
+		sniffers:        sniffers,
 		err:             err,
+		done:            make(chan struct{}),
 		shutdownTimeout: shutdownTimeout,
 	}
 }
@@ -63,24 +73,39 @@ func (p *processor) Start() {
 	if p.flows != nil {
 		p.flows.Start()
 	}
-	p.wg.Add(1)
-	go func() {
-		defer p.wg.Done()
-
-		err := p.sniffer.Run()
-		if err != nil {
-			p.err <- fmt.Errorf("sniffer loop failed: %v", err)
-			return
-		}
-		p.err <- nil
-	}()
+	if p.interfaces != nil {
+		p.interfaces.Start()
+	}
+	for _, s := range p.sniffers {
+		p.wg.Add(1)
+		go func(s *sniffer.Sniffer) {
+			defer p.wg.Done()
+
+			err := s.Run()
+			if err != nil {
+				err = fmt.Errorf("sniffer loop failed: %v", err)
+			}
+			// The error channel is shared by all the processors, don't block
+			// on it once the processor is stopped and nobody may be reading.
+			select {
+			case p.err <- err:
+			case <-p.done:
+			}
+		}(s)
+	}
 }
 
 func (p *processor) Stop() {
-	p.sniffer.Stop()
+	close(p.done)
+	for _, s := range p.sniffers {
+		s.Stop()
+	}
 	if p.flows != nil {
 		p.flows.Stop()
 	}
+	if p.interfaces != nil {
+		p.interfaces.Stop()
+	}
 	p.wg.Wait()
 	// wait for shutdownTimeout to let the publisher flush
 	// whatever pending events
@@ -113,12 +138,27 @@ func (p *processorFactory) Create(pipeline beat.PipelineConnector, cfg *common.C
 		return nil, err
 	}
 
+	if len(config.Interfaces) == 0 {
+		return nil, errors.New("no interfaces configured")
+	}
+	if len(config.Interfaces) > maxSniffers {
+		return nil, fmt.Errorf("too many interfaces configured, at most %d are supported", maxSniffers)
+	}
+
+	// Tag transactions with the interface they have been captured on only if
+	// there is more than one interface to tell apart.
+	var interfaces *publish.InterfaceTracker
+	if len(config.Interfaces) > 1 {
+		interfaces = publish.NewInterfaceTracker(interfaceTrackerTimeout)
+	}
+
 	publisher, err := publish.NewTransactionPublisher(
 		p.beat.Info.Name,
 		p.beat.Publisher,
 		config.IgnoreOutgoing,
-		config.Interfaces.File == "",
-		config.Interfaces.InternalNetworks,
+		!config.ReadsFile(),
+		config.InternalNetworks(),
+		interfaces,
 	)
 	if err != nil {
 		return nil, err
@@ -126,7 +166,7 @@ func (p *processorFactory) Create(pipeline beat.PipelineConnector, cfg *common.C
 
 	watcher := procs.ProcessesWatcher{}
 	// Enable the process watcher only if capturing live traffic
-	if config.Interfaces.File == "" {
+	if !config.ReadsFile() {
 		err = watcher.Init(config.Procs)
 		if err != nil {
 			logp.Critical(err.Error())
@@ -146,12 +186,17 @@ func (p *processorFactory) Create(pipeline beat.PipelineConnector, cfg *common.C
 	if err != nil {
 		return nil, err
 	}
-	sniffer, err := setupSniffer(config, protocols, workerFactory(publisher, protocols, watcher, flows, config))
-	if err != nil {
-		return nil, err
+
+	var sniffers []*sniffer.Sniffer
+	for _, iface := range config.Interfaces {
+		sniffer, err := setupSniffer(config, iface, protocols, workerFactory(publisher, protocols, watcher, flows, interfaces, iface.Device, config))
+		if err != nil {
+			return nil, err
+		}
+		sniffers = append(sniffers, sniffer)
 	}
 
-	return newProcessor(config.ShutdownTimeout, publisher, flows, sniffer, p.err), nil
+	return newProcessor(config.ShutdownTimeout, publisher, flows, interfaces, sniffers, p.err), nil
 }
 
 func (p *processorFactory) CheckConfig(config *common.Config) error {
@@ -28,18 +28,18 @@ import (
 	"github.com/elastic/beats/v7/packetbeat/sniffer"
 )
 
-func setupSniffer(cfg config.Config, protocols *protos.ProtocolsStruct, workerFactory sniffer.WorkerFactory) (*sniffer.Sniffer, error) {
+func setupSniffer(cfg config.Config, iface config.InterfacesConfig, protocols *protos.ProtocolsStruct, workerFactory sniffer.WorkerFactory) (*sniffer.Sniffer, error) {
 	icmp, err := cfg.ICMP()
 	if err != nil {
 		return nil, err
 	}
 
-	filter := cfg.Interfaces.BpfFilter
+	filter := iface.BpfFilter
 	if filter == "" && !cfg.Flows.IsEnabled() {
-		filter = protocols.BpfFilter(cfg.Interfaces.WithVlans, icmp.Enabled())
+		filter = protocols.BpfFilter(iface.WithVlans, icmp.Enabled())
 	}
 
-	return sniffer.New(false, filter, workerFactory, cfg.Interfaces)
+	return sniffer.New(false, filter, workerFactory, iface)
 }
 
 func setupFlows(pipeline beat.Pipeline, watcher procs.ProcessesWatcher, cfg config.Config) (*flows.Flows, error) {
@@ -32,7 +32,7 @@ import (
 	"github.com/elastic/beats/v7/packetbeat/sniffer"
 )
 
-func workerFactory(publisher *publish.TransactionPublisher, protocols *protos.ProtocolsStruct, watcher procs.ProcessesWatcher, flows *flows.Flows, cfg config.Config) func(dl layers.LinkType) (sniffer.Worker, error) {
+func workerFactory(publisher *publish.TransactionPublisher, protocols *protos.ProtocolsStruct, watcher procs.ProcessesWatcher, flows *flows.Flows, interfaces *publish.InterfaceTracker, device string, cfg config.Config) func(dl layers.LinkType) (sniffer.Worker, error) {
 	return func(dl layers.LinkType) (sniffer.Worker, error) {
 		var icmp4 icmp.ICMPv4Processor
 		var icmp6 icmp.ICMPv6Processor
@@ -69,6 +69,12 @@ func workerFactory(publisher *publish.TransactionPublisher, protocols *protos.Pr
 		if err != nil {
 			return nil, err
 		}
+		if interfaces != nil {
+			worker.SetDevice(device, interfaces)
+		}
+		if cfg.IPDefrag.IsEnabled() {
+			worker.EnableDefrag(cfg.IPDefrag.Timeout, int(cfg.IPDefrag.MaxBytes))
+		}
 
 		return worker, nil
 	}
@@ -120,10 +120,10 @@ func NewAgentConfig(cfg *common.Config) (Config, error) {
 	logp.Debug("agent", "Normalizing agent configuration")
 	var input agentInput
 	config := Config{
-		Interfaces: InterfacesConfig{
+		Interfaces: []InterfacesConfig{{
 			// TODO: make this configurable rather than just using the default device
 			Device: defaultDevice(),
-		},
+		}},
 	}
 	if err := cfg.Unpack(&input); err != nil {
 		return config, err
@@ -136,7 +136,8 @@ func NewAgentConfig(cfg *common.Config) (Config, error) {
 			if err != nil {
 				return config, err
 			}
-			if err := cfg.Unpack(&config.Interfaces); err != nil {
+			config.Interfaces, err = UnpackInterfaces(cfg, config.Interfaces[0])
+			if err != nil {
 				return config, err
 			}
 		}
@@ -19,16 +19,19 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/common/cfgtype"
 	"github.com/elastic/beats/v7/libbeat/processors"
 	"github.com/elastic/beats/v7/packetbeat/procs"
 )
 
 type Config struct {
-	Interfaces      InterfacesConfig          `config:"interfaces"`
+	Interfaces      []InterfacesConfig        `config:",ignore"`
 	Flows           *Flows                    `config:"flows"`
+	IPDefrag        IPDefrag                  `config:"ip_defrag"`
 	Protocols       map[string]*common.Config `config:"protocols"`
 	ProtocolsList   []*common.Config          `config:"protocols"`
 	Procs           procs.ProcsConfig         `config:"procs"`
@@ -36,13 +39,98 @@ type Config struct {
 	ShutdownTimeout time.Duration             `config:"shutdown_timeout"`
 }
 
-// FromStatic initializes a configuration given a common.Config
+// FromStatic initializes a configuration given a common.Config. Interface
+// settings already present in c are used as defaults for every configured
+// interface.
 func (c Config) FromStatic(cfg *common.Config) (Config, error) {
 	err := cfg.Unpack(&c)
 	if err != nil {
 		return c, err
 	}
-	return c, nil
+
+	if cfg.HasField("interfaces") {
+		raw, err := cfg.Child("interfaces", -1)
+		if err != nil {
+			return c, err
+		}
+
+		var defaults InterfacesConfig
+		if len(c.Interfaces) > 0 {
+			defaults = c.Interfaces[0]
+		}
+		c.Interfaces, err = UnpackInterfaces(raw, defaults)
+		if err != nil {
+			return c, err
+		}
+	}
+
+	if len(c.Interfaces) > 1 && c.Interfaces[0].File != "" {
+		// all interfaces share the same input file, read it only once.
+		c.Interfaces = c.Interfaces[:1]
+	}
+	return c, c.validateInterfaces()
+}
+
+// UnpackInterfaces reads a single interface or a list of interfaces from
+// cfg. Every entry starts out with the settings from defaults.
+func UnpackInterfaces(cfg *common.Config, defaults InterfacesConfig) ([]InterfacesConfig, error) {
+	var children []*common.Config
+	if cfg.IsArray() {
+		if err := cfg.Unpack(&children); err != nil {
+			return nil, err
+		}
+	} else {
+		children = []*common.Config{cfg}
+	}
+
+	interfaces := make([]InterfacesConfig, len(children))
+	for i, child := range children {
+		interfaces[i] = defaults
+		if err := child.Unpack(&interfaces[i]); err != nil {
+			return nil, err
+		}
+	}
+	return interfaces, nil
+}
+
+func (c Config) validateInterfaces() error {
+	if len(c.Interfaces) < 2 {
+		return nil
+	}
+
+	devices := map[string]bool{}
+	for _, iface := range c.Interfaces {
+		if iface.Dumpfile != "" {
+			return errors.New("dumping packets to a file is not supported with multiple interfaces")
+		}
+		if devices[iface.Device] {
+			return fmt.Errorf("interface '%s' is configured more than once", iface.Device)
+		}
+		devices[iface.Device] = true
+	}
+	return nil
+}
+
+// InternalNetworks returns the internal networks configured for all
+// interfaces, without duplicates.
+func (c Config) InternalNetworks() []string {
+	var networks []string
+	seen := map[string]bool{}
+	for _, iface := range c.Interfaces {
+		for _, network := range iface.InternalNetworks {
+			if !seen[network] {
+				seen[network] = true
+				networks = append(networks, network)
+			}
+		}
+	}
+	return networks
+}
+
+// ReadsFile returns true if packets are read from a pcap file instead of
+// being captured live.
+func (c Config) ReadsFile() bool {
+	return len(c.Interfaces) > 0 && c.Interfaces[0].File != ""
 }
 
 // ICMP returns the ICMP configuration
@@ -99,6 +187,26 @@ type Flows struct {
 	KeepNull      bool                    `config:"keep_null"`
 	// Index is used to overwrite the index where flows are published
 	Index string `config:"index"`
+	// Export configures sending flow records to NetFlow v9 or IPFIX collectors
+	Export FlowsExport `config:"export"`
+}
+
+// FlowsExport configures the export of flow records to NetFlow v9 or IPFIX
+// collectors over UDP.
+type FlowsExport struct {
+	Enabled             *bool            `config:"enabled"`
+	Protocol            string           `config:"protocol"`
+	Hosts               []string         `config:"hosts"`
+	ObservationDomainID uint32           `config:"observation_domain_id"`
+	TemplateRefresh     time.Duration    `config:"template_refresh"`
+	MaxPacketSize       cfgtype.ByteSize `config:"max_packet_size"`
+}
+
+// IPDefrag configures the reassembly of fragmented IPv4 and IPv6 datagrams.
+type IPDefrag struct {
+	Enabled  *bool            `config:"enabled"`
+	Timeout  time.Duration    `config:"timeout"`
+	MaxBytes cfgtype.ByteSize `config:"max_bytes"`
 }
 
 type ProtocolCommon struct {
@@ -111,3 +219,15 @@ type ProtocolCommon struct {
 func (f *Flows) IsEnabled() bool {
 	return f != nil && (f.Enabled == nil || *f.Enabled)
 }
+
+// IsEnabled returns true if collectors are configured and the export has not
+// been disabled explicitly.
+func (e FlowsExport) IsEnabled() bool {
+	return len(e.Hosts) > 0 && (e.Enabled == nil || *e.Enabled)
+}
+
+// IsEnabled returns true unless the reassembly of IP fragments has been
+// disabled explicitly.
+func (d IPDefrag) IsEnabled() bool {
+	return d.Enabled == nil || *d.Enabled
+}
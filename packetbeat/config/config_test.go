@@ -0,0 +1,90 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+)
+
+func TestInterfacesConfig(t *testing.T) {
+	defaults := Config{Interfaces: []InterfacesConfig{{Loop: 1}}}
+
+	t.Run("single interface", func(t *testing.T) {
+		cfg := common.MustNewConfigFrom(`
+interfaces:
+  device: eth0
+  snaplen: 1514
+`)
+		config, err := defaults.FromStatic(cfg)
+		require.NoError(t, err)
+		assert.Equal(t, []InterfacesConfig{{Device: "eth0", Snaplen: 1514, Loop: 1}}, config.Interfaces)
+	})
+
+	t.Run("interface list", func(t *testing.T) {
+		cfg := common.MustNewConfigFrom(`
+interfaces:
+  - device: eth0
+    internal_networks: [private]
+  - device: eth1
+    bpf_filter: port 53
+    internal_networks: [private, loopback]
+`)
+		config, err := defaults.FromStatic(cfg)
+		require.NoError(t, err)
+		require.Len(t, config.Interfaces, 2)
+		assert.Equal(t, "eth0", config.Interfaces[0].Device)
+		assert.Equal(t, "eth1", config.Interfaces[1].Device)
+		assert.Equal(t, "port 53", config.Interfaces[1].BpfFilter)
+		assert.Equal(t, 1, config.Interfaces[1].Loop)
+		assert.Equal(t, []string{"private", "loopback"}, config.InternalNetworks())
+	})
+
+	t.Run("no interfaces", func(t *testing.T) {
+		config, err := defaults.FromStatic(common.NewConfig())
+		require.NoError(t, err)
+		assert.Equal(t, defaults.Interfaces, config.Interfaces)
+	})
+
+	t.Run("duplicate device", func(t *testing.T) {
+		cfg := common.MustNewConfigFrom(`
+interfaces:
+  - device: eth0
+  - device: eth0
+`)
+		_, err := defaults.FromStatic(cfg)
+		assert.Error(t, err)
+	})
+
+	t.Run("file input", func(t *testing.T) {
+		cfg := common.MustNewConfigFrom(`
+interfaces:
+  - device: eth0
+  - device: eth1
+`)
+		fileDefaults := Config{Interfaces: []InterfacesConfig{{File: "test.pcap"}}}
+		config, err := fileDefaults.FromStatic(cfg)
+		require.NoError(t, err)
+		require.Len(t, config.Interfaces, 1)
+		assert.True(t, config.ReadsFile())
+	})
+}
@@ -19,7 +19,9 @@ package decoder
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/elastic/beats/v7/libbeat/common"
 	"github.com/elastic/beats/v7/libbeat/logp"
 	"github.com/elastic/beats/v7/packetbeat/flows"
 	"github.com/elastic/beats/v7/packetbeat/protos"
@@ -66,6 +68,17 @@ type Decoder struct {
 	// hold current flow ID
 	flowID              *flows.FlowID // buffer flowID among many calls
 	flowIDBufferBacking [flows.SizeFlowIDMax]byte
+
+	device  string
+	devices DeviceTracker
+
+	defrag *defragmenter
+}
+
+// DeviceTracker records the network interface the packets of a connection
+// are captured on.
+type DeviceTracker interface {
+	Track(tuple *common.IPPortTuple, device string)
 }
 
 const (
@@ -144,6 +157,37 @@ func New(
 	return &d, nil
 }
 
+// SetDevice sets the name of the network interface the decoder receives
+// packets from. Flows are tagged with the device. If tracker is not nil, the
+// device is recorded for every connection passed to the protocol analyzers.
+func (d *Decoder) SetDevice(name string, tracker DeviceTracker) {
+	d.device = name
+	d.devices = tracker
+	if d.flowID != nil {
+		d.flowID.SetDevice(name)
+	}
+}
+
+// EnableDefrag enables the reassembly of fragmented IPv4 and IPv6 datagrams.
+// Incomplete datagrams are dropped after timeout, or if more than maxBytes
+// are buffered. Zero values select the defaults. The fragments of dropped
+// datagrams are accounted to the flow of their IP addresses.
+func (d *Decoder) EnableDefrag(timeout time.Duration, maxBytes int) {
+	d.defrag = newDefragmenter(timeout, maxBytes)
+	if d.flowID != nil {
+		d.defrag.onDrop = d.onDroppedDatagram
+	}
+}
+
+func (d *Decoder) onDroppedDatagram(dg *datagram) {
+	if dg.flowID == nil {
+		return
+	}
+	flow := d.flows.Get(dg.flowID)
+	d.statPackets.Add(flow, uint64(dg.packets))
+	d.statBytes.Add(flow, uint64(dg.length))
+}
+
 func (d *Decoder) SetTruncated() {
 	d.truncated = true
 }
@@ -169,6 +213,7 @@ func (d *Decoder) OnPacket(data []byte, ci *gopacket.CaptureInfo) {
 	currentType := d.linkLayerType
 
 	packet := protos.Packet{Ts: ci.Timestamp}
+	packets, bytes := uint64(1), uint64(ci.Length)
 
 	debugf("decode packet data")
 	processed := false
@@ -191,13 +236,23 @@ func (d *Decoder) OnPacket(data []byte, ci *gopacket.CaptureInfo) {
 		nextType := current.NextLayerType()
 		data = current.LayerPayload()
 
-		processed, err = d.process(&packet, currentType)
-		if err != nil {
-			logp.Info("Error processing packet: %v", err)
-			break
-		}
-		if processed {
-			break
+		if d.defrag != nil && isFragment(currentType, nextType) {
+			dg := d.defragment(&packet, currentType, data, ci)
+			if dg == nil {
+				// wait for the remaining fragments
+				return
+			}
+			data, nextType = dg.assemble(), dg.next
+			packets, bytes = uint64(dg.packets), uint64(dg.length)
+		} else {
+			processed, err = d.process(&packet, currentType)
+			if err != nil {
+				logp.Info("Error processing packet: %v", err)
+				break
+			}
+			if processed {
+				break
+			}
 		}
 
 		// choose next decoding layer
@@ -218,9 +273,40 @@ func (d *Decoder) OnPacket(data []byte, ci *gopacket.CaptureInfo) {
 
 	if d.flowID != nil && d.flowID.Flags() != 0 {
 		flow := d.flows.Get(d.flowID)
-		d.statPackets.Add(flow, 1)
-		d.statBytes.Add(flow, uint64(ci.Length))
+		d.statPackets.Add(flow, packets)
+		d.statBytes.Add(flow, bytes)
+	}
+}
+
+func isFragment(layerType, nextType gopacket.LayerType) bool {
+	return (layerType == layers.LayerTypeIPv4 && nextType == gopacket.LayerTypeFragment) ||
+		(layerType == layers.LayerTypeIPv6 && nextType == layers.LayerTypeIPv6Fragment)
+}
+
+// defragment processes the IP layer of a fragment and passes the fragment to
+// the defragmenter. The reassembled datagram is returned once all fragments
+// have been seen.
+func (d *Decoder) defragment(
+	packet *protos.Packet,
+	layerType gopacket.LayerType,
+	payload []byte,
+	ci *gopacket.CaptureInfo,
+) *datagram {
+	ip4, ip6 := &d.ip4[d.stIP4.i], &d.ip6[d.stIP6.i]
+
+	// Processing the IP layer adds the addresses to the flow ID. The ports
+	// are only known after reassembly, so the fragments of a datagram that
+	// is dropped are accounted to the flow of the IP addresses.
+	d.process(packet, layerType)
+	var flowID *flows.FlowID
+	if d.flowID != nil && d.flowID.Flags() != 0 {
+		flowID = d.flowID
+	}
+
+	if layerType == layers.LayerTypeIPv4 {
+		return d.defrag.ipv4(ci, ip4, payload, flowID)
 	}
+	return d.defrag.ipv6(ci, ip6, payload, flowID)
 }
 
 func (d *Decoder) process(
@@ -301,6 +387,7 @@ func (d *Decoder) onICMPv4(packet *protos.Packet) {
 	if d.icmp4Proc != nil {
 		packet.Payload = d.icmp4.Payload
 		packet.Tuple.ComputeHashables()
+		d.trackDevice(&packet.Tuple)
 		d.icmp4Proc.ProcessICMPv4(d.flowID, &d.icmp4, packet)
 	}
 }
@@ -314,6 +401,7 @@ func (d *Decoder) onICMPv6(packet *protos.Packet) {
 	if d.icmp6Proc != nil {
 		packet.Payload = d.icmp6.Payload
 		packet.Tuple.ComputeHashables()
+		d.trackDevice(&packet.Tuple)
 		d.icmp6Proc.ProcessICMPv6(d.flowID, &d.icmp6, packet)
 	}
 }
@@ -332,6 +420,7 @@ func (d *Decoder) onUDP(packet *protos.Packet) {
 	packet.Payload = d.udp.Payload
 	packet.Tuple.ComputeHashables()
 
+	d.trackDevice(&packet.Tuple)
 	d.udpProc.Process(id, packet)
 }
 
@@ -354,5 +443,12 @@ func (d *Decoder) onTCP(packet *protos.Packet) {
 		return
 	}
 	packet.Tuple.ComputeHashables()
+	d.trackDevice(&packet.Tuple)
 	d.tcpProc.Process(id, &d.tcp, packet)
 }
+
+func (d *Decoder) trackDevice(tuple *common.IPPortTuple) {
+	if d.devices != nil {
+		d.devices.Track(tuple, d.device)
+	}
+}
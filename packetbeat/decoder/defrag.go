@@ -0,0 +1,312 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decoder
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/tsg/gopacket"
+	"github.com/tsg/gopacket/layers"
+
+	"github.com/elastic/beats/v7/libbeat/monitoring"
+	"github.com/elastic/beats/v7/packetbeat/flows"
+)
+
+const (
+	// DefaultDefragTimeout is the time fragments of an incomplete datagram
+	// are kept, counting from the first fragment seen.
+	DefaultDefragTimeout = 30 * time.Second
+
+	// DefaultDefragMaxBytes is the maximum number of bytes kept in
+	// fragments waiting for reassembly.
+	DefaultDefragMaxBytes = 4 * 1024 * 1024
+
+	// maximum size of a reassembled IP payload.
+	maxDatagramSize = 65535
+
+	// maximum number of fragments a single datagram can be split into.
+	maxFragmentsPerDatagram = 64
+
+	ipv6FragmentHeaderLen = 8
+)
+
+var (
+	fragmentsSeen        = monitoring.NewInt(nil, "ip_defrag.fragments")
+	datagramsReassembled = monitoring.NewInt(nil, "ip_defrag.reassembled")
+	datagramsExpired     = monitoring.NewInt(nil, "ip_defrag.expired")
+	datagramsDropped     = monitoring.NewInt(nil, "ip_defrag.dropped")
+)
+
+var (
+	errFragmentOverlap   = errors.New("overlapping fragment")
+	errFragmentTooLarge  = errors.New("fragment exceeds maximum datagram size")
+	errTooManyFragments  = errors.New("too many fragments")
+	errFragmentTruncated = errors.New("truncated fragment header")
+)
+
+// fragmentKey identifies the fragments belonging to the same datagram, as
+// defined in RFC 791 (IPv4) and RFC 8200 (IPv6).
+type fragmentKey struct {
+	src, dst [16]byte
+	id       uint32
+	proto    layers.IPProtocol // IPv4 only, IPv6 fragments of a datagram may differ
+	ipv6     bool
+}
+
+type fragment struct {
+	offset int
+	data   []byte
+}
+
+// datagram collects the fragments of a single IP datagram.
+type datagram struct {
+	key       fragmentKey
+	ts        time.Time // timestamp of the first fragment seen
+	fragments []fragment
+	size      int // number of payload bytes buffered
+	total     int // length of the datagram payload, -1 if the last fragment is missing
+	next      gopacket.LayerType
+	packets   int // number of fragments seen on the wire
+	length    int // number of bytes of all fragments on the wire
+
+	// flow ID the fragments are accounted to if the datagram is dropped
+	// before it is reassembled, nil if flows are disabled
+	flowID *flows.FlowID
+
+	prev, nxt *datagram
+}
+
+// defragmenter reassembles fragmented IP datagrams. Incomplete datagrams are
+// dropped once they exceed the timeout or if the memory limit is reached,
+// dropping the oldest datagrams first. Time is taken from the packet
+// timestamps, so reading a pcap file behaves the same as live capturing.
+type defragmenter struct {
+	timeout  time.Duration
+	maxBytes int
+
+	bytes     int
+	datagrams map[fragmentKey]*datagram
+
+	// datagrams ordered by timestamp of the first fragment
+	head, tail *datagram
+
+	// onDrop is called for datagrams dropped before being reassembled.
+	onDrop func(dg *datagram)
+}
+
+func newDefragmenter(timeout time.Duration, maxBytes int) *defragmenter {
+	if timeout <= 0 {
+		timeout = DefaultDefragTimeout
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultDefragMaxBytes
+	}
+	return &defragmenter{
+		timeout:   timeout,
+		maxBytes:  maxBytes,
+		datagrams: map[fragmentKey]*datagram{},
+	}
+}
+
+// ipv4 processes an IPv4 fragment. If the fragment completes its datagram,
+// the datagram is returned.
+func (d *defragmenter) ipv4(ci *gopacket.CaptureInfo, ip4 *layers.IPv4, payload []byte, flowID *flows.FlowID) *datagram {
+	key := fragmentKey{id: uint32(ip4.Id), proto: ip4.Protocol}
+	copy(key.src[:], ip4.SrcIP)
+	copy(key.dst[:], ip4.DstIP)
+
+	more := ip4.Flags&layers.IPv4MoreFragments != 0
+	return d.add(ci, key, int(ip4.FragOffset)*8, more, ip4.Protocol.LayerType(), payload, flowID)
+}
+
+// ipv6 processes an IPv6 packet whose payload starts with a fragment
+// header. If the fragment completes its datagram, the datagram is returned.
+func (d *defragmenter) ipv6(ci *gopacket.CaptureInfo, ip6 *layers.IPv6, payload []byte, flowID *flows.FlowID) *datagram {
+	if len(payload) < ipv6FragmentHeaderLen {
+		debugf("Dropping IPv6 fragment: %v", errFragmentTruncated)
+		d.dropped(&datagram{packets: 1, length: ci.Length, flowID: flowID})
+		return nil
+	}
+
+	key := fragmentKey{id: binary.BigEndian.Uint32(payload[4:8]), ipv6: true}
+	copy(key.src[:], ip6.SrcIP)
+	copy(key.dst[:], ip6.DstIP)
+
+	next := layers.IPProtocol(payload[0]).LayerType()
+	offsetFlags := binary.BigEndian.Uint16(payload[2:4])
+	offset := int(offsetFlags>>3) * 8
+	more := offsetFlags&0x1 != 0
+	return d.add(ci, key, offset, more, next, payload[ipv6FragmentHeaderLen:], flowID)
+}
+
+func (d *defragmenter) add(
+	ci *gopacket.CaptureInfo,
+	key fragmentKey,
+	offset int,
+	more bool,
+	next gopacket.LayerType,
+	payload []byte,
+	flowID *flows.FlowID,
+) *datagram {
+	fragmentsSeen.Inc()
+	d.expire(ci.Timestamp)
+
+	dg := d.datagrams[key]
+	if dg == nil {
+		dg = &datagram{key: key, ts: ci.Timestamp, total: -1}
+		if flowID != nil {
+			dg.flowID = flowID.Clone()
+		}
+		d.datagrams[key] = dg
+		d.push(dg)
+	}
+
+	dg.packets++
+	dg.length += ci.Length
+	if err := dg.add(offset, more, payload); err != nil {
+		debugf("Dropping fragmented datagram: %v", err)
+		datagramsDropped.Inc()
+		d.remove(dg)
+		d.dropped(dg)
+		return nil
+	}
+	if offset == 0 {
+		dg.next = next
+	}
+	d.bytes += len(payload)
+
+	if dg.complete() {
+		d.remove(dg)
+		datagramsReassembled.Inc()
+		return dg
+	}
+
+	// enforce the memory limit by dropping the oldest datagrams
+	for d.bytes > d.maxBytes && d.head != nil {
+		datagramsDropped.Inc()
+		oldest := d.head
+		d.remove(oldest)
+		d.dropped(oldest)
+	}
+	return nil
+}
+
+// expire drops all datagrams whose first fragment has been seen more than
+// timeout before ts.
+func (d *defragmenter) expire(ts time.Time) {
+	for d.head != nil && ts.Sub(d.head.ts) > d.timeout {
+		debugf("Expiring incomplete fragmented datagram")
+		datagramsExpired.Inc()
+		oldest := d.head
+		d.remove(oldest)
+		d.dropped(oldest)
+	}
+}
+
+// dropped passes a datagram that will never be reassembled to the onDrop
+// callback, so its fragments can still be accounted for.
+func (d *defragmenter) dropped(dg *datagram) {
+	if d.onDrop != nil {
+		d.onDrop(dg)
+	}
+}
+
+func (d *defragmenter) push(dg *datagram) {
+	dg.prev = d.tail
+	if d.tail == nil {
+		d.head = dg
+	} else {
+		d.tail.nxt = dg
+	}
+	d.tail = dg
+}
+
+func (d *defragmenter) remove(dg *datagram) {
+	if dg.prev == nil {
+		d.head = dg.nxt
+	} else {
+		dg.prev.nxt = dg.nxt
+	}
+	if dg.nxt == nil {
+		d.tail = dg.prev
+	} else {
+		dg.nxt.prev = dg.prev
+	}
+	dg.prev, dg.nxt = nil, nil
+
+	d.bytes -= dg.size
+	delete(d.datagrams, dg.key)
+}
+
+// add stores a copy of the fragment payload. Overlapping fragments invalidate
+// the datagram, as required by RFC 5722 for IPv6.
+func (dg *datagram) add(offset int, more bool, payload []byte) error {
+	end := offset + len(payload)
+	if end > maxDatagramSize {
+		return errFragmentTooLarge
+	}
+	if len(dg.fragments) >= maxFragmentsPerDatagram {
+		return errTooManyFragments
+	}
+	if !more {
+		if dg.total >= 0 && dg.total != end {
+			return errFragmentOverlap
+		}
+		dg.total = end
+	}
+	if dg.total >= 0 && end > dg.total {
+		return errFragmentOverlap
+	}
+
+	i := sort.Search(len(dg.fragments), func(i int) bool {
+		return dg.fragments[i].offset >= offset
+	})
+	if i > 0 {
+		prev := dg.fragments[i-1]
+		if prev.offset+len(prev.data) > offset {
+			return errFragmentOverlap
+		}
+	}
+	if i < len(dg.fragments) && dg.fragments[i].offset < end {
+		return errFragmentOverlap
+	}
+
+	dg.fragments = append(dg.fragments, fragment{})
+	copy(dg.fragments[i+1:], dg.fragments[i:])
+	dg.fragments[i] = fragment{offset: offset, data: append([]byte(nil), payload...)}
+	dg.size += len(payload)
+	return nil
+}
+
+// complete returns true if all fragments of the datagram have been seen.
+// Fragments never overlap, so the datagram is complete once the buffered
+// bytes add up to the datagram length.
+func (dg *datagram) complete() bool {
+	return dg.total >= 0 && dg.size == dg.total
+}
+
+func (dg *datagram) assemble() []byte {
+	buf := make([]byte, 0, dg.total)
+	for _, f := range dg.fragments {
+		buf = append(buf, f.data...)
+	}
+	return buf
+}
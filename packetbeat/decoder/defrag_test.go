@@ -0,0 +1,241 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+
+package decoder
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsg/gopacket"
+	"github.com/tsg/gopacket/layers"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/packetbeat/config"
+	"github.com/elastic/beats/v7/packetbeat/flows"
+	"github.com/elastic/beats/v7/packetbeat/procs"
+)
+
+// readPcap reads all packets from a little-endian, microsecond resolution
+// pcap file.
+func readPcap(t *testing.T, name string) ([][]byte, []gopacket.CaptureInfo) {
+	content, err := ioutil.ReadFile(filepath.Join("..", "tests", "system", "pcaps", name))
+	require.NoError(t, err)
+	require.True(t, len(content) >= 24, "pcap file header missing")
+	require.Equal(t, uint32(0xa1b2c3d4), binary.LittleEndian.Uint32(content))
+
+	var (
+		packets [][]byte
+		infos   []gopacket.CaptureInfo
+	)
+	for rest := content[24:]; len(rest) > 0; {
+		require.True(t, len(rest) >= 16, "truncated pcap record header")
+		sec := binary.LittleEndian.Uint32(rest[0:])
+		usec := binary.LittleEndian.Uint32(rest[4:])
+		capLen := int(binary.LittleEndian.Uint32(rest[8:]))
+		origLen := int(binary.LittleEndian.Uint32(rest[12:]))
+		rest = rest[16:]
+		require.True(t, len(rest) >= capLen, "truncated pcap record")
+
+		packets = append(packets, rest[:capLen])
+		infos = append(infos, gopacket.CaptureInfo{
+			Timestamp:     time.Unix(int64(sec), int64(usec)*int64(time.Microsecond)),
+			CaptureLength: capLen,
+			Length:        origLen,
+		})
+		rest = rest[capLen:]
+	}
+	return packets, infos
+}
+
+func TestDecodeFragmentedPcap(t *testing.T) {
+	for _, test := range []struct {
+		file     string
+		clientIP string
+		serverIP string
+	}{
+		{"dns_udp_ipv4_fragmented.pcap", "192.168.1.10", "192.168.1.1"},
+		{"dns_udp_ipv6_fragmented.pcap", "2001:db8::10", "2001:db8::1"},
+	} {
+		t.Run(test.file, func(t *testing.T) {
+			reassembled := datagramsReassembled.Get()
+
+			d, _, udp := newTestDecoder(t)
+			d.EnableDefrag(0, 0)
+
+			packets, infos := readPcap(t, test.file)
+			require.True(t, len(packets) > 2)
+
+			// query is not fragmented
+			d.OnPacket(packets[0], &infos[0])
+			require.NotNil(t, udp.pkt)
+			assert.Equal(t, uint16(53), udp.pkt.Tuple.DstPort)
+			udp.pkt = nil
+
+			// no payload is passed on before the last fragment is seen
+			for i := 1; i < len(packets)-1; i++ {
+				d.OnPacket(packets[i], &infos[i])
+				assert.Nil(t, udp.pkt)
+			}
+			last := len(packets) - 1
+			d.OnPacket(packets[last], &infos[last])
+			require.NotNil(t, udp.pkt)
+
+			assert.Equal(t, reassembled+1, datagramsReassembled.Get())
+			assert.Equal(t, infos[last].Timestamp, udp.pkt.Ts)
+			assert.Equal(t, test.serverIP, udp.pkt.Tuple.SrcIP.String())
+			assert.Equal(t, test.clientIP, udp.pkt.Tuple.DstIP.String())
+			assert.Equal(t, uint16(53), udp.pkt.Tuple.SrcPort)
+
+			var dns layers.DNS
+			require.NoError(t, dns.DecodeFromBytes(udp.pkt.Payload, gopacket.NilDecodeFeedback))
+			assert.True(t, dns.QR)
+			assert.Len(t, dns.Answers, 14)
+			assert.Empty(t, d.defrag.datagrams)
+			assert.Zero(t, d.defrag.bytes)
+		})
+	}
+}
+
+func TestDecodeFragmentedWithoutDefrag(t *testing.T) {
+	d, _, udp := newTestDecoder(t)
+
+	packets, infos := readPcap(t, "dns_udp_ipv4_fragmented.pcap")
+	for i := 1; i < len(packets); i++ {
+		d.OnPacket(packets[i], &infos[i])
+	}
+	assert.Nil(t, udp.pkt)
+}
+
+func TestDecodeFragmentedExpiredFlowStats(t *testing.T) {
+	var events []beat.Event
+	f, err := flows.NewFlows(func(e []beat.Event) {
+		events = append(events, e...)
+	}, procs.ProcessesWatcher{}, &config.Flows{})
+	require.NoError(t, err)
+
+	d, err := New(f, layers.LinkTypeEthernet,
+		&TestIcmp4Processor{}, &TestIcmp6Processor{}, &TestTCPProcessor{}, &TestUDPProcessor{})
+	require.NoError(t, err)
+	d.EnableDefrag(time.Second, 0)
+
+	packets, infos := readPcap(t, "dns_udp_ipv4_fragmented.pcap")
+	last := len(packets) - 1
+
+	// the response is missing its last fragment
+	bytes := 0
+	for i := 1; i < last; i++ {
+		d.OnPacket(packets[i], &infos[i])
+		bytes += infos[i].Length
+	}
+
+	// the last fragment arrives too late and expires the incomplete datagram
+	ci := infos[last]
+	ci.Timestamp = infos[1].Timestamp.Add(2 * time.Second)
+	d.OnPacket(packets[last], &ci)
+
+	f.Start()
+	f.Stop()
+
+	require.Len(t, events, 1)
+	network, err := events[0].Fields.GetValue("network")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(last-1), network.(common.MapStr)["packets"])
+	assert.Equal(t, uint64(bytes), network.(common.MapStr)["bytes"])
+}
+
+func testIPv4Fragment(id uint16, offset int, more bool) *layers.IPv4 {
+	ip4 := &layers.IPv4{
+		SrcIP:      net.IPv4(10, 0, 0, 1).To4(),
+		DstIP:      net.IPv4(10, 0, 0, 2).To4(),
+		Id:         id,
+		Protocol:   layers.IPProtocolUDP,
+		FragOffset: uint16(offset / 8),
+	}
+	if more {
+		ip4.Flags = layers.IPv4MoreFragments
+	}
+	return ip4
+}
+
+func testCaptureInfo(ts time.Time, payload []byte) *gopacket.CaptureInfo {
+	return &gopacket.CaptureInfo{Timestamp: ts, Length: len(payload) + 34}
+}
+
+func TestDefragmenterOverlap(t *testing.T) {
+	dropped := datagramsDropped.Get()
+
+	ts := time.Now()
+	d := newDefragmenter(time.Second, 0)
+	payload := make([]byte, 16)
+
+	assert.Nil(t, d.ipv4(testCaptureInfo(ts, payload), testIPv4Fragment(1, 0, true), payload, nil))
+	assert.Nil(t, d.ipv4(testCaptureInfo(ts, payload), testIPv4Fragment(1, 8, false), payload, nil))
+	assert.Equal(t, dropped+1, datagramsDropped.Get())
+	assert.Empty(t, d.datagrams)
+	assert.Zero(t, d.bytes)
+}
+
+func TestDefragmenterExpire(t *testing.T) {
+	expired := datagramsExpired.Get()
+
+	ts := time.Now()
+	d := newDefragmenter(time.Second, 0)
+	payload := make([]byte, 16)
+
+	assert.Nil(t, d.ipv4(testCaptureInfo(ts, payload), testIPv4Fragment(1, 0, true), payload, nil))
+	assert.Len(t, d.datagrams, 1)
+
+	// second fragment arrives too late, the first one has expired already
+	ts = ts.Add(2 * time.Second)
+	assert.Nil(t, d.ipv4(testCaptureInfo(ts, payload), testIPv4Fragment(1, 16, false), payload, nil))
+	assert.Equal(t, expired+1, datagramsExpired.Get())
+	assert.Len(t, d.datagrams, 1)
+	assert.Equal(t, len(payload), d.bytes)
+}
+
+func TestDefragmenterMemoryLimit(t *testing.T) {
+	dropped := datagramsDropped.Get()
+
+	ts := time.Now()
+	d := newDefragmenter(time.Minute, 40)
+	payload := make([]byte, 16)
+
+	for id := uint16(1); id <= 3; id++ {
+		assert.Nil(t, d.ipv4(testCaptureInfo(ts, payload), testIPv4Fragment(id, 0, true), payload, nil))
+	}
+	assert.Equal(t, dropped+1, datagramsDropped.Get())
+	assert.Len(t, d.datagrams, 2)
+	assert.Equal(t, 32, d.bytes)
+
+	// oldest datagram has been dropped, the others can still be completed
+	assert.Nil(t, d.ipv4(testCaptureInfo(ts, payload), testIPv4Fragment(1, 16, false), payload, nil))
+	dg := d.ipv4(testCaptureInfo(ts, payload), testIPv4Fragment(3, 16, false), payload, nil)
+	require.NotNil(t, dg)
+	assert.Len(t, dg.assemble(), 32)
+	assert.Equal(t, layers.LayerTypeUDP, dg.next)
+	assert.Equal(t, 2*(len(payload)+34), dg.length)
+}
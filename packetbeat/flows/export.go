@@ -0,0 +1,570 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package flows
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/logp"
+	"github.com/elastic/beats/v7/packetbeat/config"
+)
+
+const (
+	versionNetflow9 = 9
+	versionIPFIX    = 10
+
+	defaultTemplateRefresh = time.Minute
+	defaultMaxPacketSize   = 1400
+	minPacketSize          = 512
+	maxPacketSize          = 65507
+)
+
+// Information element identifiers as registered by IANA for IPFIX. NetFlow v9
+// uses the same numbers for the field types exported here, except for the
+// flow timestamps.
+const (
+	ieOctetDeltaCount          = 1
+	iePacketDeltaCount         = 2
+	ieProtocolIdentifier       = 4
+	ieSourceTransportPort      = 7
+	ieSourceIPv4Address        = 8
+	ieDestinationTransportPort = 11
+	ieDestinationIPv4Address   = 12
+	ieLastSwitched             = 21
+	ieFirstSwitched            = 22
+	ieSourceIPv6Address        = 27
+	ieDestinationIPv6Address   = 28
+	ieICMPTypeCodeIPv4         = 32
+	ieSourceMacAddress         = 56
+	ieVlanID                   = 58
+	ieDestinationMacAddress    = 80
+	ieFlowEndReason            = 136
+	ieICMPTypeCodeIPv6         = 139
+	ieFlowStartMilliseconds    = 152
+	ieFlowEndMilliseconds      = 153
+)
+
+// flowEndReason values (RFC 7012).
+const (
+	endReasonIdleTimeout   = 1
+	endReasonActiveTimeout = 2
+	endReasonForcedEnd     = 4
+)
+
+const (
+	templateIDIPv4 = 256
+	templateIDIPv6 = 257
+)
+
+type templateField struct {
+	id, length uint16
+}
+
+type template struct {
+	id     uint16
+	fields []templateField
+	size   int // size of a data record
+}
+
+// flowRecord is one direction of a biflow, as exported to a collector.
+type flowRecord struct {
+	ipv6             bool
+	srcIP, dstIP     net.IP
+	srcPort, dstPort uint16
+	proto            uint8
+	icmpTypeCode     uint16
+	vlan             uint16
+	srcMAC, dstMAC   net.HardwareAddr
+	bytes, packets   uint64
+	start, end       time.Time
+	endReason        uint8
+}
+
+// flowCounts holds the counters already sent to the collectors for one
+// direction of a flow.
+type flowCounts struct {
+	bytes, packets uint64
+}
+
+// exporter sends flow records to NetFlow v9 or IPFIX collectors over UDP.
+// Periodic reports of long lived flows are exported as the delta to the
+// previous report, so collectors can sum up the records of a flow.
+type exporter struct {
+	encoder *recordEncoder
+	hosts   []string
+	conns   []net.Conn
+}
+
+func newExporter(cfg config.FlowsExport) (*exporter, error) {
+	var version uint16
+	switch cfg.Protocol {
+	case "", "ipfix":
+		version = versionIPFIX
+	case "netflow9", "netflow_v9":
+		version = versionNetflow9
+	default:
+		return nil, fmt.Errorf("unsupported flow export protocol '%v'", cfg.Protocol)
+	}
+
+	refresh := cfg.TemplateRefresh
+	if refresh <= 0 {
+		refresh = defaultTemplateRefresh
+	}
+
+	size := int(cfg.MaxPacketSize)
+	if size == 0 {
+		size = defaultMaxPacketSize
+	}
+	if size < minPacketSize || size > maxPacketSize {
+		return nil, fmt.Errorf("flow export max_packet_size must be between %v and %v", minPacketSize, maxPacketSize)
+	}
+
+	e := &exporter{
+		encoder: newRecordEncoder(version, cfg.ObservationDomainID, size, refresh, time.Now()),
+		hosts:   cfg.Hosts,
+	}
+	for _, host := range cfg.Hosts {
+		conn, err := net.Dial("udp", host)
+		if err != nil {
+			e.close()
+			return nil, fmt.Errorf("failed to connect to flow collector %v: %v", host, err)
+		}
+		e.conns = append(e.conns, conn)
+	}
+	return e, nil
+}
+
+// report adds the records for the traffic seen on f since the last report.
+func (e *exporter) report(
+	ts time.Time,
+	f *biFlow,
+	isOver bool,
+	intNames, uintNames, floatNames []string,
+) {
+	reason := uint8(endReasonActiveTimeout)
+	if !f.isAlive() {
+		reason = endReasonIdleTimeout
+	} else if isOver {
+		reason = endReasonForcedEnd
+	}
+
+	for _, rec := range exportRecords(f, ts, reason, intNames, uintNames, floatNames) {
+		e.encoder.add(ts, rec)
+	}
+}
+
+// flush sends all pending records to the collectors.
+func (e *exporter) flush() {
+	for _, msg := range e.encoder.finish() {
+		for i, conn := range e.conns {
+			if _, err := conn.Write(msg); err != nil {
+				logp.Err("failed to send flow records to %v: %v", e.hosts[i], err)
+			}
+		}
+	}
+}
+
+func (e *exporter) close() {
+	for _, conn := range e.conns {
+		conn.Close()
+	}
+	e.conns = nil
+}
+
+// exportRecords builds one record per direction that has seen new packets
+// since the flow was last exported. Flows without an IP layer are not
+// exported.
+func exportRecords(
+	f *biFlow,
+	ts time.Time,
+	reason uint8,
+	intNames, uintNames, floatNames []string,
+) []flowRecord {
+	var rec flowRecord
+
+	if src, dst, ok := f.id.IPv4Addr(); ok {
+		rec.srcIP, rec.dstIP = net.IP(src), net.IP(dst)
+	} else if src, dst, ok := f.id.OutterIPv4Addr(); ok {
+		rec.srcIP, rec.dstIP = net.IP(src), net.IP(dst)
+	} else if src, dst, ok := f.id.IPv6Addr(); ok {
+		rec.srcIP, rec.dstIP, rec.ipv6 = net.IP(src), net.IP(dst), true
+	} else if src, dst, ok := f.id.OutterIPv6Addr(); ok {
+		rec.srcIP, rec.dstIP, rec.ipv6 = net.IP(src), net.IP(dst), true
+	} else {
+		return nil
+	}
+
+	if src, dst, ok := f.id.EthAddr(); ok {
+		rec.srcMAC, rec.dstMAC = net.HardwareAddr(src), net.HardwareAddr(dst)
+	}
+	if vlan := f.id.VLan(); vlan != nil {
+		rec.vlan = binary.LittleEndian.Uint16(vlan)
+	} else if vlan := f.id.OutterVLan(); vlan != nil {
+		rec.vlan = binary.LittleEndian.Uint16(vlan)
+	}
+	if src, dst, ok := f.id.UDPAddr(); ok {
+		rec.srcPort = binary.LittleEndian.Uint16(src)
+		rec.dstPort = binary.LittleEndian.Uint16(dst)
+		rec.proto = 17
+	}
+	if src, dst, ok := f.id.TCPAddr(); ok {
+		rec.srcPort = binary.LittleEndian.Uint16(src)
+		rec.dstPort = binary.LittleEndian.Uint16(dst)
+		rec.proto = 6
+	}
+
+	start := f.exportTS
+	if start.IsZero() {
+		start = f.createTS
+	}
+
+	var records []flowRecord
+	for dir, stats := range f.stats {
+		if stats == nil {
+			continue
+		}
+
+		var cur flowCounts
+		r := rec
+		for k, v := range encodeStats(stats, intNames, uintNames, floatNames) {
+			n, _ := v.(uint64)
+			switch k {
+			case "bytes":
+				cur.bytes = n
+			case "packets":
+				cur.packets = n
+			case "icmpV4TypeCode":
+				if n > 0 {
+					r.proto, r.icmpTypeCode = 1, uint16(n)
+				}
+			case "icmpV6TypeCode":
+				if n > 0 {
+					r.proto, r.icmpTypeCode = 58, uint16(n)
+				}
+			}
+		}
+
+		prev := f.exported[dir]
+		if cur.packets <= prev.packets {
+			continue
+		}
+		f.exported[dir] = cur
+
+		if dir == 1 {
+			r.srcIP, r.dstIP = r.dstIP, r.srcIP
+			r.srcPort, r.dstPort = r.dstPort, r.srcPort
+			r.srcMAC, r.dstMAC = r.dstMAC, r.srcMAC
+		}
+		r.bytes = cur.bytes - prev.bytes
+		r.packets = cur.packets - prev.packets
+		r.start, r.end = start, f.ts
+		if r.end.Before(r.start) {
+			r.end = r.start
+		}
+		r.endReason = reason
+		records = append(records, r)
+	}
+	f.exportTS = ts
+
+	return records
+}
+
+// recordEncoder packs flow records into NetFlow v9 or IPFIX messages of at
+// most maxSize bytes. Templates are included with the first message and
+// again after every refresh interval.
+type recordEncoder struct {
+	version   uint16
+	domainID  uint32
+	maxSize   int
+	refresh   time.Duration
+	boot      time.Time
+	templates [2]template
+
+	sequence     uint32
+	lastTemplate time.Time
+
+	buf      []byte
+	msgTS    time.Time
+	setStart int // offset of the open set, -1 if none
+	setID    uint16
+	records  int // number of template and data records in buf
+	data     int // number of data records in buf
+
+	out [][]byte
+}
+
+func newRecordEncoder(
+	version uint16,
+	domainID uint32,
+	maxSize int,
+	refresh time.Duration,
+	boot time.Time,
+) *recordEncoder {
+	return &recordEncoder{
+		version:  version,
+		domainID: domainID,
+		maxSize:  maxSize,
+		refresh:  refresh,
+		boot:     boot,
+		templates: [2]template{
+			makeTemplate(version, templateIDIPv4, false),
+			makeTemplate(version, templateIDIPv6, true),
+		},
+		setStart: -1,
+	}
+}
+
+func makeTemplate(version, id uint16, ipv6 bool) template {
+	var fields []templateField
+	if ipv6 {
+		fields = append(fields,
+			templateField{ieSourceIPv6Address, 16},
+			templateField{ieDestinationIPv6Address, 16},
+		)
+	} else {
+		fields = append(fields,
+			templateField{ieSourceIPv4Address, 4},
+			templateField{ieDestinationIPv4Address, 4},
+		)
+	}
+	icmp := uint16(ieICMPTypeCodeIPv4)
+	if ipv6 {
+		icmp = ieICMPTypeCodeIPv6
+	}
+	fields = append(fields,
+		templateField{ieProtocolIdentifier, 1},
+		templateField{ieSourceTransportPort, 2},
+		templateField{ieDestinationTransportPort, 2},
+		templateField{icmp, 2},
+		templateField{ieVlanID, 2},
+		templateField{ieSourceMacAddress, 6},
+		templateField{ieDestinationMacAddress, 6},
+		templateField{ieOctetDeltaCount, 8},
+		templateField{iePacketDeltaCount, 8},
+	)
+	if version == versionNetflow9 {
+		// NetFlow v9 timestamps are relative to the exporter's uptime.
+		fields = append(fields,
+			templateField{ieFirstSwitched, 4},
+			templateField{ieLastSwitched, 4},
+		)
+	} else {
+		fields = append(fields,
+			templateField{ieFlowStartMilliseconds, 8},
+			templateField{ieFlowEndMilliseconds, 8},
+		)
+	}
+	fields = append(fields, templateField{ieFlowEndReason, 1})
+
+	t := template{id: id, fields: fields}
+	for _, f := range fields {
+		t.size += int(f.length)
+	}
+	return t
+}
+
+func (e *recordEncoder) headerSize() int {
+	if e.version == versionNetflow9 {
+		return 20
+	}
+	return 16
+}
+
+func (e *recordEncoder) add(ts time.Time, rec flowRecord) {
+	t := &e.templates[0]
+	if rec.ipv6 {
+		t = &e.templates[1]
+	}
+
+	switch {
+	case e.buf != nil && e.setID == t.id && len(e.buf)+t.size+3 <= e.maxSize:
+	case e.buf != nil && len(e.buf)+4+t.size+3 <= e.maxSize:
+		e.closeSet()
+		e.openSet(t.id)
+	default:
+		e.finishMessage()
+		e.startMessage(ts)
+		e.openSet(t.id)
+	}
+
+	e.buf = e.appendRecord(e.buf, t, &rec)
+	e.records++
+	e.data++
+}
+
+// finish completes the current message and returns all messages encoded
+// since the last call.
+func (e *recordEncoder) finish() [][]byte {
+	e.finishMessage()
+	out := e.out
+	e.out = nil
+	return out
+}
+
+func (e *recordEncoder) startMessage(ts time.Time) {
+	e.buf = make([]byte, e.headerSize(), e.maxSize)
+	e.msgTS = ts
+	e.records = 0
+	e.data = 0
+
+	if !e.lastTemplate.IsZero() && ts.Sub(e.lastTemplate) < e.refresh {
+		return
+	}
+	e.lastTemplate = ts
+
+	setID := uint16(2)
+	if e.version == versionNetflow9 {
+		setID = 0
+	}
+	e.openSet(setID)
+	for _, t := range e.templates {
+		e.buf = appendUint16(e.buf, t.id)
+		e.buf = appendUint16(e.buf, uint16(len(t.fields)))
+		for _, f := range t.fields {
+			e.buf = appendUint16(e.buf, f.id)
+			e.buf = appendUint16(e.buf, f.length)
+		}
+		e.records++
+	}
+	e.closeSet()
+}
+
+func (e *recordEncoder) openSet(id uint16) {
+	e.setStart = len(e.buf)
+	e.setID = id
+	e.buf = append(e.buf, 0, 0, 0, 0)
+	binary.BigEndian.PutUint16(e.buf[e.setStart:], id)
+}
+
+func (e *recordEncoder) closeSet() {
+	if e.setStart < 0 {
+		return
+	}
+	if e.version == versionNetflow9 {
+		// NetFlow v9 flowsets are padded to 32 bit boundaries.
+		for (len(e.buf)-e.setStart)%4 != 0 {
+			e.buf = append(e.buf, 0)
+		}
+	}
+	binary.BigEndian.PutUint16(e.buf[e.setStart+2:], uint16(len(e.buf)-e.setStart))
+	e.setStart = -1
+}
+
+func (e *recordEncoder) finishMessage() {
+	if e.buf == nil {
+		return
+	}
+	e.closeSet()
+
+	hdr := e.buf[:e.headerSize()]
+	binary.BigEndian.PutUint16(hdr[0:], e.version)
+	if e.version == versionNetflow9 {
+		binary.BigEndian.PutUint16(hdr[2:], uint16(e.records))
+		binary.BigEndian.PutUint32(hdr[4:], e.uptime(e.msgTS))
+		binary.BigEndian.PutUint32(hdr[8:], uint32(e.msgTS.Unix()))
+		binary.BigEndian.PutUint32(hdr[12:], e.sequence)
+		binary.BigEndian.PutUint32(hdr[16:], e.domainID)
+		e.sequence++
+	} else {
+		binary.BigEndian.PutUint16(hdr[2:], uint16(len(e.buf)))
+		binary.BigEndian.PutUint32(hdr[4:], uint32(e.msgTS.Unix()))
+		binary.BigEndian.PutUint32(hdr[8:], e.sequence)
+		binary.BigEndian.PutUint32(hdr[12:], e.domainID)
+		e.sequence += uint32(e.data)
+	}
+
+	e.out = append(e.out, e.buf)
+	e.buf = nil
+}
+
+func (e *recordEncoder) appendRecord(buf []byte, t *template, rec *flowRecord) []byte {
+	for _, f := range t.fields {
+		switch f.id {
+		case ieSourceIPv4Address:
+			buf = appendBytes(buf, rec.srcIP.To4(), 4)
+		case ieDestinationIPv4Address:
+			buf = appendBytes(buf, rec.dstIP.To4(), 4)
+		case ieSourceIPv6Address:
+			buf = appendBytes(buf, rec.srcIP.To16(), 16)
+		case ieDestinationIPv6Address:
+			buf = appendBytes(buf, rec.dstIP.To16(), 16)
+		case ieProtocolIdentifier:
+			buf = append(buf, rec.proto)
+		case ieSourceTransportPort:
+			buf = appendUint16(buf, rec.srcPort)
+		case ieDestinationTransportPort:
+			buf = appendUint16(buf, rec.dstPort)
+		case ieICMPTypeCodeIPv4, ieICMPTypeCodeIPv6:
+			buf = appendUint16(buf, rec.icmpTypeCode)
+		case ieVlanID:
+			buf = appendUint16(buf, rec.vlan)
+		case ieSourceMacAddress:
+			buf = appendBytes(buf, rec.srcMAC, 6)
+		case ieDestinationMacAddress:
+			buf = appendBytes(buf, rec.dstMAC, 6)
+		case ieOctetDeltaCount:
+			buf = appendUint64(buf, rec.bytes)
+		case iePacketDeltaCount:
+			buf = appendUint64(buf, rec.packets)
+		case ieFirstSwitched:
+			buf = appendUint32(buf, e.uptime(rec.start))
+		case ieLastSwitched:
+			buf = appendUint32(buf, e.uptime(rec.end))
+		case ieFlowStartMilliseconds:
+			buf = appendUint64(buf, uint64(rec.start.UnixNano()/int64(time.Millisecond)))
+		case ieFlowEndMilliseconds:
+			buf = appendUint64(buf, uint64(rec.end.UnixNano()/int64(time.Millisecond)))
+		case ieFlowEndReason:
+			buf = append(buf, rec.endReason)
+		}
+	}
+	return buf
+}
+
+// uptime returns the milliseconds passed between the start of the exporter
+// and ts, as used by NetFlow v9 timestamps.
+func (e *recordEncoder) uptime(ts time.Time) uint32 {
+	d := ts.Sub(e.boot)
+	if d < 0 {
+		return 0
+	}
+	return uint32(d / time.Millisecond)
+}
+
+func appendUint16(buf []byte, v uint16) []byte {
+	return append(buf, byte(v>>8), byte(v))
+}
+
+func appendUint32(buf []byte, v uint32) []byte {
+	return append(buf, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendUint64(buf []byte, v uint64) []byte {
+	return appendUint32(appendUint32(buf, uint32(v>>32)), uint32(v))
+}
+
+// appendBytes appends b, or n zero bytes if b doesn't have the expected size.
+func appendBytes(buf, b []byte, n int) []byte {
+	if len(b) != n {
+		return append(buf, make([]byte, n)...)
+	}
+	return append(buf, b...)
+}
@@ -0,0 +1,253 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package flows
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/logp"
+	"github.com/elastic/beats/v7/packetbeat/config"
+)
+
+type decodedMessage struct {
+	version, count uint16
+	sequence       uint32
+	domainID       uint32
+	templates      map[uint16][]templateField
+	records        []map[uint16][]byte
+}
+
+// decodeMessage parses a NetFlow v9 or IPFIX message, using the templates
+// seen in previous messages to decode data records.
+func decodeMessage(t *testing.T, msg []byte, templates map[uint16][]templateField) decodedMessage {
+	t.Helper()
+
+	m := decodedMessage{
+		version:   binary.BigEndian.Uint16(msg),
+		templates: map[uint16][]templateField{},
+	}
+	hdrSize, templateSet := 16, uint16(2)
+	if m.version == versionNetflow9 {
+		hdrSize, templateSet = 20, 0
+		m.count = binary.BigEndian.Uint16(msg[2:])
+		m.sequence = binary.BigEndian.Uint32(msg[12:])
+		m.domainID = binary.BigEndian.Uint32(msg[16:])
+	} else {
+		require.Equal(t, len(msg), int(binary.BigEndian.Uint16(msg[2:])))
+		m.sequence = binary.BigEndian.Uint32(msg[8:])
+		m.domainID = binary.BigEndian.Uint32(msg[12:])
+	}
+
+	for buf := msg[hdrSize:]; len(buf) > 0; {
+		require.True(t, len(buf) >= 4)
+		id := binary.BigEndian.Uint16(buf)
+		length := int(binary.BigEndian.Uint16(buf[2:]))
+		require.True(t, length >= 4 && length <= len(buf), "invalid set length")
+		set := buf[4:length]
+		buf = buf[length:]
+
+		if id == templateSet {
+			for len(set) >= 4 {
+				tid := binary.BigEndian.Uint16(set)
+				n := int(binary.BigEndian.Uint16(set[2:]))
+				set = set[4:]
+				var fields []templateField
+				for i := 0; i < n; i++ {
+					fields = append(fields, templateField{
+						id:     binary.BigEndian.Uint16(set),
+						length: binary.BigEndian.Uint16(set[2:]),
+					})
+					set = set[4:]
+				}
+				m.templates[tid] = fields
+				templates[tid] = fields
+			}
+			continue
+		}
+
+		fields, found := templates[id]
+		require.True(t, found, "data set %v without template", id)
+		size := 0
+		for _, f := range fields {
+			size += int(f.length)
+		}
+		for len(set) >= size {
+			rec := map[uint16][]byte{}
+			for _, f := range fields {
+				rec[f.id] = set[:f.length]
+				set = set[f.length:]
+			}
+			m.records = append(m.records, rec)
+		}
+	}
+	return m
+}
+
+func testBiFlow(start time.Time) *biFlow {
+	id := newFlowID()
+	id.AddEth([]byte{1, 2, 3, 4, 5, 6}, []byte{6, 5, 4, 3, 2, 1})
+	id.AddVLan(171)
+	id.AddIPv4([]byte{203, 0, 113, 3}, []byte{198, 51, 100, 2})
+	id.AddTCP(38901, 80)
+
+	bif := &biFlow{
+		id:       id.rawFlowID,
+		createTS: start,
+		ts:       start.Add(3 * time.Second),
+		dir:      flowDirForward,
+	}
+	bif.stats[0] = &flowStats{uintFlags: []uint8{3}, uints: []uint64{10, 1}}
+	bif.stats[1] = &flowStats{uintFlags: []uint8{3}, uints: []uint64{460, 2}}
+	return bif
+}
+
+func TestExportIPFIX(t *testing.T) {
+	logp.TestingSetup()
+
+	collector, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer collector.Close()
+
+	exp, err := newExporter(config.FlowsExport{
+		Hosts:               []string{collector.LocalAddr().String()},
+		ObservationDomainID: 42,
+	})
+	require.NoError(t, err)
+	defer exp.close()
+
+	start := time.Unix(1542292881, 0)
+	bif := testBiFlow(start)
+	uintNames := []string{"bytes", "packets"}
+
+	exp.report(start.Add(5*time.Second), bif, false, nil, uintNames, nil)
+	exp.flush()
+
+	templates := map[uint16][]templateField{}
+	buf := make([]byte, 65536)
+	require.NoError(t, collector.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := collector.ReadFrom(buf)
+	require.NoError(t, err)
+
+	msg := decodeMessage(t, buf[:n], templates)
+	assert.EqualValues(t, versionIPFIX, msg.version)
+	assert.EqualValues(t, 42, msg.domainID)
+	assert.EqualValues(t, 0, msg.sequence)
+	assert.Len(t, msg.templates, 2)
+	require.Len(t, msg.records, 2)
+
+	fwd, rev := msg.records[0], msg.records[1]
+	assert.Equal(t, []byte{203, 0, 113, 3}, fwd[ieSourceIPv4Address])
+	assert.Equal(t, []byte{198, 51, 100, 2}, fwd[ieDestinationIPv4Address])
+	assert.EqualValues(t, 38901, binary.BigEndian.Uint16(fwd[ieSourceTransportPort]))
+	assert.EqualValues(t, 80, binary.BigEndian.Uint16(fwd[ieDestinationTransportPort]))
+	assert.Equal(t, []byte{6}, fwd[ieProtocolIdentifier])
+	assert.EqualValues(t, 171, binary.BigEndian.Uint16(fwd[ieVlanID]))
+	assert.Equal(t, []byte{1, 2, 3, 4, 5, 6}, fwd[ieSourceMacAddress])
+	assert.EqualValues(t, 10, binary.BigEndian.Uint64(fwd[ieOctetDeltaCount]))
+	assert.EqualValues(t, 1, binary.BigEndian.Uint64(fwd[iePacketDeltaCount]))
+	assert.EqualValues(t, 1542292881000, binary.BigEndian.Uint64(fwd[ieFlowStartMilliseconds]))
+	assert.EqualValues(t, 1542292884000, binary.BigEndian.Uint64(fwd[ieFlowEndMilliseconds]))
+	assert.Equal(t, []byte{endReasonActiveTimeout}, fwd[ieFlowEndReason])
+
+	assert.Equal(t, []byte{198, 51, 100, 2}, rev[ieSourceIPv4Address])
+	assert.EqualValues(t, 80, binary.BigEndian.Uint16(rev[ieSourceTransportPort]))
+	assert.Equal(t, []byte{6, 5, 4, 3, 2, 1}, rev[ieSourceMacAddress])
+	assert.EqualValues(t, 460, binary.BigEndian.Uint64(rev[ieOctetDeltaCount]))
+	assert.EqualValues(t, 2, binary.BigEndian.Uint64(rev[iePacketDeltaCount]))
+
+	// Only the delta since the last report is exported. The reverse
+	// direction didn't see new packets and is omitted.
+	bif.stats[0].uints = []uint64{25, 3}
+	bif.ts = start.Add(8 * time.Second)
+	bif.kill()
+	exp.report(start.Add(40*time.Second), bif, true, nil, uintNames, nil)
+	exp.flush()
+
+	n, _, err = collector.ReadFrom(buf)
+	require.NoError(t, err)
+
+	msg = decodeMessage(t, buf[:n], templates)
+	assert.EqualValues(t, 2, msg.sequence)
+	assert.Empty(t, msg.templates)
+	require.Len(t, msg.records, 1)
+	fwd = msg.records[0]
+	assert.EqualValues(t, 15, binary.BigEndian.Uint64(fwd[ieOctetDeltaCount]))
+	assert.EqualValues(t, 2, binary.BigEndian.Uint64(fwd[iePacketDeltaCount]))
+	assert.EqualValues(t, 1542292886000, binary.BigEndian.Uint64(fwd[ieFlowStartMilliseconds]))
+	assert.EqualValues(t, 1542292889000, binary.BigEndian.Uint64(fwd[ieFlowEndMilliseconds]))
+	assert.Equal(t, []byte{endReasonIdleTimeout}, fwd[ieFlowEndReason])
+}
+
+func TestExportNetflow9(t *testing.T) {
+	start := time.Unix(1542292881, 0)
+	enc := newRecordEncoder(versionNetflow9, 7, minPacketSize, time.Minute, start)
+
+	// Enough records to span multiple messages.
+	for i := 0; i < 20; i++ {
+		enc.add(start.Add(time.Second), flowRecord{
+			ipv6:    i%2 == 1,
+			srcIP:   net.ParseIP("2001:db8::1"),
+			dstIP:   net.ParseIP("2001:db8::2"),
+			proto:   58,
+			bytes:   uint64(i),
+			packets: 1,
+			start:   start,
+			end:     start.Add(500 * time.Millisecond),
+		})
+	}
+	msgs := enc.finish()
+	require.True(t, len(msgs) > 1)
+
+	templates := map[uint16][]templateField{}
+	records := 0
+	for i, raw := range msgs {
+		assert.True(t, len(raw) <= minPacketSize)
+		assert.Zero(t, len(raw)%4)
+
+		msg := decodeMessage(t, raw, templates)
+		assert.EqualValues(t, versionNetflow9, msg.version)
+		assert.EqualValues(t, i, msg.sequence)
+		assert.EqualValues(t, 7, msg.domainID)
+		assert.Equal(t, int(msg.count), len(msg.templates)+len(msg.records))
+		if i == 0 {
+			assert.Len(t, msg.templates, 2)
+		}
+
+		for _, rec := range msg.records {
+			assert.EqualValues(t, 0, binary.BigEndian.Uint32(rec[ieFirstSwitched]))
+			assert.EqualValues(t, 500, binary.BigEndian.Uint32(rec[ieLastSwitched]))
+			assert.EqualValues(t, records, binary.BigEndian.Uint64(rec[ieOctetDeltaCount]))
+			records++
+		}
+	}
+	assert.Equal(t, 20, records)
+}
+
+func TestExportConfig(t *testing.T) {
+	_, err := newExporter(config.FlowsExport{Hosts: []string{"127.0.0.1:4739"}, Protocol: "sflow"})
+	assert.Error(t, err)
+
+	_, err = newExporter(config.FlowsExport{Hosts: []string{"127.0.0.1:4739"}, MaxPacketSize: 100})
+	assert.Error(t, err)
+}
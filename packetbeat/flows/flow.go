@@ -30,6 +30,9 @@ type biFlow struct {
 
 	dir        flowDirection
 	stats      [2]*flowStats
+	devices    []string // interfaces the flow has been captured on
+	exported   [2]flowCounts
+	exportTS   time.Time
 	prev, next *biFlow
 }
 
@@ -46,6 +49,18 @@ func newBiFlow(id rawFlowID, ts time.Time, dir flowDirection) *biFlow {
 	}
 }
 
+func (f *biFlow) addDevice(name string) {
+	if name == "" {
+		return
+	}
+	for _, device := range f.devices {
+		if device == name {
+			return
+		}
+	}
+	f.devices = append(f.devices, name)
+}
+
 func (f *biFlow) kill() {
 	atomic.StoreUint32(&f.killed, 1)
 }
@@ -26,7 +26,8 @@ import (
 
 type FlowID struct {
 	rawFlowID
-	flow Flow // remember associated flow for faster lookup
+	flow   Flow   // remember associated flow for faster lookup
+	device string // capture device, not part of the flow identity
 }
 
 type rawFlowID struct {
@@ -153,6 +154,12 @@ func (f *FlowID) Reset(buf []byte) {
 	f.flow.stats = nil
 }
 
+// SetDevice sets the name of the network interface packets for this ID are
+// captured on. The device is kept when the ID is reset.
+func (f *FlowID) SetDevice(name string) {
+	f.device = name
+}
+
 func (f *FlowID) AddEth(src, dst net.HardwareAddr) {
 	debugf("flowid: add eth")
 	f.addID(&f.offEth, EthFlow, src, dst, flowDirUnset)
@@ -246,6 +253,7 @@ func (f *FlowID) addMultLayerID(
 	a, b []byte,
 	hint flowDirection,
 ) {
+	f.flow.stats = nil // the ID changes, the flow must be looked up again
 	a, b = f.sortAddrWrite(a, b, hint)
 
 	flags := f.flags & (flag | outerFlag)
@@ -275,6 +283,7 @@ func (f *FlowID) addID(
 	a, b []byte,
 	hint flowDirection,
 ) {
+	f.flow.stats = nil // the ID changes, the flow must be looked up again
 	a, b = f.sortAddrWrite(a, b, hint)
 
 	if *off == offUnset {
@@ -332,6 +341,11 @@ func (f rawFlowID) clone() rawFlowID {
 	return n
 }
 
+// Clone returns a copy of the flow ID that does not share the buffer of f.
+func (f *FlowID) Clone() *FlowID {
+	return &FlowID{rawFlowID: f.rawFlowID.clone(), device: f.device}
+}
+
 func FlowIDsEqual(f1, f2 *FlowID) bool {
 	return f1.flags == f2.flags && bytes.Equal(f1.flowID, f2.flowID)
 }
@@ -30,6 +30,7 @@ type Flows struct {
 	worker     *worker
 	table      *flowMetaTable
 	counterReg *counterReg
+	exporter   *exporter
 }
 
 // Reporter callback type, to report flow events to.
@@ -68,9 +69,21 @@ func NewFlows(pub Reporter, watcher procs.ProcessesWatcher, config *config.Flows
 
 	counter := &counterReg{}
 
-	worker, err := newFlowsWorker(pub, watcher, table, counter, timeout, period)
+	var exp *exporter
+	if config.Export.IsEnabled() {
+		exp, err = newExporter(config.Export)
+		if err != nil {
+			logp.Err("failed to configure flows export: %v", err)
+			return nil, err
+		}
+	}
+
+	worker, err := newFlowsWorker(pub, watcher, table, counter, exp, timeout, period)
 	if err != nil {
 		logp.Err("failed to configure flows processing intervals: %v", err)
+		if exp != nil {
+			exp.close()
+		}
 		return nil, err
 	}
 
@@ -78,6 +91,7 @@ func NewFlows(pub Reporter, watcher procs.ProcessesWatcher, config *config.Flows
 		table:      table,
 		worker:     worker,
 		counterReg: counter,
+		exporter:   exp,
 	}, nil
 }
 
@@ -106,6 +120,9 @@ func (f *Flows) Start() {
 
 func (f *Flows) Stop() {
 	f.worker.Stop()
+	if f.exporter != nil {
+		f.exporter.close()
+	}
 }
 
 func (f *Flows) NewInt(name string) (*Int, error) {
@@ -93,6 +93,7 @@ func (t *flowTable) get(id *FlowID, counter *counterReg) Flow {
 	}
 
 	bf.ts = ts
+	bf.addDevice(id.device)
 	stats := bf.stats[dir]
 	if stats == nil {
 		stats = newFlowStats(counter)
@@ -35,6 +35,7 @@ type flowsProcessor struct {
 	watcher  procs.ProcessesWatcher
 	table    *flowMetaTable
 	counters *counterReg
+	exporter *exporter
 	timeout  time.Duration
 }
 
@@ -48,6 +49,7 @@ func newFlowsWorker(
 	watcher procs.ProcessesWatcher,
 	table *flowMetaTable,
 	counters *counterReg,
+	exporter *exporter,
 	timeout, period time.Duration,
 ) (*worker, error) {
 	oneSecond := 1 * time.Second
@@ -88,6 +90,7 @@ func newFlowsWorker(
 		table:    table,
 		watcher:  watcher,
 		counters: counters,
+		exporter: exporter,
 		timeout:  timeout,
 	}
 	processor.spool.init(pub, defaultBatchSize)
@@ -188,6 +191,9 @@ func (fw *flowsProcessor) execute(w *worker, checkTimeout, handleReports, lastRe
 	}
 
 	fw.spool.flush()
+	if fw.exporter != nil {
+		fw.exporter.flush()
+	}
 }
 
 func (fw *flowsProcessor) report(
@@ -201,6 +207,10 @@ func (fw *flowsProcessor) report(
 
 	debugf("add event: %v", event)
 	fw.spool.publish(event)
+
+	if fw.exporter != nil {
+		fw.exporter.report(ts, flow, isOver, intNames, uintNames, floatNames)
+	}
 }
 
 func createEvent(
@@ -435,6 +445,12 @@ func createEvent(
 	fields["source"] = source
 	fields["destination"] = dest
 
+	if len(f.devices) == 1 {
+		fields.Put("observer.ingress.interface.name", f.devices[0])
+	} else if len(f.devices) > 1 {
+		fields.Put("observer.ingress.interface.name", append([]string(nil), f.devices...))
+	}
+
 	return beat.Event{
 		Timestamp: timestamp,
 		Fields:    fields,
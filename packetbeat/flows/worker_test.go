@@ -27,6 +27,7 @@ import (
 	"github.com/elastic/go-lookslike/isdef"
 
 	"github.com/elastic/go-lookslike"
+	"github.com/stretchr/testify/assert"
 
 	"github.com/elastic/beats/v7/libbeat/common"
 	"github.com/elastic/beats/v7/libbeat/logp"
@@ -129,3 +130,28 @@ func TestCreateEvent(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateEventDevices(t *testing.T) {
+	logp.TestingSetup()
+
+	id := newFlowID()
+	id.AddIPv4([]byte{203, 0, 113, 3}, []byte{198, 51, 100, 2})
+	id.AddUDP(53, 38901)
+
+	bif := newBiFlow(id.rawFlowID, time.Now(), flowDirForward)
+
+	event := createEvent(procs.ProcessesWatcher{}, time.Now(), bif, false, nil, nil, nil)
+	_, err := event.Fields.GetValue("observer.ingress.interface.name")
+	assert.Equal(t, common.ErrKeyNotFound, err)
+
+	bif.addDevice("eth0")
+	bif.addDevice("eth0")
+	event = createEvent(procs.ProcessesWatcher{}, time.Now(), bif, false, nil, nil, nil)
+	device, _ := event.Fields.GetValue("observer.ingress.interface.name")
+	assert.Equal(t, "eth0", device)
+
+	bif.addDevice("eth1")
+	event = createEvent(procs.ProcessesWatcher{}, time.Now(), bif, false, nil, nil, nil)
+	device, _ = event.Fields.GetValue("observer.ingress.interface.name")
+	assert.Equal(t, []string{"eth0", "eth1"}, device)
+}
@@ -26,7 +26,9 @@ import (
 	_ "github.com/elastic/beats/v7/packetbeat/protos/dhcpv4"
 	_ "github.com/elastic/beats/v7/packetbeat/protos/dns"
 	_ "github.com/elastic/beats/v7/packetbeat/protos/http"
+	_ "github.com/elastic/beats/v7/packetbeat/protos/http2"
 	_ "github.com/elastic/beats/v7/packetbeat/protos/icmp"
+	_ "github.com/elastic/beats/v7/packetbeat/protos/kafka"
 	_ "github.com/elastic/beats/v7/packetbeat/protos/memcache"
 	_ "github.com/elastic/beats/v7/packetbeat/protos/mongodb"
 	_ "github.com/elastic/beats/v7/packetbeat/protos/mysql"
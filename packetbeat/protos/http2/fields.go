@@ -0,0 +1,36 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Code generated by beats/dev-tools/cmd/asset/asset.go - DO NOT EDIT.
+
+package http2
+
+import (
+	"github.com/elastic/beats/v7/libbeat/asset"
+)
+
+func init() {
+	if err := asset.SetFields("packetbeat", "http2", asset.ModuleFieldsPri, AssetHttp2); err != nil {
+		panic(err)
+	}
+}
+
+// AssetHttp2 returns asset data.
+// This is the base64 encoded gzipped contents of protos/http2.
+func AssetHttp2() string {
+	return "eJyck81u2zAQhO96ikHOsQPk6EOBwE3RAq0TOOrZZMWVRZQimeUqrd++oH4SJbVRp9BFEDWz384uF/hJhxUakXhdAGLF0QoXn8vy/ur6ogAMpYptFBv8Ch8KABgOob3Bfnu/RopU2dpWoCfygtqSM2mJsiEwPXaUpP+XKcXgE0Ez9T7apQCmGFjIwHpIQ1AZRfUC1bFTk12B8W3VaxfwuqUX8PzIIdIKew5dHL/MFXNVEibd7qx5PpnULvj97OOR7qcnt2cNebG1JUaoe/wxm6EApNGCSjNbMpCXPJbFX0jEHHhXBUPnl+81yJqp/Pah3D2U29ubb6hZtzQQCHFrvZYRYoC7fGVXBwb91m10BLW+2axvv6qRciLcc6xOJn2C9IuvA7c6bw/0j9DJsDGVdi5dIpHgV0N+Hg2q4CWvUZ4HbHq2UjpGZ6ve6yqzqOW/pkz8ZKt35Fl3zh3w2GmXZ2p6mynZjExm8jwywJakCeb8YkfMB4sj3km0dOntdvzPxvbxD3bD4jBJx352/3K0i+EPBWFtHfFJpPMLz9t9C3H5ev02d+Xu0933zUd1pG5LKen9O4Y6XJJRdqLd8VRBWFtHvCz+DACPZn1J"
+}
@@ -0,0 +1,83 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http2
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// grpcStatusNames maps the gRPC status codes to their canonical names.
+var grpcStatusNames = map[int]string{
+	0:  "OK",
+	1:  "CANCELLED",
+	2:  "UNKNOWN",
+	3:  "INVALID_ARGUMENT",
+	4:  "DEADLINE_EXCEEDED",
+	5:  "NOT_FOUND",
+	6:  "ALREADY_EXISTS",
+	7:  "PERMISSION_DENIED",
+	8:  "RESOURCE_EXHAUSTED",
+	9:  "FAILED_PRECONDITION",
+	10: "ABORTED",
+	11: "OUT_OF_RANGE",
+	12: "UNIMPLEMENTED",
+	13: "INTERNAL",
+	14: "UNAVAILABLE",
+	15: "DATA_LOSS",
+	16: "UNAUTHENTICATED",
+}
+
+func grpcStatusName(code int) string {
+	if name, exists := grpcStatusNames[code]; exists {
+		return name
+	}
+	return "UNKNOWN_STATUS(" + strconv.Itoa(code) + ")"
+}
+
+// isGRPC reports whether the content type denotes a gRPC request, that is
+// application/grpc optionally followed by a suffix such as +proto.
+func isGRPC(contentType string) bool {
+	const prefix = "application/grpc"
+	if !strings.HasPrefix(contentType, prefix) {
+		return false
+	}
+	rest := contentType[len(prefix):]
+	return rest == "" || rest[0] == '+' || rest[0] == ';'
+}
+
+// splitGRPCPath splits a gRPC request path of the form /package.Service/Method
+// into service and method.
+func splitGRPCPath(path string) (service, method string) {
+	path = strings.TrimPrefix(path, "/")
+	idx := strings.LastIndexByte(path, '/')
+	if idx < 0 {
+		return "", path
+	}
+	return path[:idx], path[idx+1:]
+}
+
+// decodeGRPCMessage undoes the percent-encoding applied to the grpc-message
+// header. Invalid encodings are returned unchanged.
+func decodeGRPCMessage(msg string) string {
+	if decoded, err := url.PathUnescape(msg); err == nil {
+		return decoded
+	}
+	return msg
+}
@@ -0,0 +1,702 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	h2 "golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/logp"
+	"github.com/elastic/beats/v7/libbeat/monitoring"
+	"github.com/elastic/ecs/code/go/ecs"
+
+	"github.com/elastic/beats/v7/packetbeat/pb"
+	"github.com/elastic/beats/v7/packetbeat/procs"
+	"github.com/elastic/beats/v7/packetbeat/protos"
+	"github.com/elastic/beats/v7/packetbeat/protos/tcp"
+)
+
+// HTTP/2 protocol plugin
+type http2Plugin struct {
+	// config
+	ports              []int
+	sendAllHeaders     bool
+	headersWhitelist   map[string]bool
+	maxStreams         int
+	maxHeaderTableSize uint32
+	maxHeaderBlockSize int
+	transactionTimeout time.Duration
+
+	results protos.Reporter
+	watcher procs.ProcessesWatcher
+}
+
+// direction holds the parser state for one direction of a TCP connection.
+type direction struct {
+	buf []byte
+
+	// skip is the number of bytes still to be discarded from a frame whose
+	// payload isn't needed.
+	skip int
+
+	reader  bytes.Reader
+	framer  *h2.Framer
+	decoder *hpack.Decoder
+
+	// header block being accumulated from HEADERS or PUSH_PROMISE and
+	// CONTINUATION frames.
+	block          []byte
+	blockSize      int
+	blockStream    uint32
+	blockEndStream bool
+	blockPromised  uint32
+}
+
+type connectionData struct {
+	dirs [2]*direction
+
+	// clientDir is the direction the connection preface was sent in, or -1
+	// if it hasn't been seen yet.
+	clientDir int
+
+	// broken is set once the connection can't be followed anymore. As the
+	// HPACK state is lost after a parse error or gap, no further events are
+	// generated for the connection.
+	broken bool
+
+	tcptuple     common.TCPTuple
+	cmdlineTuple *common.ProcessTuple
+
+	streams map[uint32]*h2stream
+}
+
+const frameHeaderLen = 9
+
+var clientPreface = []byte(h2.ClientPreface)
+
+var (
+	errNoPreface              = errors.New("no HTTP/2 connection preface")
+	errHeaderBlockTooLarge    = errors.New("header block exceeds max_header_block_size")
+	errUnexpectedContinuation = errors.New("unexpected CONTINUATION frame")
+	errMissingContinuation    = errors.New("expected CONTINUATION frame")
+)
+
+var (
+	debugf  = logp.MakeDebug("http2")
+	isDebug = false
+)
+
+var (
+	unmatchedResponses = monitoring.NewInt(nil, "http2.unmatched_responses")
+	droppedStreams     = monitoring.NewInt(nil, "http2.dropped_streams")
+	expiredStreams     = monitoring.NewInt(nil, "http2.expired_streams")
+	brokenConnections  = monitoring.NewInt(nil, "http2.broken_connections")
+)
+
+func init() {
+	protos.Register("http2", New)
+}
+
+func New(
+	testMode bool,
+	results protos.Reporter,
+	watcher procs.ProcessesWatcher,
+	cfg *common.Config,
+) (protos.Plugin, error) {
+	p := &http2Plugin{}
+	config := defaultConfig
+	if !testMode {
+		if err := cfg.Unpack(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.init(results, watcher, &config); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (http2 *http2Plugin) init(results protos.Reporter, watcher procs.ProcessesWatcher, config *http2Config) error {
+	http2.setFromConfig(config)
+
+	http2.results = results
+	http2.watcher = watcher
+	isDebug = logp.IsDebug("http2")
+
+	return nil
+}
+
+func (http2 *http2Plugin) setFromConfig(config *http2Config) {
+	http2.ports = config.Ports
+	http2.transactionTimeout = config.TransactionTimeout
+	http2.maxStreams = config.MaxStreams
+	http2.maxHeaderTableSize = config.MaxHeaderTableSize
+	http2.maxHeaderBlockSize = config.MaxHeaderBlockSize
+
+	http2.sendAllHeaders = config.SendAllHeaders
+	if !config.SendAllHeaders && len(config.SendHeaders) > 0 {
+		http2.headersWhitelist = map[string]bool{}
+		for _, hdr := range config.SendHeaders {
+			http2.headersWhitelist[strings.ToLower(hdr)] = true
+		}
+	}
+}
+
+func (http2 *http2Plugin) GetPorts() []int {
+	return http2.ports
+}
+
+func (http2 *http2Plugin) ConnectionTimeout() time.Duration {
+	return http2.transactionTimeout
+}
+
+func (http2 *http2Plugin) Parse(
+	pkt *protos.Packet,
+	tcptuple *common.TCPTuple,
+	dir uint8,
+	private protos.ProtocolData,
+) protos.ProtocolData {
+	defer logp.Recover("ParseHTTP2 exception")
+
+	conn := http2.ensureConnection(private, tcptuple)
+	if conn.broken {
+		return conn
+	}
+	http2.expireStreams(conn, pkt.Ts)
+	if err := http2.doParse(conn, pkt, dir); err != nil {
+		http2.breakConnection(conn, err)
+	}
+	return conn
+}
+
+func (http2 *http2Plugin) newConnectionData(tcptuple *common.TCPTuple) *connectionData {
+	conn := &connectionData{
+		clientDir:    -1,
+		tcptuple:     *tcptuple,
+		cmdlineTuple: http2.watcher.FindProcessesTupleTCP(tcptuple.IPPort()),
+		streams:      map[uint32]*h2stream{},
+	}
+	for i := range conn.dirs {
+		conn.dirs[i] = http2.newDirection()
+	}
+	return conn
+}
+
+func (http2 *http2Plugin) newDirection() *direction {
+	d := &direction{}
+	d.framer = h2.NewFramer(nil, &d.reader)
+	d.framer.AllowIllegalReads = true
+	d.framer.SetMaxReadFrameSize(uint32(http2.maxHeaderBlockSize))
+	d.decoder = hpack.NewDecoder(4096, nil)
+	d.decoder.SetMaxStringLength(http2.maxHeaderBlockSize)
+	return d
+}
+
+func (http2 *http2Plugin) ensureConnection(private protos.ProtocolData, tcptuple *common.TCPTuple) *connectionData {
+	if private == nil {
+		return http2.newConnectionData(tcptuple)
+	}
+
+	priv, ok := private.(*connectionData)
+	if !ok {
+		logp.Warn("http2 connection data type error, create new one")
+		return http2.newConnectionData(tcptuple)
+	}
+	if priv == nil {
+		logp.Warn("Unexpected: http2 connection data not set, create new one")
+		return http2.newConnectionData(tcptuple)
+	}
+
+	return priv
+}
+
+// breakConnection stops following the connection. Streams in progress are
+// dropped.
+func (http2 *http2Plugin) breakConnection(conn *connectionData, err error) {
+	if isDebug {
+		debugf("Stop following HTTP/2 connection %s: %v", &conn.tcptuple, err)
+	}
+	brokenConnections.Add(1)
+	conn.broken = true
+	conn.dirs = [2]*direction{}
+	conn.streams = nil
+}
+
+func (http2 *http2Plugin) doParse(conn *connectionData, pkt *protos.Packet, dir uint8) error {
+	d := conn.dirs[dir]
+	payload := pkt.Payload
+	if d.skip > 0 {
+		n := d.skip
+		if n > len(payload) {
+			n = len(payload)
+		}
+		d.skip -= n
+		payload = payload[n:]
+	}
+	d.buf = append(d.buf, payload...)
+
+	if conn.clientDir < 0 {
+		found, err := http2.findPreface(conn, dir)
+		if !found {
+			return err
+		}
+		// The server may have sent its SETTINGS before the preface was
+		// captured.
+		if err := http2.parseFrames(conn, 1-dir, pkt.Ts); err != nil {
+			return err
+		}
+	}
+	return http2.parseFrames(conn, dir, pkt.Ts)
+}
+
+// findPreface checks whether the data received in the given direction starts
+// with the client connection preface. Data received from the server before
+// the preface is kept until the preface shows up.
+func (http2 *http2Plugin) findPreface(conn *connectionData, dir uint8) (found bool, err error) {
+	d := conn.dirs[dir]
+	n := len(d.buf)
+	if n > len(clientPreface) {
+		n = len(clientPreface)
+	}
+	if bytes.Equal(d.buf[:n], clientPreface[:n]) {
+		if n < len(clientPreface) {
+			// wait for more data
+			return false, nil
+		}
+		conn.clientDir = int(dir)
+		d.buf = d.buf[len(clientPreface):]
+		return true, nil
+	}
+
+	if len(d.buf) > http2.maxHeaderBlockSize {
+		return false, errNoPreface
+	}
+	return false, nil
+}
+
+// needsPayload reports whether the frame payload is used by the analyzer.
+// Other frames are skipped without being buffered.
+func needsPayload(t h2.FrameType) bool {
+	switch t {
+	case h2.FrameHeaders, h2.FrameContinuation, h2.FramePushPromise,
+		h2.FrameSettings, h2.FrameRSTStream:
+		return true
+	}
+	return false
+}
+
+func isHeaderBlockFrame(t h2.FrameType) bool {
+	return t == h2.FrameHeaders || t == h2.FrameContinuation || t == h2.FramePushPromise
+}
+
+func (http2 *http2Plugin) parseFrames(conn *connectionData, dir uint8, ts time.Time) error {
+	d := conn.dirs[dir]
+	for len(d.buf) >= frameHeaderLen {
+		length := int(d.buf[0])<<16 | int(d.buf[1])<<8 | int(d.buf[2])
+		typ := h2.FrameType(d.buf[3])
+		flags := h2.Flags(d.buf[4])
+		streamID := binary.BigEndian.Uint32(d.buf[5:]) & (1<<31 - 1)
+		size := frameHeaderLen + length
+
+		if d.blockStream != 0 && typ != h2.FrameContinuation {
+			return errMissingContinuation
+		}
+
+		if !needsPayload(typ) || (length > http2.maxHeaderBlockSize && !isHeaderBlockFrame(typ)) {
+			http2.onFrameHeader(conn, dir, typ, flags, streamID, size, ts)
+			if size > len(d.buf) {
+				d.skip = size - len(d.buf)
+				d.buf = d.buf[:0]
+				return nil
+			}
+			d.buf = d.buf[size:]
+			continue
+		}
+
+		if length > http2.maxHeaderBlockSize {
+			return errHeaderBlockTooLarge
+		}
+		if size > len(d.buf) {
+			// wait for more data
+			return nil
+		}
+
+		d.reader.Reset(d.buf[:size])
+		frame, err := d.framer.ReadFrame()
+		d.buf = d.buf[size:]
+		if err != nil {
+			return err
+		}
+		if err := http2.onFrame(conn, dir, frame, size, ts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// onFrameHeader handles frames whose payload is skipped.
+func (http2 *http2Plugin) onFrameHeader(
+	conn *connectionData,
+	dir uint8,
+	typ h2.FrameType,
+	flags h2.Flags,
+	streamID uint32,
+	size int,
+	ts time.Time,
+) {
+	st := conn.streams[streamID]
+	if st == nil {
+		return
+	}
+	m := conn.message(st, dir)
+	m.size += size
+	if typ == h2.FrameData && flags.Has(h2.FlagDataEndStream) {
+		http2.onEndStream(conn, st, dir, ts)
+	}
+}
+
+func (http2 *http2Plugin) onFrame(conn *connectionData, dir uint8, frame h2.Frame, size int, ts time.Time) error {
+	d := conn.dirs[dir]
+	switch f := frame.(type) {
+	case *h2.SettingsFrame:
+		if f.IsAck() {
+			return nil
+		}
+		// The header table size announced by an endpoint limits the
+		// dynamic table used for the headers it receives.
+		return f.ForeachSetting(func(s h2.Setting) error {
+			if s.ID == h2.SettingHeaderTableSize {
+				size := s.Val
+				if size > http2.maxHeaderTableSize {
+					size = http2.maxHeaderTableSize
+				}
+				conn.dirs[1-dir].decoder.SetAllowedMaxDynamicTableSize(size)
+			}
+			return nil
+		})
+
+	case *h2.HeadersFrame:
+		return http2.startHeaderBlock(conn, dir, f.StreamID, 0, f.StreamEnded(),
+			f.HeaderBlockFragment(), f.HeadersEnded(), size, ts)
+
+	case *h2.PushPromiseFrame:
+		return http2.startHeaderBlock(conn, dir, f.StreamID, f.PromiseID, false,
+			f.HeaderBlockFragment(), f.HeadersEnded(), size, ts)
+
+	case *h2.ContinuationFrame:
+		if d.blockStream != f.StreamID {
+			return errUnexpectedContinuation
+		}
+		d.blockSize += size
+		d.block = append(d.block, f.HeaderBlockFragment()...)
+		if len(d.block) > http2.maxHeaderBlockSize {
+			return errHeaderBlockTooLarge
+		}
+		if f.HeadersEnded() {
+			return http2.onHeaderBlock(conn, dir, ts)
+		}
+
+	case *h2.RSTStreamFrame:
+		st := conn.streams[f.StreamID]
+		if st == nil {
+			return nil
+		}
+		conn.message(st, dir).size += size
+		st.reset = true
+		st.errorCode = uint32(f.ErrCode)
+		st.endTs = ts
+		http2.publishStream(conn, st)
+	}
+	return nil
+}
+
+func (http2 *http2Plugin) startHeaderBlock(
+	conn *connectionData,
+	dir uint8,
+	streamID, promised uint32,
+	endStream bool,
+	fragment []byte,
+	endHeaders bool,
+	size int,
+	ts time.Time,
+) error {
+	d := conn.dirs[dir]
+	d.blockStream = streamID
+	d.blockPromised = promised
+	d.blockEndStream = endStream
+	d.blockSize = size
+	d.block = append(d.block[:0], fragment...)
+
+	if endHeaders {
+		return http2.onHeaderBlock(conn, dir, ts)
+	}
+	return nil
+}
+
+// onHeaderBlock decodes a complete header block. Blocks are always decoded,
+// even for streams that aren't tracked, to keep the HPACK state in sync.
+func (http2 *http2Plugin) onHeaderBlock(conn *connectionData, dir uint8, ts time.Time) error {
+	d := conn.dirs[dir]
+	streamID, promised, endStream := d.blockStream, d.blockPromised, d.blockEndStream
+	d.blockStream, d.blockPromised = 0, 0
+
+	fields, err := d.decoder.DecodeFull(d.block)
+	if err != nil {
+		return err
+	}
+
+	if promised != 0 {
+		// A server push. The promised request is complete.
+		if st := http2.newStream(conn, promised); st != nil {
+			http2.onRequestHeaders(st, fields, ts)
+			st.request.ended = true
+		}
+		return nil
+	}
+
+	st := conn.streams[streamID]
+	if int(dir) == conn.clientDir {
+		if st == nil {
+			if st = http2.newStream(conn, streamID); st == nil {
+				return nil
+			}
+		}
+		http2.onRequestHeaders(st, fields, ts)
+	} else {
+		if st == nil {
+			if isDebug {
+				debugf("Response on unknown stream %d. Ignoring", streamID)
+			}
+			unmatchedResponses.Add(1)
+			return nil
+		}
+		http2.onResponseHeaders(st, fields, ts)
+	}
+	conn.message(st, dir).size += d.blockSize
+
+	if endStream {
+		http2.onEndStream(conn, st, dir, ts)
+	}
+	return nil
+}
+
+func (http2 *http2Plugin) newStream(conn *connectionData, id uint32) *h2stream {
+	if len(conn.streams) >= http2.maxStreams {
+		if isDebug {
+			debugf("Too many concurrent streams. Ignoring stream %d", id)
+		}
+		droppedStreams.Add(1)
+		return nil
+	}
+	st := &h2stream{id: id}
+	conn.streams[id] = st
+	return st
+}
+
+// expireStreams publishes the streams that haven't been completed within
+// transaction_timeout, so they don't count against max_streams forever.
+func (http2 *http2Plugin) expireStreams(conn *connectionData, ts time.Time) {
+	for _, st := range conn.streams {
+		if ts.Sub(st.request.ts) > http2.transactionTimeout {
+			http2.expireStream(conn, st)
+		}
+	}
+}
+
+// expireStream publishes a stream that is still in progress as an unmatched
+// or incomplete transaction.
+func (http2 *http2Plugin) expireStream(conn *connectionData, st *h2stream) {
+	if isDebug {
+		debugf("Expiring incomplete stream %d", st.id)
+	}
+	expiredStreams.Add(1)
+	st.endTs = st.request.ts
+	if st.response.headersReceived {
+		st.endTs = st.response.ts
+	}
+	http2.publishStream(conn, st)
+}
+
+// message returns the side of the stream sent in the given direction.
+func (conn *connectionData) message(st *h2stream, dir uint8) *message {
+	if int(dir) == conn.clientDir {
+		return &st.request
+	}
+	return &st.response
+}
+
+// onEndStream handles the END_STREAM flag. The transaction is complete once
+// the server has ended its side of the stream.
+func (http2 *http2Plugin) onEndStream(conn *connectionData, st *h2stream, dir uint8, ts time.Time) {
+	conn.message(st, dir).ended = true
+	if int(dir) != conn.clientDir {
+		st.endTs = ts
+		http2.publishStream(conn, st)
+	}
+}
+
+func (http2 *http2Plugin) publishStream(conn *connectionData, st *h2stream) {
+	delete(conn.streams, st.id)
+	if !st.request.headersReceived {
+		return
+	}
+	if http2.results != nil {
+		http2.results(http2.newTransaction(conn, st))
+	}
+}
+
+type httpFields struct {
+	Version            string        `ecs:"version"`
+	RequestMethod      string        `ecs:"request.method"`
+	RequestBytes       int64         `ecs:"request.bytes"`
+	RequestHeaders     common.MapStr `packetbeat:"request.headers"`
+	ResponseStatusCode int64         `ecs:"response.status_code"`
+	ResponseBytes      int64         `ecs:"response.bytes"`
+	ResponseHeaders    common.MapStr `packetbeat:"response.headers"`
+}
+
+func (http2 *http2Plugin) newTransaction(conn *connectionData, st *h2stream) beat.Event {
+	source, destination := common.MakeEndpointPair(conn.tcptuple.BaseTuple, conn.cmdlineTuple)
+	src, dst := &source, &destination
+	if conn.clientDir == tcp.TCPDirectionReverse {
+		src, dst = dst, src
+	}
+
+	evt, pbf := pb.NewBeatEvent(st.request.ts)
+	pbf.SetSource(src)
+	pbf.SetDestination(dst)
+	pbf.AddIP(src.IP)
+	pbf.AddIP(dst.IP)
+	pbf.Source.Bytes = int64(st.request.size)
+	pbf.Destination.Bytes = int64(st.response.size)
+	pbf.Event.Dataset = "http2"
+	pbf.Event.Start = st.request.ts
+	pbf.Event.End = st.endTs
+	pbf.Network.Transport = "tcp"
+	pbf.Network.Protocol = pbf.Event.Dataset
+
+	fields := evt.Fields
+	fields["type"] = pbf.Event.Dataset
+	fields["method"] = st.method
+	fields["query"] = fmt.Sprintf("%s %s", st.method, st.path)
+	fields["http2"] = common.MapStr{"stream_id": st.id}
+
+	hf := httpFields{
+		Version:            "2",
+		RequestMethod:      strings.ToLower(st.method),
+		RequestBytes:       int64(st.request.size),
+		RequestHeaders:     st.request.headers,
+		ResponseStatusCode: int64(st.statusCode),
+		ResponseBytes:      int64(st.response.size),
+		ResponseHeaders:    st.response.headers,
+	}
+	pb.MarshalStruct(evt.Fields, "http", hf)
+
+	path, query := st.path, ""
+	if idx := strings.IndexByte(path, '?'); idx >= 0 {
+		path, query = path[:idx], path[idx+1:]
+	}
+	pb.MarshalStruct(evt.Fields, "url", ecs.Url{
+		Scheme: st.scheme,
+		Domain: st.authority,
+		Path:   path,
+		Query:  query,
+	})
+	if st.userAgent != "" {
+		pb.MarshalStruct(evt.Fields, "user_agent", ecs.UserAgent{Original: st.userAgent})
+	}
+
+	failed := st.statusCode >= 400
+	if st.reset {
+		failed = true
+		evt.PutValue("http2.error_code", h2.ErrCode(st.errorCode).String())
+		pbf.Error.Message = append(pbf.Error.Message, "Stream reset")
+	} else if !st.response.headersReceived {
+		failed = true
+		pbf.Error.Message = append(pbf.Error.Message, "Unmatched request")
+	} else if !st.response.ended {
+		failed = true
+		pbf.Error.Message = append(pbf.Error.Message, "Incomplete response")
+	}
+
+	if st.isGRPC() {
+		pbf.Network.Application = "grpc"
+		grpcFields := common.MapStr{}
+		service, method := splitGRPCPath(path)
+		grpcFields["service"] = service
+		grpcFields["method"] = method
+		if st.hasGRPCStatus {
+			grpcFields["status_code"] = st.grpcStatus
+			grpcFields["status"] = grpcStatusName(st.grpcStatus)
+			if st.grpcStatus != 0 {
+				failed = true
+			}
+		}
+		if st.grpcMessage != "" {
+			grpcFields["message"] = st.grpcMessage
+		}
+		fields["grpc"] = grpcFields
+	}
+
+	if failed {
+		fields["status"] = common.ERROR_STATUS
+		pbf.Event.Outcome = "failure"
+	} else {
+		fields["status"] = common.OK_STATUS
+	}
+	return evt
+}
+
+// GapInStream marks the connection as broken, as the HPACK state can't be
+// recovered after losing data.
+func (http2 *http2Plugin) GapInStream(tcptuple *common.TCPTuple, dir uint8,
+	nbytes int, private protos.ProtocolData) (priv protos.ProtocolData, drop bool) {
+
+	conn := http2.ensureConnection(private, tcptuple)
+	if !conn.broken {
+		http2.breakConnection(conn, fmt.Errorf("gap of %d bytes in stream", nbytes))
+	}
+	return conn, false
+}
+
+// Expired publishes the streams still in progress when the connection
+// expires.
+func (http2 *http2Plugin) Expired(tuple *common.TCPTuple, private protos.ProtocolData) {
+	conn, ok := private.(*connectionData)
+	if !ok || conn == nil || conn.broken {
+		return
+	}
+	for _, st := range conn.streams {
+		http2.expireStream(conn, st)
+	}
+}
+
+func (http2 *http2Plugin) ReceivedFin(tcptuple *common.TCPTuple, dir uint8,
+	private protos.ProtocolData) protos.ProtocolData {
+	return private
+}
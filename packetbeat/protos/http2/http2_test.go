@@ -0,0 +1,474 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+
+package http2
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	h2 "golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/packetbeat/pb"
+	"github.com/elastic/beats/v7/packetbeat/procs"
+	"github.com/elastic/beats/v7/packetbeat/protos"
+	"github.com/elastic/beats/v7/packetbeat/protos/tcp"
+)
+
+type eventStore struct {
+	events []beat.Event
+}
+
+func (e *eventStore) publish(event beat.Event) {
+	e.events = append(e.events, event)
+}
+
+func http2ModForTests(config *http2Config) (*eventStore, *http2Plugin) {
+	var http2 http2Plugin
+	results := &eventStore{}
+	if config == nil {
+		c := defaultConfig
+		config = &c
+	}
+	http2.init(results.publish, procs.ProcessesWatcher{}, config)
+	return results, &http2
+}
+
+func testTCPTuple() *common.TCPTuple {
+	t := &common.TCPTuple{
+		IPLength: 4,
+		BaseTuple: common.BaseTuple{
+			SrcIP: net.IPv4(192, 168, 0, 1), DstIP: net.IPv4(192, 168, 0, 2),
+			SrcPort: 6512, DstPort: 50051,
+		},
+	}
+	t.ComputeHashables()
+	return t
+}
+
+// endpoint writes the frames sent by one side of a connection.
+type endpoint struct {
+	buf    bytes.Buffer
+	framer *h2.Framer
+	hbuf   bytes.Buffer
+	enc    *hpack.Encoder
+}
+
+func newEndpoint() *endpoint {
+	e := &endpoint{}
+	e.framer = h2.NewFramer(&e.buf, nil)
+	e.enc = hpack.NewEncoder(&e.hbuf)
+	return e
+}
+
+func (e *endpoint) headerBlock(headers ...string) []byte {
+	e.hbuf.Reset()
+	for i := 0; i < len(headers); i += 2 {
+		e.enc.WriteField(hpack.HeaderField{Name: headers[i], Value: headers[i+1]})
+	}
+	return append([]byte{}, e.hbuf.Bytes()...)
+}
+
+func (e *endpoint) headers(streamID uint32, endStream bool, headers ...string) *endpoint {
+	e.framer.WriteHeaders(h2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: e.headerBlock(headers...),
+		EndStream:     endStream,
+		EndHeaders:    true,
+	})
+	return e
+}
+
+func (e *endpoint) data(streamID uint32, endStream bool, size int) *endpoint {
+	e.framer.WriteData(streamID, endStream, make([]byte, size))
+	return e
+}
+
+// flush returns the bytes written so far.
+func (e *endpoint) flush() []byte {
+	b := append([]byte{}, e.buf.Bytes()...)
+	e.buf.Reset()
+	return b
+}
+
+type testConn struct {
+	http2   *http2Plugin
+	tuple   *common.TCPTuple
+	private protos.ProtocolData
+
+	// elapsed is added to the timestamp of the packets.
+	elapsed time.Duration
+}
+
+func (c *testConn) client(payload []byte) {
+	c.parse(tcp.TCPDirectionOriginal, payload)
+}
+
+func (c *testConn) server(payload []byte) {
+	c.parse(tcp.TCPDirectionReverse, payload)
+}
+
+func (c *testConn) parse(dir uint8, payload []byte) {
+	pkt := &protos.Packet{Ts: time.Now().Add(c.elapsed), Payload: payload}
+	c.private = c.http2.Parse(pkt, c.tuple, dir, c.private)
+}
+
+// newTestConn returns a connection where the preface and settings have
+// already been exchanged.
+func newTestConn(http2 *http2Plugin) (*testConn, *endpoint, *endpoint) {
+	c := &testConn{http2: http2, tuple: testTCPTuple()}
+	client, server := newEndpoint(), newEndpoint()
+
+	client.buf.WriteString(h2.ClientPreface)
+	client.framer.WriteSettings()
+	c.client(client.flush())
+	server.framer.WriteSettings()
+	server.framer.WriteSettingsAck()
+	c.server(server.flush())
+	client.framer.WriteSettingsAck()
+	c.client(client.flush())
+	return c, client, server
+}
+
+func expectTransaction(t *testing.T, e *eventStore) common.MapStr {
+	if len(e.events) == 0 {
+		t.Fatal("No transaction")
+	}
+
+	event := e.events[0]
+	e.events = e.events[1:]
+	return event.Fields
+}
+
+func TestHTTP2_SimpleRequest(t *testing.T) {
+	results, http2 := http2ModForTests(nil)
+	c, client, server := newTestConn(http2)
+
+	c.client(client.headers(1, true,
+		":method", "GET",
+		":scheme", "http",
+		":authority", "example.com",
+		":path", "/index.html?q=1",
+		"user-agent", "test/1.0").flush())
+	c.server(server.headers(1, false,
+		":status", "200",
+		"content-type", "text/html").
+		data(1, false, 100).
+		data(1, true, 50).flush())
+
+	trans := expectTransaction(t, results)
+	assert.Equal(t, "http2", trans["type"])
+	assert.Equal(t, common.OK_STATUS, trans["status"])
+	assert.Equal(t, "GET", trans["method"])
+	assert.Equal(t, "GET /index.html?q=1", trans["query"])
+	assert.Equal(t, uint32(1), trans["http2"].(common.MapStr)["stream_id"])
+
+	for field, expected := range map[string]interface{}{
+		"http.version":              "2",
+		"http.request.method":       "get",
+		"http.response.status_code": int64(200),
+		"url.scheme":                "http",
+		"url.domain":                "example.com",
+		"url.path":                  "/index.html",
+		"url.query":                 "q=1",
+		"user_agent.original":       "test/1.0",
+	} {
+		v, err := trans.GetValue(field)
+		if assert.NoError(t, err, field) {
+			assert.Equal(t, expected, v, field)
+		}
+	}
+	assert.NotContains(t, trans, "grpc")
+
+	// response size includes both DATA frames and their headers
+	size, _ := trans.GetValue("http.response.bytes")
+	assert.True(t, size.(int64) > 150+2*frameHeaderLen)
+}
+
+func TestHTTP2_GRPC(t *testing.T) {
+	results, http2 := http2ModForTests(nil)
+	c, client, server := newTestConn(http2)
+
+	c.client(client.headers(1, false,
+		":method", "POST",
+		":scheme", "http",
+		":authority", "localhost:50051",
+		":path", "/helloworld.Greeter/SayHello",
+		"content-type", "application/grpc",
+		"te", "trailers").
+		data(1, true, 12).flush())
+	c.server(server.headers(1, false,
+		":status", "200",
+		"content-type", "application/grpc").
+		data(1, false, 20).flush())
+	assert.Empty(t, results.events)
+	c.server(server.headers(1, true,
+		"grpc-status", "5",
+		"grpc-message", "user%20not%20found").flush())
+
+	trans := expectTransaction(t, results)
+	assert.Equal(t, common.ERROR_STATUS, trans["status"])
+	assert.Equal(t, common.MapStr{
+		"service":     "helloworld.Greeter",
+		"method":      "SayHello",
+		"status_code": 5,
+		"status":      "NOT_FOUND",
+		"message":     "user not found",
+	}, trans["grpc"])
+
+	pbf, err := pb.GetFields(trans)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "grpc", pbf.Network.Application)
+		assert.Equal(t, "failure", pbf.Event.Outcome)
+	}
+}
+
+func TestHTTP2_TrailersOnly(t *testing.T) {
+	results, http2 := http2ModForTests(nil)
+	c, client, server := newTestConn(http2)
+
+	c.client(client.headers(3, true,
+		":method", "POST",
+		":path", "/pkg.Service/Method",
+		"content-type", "application/grpc+proto").flush())
+	c.server(server.headers(3, true,
+		":status", "200",
+		"grpc-status", "0").flush())
+
+	trans := expectTransaction(t, results)
+	assert.Equal(t, common.OK_STATUS, trans["status"])
+	grpc := trans["grpc"].(common.MapStr)
+	assert.Equal(t, "OK", grpc["status"])
+	assert.Equal(t, "pkg.Service", grpc["service"])
+}
+
+func TestHTTP2_ConcurrentStreams(t *testing.T) {
+	results, http2 := http2ModForTests(nil)
+	c, client, server := newTestConn(http2)
+
+	// Requests are sent on two streams before either response. The HPACK
+	// dynamic table is shared between them.
+	c.client(client.
+		headers(1, true, ":method", "GET", ":path", "/a", "x-request-id", "1").
+		headers(3, true, ":method", "GET", ":path", "/b", "x-request-id", "2").flush())
+	c.server(server.
+		headers(3, true, ":status", "404").
+		headers(1, true, ":status", "200").flush())
+
+	first := expectTransaction(t, results)
+	second := expectTransaction(t, results)
+	assert.Equal(t, "GET /b", first["query"])
+	assert.Equal(t, common.ERROR_STATUS, first["status"])
+	assert.Equal(t, "GET /a", second["query"])
+	assert.Equal(t, common.OK_STATUS, second["status"])
+}
+
+func TestHTTP2_Continuation(t *testing.T) {
+	results, http2 := http2ModForTests(nil)
+	c, client, server := newTestConn(http2)
+
+	block := client.headerBlock(":method", "GET", ":path", "/continued", "user-agent", "test/1.0")
+	client.framer.WriteHeaders(h2.HeadersFrameParam{
+		StreamID:      1,
+		BlockFragment: block[:5],
+		EndStream:     true,
+	})
+	client.framer.WriteContinuation(1, false, block[5:10])
+	client.framer.WriteContinuation(1, true, block[10:])
+	payload := client.flush()
+
+	// deliver the frames byte by byte
+	for i := range payload {
+		c.client(payload[i : i+1])
+	}
+	c.server(server.headers(1, true, ":status", "204").flush())
+
+	trans := expectTransaction(t, results)
+	assert.Equal(t, "GET /continued", trans["query"])
+	ua, _ := trans.GetValue("user_agent.original")
+	assert.Equal(t, "test/1.0", ua)
+}
+
+func TestHTTP2_ResetStream(t *testing.T) {
+	results, http2 := http2ModForTests(nil)
+	c, client, _ := newTestConn(http2)
+
+	c.client(client.headers(1, false, ":method", "POST", ":path", "/upload").flush())
+	client.framer.WriteRSTStream(1, h2.ErrCodeCancel)
+	c.client(client.flush())
+
+	trans := expectTransaction(t, results)
+	assert.Equal(t, common.ERROR_STATUS, trans["status"])
+	code, _ := trans.GetValue("http2.error_code")
+	assert.Equal(t, "CANCEL", code)
+}
+
+func TestHTTP2_SendHeaders(t *testing.T) {
+	config := defaultConfig
+	config.SendHeaders = []string{"X-Request-ID"}
+	results, http2 := http2ModForTests(&config)
+	c, client, server := newTestConn(http2)
+
+	c.client(client.headers(1, true, ":method", "GET", ":path", "/",
+		"x-request-id", "abc", "cookie", "secret").flush())
+	c.server(server.headers(1, true, ":status", "200", "x-request-id", "abc").flush())
+
+	trans := expectTransaction(t, results)
+	headers, _ := trans.GetValue("http.request.headers")
+	assert.Equal(t, common.MapStr{"x-request-id": "abc"}, headers)
+	headers, _ = trans.GetValue("http.response.headers")
+	assert.Equal(t, common.MapStr{"x-request-id": "abc"}, headers)
+}
+
+func TestHTTP2_ServerPush(t *testing.T) {
+	results, http2 := http2ModForTests(nil)
+	c, client, server := newTestConn(http2)
+
+	c.client(client.headers(1, true, ":method", "GET", ":path", "/").flush())
+	server.framer.WritePushPromise(h2.PushPromiseParam{
+		StreamID:      1,
+		PromiseID:     2,
+		BlockFragment: server.headerBlock(":method", "GET", ":path", "/style.css"),
+		EndHeaders:    true,
+	})
+	server.headers(1, true, ":status", "200")
+	server.headers(2, true, ":status", "200")
+	c.server(server.flush())
+
+	assert.Equal(t, "GET /", expectTransaction(t, results)["query"])
+	assert.Equal(t, "GET /style.css", expectTransaction(t, results)["query"])
+}
+
+func TestHTTP2_MaxStreams(t *testing.T) {
+	config := defaultConfig
+	config.MaxStreams = 1
+	results, http2 := http2ModForTests(&config)
+	c, client, server := newTestConn(http2)
+
+	c.client(client.
+		headers(1, true, ":method", "GET", ":path", "/a").
+		headers(3, true, ":method", "GET", ":path", "/b").flush())
+	conn := c.private.(*connectionData)
+	assert.Len(t, conn.streams, 1)
+
+	// The response to the untracked stream is still decoded to keep the
+	// HPACK state in sync.
+	c.server(server.
+		headers(3, true, ":status", "200", "x-dynamic", "value").
+		headers(1, true, ":status", "200", "x-dynamic", "value").flush())
+	assert.Len(t, results.events, 1)
+	assert.False(t, conn.broken)
+}
+
+func TestHTTP2_UnansweredRequestExpires(t *testing.T) {
+	config := defaultConfig
+	config.MaxStreams = 1
+	results, http2 := http2ModForTests(&config)
+	c, client, server := newTestConn(http2)
+
+	c.client(client.headers(1, true, ":method", "GET", ":path", "/a").flush())
+	assert.Empty(t, results.events)
+
+	// The unanswered stream is published once the transaction timeout has
+	// passed, so the next stream can be tracked.
+	c.elapsed = config.TransactionTimeout + time.Second
+	c.client(client.headers(3, true, ":method", "GET", ":path", "/b").flush())
+	trans := expectTransaction(t, results)
+	assert.Equal(t, "GET /a", trans["query"])
+	assert.Equal(t, common.ERROR_STATUS, trans["status"])
+	pbf, err := pb.GetFields(trans)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Unmatched request"}, pbf.Error.Message)
+
+	c.server(server.headers(3, true, ":status", "200").flush())
+	trans = expectTransaction(t, results)
+	assert.Equal(t, "GET /b", trans["query"])
+	assert.Equal(t, common.OK_STATUS, trans["status"])
+}
+
+func TestHTTP2_ExpiredConnection(t *testing.T) {
+	results, http2 := http2ModForTests(nil)
+	c, client, server := newTestConn(http2)
+
+	c.client(client.headers(1, true, ":method", "GET", ":path", "/").flush())
+	c.server(server.headers(1, false, ":status", "200").flush())
+	http2.Expired(c.tuple, c.private)
+
+	trans := expectTransaction(t, results)
+	assert.Equal(t, common.ERROR_STATUS, trans["status"])
+	pbf, err := pb.GetFields(trans)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Incomplete response"}, pbf.Error.Message)
+	assert.Empty(t, c.private.(*connectionData).streams)
+}
+
+func TestHTTP2_NoPreface(t *testing.T) {
+	results, http2 := http2ModForTests(nil)
+	c := &testConn{http2: http2, tuple: testTCPTuple()}
+
+	c.client([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+	conn := c.private.(*connectionData)
+	assert.Equal(t, -1, conn.clientDir)
+
+	c.client(make([]byte, http2.maxHeaderBlockSize))
+	assert.True(t, conn.broken)
+	assert.Empty(t, results.events)
+}
+
+func TestHTTP2_Gap(t *testing.T) {
+	results, http2 := http2ModForTests(nil)
+	c, client, server := newTestConn(http2)
+
+	c.client(client.headers(1, true, ":method", "GET", ":path", "/").flush())
+	c.private, _ = http2.GapInStream(c.tuple, tcp.TCPDirectionReverse, 10, c.private)
+	c.server(server.headers(1, true, ":status", "200").flush())
+
+	assert.True(t, c.private.(*connectionData).broken)
+	assert.Empty(t, results.events)
+}
+
+func TestHTTP2_HeaderBlockTooLarge(t *testing.T) {
+	config := defaultConfig
+	config.MaxHeaderBlockSize = 64
+	_, http2 := http2ModForTests(&config)
+	c, client, _ := newTestConn(http2)
+
+	c.client(client.headers(1, true, ":method", "GET", ":path", "/",
+		"x-large", string(make([]byte, 100))).flush())
+	assert.True(t, c.private.(*connectionData).broken)
+}
+
+func TestSplitGRPCPath(t *testing.T) {
+	service, method := splitGRPCPath("/grpc.health.v1.Health/Check")
+	assert.Equal(t, "grpc.health.v1.Health", service)
+	assert.Equal(t, "Check", method)
+}
+
+func TestIsGRPC(t *testing.T) {
+	assert.True(t, isGRPC("application/grpc"))
+	assert.True(t, isGRPC("application/grpc+proto"))
+	assert.False(t, isGRPC("application/grpc-web"))
+	assert.False(t, isGRPC("application/json"))
+}
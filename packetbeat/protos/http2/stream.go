@@ -0,0 +1,151 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http2
+
+import (
+	"strconv"
+	"time"
+
+	"golang.org/x/net/http2/hpack"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+)
+
+// message holds one side of an HTTP/2 stream.
+type message struct {
+	ts time.Time
+
+	// size is the number of bytes of all frames sent on the stream,
+	// including the frame headers.
+	size int
+
+	headersReceived bool
+	ended           bool
+
+	headers common.MapStr
+}
+
+// h2stream is an HTTP/2 stream, which carries a single request/response
+// exchange.
+type h2stream struct {
+	id uint32
+
+	request  message
+	response message
+
+	method      string
+	path        string
+	authority   string
+	scheme      string
+	userAgent   string
+	contentType string
+
+	statusCode int
+
+	hasGRPCStatus bool
+	grpcStatus    int
+	grpcMessage   string
+
+	// reset is set when the stream was terminated by a RST_STREAM frame.
+	reset     bool
+	errorCode uint32
+
+	endTs time.Time
+}
+
+func (st *h2stream) isGRPC() bool {
+	return isGRPC(st.contentType)
+}
+
+// onRequestHeaders applies a decoded request header block to the stream.
+// Trailers sent by the client are ignored.
+func (http2 *http2Plugin) onRequestHeaders(st *h2stream, fields []hpack.HeaderField, ts time.Time) {
+	if st.request.headersReceived {
+		return
+	}
+	st.request.ts = ts
+	st.request.headersReceived = true
+
+	for _, f := range fields {
+		switch f.Name {
+		case ":method":
+			st.method = f.Value
+		case ":path":
+			st.path = f.Value
+		case ":authority":
+			st.authority = f.Value
+		case ":scheme":
+			st.scheme = f.Value
+		case "user-agent":
+			st.userAgent = f.Value
+		case "content-type":
+			st.contentType = f.Value
+		}
+		http2.collectHeader(&st.request, f)
+	}
+}
+
+// onResponseHeaders applies a decoded response header block to the stream.
+// The first block holds the response headers, a later block holds the
+// trailers. Informational (1xx) responses are skipped.
+func (http2 *http2Plugin) onResponseHeaders(st *h2stream, fields []hpack.HeaderField, ts time.Time) {
+	trailers := st.response.headersReceived
+	for _, f := range fields {
+		switch f.Name {
+		case ":status":
+			code, _ := strconv.Atoi(f.Value)
+			if code >= 100 && code < 200 {
+				return
+			}
+			st.statusCode = code
+		case "grpc-status":
+			if code, err := strconv.Atoi(f.Value); err == nil {
+				st.hasGRPCStatus = true
+				st.grpcStatus = code
+			}
+		case "grpc-message":
+			st.grpcMessage = decodeGRPCMessage(f.Value)
+		}
+		if !trailers {
+			http2.collectHeader(&st.response, f)
+		}
+	}
+	if !trailers {
+		st.response.ts = ts
+		st.response.headersReceived = true
+	}
+}
+
+// collectHeader stores the header in the message if it has been configured
+// to be sent. Pseudo-headers are reported in dedicated fields.
+func (http2 *http2Plugin) collectHeader(m *message, f hpack.HeaderField) {
+	if f.IsPseudo() {
+		return
+	}
+	if !http2.sendAllHeaders && !http2.headersWhitelist[f.Name] {
+		return
+	}
+	if m.headers == nil {
+		m.headers = common.MapStr{}
+	}
+	if prev, exists := m.headers[f.Name]; exists {
+		m.headers[f.Name] = prev.(string) + ", " + f.Value
+		return
+	}
+	m.headers[f.Name] = f.Value
+}
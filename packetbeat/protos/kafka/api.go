@@ -0,0 +1,237 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kafka
+
+import "strconv"
+
+// apiKey identifies the type of a Kafka request.
+type apiKey int16
+
+const (
+	apiProduce     apiKey = 0
+	apiFetch       apiKey = 1
+	apiMetadata    apiKey = 3
+	apiApiVersions apiKey = 18
+)
+
+var apiNames = map[apiKey]string{
+	0:  "Produce",
+	1:  "Fetch",
+	2:  "ListOffsets",
+	3:  "Metadata",
+	4:  "LeaderAndIsr",
+	5:  "StopReplica",
+	6:  "UpdateMetadata",
+	7:  "ControlledShutdown",
+	8:  "OffsetCommit",
+	9:  "OffsetFetch",
+	10: "FindCoordinator",
+	11: "JoinGroup",
+	12: "Heartbeat",
+	13: "LeaveGroup",
+	14: "SyncGroup",
+	15: "DescribeGroups",
+	16: "ListGroups",
+	17: "SaslHandshake",
+	18: "ApiVersions",
+	19: "CreateTopics",
+	20: "DeleteTopics",
+	21: "DeleteRecords",
+	22: "InitProducerId",
+	23: "OffsetForLeaderEpoch",
+	24: "AddPartitionsToTxn",
+	25: "AddOffsetsToTxn",
+	26: "EndTxn",
+	27: "WriteTxnMarkers",
+	28: "TxnOffsetCommit",
+	29: "DescribeAcls",
+	30: "CreateAcls",
+	31: "DeleteAcls",
+	32: "DescribeConfigs",
+	33: "AlterConfigs",
+	34: "AlterReplicaLogDirs",
+	35: "DescribeLogDirs",
+	36: "SaslAuthenticate",
+	37: "CreatePartitions",
+	38: "CreateDelegationToken",
+	39: "RenewDelegationToken",
+	40: "ExpireDelegationToken",
+	41: "DescribeDelegationToken",
+	42: "DeleteGroups",
+	43: "ElectLeaders",
+	44: "IncrementalAlterConfigs",
+	45: "AlterPartitionReassignments",
+	46: "ListPartitionReassignments",
+	47: "OffsetDelete",
+	48: "DescribeClientQuotas",
+	49: "AlterClientQuotas",
+	50: "DescribeUserScramCredentials",
+	51: "AlterUserScramCredentials",
+	56: "AlterPartition",
+	57: "UpdateFeatures",
+	60: "DescribeCluster",
+	61: "DescribeProducers",
+	65: "DescribeTransactions",
+	66: "ListTransactions",
+	67: "AllocateProducerIds",
+}
+
+func (k apiKey) String() string {
+	if name, exists := apiNames[k]; exists {
+		return name
+	}
+	return "Unknown(" + strconv.Itoa(int(k)) + ")"
+}
+
+// known reports whether the API key is one of the requests defined by the
+// protocol. It is used to detect streams that don't carry Kafka traffic.
+func (k apiKey) known() bool {
+	_, exists := apiNames[k]
+	return exists
+}
+
+// firstFlexibleVersion holds, for the APIs whose bodies are parsed, the
+// first version using the compact encodings and tagged fields of KIP-482.
+var firstFlexibleVersion = map[apiKey]int16{
+	apiProduce:     9,
+	apiFetch:       12,
+	apiMetadata:    9,
+	apiApiVersions: 3,
+}
+
+// isFlexible reports whether the given version of an API uses the flexible
+// encoding. APIs not listed in firstFlexibleVersion are reported as
+// non-flexible, which is fine as their bodies are never parsed.
+func isFlexible(key apiKey, version int16) bool {
+	first, exists := firstFlexibleVersion[key]
+	return exists && version >= first
+}
+
+// errorNames maps the error codes returned in responses to their names as
+// defined by the protocol.
+var errorNames = map[int16]string{
+	-1:  "UNKNOWN_SERVER_ERROR",
+	0:   "NONE",
+	1:   "OFFSET_OUT_OF_RANGE",
+	2:   "CORRUPT_MESSAGE",
+	3:   "UNKNOWN_TOPIC_OR_PARTITION",
+	4:   "INVALID_FETCH_SIZE",
+	5:   "LEADER_NOT_AVAILABLE",
+	6:   "NOT_LEADER_OR_FOLLOWER",
+	7:   "REQUEST_TIMED_OUT",
+	8:   "BROKER_NOT_AVAILABLE",
+	9:   "REPLICA_NOT_AVAILABLE",
+	10:  "MESSAGE_TOO_LARGE",
+	11:  "STALE_CONTROLLER_EPOCH",
+	12:  "OFFSET_METADATA_TOO_LARGE",
+	13:  "NETWORK_EXCEPTION",
+	14:  "COORDINATOR_LOAD_IN_PROGRESS",
+	15:  "COORDINATOR_NOT_AVAILABLE",
+	16:  "NOT_COORDINATOR",
+	17:  "INVALID_TOPIC_EXCEPTION",
+	18:  "RECORD_LIST_TOO_LARGE",
+	19:  "NOT_ENOUGH_REPLICAS",
+	20:  "NOT_ENOUGH_REPLICAS_AFTER_APPEND",
+	21:  "INVALID_REQUIRED_ACKS",
+	22:  "ILLEGAL_GENERATION",
+	23:  "INCONSISTENT_GROUP_PROTOCOL",
+	24:  "INVALID_GROUP_ID",
+	25:  "UNKNOWN_MEMBER_ID",
+	26:  "INVALID_SESSION_TIMEOUT",
+	27:  "REBALANCE_IN_PROGRESS",
+	28:  "INVALID_COMMIT_OFFSET_SIZE",
+	29:  "TOPIC_AUTHORIZATION_FAILED",
+	30:  "GROUP_AUTHORIZATION_FAILED",
+	31:  "CLUSTER_AUTHORIZATION_FAILED",
+	32:  "INVALID_TIMESTAMP",
+	33:  "UNSUPPORTED_SASL_MECHANISM",
+	34:  "ILLEGAL_SASL_STATE",
+	35:  "UNSUPPORTED_VERSION",
+	36:  "TOPIC_ALREADY_EXISTS",
+	37:  "INVALID_PARTITIONS",
+	38:  "INVALID_REPLICATION_FACTOR",
+	39:  "INVALID_REPLICA_ASSIGNMENT",
+	40:  "INVALID_CONFIG",
+	41:  "NOT_CONTROLLER",
+	42:  "INVALID_REQUEST",
+	43:  "UNSUPPORTED_FOR_MESSAGE_FORMAT",
+	44:  "POLICY_VIOLATION",
+	45:  "OUT_OF_ORDER_SEQUENCE_NUMBER",
+	46:  "DUPLICATE_SEQUENCE_NUMBER",
+	47:  "INVALID_PRODUCER_EPOCH",
+	48:  "INVALID_TXN_STATE",
+	49:  "INVALID_PRODUCER_ID_MAPPING",
+	50:  "INVALID_TRANSACTION_TIMEOUT",
+	51:  "CONCURRENT_TRANSACTIONS",
+	52:  "TRANSACTION_COORDINATOR_FENCED",
+	53:  "TRANSACTIONAL_ID_AUTHORIZATION_FAILED",
+	54:  "SECURITY_DISABLED",
+	55:  "OPERATION_NOT_ATTEMPTED",
+	56:  "KAFKA_STORAGE_ERROR",
+	57:  "LOG_DIR_NOT_FOUND",
+	58:  "SASL_AUTHENTICATION_FAILED",
+	59:  "UNKNOWN_PRODUCER_ID",
+	60:  "REASSIGNMENT_IN_PROGRESS",
+	61:  "DELEGATION_TOKEN_AUTH_DISABLED",
+	62:  "DELEGATION_TOKEN_NOT_FOUND",
+	63:  "DELEGATION_TOKEN_OWNER_MISMATCH",
+	64:  "DELEGATION_TOKEN_REQUEST_NOT_ALLOWED",
+	65:  "DELEGATION_TOKEN_AUTHORIZATION_FAILED",
+	66:  "DELEGATION_TOKEN_EXPIRED",
+	67:  "INVALID_PRINCIPAL_TYPE",
+	68:  "NON_EMPTY_GROUP",
+	69:  "GROUP_ID_NOT_FOUND",
+	70:  "FETCH_SESSION_ID_NOT_FOUND",
+	71:  "INVALID_FETCH_SESSION_EPOCH",
+	72:  "LISTENER_NOT_FOUND",
+	73:  "TOPIC_DELETION_DISABLED",
+	74:  "FENCED_LEADER_EPOCH",
+	75:  "UNKNOWN_LEADER_EPOCH",
+	76:  "UNSUPPORTED_COMPRESSION_TYPE",
+	77:  "STALE_BROKER_EPOCH",
+	78:  "OFFSET_NOT_AVAILABLE",
+	79:  "MEMBER_ID_REQUIRED",
+	80:  "PREFERRED_LEADER_NOT_AVAILABLE",
+	81:  "GROUP_MAX_SIZE_REACHED",
+	82:  "FENCED_INSTANCE_ID",
+	83:  "ELIGIBLE_LEADERS_NOT_AVAILABLE",
+	84:  "ELECTION_NOT_NEEDED",
+	85:  "NO_REASSIGNMENT_IN_PROGRESS",
+	86:  "GROUP_SUBSCRIBED_TO_TOPIC",
+	87:  "INVALID_RECORD",
+	88:  "UNSTABLE_OFFSET_COMMIT",
+	89:  "THROTTLING_QUOTA_EXCEEDED",
+	90:  "PRODUCER_FENCED",
+	91:  "RESOURCE_NOT_FOUND",
+	92:  "DUPLICATE_RESOURCE",
+	93:  "UNACCEPTABLE_CREDENTIAL",
+	94:  "INCONSISTENT_VOTER_SET",
+	95:  "INVALID_UPDATE_VERSION",
+	96:  "FEATURE_UPDATE_FAILED",
+	97:  "PRINCIPAL_DESERIALIZATION_FAILURE",
+	98:  "SNAPSHOT_NOT_FOUND",
+	99:  "POSITION_OUT_OF_RANGE",
+	100: "UNKNOWN_TOPIC_ID",
+}
+
+func errorName(code int16) string {
+	if name, exists := errorNames[code]; exists {
+		return name
+	}
+	return "UNKNOWN_ERROR_CODE(" + strconv.Itoa(int(code)) + ")"
+}
@@ -0,0 +1,223 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kafka
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	errTruncated      = errors.New("kafka message truncated")
+	errInvalidLength  = errors.New("invalid kafka length field")
+	errVarintOverflow = errors.New("kafka varint overflows 64 bits")
+)
+
+// decoder reads the primitive types of the Kafka wire protocol from a byte
+// slice. Errors are sticky: once a read fails, all subsequent reads return
+// zero values and err holds the first error encountered. This allows the
+// message parsers to be written without checking every single field.
+//
+// When flexible is set the compact encodings introduced by KIP-482 are used
+// for strings, byte arrays and arrays.
+type decoder struct {
+	buf      []byte
+	off      int
+	err      error
+	flexible bool
+}
+
+func newDecoder(buf []byte) *decoder {
+	return &decoder{buf: buf}
+}
+
+func (d *decoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 {
+		d.fail(errInvalidLength)
+		return nil
+	}
+	if len(d.buf)-d.off < n {
+		d.off = len(d.buf)
+		d.fail(errTruncated)
+		return nil
+	}
+	b := d.buf[d.off : d.off+n]
+	d.off += n
+	return b
+}
+
+func (d *decoder) skip(n int) {
+	d.next(n)
+}
+
+func (d *decoder) bool() bool {
+	return d.int8() != 0
+}
+
+func (d *decoder) int8() int8 {
+	b := d.next(1)
+	if b == nil {
+		return 0
+	}
+	return int8(b[0])
+}
+
+func (d *decoder) int16() int16 {
+	b := d.next(2)
+	if b == nil {
+		return 0
+	}
+	return int16(binary.BigEndian.Uint16(b))
+}
+
+func (d *decoder) int32() int32 {
+	b := d.next(4)
+	if b == nil {
+		return 0
+	}
+	return int32(binary.BigEndian.Uint32(b))
+}
+
+func (d *decoder) int64() int64 {
+	b := d.next(8)
+	if b == nil {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf[d.off:])
+	switch {
+	case n == 0:
+		d.off = len(d.buf)
+		d.fail(errTruncated)
+		return 0
+	case n < 0:
+		d.fail(errVarintOverflow)
+		return 0
+	}
+	d.off += n
+	return v
+}
+
+// compactLength reads an unsigned varint holding length+1, as used by the
+// compact encodings. A return value of -1 denotes null.
+func (d *decoder) compactLength() int {
+	v := d.uvarint()
+	if v > uint64(len(d.buf)) {
+		// Neither a string nor an array element can be shorter than one
+		// byte, so the length can't possibly exceed the message size.
+		d.fail(errInvalidLength)
+		return -1
+	}
+	return int(v) - 1
+}
+
+// string reads a nullable string. Null strings are returned as "".
+func (d *decoder) string() string {
+	var n int
+	if d.flexible {
+		n = d.compactLength()
+	} else {
+		n = int(d.int16())
+	}
+	if n < 0 {
+		return ""
+	}
+	return string(d.next(n))
+}
+
+// legacyString reads a nullable string that is never compact encoded, such
+// as the client ID in the request header.
+func (d *decoder) legacyString() string {
+	n := int(d.int16())
+	if n < 0 {
+		return ""
+	}
+	return string(d.next(n))
+}
+
+// skipBytes skips over a nullable byte array.
+func (d *decoder) skipBytes() {
+	var n int
+	if d.flexible {
+		n = d.compactLength()
+	} else {
+		n = int(d.int32())
+	}
+	if n > 0 {
+		d.skip(n)
+	}
+}
+
+// arrayLength reads the number of elements of a nullable array. A return
+// value of -1 denotes null.
+func (d *decoder) arrayLength() int {
+	if d.flexible {
+		return d.compactLength()
+	}
+	n := int(d.int32())
+	if n < -1 || n > len(d.buf) {
+		d.fail(errInvalidLength)
+		return -1
+	}
+	return n
+}
+
+// skipInt32Array skips over an array of int32 values.
+func (d *decoder) skipInt32Array() {
+	if n := d.arrayLength(); n > 0 {
+		d.skip(4 * n)
+	}
+}
+
+// skipUUID skips over a 16 byte UUID.
+func (d *decoder) skipUUID() {
+	d.skip(16)
+}
+
+// skipTaggedFields skips over the tagged fields section present at the end
+// of every structure in flexible versions.
+func (d *decoder) skipTaggedFields() {
+	if !d.flexible {
+		return
+	}
+	n := d.uvarint()
+	for i := uint64(0); i < n && d.err == nil; i++ {
+		d.uvarint() // tag
+		size := d.uvarint()
+		if size > uint64(len(d.buf)) {
+			d.fail(errInvalidLength)
+			return
+		}
+		d.skip(int(size))
+	}
+}
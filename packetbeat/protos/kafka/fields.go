@@ -0,0 +1,36 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Code generated by beats/dev-tools/cmd/asset/asset.go - DO NOT EDIT.
+
+package kafka
+
+import (
+	"github.com/elastic/beats/v7/libbeat/asset"
+)
+
+func init() {
+	if err := asset.SetFields("packetbeat", "kafka", asset.ModuleFieldsPri, AssetKafka); err != nil {
+		panic(err)
+	}
+}
+
+// AssetKafka returns asset data.
+// This is the base64 encoded gzipped contents of protos/kafka.
+func AssetKafka() string {
+	return "eJyklE9v2kAQxe98iqecAfWMokhRq0ooKqCIqkdYdsewwt5xZ8ch5NNX6z/8UdwmFfLFLJ43vzczOyPs6TjB3mR7MwDUa04T3D2l33cDwFG04kv1HCZ4GABA/d8olmR95i3ohYIi85S7OB6gfZvUn44QTEFn+fTosaQJtsJV2Z5cRlxGmdKv9nQ8nXexOYftxWEPYvcsd4RQFSTe4nExTV7hHQX12dGHLXRHtSY4q9+FflcUdTzoZXkhiZ7DjTytSpcyYVWRHDbHDxAsi1BuUitW3t1IcSGG6TdE0g7A5p6CDhsoZRRG7a5FiyWHSFdiyvAa/4Vd610TfwRXhzRcQeHDZWWwI+NIejIpl97Gz6dJUxa7PjTBMM4Jxff9AAuEtJJArgW60utqM8Y85EcshF1laYjvlKpngsMPUuOMGhQUo9lShBGCD+kiKbkeP6UR9Qn+PzydY66ttEA1ScPUGovDPjsZS4H1fV2Uh9H9SfVh3cNp7D7eOI6hKjYkqRfG7gMfcnLbgsJ5sBoX5uSjPR5jxr19gI+g16a0OOwowGs6+9LDTyIsK8uObnSReYmKwGH0RsKNLpIuMq5CNzcnxr+hfD5hmuBugLts0si7er8x1vXuHZ89rofIWECvpijz666vf86eZvNfs9Vyvph+Xc2fV4vH5+V0OZ3P+vquUgVrlN7vog1zTiZ80ohUBH+1gJvb1nbyYCKUGbmRLUEZG8KmyjIScml4r8QOO293sCYSOF3EtJuahVHrmGDy4xu58eDPAFXrCBA="
+}
@@ -0,0 +1,431 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kafka
+
+import (
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/logp"
+	"github.com/elastic/beats/v7/libbeat/monitoring"
+
+	"github.com/elastic/beats/v7/packetbeat/pb"
+	"github.com/elastic/beats/v7/packetbeat/procs"
+	"github.com/elastic/beats/v7/packetbeat/protos"
+	"github.com/elastic/beats/v7/packetbeat/protos/tcp"
+)
+
+// Kafka protocol plugin
+type kafkaPlugin struct {
+	// config
+	ports              []int
+	transactionTimeout time.Duration
+	maxPendingRequests int
+
+	watcher procs.ProcessesWatcher
+	results protos.Reporter
+}
+
+type stream struct {
+	tcptuple *common.TCPTuple
+
+	data []byte
+
+	// skip is the number of bytes still to be discarded from an oversized
+	// message whose header has already been reported.
+	skip int
+}
+
+type kafkaConnectionData struct {
+	streams [2]*stream
+
+	// requests waiting for a response, indexed by correlation ID. order
+	// holds the correlation IDs in the order the requests were seen.
+	requests map[int32]*kafkaMessage
+	order    []int32
+}
+
+var (
+	debugf  = logp.MakeDebug("kafka")
+	isDebug = false
+)
+
+var (
+	unmatchedResponses = monitoring.NewInt(nil, "kafka.unmatched_responses")
+	unmatchedRequests  = monitoring.NewInt(nil, "kafka.unmatched_requests")
+)
+
+func init() {
+	protos.Register("kafka", New)
+}
+
+func New(
+	testMode bool,
+	results protos.Reporter,
+	watcher procs.ProcessesWatcher,
+	cfg *common.Config,
+) (protos.Plugin, error) {
+	p := &kafkaPlugin{}
+	config := defaultConfig
+	if !testMode {
+		if err := cfg.Unpack(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.init(results, watcher, &config); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (kafka *kafkaPlugin) init(results protos.Reporter, watcher procs.ProcessesWatcher, config *kafkaConfig) error {
+	kafka.setFromConfig(config)
+
+	kafka.results = results
+	kafka.watcher = watcher
+	isDebug = logp.IsDebug("kafka")
+
+	return nil
+}
+
+func (kafka *kafkaPlugin) setFromConfig(config *kafkaConfig) {
+	kafka.ports = config.Ports
+	kafka.transactionTimeout = config.TransactionTimeout
+	kafka.maxPendingRequests = config.MaxPendingRequests
+}
+
+func (kafka *kafkaPlugin) GetPorts() []int {
+	return kafka.ports
+}
+
+func (kafka *kafkaPlugin) ConnectionTimeout() time.Duration {
+	return kafka.transactionTimeout
+}
+
+func (kafka *kafkaPlugin) Parse(
+	pkt *protos.Packet,
+	tcptuple *common.TCPTuple,
+	dir uint8,
+	private protos.ProtocolData,
+) protos.ProtocolData {
+	defer logp.Recover("ParseKafka exception")
+
+	conn := ensureKafkaConnection(private)
+	kafka.doParse(conn, pkt, tcptuple, dir)
+	return conn
+}
+
+func newConnectionData() *kafkaConnectionData {
+	return &kafkaConnectionData{
+		requests: map[int32]*kafkaMessage{},
+	}
+}
+
+func ensureKafkaConnection(private protos.ProtocolData) *kafkaConnectionData {
+	if private == nil {
+		return newConnectionData()
+	}
+
+	priv, ok := private.(*kafkaConnectionData)
+	if !ok {
+		logp.Warn("kafka connection data type error, create new one")
+		return newConnectionData()
+	}
+	if priv == nil {
+		logp.Warn("Unexpected: kafka connection data not set, create new one")
+		return newConnectionData()
+	}
+
+	return priv
+}
+
+// isRequestDirection reports whether packets flowing in the given direction
+// are sent to the broker. As Kafka messages don't carry a request/response
+// marker, this is decided by the configured ports.
+func (kafka *kafkaPlugin) isRequestDirection(tcptuple *common.TCPTuple, dir uint8) bool {
+	dst, src := tcptuple.DstPort, tcptuple.SrcPort
+	if dir == tcp.TCPDirectionReverse {
+		dst, src = src, dst
+	}
+	for _, port := range kafka.ports {
+		if int(dst) == port {
+			return true
+		}
+		if int(src) == port {
+			return false
+		}
+	}
+	return dir == tcp.TCPDirectionOriginal
+}
+
+func (kafka *kafkaPlugin) doParse(
+	conn *kafkaConnectionData,
+	pkt *protos.Packet,
+	tcptuple *common.TCPTuple,
+	dir uint8,
+) {
+	st := conn.streams[dir]
+	if st == nil {
+		st = &stream{tcptuple: tcptuple}
+		conn.streams[dir] = st
+		if isDebug {
+			debugf("new stream: %p (dir=%v, len=%v)", st, dir, len(pkt.Payload))
+		}
+	}
+
+	payload := pkt.Payload
+	if st.skip > 0 {
+		n := st.skip
+		if n > len(payload) {
+			n = len(payload)
+		}
+		st.skip -= n
+		payload = payload[n:]
+	}
+	st.data = append(st.data, payload...)
+
+	isRequest := kafka.isRequestDirection(tcptuple, dir)
+	for len(st.data) > 0 {
+		msg, body, complete, err := parseMessage(st, pkt.Ts, isRequest)
+		if err != nil {
+			// drop this tcp stream. Will retry parsing with the next
+			// segment in it
+			conn.streams[dir] = nil
+			if isDebug {
+				debugf("Ignore Kafka message: %v. Drop tcp stream. Try parsing with the next segment", err)
+			}
+			return
+		}
+		if !complete {
+			// wait for more data
+			break
+		}
+
+		msg.tcpTuple = *tcptuple
+		msg.direction = dir
+		msg.cmdlineTuple = kafka.watcher.FindProcessesTupleTCP(tcptuple.IPPort())
+		if msg.isRequest {
+			kafka.onRequest(conn, msg)
+		} else {
+			kafka.onResponse(conn, msg, body)
+		}
+	}
+}
+
+// parseMessage consumes the next message from the stream. The body of
+// requests is parsed right away. For responses the undecoded body is
+// returned, as parsing it requires the API key and version of the matching
+// request. The body is nil if the message was too large to be buffered.
+func parseMessage(
+	st *stream,
+	ts time.Time,
+	isRequest bool,
+) (msg *kafkaMessage, body []byte, complete bool, err error) {
+	size, err := messageSize(st.data)
+	if err != nil || size == 0 {
+		return nil, nil, false, err
+	}
+
+	msg = &kafkaMessage{ts: ts, isRequest: isRequest, size: size}
+	buf := st.data[4:]
+	if size > len(st.data) {
+		if size <= tcp.TCPMaxDataInStream {
+			return nil, nil, false, nil
+		}
+		// The message is too large to be buffered. Report what can be
+		// learned from its header and discard the rest.
+		msg.truncated = true
+	} else {
+		buf = buf[:size-4]
+	}
+
+	var d *decoder
+	if isRequest {
+		d, err = parseRequestHeader(msg, buf)
+	} else {
+		err = parseResponseHeader(msg, buf)
+	}
+	if err == errTruncated && msg.truncated {
+		// wait for the rest of the header
+		return nil, nil, false, nil
+	}
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	if msg.truncated {
+		st.skip = size - len(st.data)
+		st.data = nil
+		return msg, nil, true, nil
+	}
+
+	st.data = st.data[size:]
+	if isRequest {
+		if err := parseRequestBody(msg, d); err != nil && isDebug {
+			debugf("Failed to parse body of kafka %s request: %v", msg.apiKey, err)
+		}
+		return msg, nil, true, nil
+	}
+	return msg, buf, true, nil
+}
+
+func (kafka *kafkaPlugin) onRequest(conn *kafkaConnectionData, msg *kafkaMessage) {
+	if !msg.expectsResponse() {
+		kafka.publishTransaction(msg, nil)
+		return
+	}
+
+	id := msg.correlationID
+	if _, exists := conn.requests[id]; exists {
+		debugf("Two requests with the same correlation ID. Dropping old request")
+		unmatchedRequests.Add(1)
+		conn.removeRequest(id)
+	}
+	if len(conn.requests) >= kafka.maxPendingRequests {
+		debugf("Too many pending requests. Dropping oldest request")
+		unmatchedRequests.Add(1)
+		conn.removeRequest(conn.order[0])
+	}
+	conn.requests[id] = msg
+	conn.order = append(conn.order, id)
+}
+
+func (kafka *kafkaPlugin) onResponse(conn *kafkaConnectionData, msg *kafkaMessage, body []byte) {
+	id := msg.correlationID
+	requ, exists := conn.requests[id]
+	if !exists {
+		debugf("Response from unknown transaction. Ignoring")
+		unmatchedResponses.Add(1)
+		return
+	}
+
+	// Brokers answer the requests of a connection in order, so requests
+	// sent before this one won't get a response anymore.
+	for conn.order[0] != id {
+		unmatchedRequests.Add(1)
+		conn.removeRequest(conn.order[0])
+	}
+	conn.removeRequest(id)
+
+	msg.apiKey = requ.apiKey
+	msg.apiVersion = requ.apiVersion
+	if body != nil {
+		if err := parseResponseBody(msg, body); err != nil && isDebug {
+			debugf("Failed to parse body of kafka %s response: %v", msg.apiKey, err)
+		}
+	}
+	kafka.publishTransaction(requ, msg)
+}
+
+func (conn *kafkaConnectionData) removeRequest(id int32) {
+	delete(conn.requests, id)
+	for i, pending := range conn.order {
+		if pending == id {
+			conn.order = append(conn.order[:i], conn.order[i+1:]...)
+			return
+		}
+	}
+}
+
+func (kafka *kafkaPlugin) publishTransaction(requ, resp *kafkaMessage) {
+	if kafka.results == nil {
+		return
+	}
+	kafka.results(kafka.newTransaction(requ, resp))
+}
+
+func (kafka *kafkaPlugin) newTransaction(requ, resp *kafkaMessage) beat.Event {
+	source, destination := common.MakeEndpointPair(requ.tcpTuple.BaseTuple, requ.cmdlineTuple)
+	src, dst := &source, &destination
+	if requ.direction == tcp.TCPDirectionReverse {
+		src, dst = dst, src
+	}
+
+	evt, pbf := pb.NewBeatEvent(requ.ts)
+	pbf.SetSource(src)
+	pbf.SetDestination(dst)
+	pbf.Source.Bytes = int64(requ.size)
+	pbf.Event.Dataset = "kafka"
+	pbf.Event.Start = requ.ts
+	pbf.Network.Transport = "tcp"
+	pbf.Network.Protocol = pbf.Event.Dataset
+	pbf.Event.Action = "kafka." + strings.ToLower(requ.apiKey.String())
+
+	topics := requ.topics
+	if resp != nil {
+		pbf.Destination.Bytes = int64(resp.size)
+		pbf.Event.End = resp.ts
+		for _, topic := range resp.topics {
+			requ.addTopic(topic)
+		}
+		topics = requ.topics
+	}
+
+	fields := evt.Fields
+	fields["type"] = pbf.Event.Dataset
+	fields["method"] = requ.apiKey.String()
+	if len(topics) > 0 {
+		fields["resource"] = strings.Join(topics, ",")
+	}
+
+	kafkaFields := common.MapStr{
+		"api_key":        int16(requ.apiKey),
+		"api_version":    requ.apiVersion,
+		"correlation_id": requ.correlationID,
+	}
+	if requ.clientID != "" {
+		kafkaFields["client_id"] = requ.clientID
+	}
+	if len(topics) > 0 {
+		kafkaFields["topics"] = topics
+	}
+	if len(requ.partitions) > 0 {
+		kafkaFields["partitions"] = requ.partitions
+	}
+	if requ.apiKey == apiProduce && !requ.truncated {
+		kafkaFields["acks"] = requ.acks
+	}
+	if requ.truncated || (resp != nil && resp.truncated) {
+		kafkaFields["truncated"] = true
+	}
+
+	status := common.OK_STATUS
+	if resp != nil && resp.errorCode != 0 {
+		status = common.ERROR_STATUS
+		kafkaFields["error_code"] = resp.errorCode
+		kafkaFields["error"] = errorName(resp.errorCode)
+		pbf.Event.Outcome = "failure"
+	}
+	fields["status"] = status
+	fields["kafka"] = kafkaFields
+
+	return evt
+}
+
+func (kafka *kafkaPlugin) GapInStream(tcptuple *common.TCPTuple, dir uint8,
+	nbytes int, private protos.ProtocolData) (priv protos.ProtocolData, drop bool) {
+	return private, true
+}
+
+func (kafka *kafkaPlugin) ReceivedFin(tcptuple *common.TCPTuple, dir uint8,
+	private protos.ProtocolData) protos.ProtocolData {
+	return private
+}
@@ -0,0 +1,456 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+
+package kafka
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/packetbeat/pb"
+	"github.com/elastic/beats/v7/packetbeat/procs"
+	"github.com/elastic/beats/v7/packetbeat/protos"
+	"github.com/elastic/beats/v7/packetbeat/protos/tcp"
+)
+
+type eventStore struct {
+	events []beat.Event
+}
+
+func (e *eventStore) publish(event beat.Event) {
+	e.events = append(e.events, event)
+}
+
+func kafkaModForTests() (*eventStore, *kafkaPlugin) {
+	var kafka kafkaPlugin
+	results := &eventStore{}
+	config := defaultConfig
+	config.Ports = []int{9092}
+	kafka.init(results.publish, procs.ProcessesWatcher{}, &config)
+	return results, &kafka
+}
+
+func testTCPTuple() *common.TCPTuple {
+	t := &common.TCPTuple{
+		IPLength: 4,
+		BaseTuple: common.BaseTuple{
+			SrcIP: net.IPv4(192, 168, 0, 1), DstIP: net.IPv4(192, 168, 0, 2),
+			SrcPort: 6512, DstPort: 9092,
+		},
+	}
+	t.ComputeHashables()
+	return t
+}
+
+func parseMessages(kafka *kafkaPlugin, private protos.ProtocolData, dir uint8, msgs ...[]byte) protos.ProtocolData {
+	tuple := testTCPTuple()
+	for _, msg := range msgs {
+		private = kafka.Parse(&protos.Packet{Ts: time.Now(), Payload: msg}, tuple, dir, private)
+	}
+	return private
+}
+
+func expectTransaction(t *testing.T, e *eventStore) common.MapStr {
+	if len(e.events) == 0 {
+		t.Fatal("No transaction")
+	}
+
+	event := e.events[0]
+	e.events = e.events[1:]
+	return event.Fields
+}
+
+func produceRequest(version int16, correlationID int32, acks int16) []byte {
+	e := requestHeader(apiProduce, version, correlationID, "producer-1")
+	if version >= 3 {
+		e.string("")
+	}
+	e.int16(acks).int32(30000)
+	e.array(2)
+	e.string("orders").array(2)
+	e.int32(0).bytes(make([]byte, 16)).tags()
+	e.int32(1).bytes(make([]byte, 16)).tags()
+	e.tags()
+	e.string("payments").array(1)
+	e.int32(3).bytes(make([]byte, 16)).tags()
+	e.tags()
+	return e.tags().frame()
+}
+
+func produceResponse(version int16, correlationID int32, errorCode int16) []byte {
+	e := responseHeader(apiProduce, version, correlationID)
+	e.array(1)
+	e.string("orders").array(2)
+	for i, code := range []int16{0, errorCode} {
+		e.int32(int32(i)).int16(code).int64(100)
+		if version >= 2 {
+			e.int64(-1)
+		}
+		if version >= 5 {
+			e.int64(0)
+		}
+		if version >= 8 {
+			e.array(0).string("")
+		}
+		e.tags()
+	}
+	e.tags()
+	return e.int32(0).tags().frame()
+}
+
+func TestProduce(t *testing.T) {
+	for _, version := range []int16{0, 3, 7, 9} {
+		results, kafka := kafkaModForTests()
+
+		private := parseMessages(kafka, nil, tcp.TCPDirectionOriginal, produceRequest(version, 42, -1))
+		parseMessages(kafka, private, tcp.TCPDirectionReverse, produceResponse(version, 42, 0))
+
+		trans := expectTransaction(t, results)
+		assert.Equal(t, "kafka", trans["type"])
+		assert.Equal(t, "Produce", trans["method"])
+		assert.Equal(t, "orders,payments", trans["resource"])
+		assert.Equal(t, common.OK_STATUS, trans["status"])
+
+		fields := trans["kafka"].(common.MapStr)
+		assert.Equal(t, int16(0), fields["api_key"])
+		assert.Equal(t, version, fields["api_version"])
+		assert.Equal(t, int32(42), fields["correlation_id"])
+		assert.Equal(t, "producer-1", fields["client_id"])
+		assert.Equal(t, int16(-1), fields["acks"])
+		assert.Equal(t, []string{"orders", "payments"}, fields["topics"])
+		assert.Equal(t, []string{"orders-0", "orders-1", "payments-3"}, fields["partitions"])
+		assert.NotContains(t, fields, "error_code")
+	}
+}
+
+func TestProduce_Error(t *testing.T) {
+	results, kafka := kafkaModForTests()
+
+	private := parseMessages(kafka, nil, tcp.TCPDirectionOriginal, produceRequest(8, 1, 1))
+	parseMessages(kafka, private, tcp.TCPDirectionReverse, produceResponse(8, 1, 6))
+
+	trans := expectTransaction(t, results)
+	assert.Equal(t, common.ERROR_STATUS, trans["status"])
+	fields := trans["kafka"].(common.MapStr)
+	assert.Equal(t, int16(6), fields["error_code"])
+	assert.Equal(t, "NOT_LEADER_OR_FOLLOWER", fields["error"])
+
+	pbf, err := pb.GetFields(trans)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "failure", pbf.Event.Outcome)
+	}
+}
+
+func TestProduce_NoAcks(t *testing.T) {
+	results, kafka := kafkaModForTests()
+
+	parseMessages(kafka, nil, tcp.TCPDirectionOriginal, produceRequest(7, 1, 0))
+
+	trans := expectTransaction(t, results)
+	assert.Equal(t, "Produce", trans["method"])
+	assert.Equal(t, common.OK_STATUS, trans["status"])
+}
+
+func fetchRequest(version int16, correlationID int32) []byte {
+	e := requestHeader(apiFetch, version, correlationID, "consumer-1")
+	e.int32(-1).int32(500).int32(1)
+	if version >= 3 {
+		e.int32(1 << 20)
+	}
+	if version >= 4 {
+		e.int8(0)
+	}
+	if version >= 7 {
+		e.int32(0).int32(-1)
+	}
+	e.array(1).string("orders").array(1)
+	e.int32(2)
+	if version >= 9 {
+		e.int32(-1)
+	}
+	e.int64(1000)
+	if version >= 12 {
+		e.int32(-1)
+	}
+	if version >= 5 {
+		e.int64(-1)
+	}
+	e.int32(1 << 20).tags()
+	e.tags()
+	if version >= 7 {
+		e.array(0) // forgotten_topics_data
+	}
+	if version >= 11 {
+		e.string("")
+	}
+	return e.tags().frame()
+}
+
+func fetchResponse(version int16, correlationID int32, errorCode int16) []byte {
+	e := responseHeader(apiFetch, version, correlationID)
+	if version >= 1 {
+		e.int32(0)
+	}
+	if version >= 7 {
+		e.int16(0).int32(0)
+	}
+	e.array(1).string("orders").array(1)
+	e.int32(2).int16(errorCode).int64(2000)
+	if version >= 4 {
+		e.int64(2000)
+	}
+	if version >= 5 {
+		e.int64(0)
+	}
+	if version >= 4 {
+		e.array(0)
+	}
+	if version >= 11 {
+		e.int32(-1)
+	}
+	e.bytes(make([]byte, 64)).tags()
+	e.tags()
+	return e.tags().frame()
+}
+
+func TestFetch(t *testing.T) {
+	for _, version := range []int16{0, 4, 11, 12} {
+		results, kafka := kafkaModForTests()
+
+		private := parseMessages(kafka, nil, tcp.TCPDirectionOriginal, fetchRequest(version, 5))
+		parseMessages(kafka, private, tcp.TCPDirectionReverse, fetchResponse(version, 5, 1))
+
+		trans := expectTransaction(t, results)
+		assert.Equal(t, "Fetch", trans["method"])
+		assert.Equal(t, common.ERROR_STATUS, trans["status"])
+
+		fields := trans["kafka"].(common.MapStr)
+		assert.Equal(t, []string{"orders"}, fields["topics"])
+		assert.Equal(t, []string{"orders-2"}, fields["partitions"])
+		assert.Equal(t, "OFFSET_OUT_OF_RANGE", fields["error"])
+	}
+}
+
+func metadataRequest(version int16, correlationID int32, topics ...string) []byte {
+	e := requestHeader(apiMetadata, version, correlationID, "admin")
+	e.array(len(topics))
+	for _, topic := range topics {
+		if version >= 10 {
+			e.buf = append(e.buf, make([]byte, 16)...)
+		}
+		e.string(topic).tags()
+	}
+	if version >= 4 {
+		e.int8(1)
+	}
+	if version >= 8 {
+		e.int8(0)
+	}
+	if version >= 8 && version <= 10 {
+		e.int8(0)
+	}
+	return e.tags().frame()
+}
+
+func metadataResponse(version int16, correlationID int32, topics ...string) []byte {
+	e := responseHeader(apiMetadata, version, correlationID)
+	if version >= 3 {
+		e.int32(0)
+	}
+	e.array(1).int32(1).string("broker-1").int32(9092)
+	if version >= 1 {
+		e.string("rack-a")
+	}
+	e.tags()
+	if version >= 2 {
+		e.string("cluster")
+	}
+	if version >= 1 {
+		e.int32(1)
+	}
+	e.array(len(topics))
+	for _, topic := range topics {
+		e.int16(0).string(topic)
+		if version >= 10 {
+			e.buf = append(e.buf, make([]byte, 16)...)
+		}
+		if version >= 1 {
+			e.int8(0)
+		}
+		e.array(1).int16(0).int32(0).int32(1)
+		if version >= 7 {
+			e.int32(0)
+		}
+		e.array(1).int32(1)
+		e.array(1).int32(1)
+		if version >= 5 {
+			e.array(0)
+		}
+		e.tags()
+		if version >= 8 {
+			e.int32(0)
+		}
+		e.tags()
+	}
+	if version >= 8 && version <= 10 {
+		e.int32(0)
+	}
+	return e.tags().frame()
+}
+
+func TestMetadata(t *testing.T) {
+	for _, version := range []int16{0, 1, 8, 9, 11} {
+		results, kafka := kafkaModForTests()
+
+		private := parseMessages(kafka, nil, tcp.TCPDirectionOriginal, metadataRequest(version, 3))
+		parseMessages(kafka, private, tcp.TCPDirectionReverse, metadataResponse(version, 3, "orders", "payments"))
+
+		trans := expectTransaction(t, results)
+		assert.Equal(t, "Metadata", trans["method"])
+		assert.Equal(t, common.OK_STATUS, trans["status"])
+
+		fields := trans["kafka"].(common.MapStr)
+		assert.Equal(t, []string{"orders", "payments"}, fields["topics"])
+		assert.NotContains(t, fields, "partitions")
+	}
+}
+
+func TestApiVersions_Error(t *testing.T) {
+	results, kafka := kafkaModForTests()
+
+	requ := requestHeader(apiApiVersions, 3, 0, "client").string("sarama").string("1.0").tags().frame()
+	resp := responseHeader(apiApiVersions, 3, 0).int16(35).array(0).int32(0).tags().frame()
+
+	private := parseMessages(kafka, nil, tcp.TCPDirectionOriginal, requ)
+	parseMessages(kafka, private, tcp.TCPDirectionReverse, resp)
+
+	trans := expectTransaction(t, results)
+	assert.Equal(t, "ApiVersions", trans["method"])
+	fields := trans["kafka"].(common.MapStr)
+	assert.Equal(t, "UNSUPPORTED_VERSION", fields["error"])
+}
+
+func TestSplitMessages(t *testing.T) {
+	results, kafka := kafkaModForTests()
+
+	requ := produceRequest(7, 1, 1)
+	resp := produceResponse(7, 1, 0)
+
+	// two requests in a single segment, the second one split in two
+	pipelined := append(append([]byte{}, requ...), produceRequest(7, 2, 1)...)
+	private := parseMessages(kafka, nil, tcp.TCPDirectionOriginal,
+		pipelined[:len(requ)+5], pipelined[len(requ)+5:])
+	parseMessages(kafka, private, tcp.TCPDirectionReverse,
+		resp[:3], resp[3:], produceResponse(7, 2, 0))
+
+	assert.Len(t, results.events, 2)
+	first := expectTransaction(t, results)
+	second := expectTransaction(t, results)
+	assert.Equal(t, int32(1), first["kafka"].(common.MapStr)["correlation_id"])
+	assert.Equal(t, int32(2), second["kafka"].(common.MapStr)["correlation_id"])
+}
+
+func TestReverseDirection(t *testing.T) {
+	results, kafka := kafkaModForTests()
+
+	// The first packet seen is the response, so the broker side of the
+	// connection is the original direction.
+	tuple := testTCPTuple()
+	tuple.SrcPort, tuple.DstPort = tuple.DstPort, tuple.SrcPort
+
+	requ := &protos.Packet{Ts: time.Now(), Payload: metadataRequest(1, 9, "orders")}
+	resp := &protos.Packet{Ts: time.Now(), Payload: metadataResponse(1, 9, "orders")}
+	private := kafka.Parse(requ, tuple, tcp.TCPDirectionReverse, nil)
+	kafka.Parse(resp, tuple, tcp.TCPDirectionOriginal, private)
+
+	trans := expectTransaction(t, results)
+	assert.Equal(t, "Metadata", trans["method"])
+	pbf, err := pb.GetFields(trans)
+	if assert.NoError(t, err) {
+		assert.EqualValues(t, 9092, pbf.Destination.Port)
+	}
+}
+
+func TestUnmatched(t *testing.T) {
+	results, kafka := kafkaModForTests()
+
+	private := parseMessages(kafka, nil, tcp.TCPDirectionOriginal,
+		metadataRequest(1, 1), metadataRequest(1, 2))
+	conn := private.(*kafkaConnectionData)
+	assert.Len(t, conn.requests, 2)
+
+	// unknown correlation ID
+	parseMessages(kafka, private, tcp.TCPDirectionReverse, metadataResponse(1, 7))
+	assert.Empty(t, results.events)
+	assert.Len(t, conn.requests, 2)
+
+	// the response to request 2 implies request 1 won't be answered
+	parseMessages(kafka, private, tcp.TCPDirectionReverse, metadataResponse(1, 2))
+	assert.Len(t, results.events, 1)
+	assert.Empty(t, conn.requests)
+	assert.Empty(t, conn.order)
+}
+
+func TestMaxPendingRequests(t *testing.T) {
+	_, kafka := kafkaModForTests()
+	kafka.maxPendingRequests = 2
+
+	private := parseMessages(kafka, nil, tcp.TCPDirectionOriginal,
+		metadataRequest(1, 1), metadataRequest(1, 2), metadataRequest(1, 3))
+	conn := private.(*kafkaConnectionData)
+	assert.Len(t, conn.requests, 2)
+	assert.Equal(t, []int32{2, 3}, conn.order)
+}
+
+func TestOversizedMessage(t *testing.T) {
+	results, kafka := kafkaModForTests()
+
+	// Only the header of a message larger than the stream buffer is
+	// reported, the rest of it is skipped.
+	header := requestHeader(apiProduce, 7, 4, "producer-1")
+	header.frame()
+	size := tcp.TCPMaxDataInStream + 100
+	header.buf[0], header.buf[1], header.buf[2], header.buf[3] =
+		byte(size>>24), byte(size>>16), byte(size>>8), byte(size)
+
+	private := parseMessages(kafka, nil, tcp.TCPDirectionOriginal, header.buf)
+	remaining := size + 4 - len(header.buf)
+	chunk := make([]byte, 1<<20)
+	for remaining > 0 {
+		n := len(chunk)
+		if n > remaining {
+			n = remaining
+		}
+		private = parseMessages(kafka, private, tcp.TCPDirectionOriginal, chunk[:n])
+		remaining -= n
+	}
+	conn := private.(*kafkaConnectionData)
+	assert.Contains(t, conn.requests, int32(4))
+
+	parseMessages(kafka, private, tcp.TCPDirectionReverse, produceResponse(7, 4, 0))
+	trans := expectTransaction(t, results)
+	fields := trans["kafka"].(common.MapStr)
+	assert.Equal(t, true, fields["truncated"])
+	assert.Equal(t, "producer-1", fields["client_id"])
+}
@@ -0,0 +1,403 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kafka
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+)
+
+var (
+	errInvalidSize    = errors.New("invalid kafka message size")
+	errUnknownAPI     = errors.New("unknown kafka api key")
+	errInvalidVersion = errors.New("invalid kafka api version")
+)
+
+// maxAPIVersion is an upper bound for the version of any API. It is used to
+// tell Kafka traffic apart from garbage when joining a stream mid-message.
+const maxAPIVersion = 32
+
+// kafkaMessage holds the information extracted from a single request or
+// response.
+type kafkaMessage struct {
+	ts time.Time
+
+	tcpTuple     common.TCPTuple
+	cmdlineTuple *common.ProcessTuple
+	direction    uint8
+
+	isRequest bool
+	size      int
+
+	// request header fields. For responses only the correlation ID is sent
+	// on the wire, the remaining fields are taken from the request.
+	apiKey        apiKey
+	apiVersion    int16
+	correlationID int32
+	clientID      string
+
+	// acks is the number of acknowledgements requested by a Produce request.
+	// No response is sent when it is 0.
+	acks int16
+
+	topics     []string
+	partitions []string
+
+	// errorCode holds the first non-zero error code found in a response.
+	errorCode int16
+
+	// truncated is set when the message was too large to be buffered and
+	// its body has not been parsed.
+	truncated bool
+}
+
+// expectsResponse reports whether the broker is going to answer the request.
+// For truncated Produce requests acks is unknown, a response is assumed.
+func (m *kafkaMessage) expectsResponse() bool {
+	return m.apiKey != apiProduce || m.truncated || m.acks != 0
+}
+
+func (m *kafkaMessage) addTopic(topic string) {
+	if topic == "" {
+		return
+	}
+	for _, t := range m.topics {
+		if t == topic {
+			return
+		}
+	}
+	m.topics = append(m.topics, topic)
+}
+
+func (m *kafkaMessage) addPartition(topic string, partition int32) {
+	m.addTopic(topic)
+	if topic == "" {
+		return
+	}
+	m.partitions = append(m.partitions, topic+"-"+strconv.Itoa(int(partition)))
+}
+
+func (m *kafkaMessage) setError(code int16) {
+	if m.errorCode == 0 {
+		m.errorCode = code
+	}
+}
+
+// messageSize returns the total size of the message at the start of buf,
+// including the length prefix. It returns 0 if the length prefix hasn't been
+// received yet.
+func messageSize(buf []byte) (int, error) {
+	if len(buf) < 4 {
+		return 0, nil
+	}
+	d := newDecoder(buf)
+	size := int(d.int32())
+	if size < 4 {
+		return 0, errInvalidSize
+	}
+	return 4 + size, nil
+}
+
+// parseRequestHeader decodes the header of a request. buf must start right
+// after the length prefix. The returned decoder is positioned at the start
+// of the request body.
+func parseRequestHeader(m *kafkaMessage, buf []byte) (*decoder, error) {
+	d := newDecoder(buf)
+	m.apiKey = apiKey(d.int16())
+	m.apiVersion = d.int16()
+	m.correlationID = d.int32()
+	if d.err != nil {
+		return nil, d.err
+	}
+	if !m.apiKey.known() {
+		return nil, errUnknownAPI
+	}
+	if m.apiVersion < 0 || m.apiVersion > maxAPIVersion {
+		return nil, errInvalidVersion
+	}
+
+	m.clientID = d.legacyString()
+	d.flexible = isFlexible(m.apiKey, m.apiVersion)
+	d.skipTaggedFields()
+	return d, d.err
+}
+
+// parseResponseHeader decodes the correlation ID of a response. buf must
+// start right after the length prefix.
+func parseResponseHeader(m *kafkaMessage, buf []byte) error {
+	d := newDecoder(buf)
+	m.correlationID = d.int32()
+	return d.err
+}
+
+// parseRequestBody extracts the topics and partitions addressed by the
+// request. Only Produce, Fetch and Metadata bodies are inspected.
+func parseRequestBody(m *kafkaMessage, d *decoder) error {
+	switch m.apiKey {
+	case apiProduce:
+		parseProduceRequest(m, d)
+	case apiFetch:
+		parseFetchRequest(m, d)
+	case apiMetadata:
+		parseMetadataRequest(m, d)
+	}
+	return d.err
+}
+
+// parseResponseBody extracts topics and error codes from a response. buf
+// must start right after the length prefix. The API key and version must
+// have been copied from the matching request.
+func parseResponseBody(m *kafkaMessage, buf []byte) error {
+	d := newDecoder(buf)
+	d.skip(4) // correlation ID
+	d.flexible = isFlexible(m.apiKey, m.apiVersion)
+	if m.apiKey != apiApiVersions {
+		// ApiVersions responses always use header version 0, so that
+		// clients can parse them before knowing what the broker supports.
+		d.skipTaggedFields()
+	}
+
+	switch m.apiKey {
+	case apiProduce:
+		parseProduceResponse(m, d)
+	case apiFetch:
+		parseFetchResponse(m, d)
+	case apiMetadata:
+		parseMetadataResponse(m, d)
+	case apiApiVersions:
+		m.setError(d.int16())
+	}
+	return d.err
+}
+
+func parseProduceRequest(m *kafkaMessage, d *decoder) {
+	v := m.apiVersion
+	if v >= 3 {
+		d.string() // transactional_id
+	}
+	m.acks = d.int16()
+	d.int32() // timeout_ms
+
+	topics := d.arrayLength()
+	for i := 0; i < topics && d.err == nil; i++ {
+		topic := d.string()
+		m.addTopic(topic)
+		partitions := d.arrayLength()
+		for j := 0; j < partitions && d.err == nil; j++ {
+			m.addPartition(topic, d.int32())
+			d.skipBytes() // records
+			d.skipTaggedFields()
+		}
+		d.skipTaggedFields()
+	}
+}
+
+func parseProduceResponse(m *kafkaMessage, d *decoder) {
+	v := m.apiVersion
+	topics := d.arrayLength()
+	for i := 0; i < topics && d.err == nil; i++ {
+		m.addTopic(d.string())
+		partitions := d.arrayLength()
+		for j := 0; j < partitions && d.err == nil; j++ {
+			d.int32() // partition index
+			m.setError(d.int16())
+			d.int64() // base_offset
+			if v >= 2 {
+				d.int64() // log_append_time_ms
+			}
+			if v >= 5 {
+				d.int64() // log_start_offset
+			}
+			if v >= 8 {
+				errs := d.arrayLength()
+				for k := 0; k < errs && d.err == nil; k++ {
+					d.int32()  // batch_index
+					d.string() // batch_index_error_message
+					d.skipTaggedFields()
+				}
+				d.string() // error_message
+			}
+			d.skipTaggedFields()
+		}
+		d.skipTaggedFields()
+	}
+}
+
+func parseFetchRequest(m *kafkaMessage, d *decoder) {
+	v := m.apiVersion
+	if v < 15 {
+		d.int32() // replica_id
+	}
+	d.int32() // max_wait_ms
+	d.int32() // min_bytes
+	if v >= 3 {
+		d.int32() // max_bytes
+	}
+	if v >= 4 {
+		d.int8() // isolation_level
+	}
+	if v >= 7 {
+		d.int32() // session_id
+		d.int32() // session_epoch
+	}
+
+	topics := d.arrayLength()
+	for i := 0; i < topics && d.err == nil; i++ {
+		var topic string
+		if v >= 13 {
+			// Topics are referenced by their ID only.
+			d.skipUUID()
+		} else {
+			topic = d.string()
+			m.addTopic(topic)
+		}
+		partitions := d.arrayLength()
+		for j := 0; j < partitions && d.err == nil; j++ {
+			m.addPartition(topic, d.int32())
+			if v >= 9 {
+				d.int32() // current_leader_epoch
+			}
+			d.int64() // fetch_offset
+			if v >= 12 {
+				d.int32() // last_fetched_epoch
+			}
+			if v >= 5 {
+				d.int64() // log_start_offset
+			}
+			d.int32() // partition_max_bytes
+			d.skipTaggedFields()
+		}
+		d.skipTaggedFields()
+	}
+}
+
+func parseFetchResponse(m *kafkaMessage, d *decoder) {
+	v := m.apiVersion
+	if v >= 1 {
+		d.int32() // throttle_time_ms
+	}
+	if v >= 7 {
+		m.setError(d.int16())
+		d.int32() // session_id
+	}
+
+	topics := d.arrayLength()
+	for i := 0; i < topics && d.err == nil; i++ {
+		if v >= 13 {
+			d.skipUUID()
+		} else {
+			m.addTopic(d.string())
+		}
+		partitions := d.arrayLength()
+		for j := 0; j < partitions && d.err == nil; j++ {
+			d.int32() // partition_index
+			m.setError(d.int16())
+			d.int64() // high_watermark
+			if v >= 4 {
+				d.int64() // last_stable_offset
+			}
+			if v >= 5 {
+				d.int64() // log_start_offset
+			}
+			if v >= 4 {
+				aborted := d.arrayLength()
+				for k := 0; k < aborted && d.err == nil; k++ {
+					d.int64() // producer_id
+					d.int64() // first_offset
+					d.skipTaggedFields()
+				}
+			}
+			if v >= 11 {
+				d.int32() // preferred_read_replica
+			}
+			d.skipBytes() // records
+			d.skipTaggedFields()
+		}
+		d.skipTaggedFields()
+	}
+}
+
+func parseMetadataRequest(m *kafkaMessage, d *decoder) {
+	v := m.apiVersion
+	topics := d.arrayLength()
+	for i := 0; i < topics && d.err == nil; i++ {
+		if v >= 10 {
+			d.skipUUID()
+		}
+		m.addTopic(d.string())
+		d.skipTaggedFields()
+	}
+}
+
+func parseMetadataResponse(m *kafkaMessage, d *decoder) {
+	v := m.apiVersion
+	if v >= 3 {
+		d.int32() // throttle_time_ms
+	}
+
+	brokers := d.arrayLength()
+	for i := 0; i < brokers && d.err == nil; i++ {
+		d.int32()  // node_id
+		d.string() // host
+		d.int32()  // port
+		if v >= 1 {
+			d.string() // rack
+		}
+		d.skipTaggedFields()
+	}
+	if v >= 2 {
+		d.string() // cluster_id
+	}
+	if v >= 1 {
+		d.int32() // controller_id
+	}
+
+	topics := d.arrayLength()
+	for i := 0; i < topics && d.err == nil; i++ {
+		m.setError(d.int16())
+		m.addTopic(d.string())
+		if v >= 10 {
+			d.skipUUID()
+		}
+		if v >= 1 {
+			d.bool() // is_internal
+		}
+		partitions := d.arrayLength()
+		for j := 0; j < partitions && d.err == nil; j++ {
+			m.setError(d.int16())
+			d.int32() // partition_index
+			d.int32() // leader_id
+			if v >= 7 {
+				d.int32() // leader_epoch
+			}
+			d.skipInt32Array() // replica_nodes
+			d.skipInt32Array() // isr_nodes
+			if v >= 5 {
+				d.skipInt32Array() // offline_replicas
+			}
+			d.skipTaggedFields()
+		}
+		if v >= 8 {
+			d.int32() // topic_authorized_operations
+		}
+		d.skipTaggedFields()
+	}
+}
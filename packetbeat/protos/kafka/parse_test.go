@@ -0,0 +1,202 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+
+package kafka
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// encoder builds Kafka messages for the tests.
+type encoder struct {
+	buf      []byte
+	flexible bool
+}
+
+func (e *encoder) int8(v int8) *encoder {
+	e.buf = append(e.buf, byte(v))
+	return e
+}
+
+func (e *encoder) int16(v int16) *encoder {
+	e.buf = append(e.buf, 0, 0)
+	binary.BigEndian.PutUint16(e.buf[len(e.buf)-2:], uint16(v))
+	return e
+}
+
+func (e *encoder) int32(v int32) *encoder {
+	e.buf = append(e.buf, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(e.buf[len(e.buf)-4:], uint32(v))
+	return e
+}
+
+func (e *encoder) int64(v int64) *encoder {
+	e.buf = append(e.buf, 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint64(e.buf[len(e.buf)-8:], uint64(v))
+	return e
+}
+
+func (e *encoder) uvarint(v uint64) *encoder {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	e.buf = append(e.buf, tmp[:n]...)
+	return e
+}
+
+func (e *encoder) string(s string) *encoder {
+	if e.flexible {
+		e.uvarint(uint64(len(s) + 1))
+	} else {
+		e.int16(int16(len(s)))
+	}
+	e.buf = append(e.buf, s...)
+	return e
+}
+
+func (e *encoder) bytes(b []byte) *encoder {
+	if e.flexible {
+		e.uvarint(uint64(len(b) + 1))
+	} else {
+		e.int32(int32(len(b)))
+	}
+	e.buf = append(e.buf, b...)
+	return e
+}
+
+func (e *encoder) array(n int) *encoder {
+	if e.flexible {
+		return e.uvarint(uint64(n + 1))
+	}
+	return e.int32(int32(n))
+}
+
+func (e *encoder) tags() *encoder {
+	if e.flexible {
+		e.uvarint(0)
+	}
+	return e
+}
+
+// requestHeader starts a request. The client ID is never compact encoded.
+func requestHeader(key apiKey, version int16, correlationID int32, clientID string) *encoder {
+	e := &encoder{}
+	e.int32(0) // size, set by frame
+	e.int16(int16(key)).int16(version).int32(correlationID)
+	e.string(clientID)
+	e.flexible = isFlexible(key, version)
+	return e.tags()
+}
+
+func responseHeader(key apiKey, version int16, correlationID int32) *encoder {
+	e := &encoder{}
+	e.int32(0) // size, set by frame
+	e.int32(correlationID)
+	e.flexible = isFlexible(key, version)
+	if key != apiApiVersions {
+		e.tags()
+	}
+	return e
+}
+
+// frame sets the size prefix and returns the encoded message.
+func (e *encoder) frame() []byte {
+	binary.BigEndian.PutUint32(e.buf, uint32(len(e.buf)-4))
+	return e.buf
+}
+
+func TestDecoder_StickyErrors(t *testing.T) {
+	d := newDecoder([]byte{0, 1, 0})
+	assert.Equal(t, int16(1), d.int16())
+	assert.Equal(t, int32(0), d.int32())
+	assert.Equal(t, errTruncated, d.err)
+	assert.Equal(t, int16(0), d.int16())
+	assert.Equal(t, errTruncated, d.err)
+}
+
+func TestDecoder_CompactString(t *testing.T) {
+	e := &encoder{flexible: true}
+	e.string("orders").uvarint(0).string("")
+	d := newDecoder(e.buf)
+	d.flexible = true
+	assert.Equal(t, "orders", d.string())
+	assert.Equal(t, "", d.string()) // null
+	assert.Equal(t, "", d.string())
+	assert.NoError(t, d.err)
+}
+
+func TestDecoder_TaggedFields(t *testing.T) {
+	e := &encoder{flexible: true}
+	e.uvarint(2)
+	e.uvarint(0).uvarint(3)
+	e.buf = append(e.buf, 1, 2, 3)
+	e.uvarint(1).uvarint(0)
+	e.int16(42)
+
+	d := newDecoder(e.buf)
+	d.flexible = true
+	d.skipTaggedFields()
+	assert.Equal(t, int16(42), d.int16())
+	assert.NoError(t, d.err)
+}
+
+func TestDecoder_InvalidArrayLength(t *testing.T) {
+	e := &encoder{}
+	e.int32(1 << 20)
+	d := newDecoder(e.buf)
+	assert.Equal(t, -1, d.arrayLength())
+	assert.Equal(t, errInvalidLength, d.err)
+}
+
+func TestParseRequestHeader(t *testing.T) {
+	msg := requestHeader(apiMetadata, 9, 7, "consumer-1").frame()
+
+	var m kafkaMessage
+	d, err := parseRequestHeader(&m, msg[4:])
+	if assert.NoError(t, err) {
+		assert.Equal(t, apiMetadata, m.apiKey)
+		assert.Equal(t, int16(9), m.apiVersion)
+		assert.Equal(t, int32(7), m.correlationID)
+		assert.Equal(t, "consumer-1", m.clientID)
+		assert.True(t, d.flexible)
+	}
+}
+
+func TestParseRequestHeader_UnknownAPI(t *testing.T) {
+	msg := requestHeader(apiKey(999), 0, 1, "client").frame()
+
+	var m kafkaMessage
+	_, err := parseRequestHeader(&m, msg[4:])
+	assert.Equal(t, errUnknownAPI, err)
+}
+
+func TestMessageSize(t *testing.T) {
+	size, err := messageSize([]byte{0, 0})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, size)
+
+	size, err = messageSize([]byte{0, 0, 0, 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 14, size)
+
+	_, err = messageSize([]byte{0xff, 0, 0, 0})
+	assert.Equal(t, errInvalidSize, err)
+}
@@ -0,0 +1,92 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package publish
+
+import (
+	"net"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/packetbeat/pb"
+)
+
+// InterfaceTracker remembers the network interface the packets of a
+// connection have last been captured on. It is used to tag transaction
+// events with the interface when sniffing on multiple devices.
+type InterfaceTracker struct {
+	timeout time.Duration
+	cache   *common.Cache
+}
+
+// NewInterfaceTracker creates a new InterfaceTracker forgetting about
+// connections that have not seen any packets for the given timeout.
+func NewInterfaceTracker(timeout time.Duration) *InterfaceTracker {
+	return &InterfaceTracker{
+		timeout: timeout,
+		cache:   common.NewCache(timeout, 8192),
+	}
+}
+
+// Start starts the background cleanup of expired connections.
+func (t *InterfaceTracker) Start() {
+	t.cache.StartJanitor(t.timeout)
+}
+
+// Stop stops the background cleanup of expired connections.
+func (t *InterfaceTracker) Stop() {
+	t.cache.StopJanitor()
+}
+
+// Track records the device a packet with the given tuple has been captured
+// on. The hashables of the tuple must be computed.
+func (t *InterfaceTracker) Track(tuple *common.IPPortTuple, device string) {
+	key := tuple.Hashable()
+	if t.cache.Get(key) != device {
+		t.cache.Put(key, device)
+	}
+}
+
+// Lookup returns the device the connection of the given endpoints has been
+// captured on. An empty string is returned if the connection is unknown.
+func (t *InterfaceTracker) Lookup(fields *pb.Fields) string {
+	if fields.Source == nil || fields.Destination == nil {
+		return ""
+	}
+
+	srcIP, dstIP := net.ParseIP(fields.Source.IP), net.ParseIP(fields.Destination.IP)
+	if srcIP == nil || dstIP == nil {
+		return ""
+	}
+
+	tuple := common.IPPortTuple{IPLength: 16}
+	if src4, dst4 := srcIP.To4(), dstIP.To4(); src4 != nil && dst4 != nil {
+		srcIP, dstIP = src4, dst4
+		tuple.IPLength = 4
+	}
+	tuple.SrcIP, tuple.DstIP = srcIP, dstIP
+	tuple.SrcPort, tuple.DstPort = uint16(fields.Source.Port), uint16(fields.Destination.Port)
+	tuple.ComputeHashables()
+
+	if device, ok := t.cache.Get(tuple.Hashable()).(string); ok {
+		return device
+	}
+	if device, ok := t.cache.Get(tuple.RevHashable()).(string); ok {
+		return device
+	}
+	return ""
+}
@@ -41,6 +41,7 @@ type transProcessor struct {
 	localIPs         []net.IP // TODO: Periodically update this list.
 	internalNetworks []string
 	name             string
+	interfaces       *InterfaceTracker
 }
 
 var debugf = logp.MakeDebug("publish")
@@ -51,6 +52,7 @@ func NewTransactionPublisher(
 	ignoreOutgoing bool,
 	canDrop bool,
 	internalNetworks []string,
+	interfaces *InterfaceTracker,
 ) (*TransactionPublisher, error) {
 	addrs, err := common.LocalIPAddrs()
 	if err != nil {
@@ -72,6 +74,7 @@ func NewTransactionPublisher(
 			internalNetworks: internalNetworks,
 			name:             name,
 			ignoreOutgoing:   ignoreOutgoing,
+			interfaces:       interfaces,
 		},
 	}
 	return p, nil
@@ -164,6 +167,12 @@ func (p *transProcessor) Run(event *beat.Event) (*beat.Event, error) {
 				fields.Source.IP, fields.Destination.IP)
 			return nil, nil
 		}
+
+		if p.interfaces != nil {
+			if device := p.interfaces.Lookup(fields); device != "" {
+				event.PutValue("observer.ingress.interface.name", device)
+			}
+		}
 	}
 
 	return event, nil
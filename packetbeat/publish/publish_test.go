@@ -185,4 +185,27 @@ func TestPublish(t *testing.T) {
 			assert.Nil(t, res)
 		}
 	})
+
+	t.Run("interface", func(t *testing.T) {
+		tuple := common.IPPortTuple{IPLength: 4}
+		tuple.SrcIP, tuple.SrcPort = net.ParseIP(dstIP).To4(), 32232
+		tuple.DstIP, tuple.DstPort = net.ParseIP(srcIP).To4(), 3267
+		tuple.ComputeHashables()
+
+		interfaces := NewInterfaceTracker(time.Minute)
+		interfaces.Track(&tuple, "eth1")
+
+		processor := transProcessor{
+			interfaces: interfaces,
+			name:       "test",
+		}
+
+		res, _ := processor.Run(event())
+		if res == nil {
+			t.Fatalf("event has been filtered out")
+		}
+
+		device, _ := res.GetValue("observer.ingress.interface.name")
+		assert.Equal(t, "eth1", device)
+	})
 }
@@ -257,3 +257,46 @@ class Test(BaseTest):
         assert o["dns.opt.ext_rcode"] == "NOERROR"
         assert len(o["dns.answers"]) == 3
         assert all("ietf.org" in x["name"] for x in o["dns.answers"])
+
+    def test_udp_ipv4_fragmented(self):
+        """
+        Should reassemble a DNS response split into IPv4 fragments
+        that are received out of order.
+        """
+        self.render_config_template(
+            dns_ports=[53],
+        )
+        self.run_packetbeat(pcap="dns_udp_ipv4_fragmented.pcap")
+
+        objs = self.read_output()
+        assert len(objs) == 1
+        o = objs[0]
+
+        assert o["type"] == "dns"
+        assert o["network.type"] == "ipv4"
+        assert o["network.transport"] == "udp"
+        assert o["query"] == "class IN, type TXT, fragmented.example.com"
+        assert o["status"] == "OK"
+        assert o["destination.bytes"] == 3022
+        assert len(o["dns.answers"]) == 14
+
+    def test_udp_ipv6_fragmented(self):
+        """
+        Should reassemble a DNS response split into IPv6 fragments.
+        """
+        self.render_config_template(
+            dns_ports=[53],
+        )
+        self.run_packetbeat(pcap="dns_udp_ipv6_fragmented.pcap")
+
+        objs = self.read_output()
+        assert len(objs) == 1
+        o = objs[0]
+
+        assert o["type"] == "dns"
+        assert o["network.type"] == "ipv6"
+        assert o["network.transport"] == "udp"
+        assert o["query"] == "class IN, type TXT, fragmented.example.com"
+        assert o["status"] == "OK"
+        assert o["destination.bytes"] == 3022
+        assert len(o["dns.answers"]) == 14
@@ -0,0 +1,412 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// +build linux
+
+package cron
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/gofrs/uuid"
+	"github.com/joeshaw/multierror"
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/v7/auditbeat/datastore"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/libbeat/logp"
+	"github.com/elastic/beats/v7/metricbeat/mb"
+	"github.com/elastic/beats/v7/x-pack/auditbeat/cache"
+	"github.com/elastic/beats/v7/x-pack/auditbeat/module/system"
+)
+
+const (
+	moduleName    = "system"
+	metricsetName = "cron"
+	namespace     = "system.audit.cron"
+
+	bucketName              = "cron.v1"
+	bucketKeyEntries        = "entries"
+	bucketKeyStateTimestamp = "state_timestamp"
+
+	eventTypeState = "state"
+	eventTypeEvent = "event"
+)
+
+type eventAction uint8
+
+const (
+	eventActionExistingEntry eventAction = iota
+	eventActionEntryAdded
+	eventActionEntryRemoved
+	eventActionEntryChanged
+)
+
+func (action eventAction) String() string {
+	switch action {
+	case eventActionExistingEntry:
+		return "existing_cron_entry"
+	case eventActionEntryAdded:
+		return "cron_entry_added"
+	case eventActionEntryRemoved:
+		return "cron_entry_removed"
+	case eventActionEntryChanged:
+		return "cron_entry_changed"
+	default:
+		return ""
+	}
+}
+
+func (action eventAction) Type() string {
+	switch action {
+	case eventActionExistingEntry:
+		return "info"
+	case eventActionEntryAdded:
+		return "creation"
+	case eventActionEntryRemoved:
+		return "deletion"
+	case eventActionEntryChanged:
+		return "change"
+	default:
+		return "info"
+	}
+}
+
+// Entry represents an entry of a crontab.
+type Entry struct {
+	Schedule string
+	User     string
+	Command  string
+	Path     string
+	Line     int
+}
+
+// Hash creates a hash for Entry.
+func (entry Entry) Hash() uint64 {
+	h := xxhash.New()
+	// Ignore the line number, it changes when other entries are edited.
+	h.WriteString(entry.Schedule)
+	h.WriteString(entry.User)
+	h.WriteString(entry.Command)
+	h.WriteString(entry.Path)
+	return h.Sum64()
+}
+
+// key identifies an entry independent of its schedule.
+func (entry Entry) key() string {
+	return entry.Path + "\x00" + entry.User + "\x00" + entry.Command
+}
+
+func (entry Entry) toMapStr() common.MapStr {
+	return common.MapStr{
+		"schedule": entry.Schedule,
+		"user":     entry.User,
+		"command":  entry.Command,
+		"path":     entry.Path,
+		"line":     entry.Line,
+	}
+}
+
+// entityID creates an ID that uniquely identifies this entry across machines.
+func (entry Entry) entityID(hostID string) string {
+	h := system.NewEntityHash()
+	h.Write([]byte(hostID))
+	h.Write([]byte(entry.Path))
+	h.Write([]byte(entry.User))
+	h.Write([]byte(entry.Command))
+	return h.Sum()
+}
+
+func init() {
+	mb.Registry.MustAddMetricSet(moduleName, metricsetName, New,
+		mb.WithNamespace(namespace),
+	)
+}
+
+// MetricSet collects data about the crontab entries of a system.
+type MetricSet struct {
+	system.SystemMetricSet
+	config    config
+	log       *logp.Logger
+	cache     *cache.Cache
+	bucket    datastore.Bucket
+	lastState time.Time
+}
+
+// New constructs a new MetricSet.
+func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	cfgwarn.Beta("The %v/%v dataset is beta", moduleName, metricsetName)
+	config := defaultConfig()
+	if err := base.Module().UnpackConfig(&config); err != nil {
+		return nil, errors.Wrapf(err, "failed to unpack the %v/%v config", moduleName, metricsetName)
+	}
+
+	bucket, err := datastore.OpenBucket(bucketName)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open persistent datastore")
+	}
+
+	ms := &MetricSet{
+		SystemMetricSet: system.NewSystemMetricSet(base),
+		config:          config,
+		log:             logp.NewLogger(metricsetName),
+		cache:           cache.New(),
+		bucket:          bucket,
+	}
+
+	// Load from disk: Time when state was last sent
+	err = bucket.Load(bucketKeyStateTimestamp, func(blob []byte) error {
+		if len(blob) > 0 {
+			return ms.lastState.UnmarshalBinary(blob)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ms.lastState.IsZero() {
+		ms.log.Debugf("Last state was sent at %v. Next state update by %v.", ms.lastState, ms.lastState.Add(ms.config.effectiveStatePeriod()))
+	} else {
+		ms.log.Debug("No state timestamp found")
+	}
+
+	// Load from disk: Entries
+	entries, err := ms.restoreEntriesFromDisk()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to restore cron entries from disk")
+	}
+	ms.log.Debugf("Restored %d cron entries from disk", len(entries))
+
+	ms.cache.DiffAndUpdateCache(convertToCacheable(entries))
+
+	return ms, nil
+}
+
+// Close cleans up the MetricSet when it finishes.
+func (ms *MetricSet) Close() error {
+	if ms.bucket != nil {
+		return ms.bucket.Close()
+	}
+	return nil
+}
+
+// Fetch collects the crontab entries. It is invoked periodically.
+func (ms *MetricSet) Fetch(report mb.ReporterV2) {
+	needsStateUpdate := time.Since(ms.lastState) > ms.config.effectiveStatePeriod()
+	if needsStateUpdate || ms.cache.IsEmpty() {
+		ms.log.Debugf("State update needed (needsStateUpdate=%v, cache.IsEmpty()=%v)", needsStateUpdate, ms.cache.IsEmpty())
+		err := ms.reportState(report)
+		if err != nil {
+			ms.log.Error(err)
+			report.Error(err)
+		}
+		ms.log.Debugf("Next state update by %v", ms.lastState.Add(ms.config.effectiveStatePeriod()))
+	}
+
+	err := ms.reportChanges(report)
+	if err != nil {
+		ms.log.Error(err)
+		report.Error(err)
+	}
+}
+
+// reportState reports all existing crontab entries.
+func (ms *MetricSet) reportState(report mb.ReporterV2) error {
+	var errs multierror.Errors
+	ms.lastState = time.Now()
+
+	entries, err := getEntries()
+	if err != nil {
+		errs = append(errs, errors.Wrap(err, "error while getting cron entries"))
+	}
+
+	ms.log.Debugf("Found %v cron entries", len(entries))
+	if len(entries) > 0 {
+		stateID, err := uuid.NewV4()
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "error generating state ID"))
+		}
+
+		for _, entry := range entries {
+			event := ms.entryEvent(entry, eventTypeState, eventActionExistingEntry)
+			event.RootFields.Put("event.id", stateID.String())
+			report.Event(event)
+		}
+
+		ms.cache.DiffAndUpdateCache(convertToCacheable(entries))
+
+		// Save time so we know when to send the state again (config.StatePeriod)
+		timeBytes, err := ms.lastState.MarshalBinary()
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			err = ms.bucket.Store(bucketKeyStateTimestamp, timeBytes)
+			if err != nil {
+				errs = append(errs, errors.Wrap(err, "error writing state timestamp to disk"))
+			}
+		}
+
+		err = ms.saveEntriesToDisk(entries)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs.Err()
+}
+
+// reportChanges detects and reports any changes to crontab entries since the last call.
+func (ms *MetricSet) reportChanges(report mb.ReporterV2) error {
+	var errs multierror.Errors
+
+	entries, err := getEntries()
+	if err != nil {
+		// Crontabs that can't be read are reported, the others are
+		// still checked for changes.
+		errs = append(errs, errors.Wrap(err, "error while getting cron entries"))
+	}
+	ms.log.Debugf("Found %v cron entries", len(entries))
+
+	newInCache, missingFromCache := ms.cache.DiffAndUpdateCache(convertToCacheable(entries))
+
+	// Entries with the same command in the same crontab that are both new
+	// and missing have been rescheduled.
+	missingEntries := make(map[string][]*Entry, len(missingFromCache))
+	for _, missing := range missingFromCache {
+		entry := missing.(*Entry)
+		missingEntries[entry.key()] = append(missingEntries[entry.key()], entry)
+	}
+
+	for _, added := range newInCache {
+		entry := added.(*Entry)
+		if old := missingEntries[entry.key()]; len(old) > 0 {
+			report.Event(ms.entryEvent(entry, eventTypeEvent, eventActionEntryChanged))
+			missingEntries[entry.key()] = old[1:]
+		} else {
+			report.Event(ms.entryEvent(entry, eventTypeEvent, eventActionEntryAdded))
+		}
+	}
+
+	for _, entries := range missingEntries {
+		for _, entry := range entries {
+			report.Event(ms.entryEvent(entry, eventTypeEvent, eventActionEntryRemoved))
+		}
+	}
+
+	if len(newInCache) > 0 || len(missingFromCache) > 0 {
+		if err = ms.saveEntriesToDisk(entries); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs.Err()
+}
+
+func (ms *MetricSet) entryEvent(entry *Entry, eventType string, action eventAction) mb.Event {
+	event := mb.Event{
+		RootFields: common.MapStr{
+			"event": common.MapStr{
+				"kind":     eventType,
+				"category": []string{"host"},
+				"type":     []string{action.Type()},
+				"action":   action.String(),
+			},
+			"related": common.MapStr{
+				"user": []string{entry.User},
+			},
+			"message": entryMessage(entry, action),
+		},
+		MetricSetFields: entry.toMapStr(),
+	}
+
+	if ms.HostID() != "" {
+		event.MetricSetFields.Put("entity_id", entry.entityID(ms.HostID()))
+	}
+
+	return event
+}
+
+func entryMessage(entry *Entry, action eventAction) string {
+	var actionString string
+	switch action {
+	case eventActionExistingEntry:
+		actionString = "Existing"
+	case eventActionEntryAdded:
+		actionString = "New"
+	case eventActionEntryRemoved:
+		actionString = "Removed"
+	case eventActionEntryChanged:
+		actionString = "Changed"
+	}
+
+	return fmt.Sprintf("%v cron entry of %v in %v: %v %v",
+		actionString, entry.User, entry.Path, entry.Schedule, entry.Command)
+}
+
+func convertToCacheable(entries []*Entry) []cache.Cacheable {
+	c := make([]cache.Cacheable, 0, len(entries))
+
+	for _, e := range entries {
+		c = append(c, e)
+	}
+
+	return c
+}
+
+// restoreEntriesFromDisk loads the cron entry cache from disk.
+func (ms *MetricSet) restoreEntriesFromDisk() (entries []*Entry, err error) {
+	var decoder *gob.Decoder
+	err = ms.bucket.Load(bucketKeyEntries, func(blob []byte) error {
+		if len(blob) > 0 {
+			buf := bytes.NewBuffer(blob)
+			decoder = gob.NewDecoder(buf)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if decoder != nil {
+		for {
+			entry := new(Entry)
+			err = decoder.Decode(entry)
+			if err == nil {
+				entries = append(entries, entry)
+			} else if err == io.EOF {
+				// Read all entries
+				break
+			} else {
+				return nil, errors.Wrap(err, "error decoding cron entries")
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// saveEntriesToDisk saves the cron entry cache to disk.
+func (ms *MetricSet) saveEntriesToDisk(entries []*Entry) error {
+	var buf bytes.Buffer
+	encoder := gob.NewEncoder(&buf)
+
+	for _, entry := range entries {
+		err := encoder.Encode(*entry)
+		if err != nil {
+			return errors.Wrap(err, "error encoding cron entries")
+		}
+	}
+
+	err := ms.bucket.Store(bucketKeyEntries, buf.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "error writing cron entries to disk")
+	}
+	return nil
+}
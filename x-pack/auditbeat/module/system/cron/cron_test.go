@@ -0,0 +1,120 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// +build linux
+
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/v7/auditbeat/core"
+	abtest "github.com/elastic/beats/v7/auditbeat/testing"
+	mbtest "github.com/elastic/beats/v7/metricbeat/mb/testing"
+)
+
+func TestData(t *testing.T) {
+	defer setupTestdata()()
+	defer abtest.SetupDataDir(t)()
+
+	f := mbtest.NewReportingMetricSetV2(t, getConfig())
+	defer f.(*MetricSet).bucket.DeleteBucket()
+
+	events, errs := mbtest.ReportingFetchV2(f)
+	if len(errs) > 0 {
+		t.Fatalf("received error: %+v", errs[0])
+	}
+
+	if len(events) != 6 {
+		t.Fatalf("expected 6 events, got %d", len(events))
+	}
+
+	fullEvent := mbtest.StandardizeEvent(f, events[0], core.AddDatasetToEvent)
+	mbtest.WriteEventToDataJSON(t, fullEvent, "")
+}
+
+func TestGetEntries(t *testing.T) {
+	defer setupTestdata()()
+
+	entries, err := getEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actual []Entry
+	for _, entry := range entries {
+		actual = append(actual, *entry)
+	}
+	assert.Equal(t, []Entry{
+		{Schedule: "17 * * * *", User: "root", Command: "cd / && run-parts --report /etc/cron.hourly", Path: "testdata/etc/crontab", Line: 6},
+		{Schedule: "25 6 * * *", User: "root", Command: "test -x /usr/sbin/anacron || ( cd / && run-parts --report /etc/cron.daily )", Path: "testdata/etc/crontab", Line: 7},
+		{Schedule: "@reboot", User: "root", Command: "/usr/local/bin/backup --verify", Path: "testdata/etc/cron.d/backup", Line: 2},
+		{Schedule: "*/15 * * * *", User: "backup", Command: "/usr/local/bin/backup --incremental", Path: "testdata/etc/cron.d/backup", Line: 3},
+		{Schedule: "0 5 * * 1", User: "alice", Command: "tar -zcf /var/backups/home.tgz /home/", Path: "testdata/spool/crontabs/alice", Line: 2},
+		{Schedule: "@hourly", User: "alice", Command: "curl -s http://example.com/ping > /dev/null", Path: "testdata/spool/crontabs/alice", Line: 3},
+	}, actual)
+}
+
+func TestReportChanges(t *testing.T) {
+	defer setupTestdata()()
+	defer abtest.SetupDataDir(t)()
+
+	f := mbtest.NewReportingMetricSetV2(t, getConfig())
+	ms := f.(*MetricSet)
+	defer ms.bucket.DeleteBucket()
+
+	entries, err := getEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pretend alice's backup ran daily and there was another entry when
+	// the state was sent.
+	var previous []*Entry
+	for _, entry := range entries {
+		e := *entry
+		if e.User == "alice" && e.Schedule == "0 5 * * 1" {
+			e.Schedule = "0 5 * * *"
+		}
+		previous = append(previous, &e)
+	}
+	previous = append(previous, &Entry{Schedule: "* * * * *", User: "root", Command: "/tmp/.x/miner", Path: "testdata/etc/cron.d/backup"})
+	ms.lastState = time.Now()
+	ms.cache.DiffAndUpdateCache(convertToCacheable(previous))
+
+	events, errs := mbtest.ReportingFetchV2(f)
+	if len(errs) > 0 {
+		t.Fatalf("received error: %+v", errs[0])
+	}
+
+	actions := map[string]string{}
+	for _, e := range events {
+		action, _ := e.RootFields.GetValue("event.action")
+		command, _ := e.MetricSetFields.GetValue("command")
+		actions[command.(string)] = action.(string)
+	}
+	assert.Equal(t, map[string]string{
+		"tar -zcf /var/backups/home.tgz /home/": "cron_entry_changed",
+		"/tmp/.x/miner":                         "cron_entry_removed",
+	}, actions)
+}
+
+func setupTestdata() func() {
+	systemCrontabsOld, userCrontabDirsOld := systemCrontabs, userCrontabDirs
+	systemCrontabs = []string{"testdata/etc/crontab", "testdata/etc/cron.d"}
+	userCrontabDirs = []string{"testdata/spool/crontabs", "testdata/spool", "testdata/does/not/exist"}
+	return func() {
+		systemCrontabs, userCrontabDirs = systemCrontabsOld, userCrontabDirsOld
+	}
+}
+
+func getConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"module":   "system",
+		"datasets": []string{"cron"},
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// +build linux
+
+package cron
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joeshaw/multierror"
+	"github.com/pkg/errors"
+)
+
+var (
+	// System crontabs have a user field in each entry.
+	systemCrontabs = []string{"/etc/crontab", "/etc/cron.d"}
+
+	// Directories with the crontabs of users, named after the user. These
+	// are the locations of Debian, Red Hat, and BusyBox respectively.
+	userCrontabDirs = []string{"/var/spool/cron/crontabs", "/var/spool/cron", "/etc/crontabs"}
+)
+
+// getEntries returns the entries of all system and user crontabs. Errors
+// reading a crontab don't prevent reading the others.
+func getEntries() ([]*Entry, error) {
+	var entries []*Entry
+	var errs multierror.Errors
+	read := func(path, user string) {
+		fileEntries, err := readCrontab(path, user)
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		entries = append(entries, fileEntries...)
+	}
+
+	for _, path := range systemCrontabs {
+		files, err := crontabFiles(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, file := range files {
+			read(file, "")
+		}
+	}
+
+	for _, dir := range userCrontabDirs {
+		files, err := crontabFiles(dir)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, file := range files {
+			if file == dir {
+				// Not a directory.
+				continue
+			}
+			read(file, filepath.Base(file))
+		}
+	}
+
+	return entries, errs.Err()
+}
+
+// crontabFiles returns path if it is a file, or the files in it if it is a
+// directory. Hidden files and backup files ending with ~ are ignored, like
+// cron does.
+func crontabFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to stat %v", path)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	infos, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading directory %v", path)
+	}
+	var files []string
+	for _, info := range infos {
+		name := info.Name()
+		if !info.Mode().IsRegular() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+			continue
+		}
+		files = append(files, filepath.Join(path, name))
+	}
+	return files, nil
+}
+
+// readCrontab reads the entries of a crontab, see crontab(5). If user is
+// empty, the entries contain a user field after the schedule.
+func readCrontab(path, user string) ([]*Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Removed since the directory was read.
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "error opening %v", path)
+	}
+	defer file.Close()
+
+	var entries []*Entry
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		entry := parseCrontabLine(scanner.Text(), user == "")
+		if entry == nil {
+			continue
+		}
+		if user != "" {
+			entry.User = user
+		}
+		entry.Path = path
+		entry.Line = lineNumber
+		entries = append(entries, entry)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "error scanning file %v", path)
+	}
+
+	return entries, nil
+}
+
+// parseCrontabLine parses an entry of a crontab. It returns nil for empty
+// lines, comments, environment settings, and malformed entries.
+func parseCrontabLine(line string, hasUser bool) *Entry {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] == '#' {
+		return nil
+	}
+
+	// The schedule is either a nickname like @daily, or five time and date
+	// fields. Environment settings (NAME = value) start with a letter.
+	numScheduleFields := 5
+	switch c := line[0]; {
+	case c == '@':
+		numScheduleFields = 1
+	case c == '*' || (c >= '0' && c <= '9'):
+	default:
+		return nil
+	}
+	if hasUser {
+		numScheduleFields++
+	}
+
+	fields, command := splitFields(line, numScheduleFields)
+	if len(fields) < numScheduleFields || command == "" {
+		return nil
+	}
+
+	entry := &Entry{Command: command}
+	if hasUser {
+		entry.User = fields[len(fields)-1]
+		fields = fields[:len(fields)-1]
+	}
+	entry.Schedule = strings.Join(fields, " ")
+	return entry
+}
+
+// splitFields returns the first n whitespace separated fields of s, and the
+// rest of s.
+func splitFields(s string, n int) (fields []string, rest string) {
+	rest = s
+	for len(fields) < n {
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" {
+			break
+		}
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			end = len(rest)
+		}
+		fields = append(fields, rest[:end])
+		rest = rest[end:]
+	}
+	return fields, strings.TrimSpace(rest)
+}
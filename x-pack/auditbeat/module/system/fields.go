@@ -19,5 +19,5 @@ func init() {
 // AssetSystem returns asset data.
 // This is the base64 encoded gzipped contents of module/system.
 func AssetSystem() string {
-	return "eJy0Wl1v27AVffevuOhLE8BVELcJCj8MSJtiCdauwZwCfbMp8VriQpEaSSVRf/1A6sOSTdmWowoIYNPkOed+kLyk8gGesJiDLrTBdAJgmOE4h3cL1/BuAkBRR4plhkkxh39MAAAeE9QIRCGYBGHNkFMNMQpUxCCFsHDtJSakkuYcgwmAQo5E4xxCNGQC1cD5ZALwAQRJcQ74jMI4DlNkOIdYyTxz3+vO9nPdWyoWM+Ga6gFPWLxIRas2j3b7/HTjQK6dTscZwGPCNEREQIhAYM04QkZMAmcYxAGsLp6JuuAytn/B5ep82qBJ5WCspBqyMj2SaSYFCgMmIQZ0nmWcIXVdKDGkxhZoOBNPq/Og7YtcozraFSgMM8WS0eHeuL+FXLD/5cgLYNQCrQsmYqfSagApgEAitQng3oD1kkyz3EaaaCCwuLv5MLu6hoToZOOU0hF2FNzfTksg+4EIWn6xuoOODQZVygThw014rEbWtJag48tMyQi1PtqdLVu2u/eKuCM6Qd1k1StGuSEhR5taaO3QbsoQHkvFTJI6Ku0cYgc8E56j69IgOg/iK6CIJEUKlMWoTdXT2betf2NByMkTzsLl7Op6g+fx6JY5X77f/OvbLGwC6jFn0sP08fOnU5g+fv40lOnqcnYK09Xl7FgmnZDZbJA5i7ub2exoS3RCBrprcXczwFMWfzncAjdmGMew9Co5js8tx3GCp5ZDfTUwpRzHsHy6upydEJGry9nFsJg4nsFRcTzHx+X1NbkeZMrv39d7jWgMkNETHl8B/KVtr1RxeLurjGkwm+2uBAAmJMUpcBkRDvcP9adMKjMFhak06JrtHlB9tb91PeJqiYDklPn94rGvuyW0tjWpTdPo29r2+Ms+KwuwgkgKQ5ioaz5e2s3EWqqU2HFBa9R21Vc/2xpbhU9mWIod4lIplyLuNJeEc6C5crydH5nIcrOsuwgipMZICqo7vWRu2t2IviWFt0emMGLaOeWy8/sef9nnl7MGmGhLCDxmh1KaHsMpMTiE84uUBiyWj6eKHir2B6mHLJSSIxFD+BY219dVGthJ0nD4BFhhf6TAwH71CNheSI4Q8O9W8V3Dt2vQKbhK+8vica8guV5rNIHG6JjsO6DpcaPDotoM2BN9q3I8f9xVaD4m5gv6iRxwf+ujICpKmMHI5GpEgzqw1dnp9fP18vrTuU9ESnxRPIH7x81XIJQq1Bq9sWOZh2ir8QDH/cN+Cqk9FNsr9wGWldSttbu1XAMJZW7cZJGZPcTbfbDad7rr7c6a3V5WKO4k8D6vH/TJz0UDOrXLCxFFFXVtFJooOQ+8SjJOjLVtVCU1aKUgQmGknkIe5sLkU3hhgsoX3aNodL+4y4ZSyQ8S2ZbfPdRrkjJejEpeQlb0CmlCzBQohoyIKawVYqjpIY88o9LbG/ZbdVWYfsInVAL5eHyPnsnyXlc0+6VY1lENt6PhTCPCt68LkDqwDS3HNxODRE8kxjdVgBXG3oWECGBCG8I5UpDKlbbPSGv+t1WH2yX/IQfudd++Q0CtdvApoH6a00CFBOVKZov9usWTJ71rxokmPrTIfTy+mfhGqj1WVfEek62C7KtDxqRqFyA+Ps4iFONaV0F6y45yjo1yZqjpKszew4Nmf446mR1FZsG8JHmaElWcAFgO9GHmio8Zll//+b67vjY39m2KIYurBThYotlOuryU363Rjl9P/1Z1AvCre72/4yW2jfh2tu4xZMMVj8v1TxvLXjLK1NiGvdeQyBQtNEZGdlO7fe2HfMTaBuBByViRFIwElQsgBriMWU89YxNy2crVUT1e3TC5V0btGyb4KeA7E/nrFEzCtN2h7eSIMZK6zPaejNg5M9UKZfhfjMwwgSsHd6AYKkpSvXmjxjRkRBlbOJyFWMjqFVBeRjxTzK5i5ait+tk/k2H/bD4UhaMiAU3+705t2DvlNvRMGIxxe5YMpO+bfhnR2mNc31H5cGxrwP3hbaJW9YYzIU1VQFYtzGjk68GR9JwTYKxI3uzItrABPEitWcjbryNhpRNC5cuy8UcP5lnHaFcZ24kpyit1h+Heq59PN75dUqZJyJGupj2oKyE3zJajnOyUiBiVzLWrx0UhBbq391zGwMS5K7P7ECNVZKYN+pKg6IbMxcZqv0ATXbhmChox1T2gRtZZYo8/KByHO/OUiDvRb1WNRJtllFiD+qfOTjlXPkcF+9H9v0HRWWNqQ1+IdgKgEhBM/h8AAP//gcIhxw=="
+	return "eJzUW99v2zjyf/dfMdiXJoCrIt5NsMjDF5s2/W6DSzfBJcX1zaLFscWLROpIKon61x+GomTZoWwr0e7dbbJALFOfz/zicDhk38MDVudgKmMxnwBYYTM8h5/u3IOfJgAcTaJFYYWS5/B/EwCA+xQNAtMINkVYCsy4gRVK1Mwih0XlnteYkCteZhhNADRmyAyewwItm4B/8XwyAXgPkuV4DviI0joOWxV4DiutysJ9bgYDrEcrLVZCuq+bFx6welKa+2cB2en3xr0HaunkdJwR3KfCQMIkLBAYLEWGUDCbwhFGqwjiD49Mf8jUiv6PTuLjaYumtIMhkRpIr3qi8kJJlBZsyiyYsigygdwN58yyBluizYR8iI+jri1Kg/pgU6C0wlZzwYdb4+oSSin+VWJWgeAEtKyEXDkpSQZQEhikytgIriyQlVRelORpZoDB3ZeL97PTM0iZSVtQbwh6C64upzUQ/cEkrz+QktGGDhZ1LiTLhqtw799s7E8EG7YstErQmIPN2dFle3ivEF+YSdE0IuAzJqVliwwptJD0MG7KsGyltLBp7qiMMwi98MiyEt2QFpEep/gMKBPFkQMXKzTWj3T6bcu/1mCRsQecLeaz0zP/TdiiW+p8vL742+fZonVoQJ1JD9PPv/7yGqaff/1lKNPpyew1TKcns0OZTMpms0Hq3H25mM0O1sSkbKC57r5cDLAU4c+Ha/DzfKAOQ8OLtJgPiC3H8QpLzYfaamBIOT2GxdPpyewVHjk9mX0Y5hPHM9grjudwvzw/p2eDVPn+/WynEq0CKnlA+59e9mop9i93XpkWs13uagAQUnGcQqYSlsHVbfNXobSdgsZcWXSPaQ3wH+m7TYu4WiJiJRdhuwT021wS1liJVk25FELaaS/6jQkghkRJy4Q0IORS6ZxRFAFbqNICk4DS6oosw4CGW7aIOiDbRWDz37bI/T7u9/Re+Xd73Qt+ULWzBduN7DYIvPaujKwroLr8SVSeM8mjgK4mSZHK5TFU/UeK0kWz5yNddCmngMKmWBetS/GIYEWOTjDObFvOKw1d99CPFMkDiQmZeECIf+NMZFUMSkP8m8aFUjYO6dTWsW/U5xtVja6Q1qU0Xc1CrP6rMYg/efs57tqIIUZy8xh0t8y2sRSYQA1dJmQoTDIlV4dzXQuJIMt8gbpNzG4WCBnmb9gpyN+USQigk0nq3WPmthndtPK21FEWFNuHWKnOY+fAS+3S2caXQhalnTdDJJPKYKIkNxujVGm7w5i5ZFVwRKExEcbF+MnhrgL45rQBIbsiRAG1aSr2KE5TfAjnR6WsSxAhHu891OIHbgZ4HYsLpTJkcgjfHa2aSx8GlG9bjpAAJNgPJTGij2PMPYA/Otv4Br75TFJNwe3ZP97d7xRILZcGbWQwOST69sh0v5aDUCkCdnifpBzPHl88WohpnCW55oCryxAF00kqLCa21DgiWRfWd2Gefz2bn/1yHBIiZ8k43F8vPgHjXKMxGPSdKAJEohjCcXW7m0Jt5qRw5t7DEiuzswqkyaIKagdSbeUr2A2Mlzl7LSE1O14E8C6r77XJzV0LOqX0wmTlvW6sRpukx1FQkiJjlvL5qJI0oF6CBKVVZgrlopS2nMKTkFw9mR6JRrcLAXpJvrIEbu7gew/1kuUiq0YlryE9vUaeMjsFjgvB5BSWGnFh+D6LPKI22wv2W+XymGHCB9QSmyblCHz3gcnyznia3aIQ63iC3Ny5t+HIIMLnT3egTEQPOobftMG87u1P9mWTHbzxBtLOpMIgU4zaoN4y64OF1xeIf9neckPmPXvMLdhAw6HZY7pdm8fsW6RfZIxXavd1N40RP/CQUqcpjxeVRTOAHXOlK8fS2MDrLWSNFRJK4zJRpT9R2iPYTvo/2i2SxiVqlAkasKojxxREhBGk6gnU0qIE4QpYIWnjH5KtNMjni2oM11DN2h48+ElSW4e2ycwCxwIlp7CzdMr1cuY0QhnL7CjRcq0Yr9E23RXBTV1Px9fiEeMpxDRSyFU8dY2EbzLzn0Pi+WQ4hoA+nD2iqwrWUgLHJGOaTnNk2E46GVGWTykmD6bMG1MZVeqEOjG1A1upnpiBRSkyC0ut8pBc1JazY4j0/xlbtSH1xKoNORyL6eQ1vymKb2JYUuNoezVWpX2vlu+txhaDnP25GQ6lNGIl27CNJtuKFSx5YKu3LTUeY/ciI0FIY1mWIaeApK7sI/KG/39ktWmk3dXBDDawmx+/vkwbvV141d3L5omP/yig51hLzm2HPMQz4hS83a+V9/eYbB4yxEY71DGpujveEF8mEpTjauchQ2x+jo3SpGroPGZvt+rQCuUgMgILkpR5znT1CsD6xRBmqbMx3fLt79fRZJuj3nbweSn9KdNrk2wXaE8574fSgYx1i11X+f/i7OrkPexSzBZqXwnvEP/sAv5bQ9Ks1sakPDKoH0WC8V91ohF0du9+9i2q0rutql7LKcT10awvNylX6PhPrYQ/SzrkzukW2EY9vDZEWxKjG8qpKubC+L+34GICEUkMRy1IwuQ7S9fW/PvHU4hzZh5qJJYJZry6C8brF7dAW1loy8KAM7nK6mZEThfTjkMGskyv/En9Gy1075Be2IVKcuFPAbwgITk60GMIc7n+sitRiJmuMMyNZdqOeMrod2x0U4zwXVPgqTnJ9ZOVbOJ4MXj0uXERrz+Lh3LpGoRuQL3IX/2q7VEOKCFuNzFaT0eTbfatM+PhyxAB7Fx+mmuCtMVr16LXLUA7UvWbTOaPvLfXhY6VBB+d7eoyzLUal+t3ahH2knGhxyQjxd4ZSFWOwIXGxCpdhZlNitmIvV2AW61WmuXULNKlBGYhUyvR08+lgJx3YnVMQX73J+zE0Z0P1JGBayHL52ndHRKUghBWmChTp4iwsC/zSSOhWvwTEztMwNjB7a4YZVW3ls36brIwUDBtKaUcLbBS/jItfffOQKEFFdX1WxurWN9M3jOb93nhIE+s4//l1N495db0QlpcoX4bfd/0K5gxAeX6lpG9nHEDuNu9rdf8aDiSdPkgxfUTYQ1my8GeDJyTjObJixdiE2wEt8oYsci6F7shNinj6mneDI17MI82lHZrJk1MXwQ4DLdwUqXXDJv3lozNTyzVmpnKQMI0rtpDrUrjDh9kRRcN6N9BZGoFQh67rk8fYqKrwnZB21KleVb7hmT/gDb54B5zMIi5mQQQySuqiRLqxqF0HK4FVyMeR71+zpix8yQlhUL+7OkuDHA2ndVxVm3kmEZR6s6SAJCkTK6QR5N/DwCruttf"
 }
@@ -0,0 +1,30 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// +build linux
+
+package kernelmodule
+
+import (
+	"time"
+)
+
+// config defines the metricset's configuration options.
+type config struct {
+	StatePeriod             time.Duration `config:"state.period"`
+	KernelModuleStatePeriod time.Duration `config:"kernel_module.state.period"`
+}
+
+func (c *config) effectiveStatePeriod() time.Duration {
+	if c.KernelModuleStatePeriod != 0 {
+		return c.KernelModuleStatePeriod
+	}
+	return c.StatePeriod
+}
+
+func defaultConfig() config {
+	return config{
+		StatePeriod: 12 * time.Hour,
+	}
+}
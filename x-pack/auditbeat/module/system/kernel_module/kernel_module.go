@@ -0,0 +1,415 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// +build linux
+
+package kernelmodule
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/gofrs/uuid"
+	"github.com/joeshaw/multierror"
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/v7/auditbeat/datastore"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/libbeat/logp"
+	"github.com/elastic/beats/v7/metricbeat/mb"
+	"github.com/elastic/beats/v7/x-pack/auditbeat/cache"
+	"github.com/elastic/beats/v7/x-pack/auditbeat/module/system"
+)
+
+const (
+	moduleName    = "system"
+	metricsetName = "kernel_module"
+	namespace     = "system.audit.kernel_module"
+
+	bucketName              = "kernel_module.v1"
+	bucketKeyModules        = "modules"
+	bucketKeyStateTimestamp = "state_timestamp"
+
+	eventTypeState = "state"
+	eventTypeEvent = "event"
+)
+
+type eventAction uint8
+
+const (
+	eventActionExistingModule eventAction = iota
+	eventActionModuleAdded
+	eventActionModuleRemoved
+	eventActionModuleChanged
+)
+
+func (action eventAction) String() string {
+	switch action {
+	case eventActionExistingModule:
+		return "existing_kernel_module"
+	case eventActionModuleAdded:
+		return "kernel_module_added"
+	case eventActionModuleRemoved:
+		return "kernel_module_removed"
+	case eventActionModuleChanged:
+		return "kernel_module_changed"
+	default:
+		return ""
+	}
+}
+
+func (action eventAction) Type() string {
+	switch action {
+	case eventActionExistingModule:
+		return "info"
+	case eventActionModuleAdded:
+		return "start"
+	case eventActionModuleRemoved:
+		return "end"
+	case eventActionModuleChanged:
+		return "change"
+	default:
+		return "info"
+	}
+}
+
+// KernelModule represents a loaded kernel module.
+type KernelModule struct {
+	Name       string
+	Size       uint64
+	RefCount   int64
+	UsedBy     []string
+	State      string
+	Version    string
+	SrcVersion string
+	Taint      string
+}
+
+// Hash creates a hash for KernelModule.
+func (m KernelModule) Hash() uint64 {
+	h := xxhash.New()
+	// Ignore the reference count, load state, and dependent modules.
+	// They change whenever other modules are loaded or unloaded.
+	h.WriteString(m.Name)
+	binary.Write(h, binary.BigEndian, m.Size)
+	h.WriteString(m.Version)
+	h.WriteString(m.SrcVersion)
+	h.WriteString(m.Taint)
+	return h.Sum64()
+}
+
+func (m KernelModule) toMapStr() common.MapStr {
+	evt := common.MapStr{
+		"name":     m.Name,
+		"size":     m.Size,
+		"refcount": m.RefCount,
+		"state":    m.State,
+	}
+
+	if len(m.UsedBy) > 0 {
+		evt.Put("used_by", m.UsedBy)
+	}
+	if m.Version != "" {
+		evt.Put("version", m.Version)
+	}
+	if m.SrcVersion != "" {
+		evt.Put("srcversion", m.SrcVersion)
+	}
+	if m.Taint != "" {
+		evt.Put("taint", m.Taint)
+	}
+
+	return evt
+}
+
+// entityID creates an ID that uniquely identifies this kernel module across machines.
+func (m KernelModule) entityID(hostID string) string {
+	h := system.NewEntityHash()
+	h.Write([]byte(hostID))
+	h.Write([]byte(m.Name))
+	return h.Sum()
+}
+
+func init() {
+	mb.Registry.MustAddMetricSet(moduleName, metricsetName, New,
+		mb.WithNamespace(namespace),
+	)
+}
+
+// MetricSet collects data about the loaded kernel modules.
+type MetricSet struct {
+	system.SystemMetricSet
+	config    config
+	log       *logp.Logger
+	cache     *cache.Cache
+	bucket    datastore.Bucket
+	lastState time.Time
+}
+
+// New constructs a new MetricSet.
+func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	cfgwarn.Beta("The %v/%v dataset is beta", moduleName, metricsetName)
+	config := defaultConfig()
+	if err := base.Module().UnpackConfig(&config); err != nil {
+		return nil, errors.Wrapf(err, "failed to unpack the %v/%v config", moduleName, metricsetName)
+	}
+
+	bucket, err := datastore.OpenBucket(bucketName)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open persistent datastore")
+	}
+
+	ms := &MetricSet{
+		SystemMetricSet: system.NewSystemMetricSet(base),
+		config:          config,
+		log:             logp.NewLogger(metricsetName),
+		cache:           cache.New(),
+		bucket:          bucket,
+	}
+
+	// Load from disk: Time when state was last sent
+	err = bucket.Load(bucketKeyStateTimestamp, func(blob []byte) error {
+		if len(blob) > 0 {
+			return ms.lastState.UnmarshalBinary(blob)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ms.lastState.IsZero() {
+		ms.log.Debugf("Last state was sent at %v. Next state update by %v.", ms.lastState, ms.lastState.Add(ms.config.effectiveStatePeriod()))
+	} else {
+		ms.log.Debug("No state timestamp found")
+	}
+
+	// Load from disk: Kernel modules
+	modules, err := ms.restoreModulesFromDisk()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to restore kernel modules from disk")
+	}
+	ms.log.Debugf("Restored %d kernel modules from disk", len(modules))
+
+	ms.cache.DiffAndUpdateCache(convertToCacheable(modules))
+
+	return ms, nil
+}
+
+// Close cleans up the MetricSet when it finishes.
+func (ms *MetricSet) Close() error {
+	if ms.bucket != nil {
+		return ms.bucket.Close()
+	}
+	return nil
+}
+
+// Fetch collects the kernel module information. It is invoked periodically.
+func (ms *MetricSet) Fetch(report mb.ReporterV2) {
+	needsStateUpdate := time.Since(ms.lastState) > ms.config.effectiveStatePeriod()
+	if needsStateUpdate || ms.cache.IsEmpty() {
+		ms.log.Debugf("State update needed (needsStateUpdate=%v, cache.IsEmpty()=%v)", needsStateUpdate, ms.cache.IsEmpty())
+		err := ms.reportState(report)
+		if err != nil {
+			ms.log.Error(err)
+			report.Error(err)
+		}
+		ms.log.Debugf("Next state update by %v", ms.lastState.Add(ms.config.effectiveStatePeriod()))
+	}
+
+	err := ms.reportChanges(report)
+	if err != nil {
+		ms.log.Error(err)
+		report.Error(err)
+	}
+}
+
+// reportState reports all loaded kernel modules.
+func (ms *MetricSet) reportState(report mb.ReporterV2) error {
+	var errs multierror.Errors
+	ms.lastState = time.Now()
+
+	modules, err := getKernelModules()
+	if err != nil {
+		errs = append(errs, errors.Wrap(err, "error while getting kernel modules"))
+	}
+
+	ms.log.Debugf("Found %v kernel modules", len(modules))
+	if len(modules) > 0 {
+		stateID, err := uuid.NewV4()
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "error generating state ID"))
+		}
+
+		for _, module := range modules {
+			event := ms.moduleEvent(module, eventTypeState, eventActionExistingModule)
+			event.RootFields.Put("event.id", stateID.String())
+			report.Event(event)
+		}
+
+		ms.cache.DiffAndUpdateCache(convertToCacheable(modules))
+
+		// Save time so we know when to send the state again (config.StatePeriod)
+		timeBytes, err := ms.lastState.MarshalBinary()
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			err = ms.bucket.Store(bucketKeyStateTimestamp, timeBytes)
+			if err != nil {
+				errs = append(errs, errors.Wrap(err, "error writing state timestamp to disk"))
+			}
+		}
+
+		err = ms.saveModulesToDisk(modules)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs.Err()
+}
+
+// reportChanges detects and reports any changes to the loaded kernel modules since the last call.
+func (ms *MetricSet) reportChanges(report mb.ReporterV2) error {
+	modules, err := getKernelModules()
+	if err != nil {
+		return errors.Wrap(err, "error while getting kernel modules")
+	}
+	ms.log.Debugf("Found %v kernel modules", len(modules))
+
+	newInCache, missingFromCache := ms.cache.DiffAndUpdateCache(convertToCacheable(modules))
+
+	// Modules that are both new and missing have been changed, e.g.
+	// by unloading and reloading a different version.
+	missingModules := make(map[string]*KernelModule, len(missingFromCache))
+	for _, missing := range missingFromCache {
+		module := missing.(*KernelModule)
+		missingModules[module.Name] = module
+	}
+
+	for _, added := range newInCache {
+		module := added.(*KernelModule)
+		if _, found := missingModules[module.Name]; found {
+			report.Event(ms.moduleEvent(module, eventTypeEvent, eventActionModuleChanged))
+			delete(missingModules, module.Name)
+		} else {
+			report.Event(ms.moduleEvent(module, eventTypeEvent, eventActionModuleAdded))
+		}
+	}
+
+	for _, module := range missingModules {
+		report.Event(ms.moduleEvent(module, eventTypeEvent, eventActionModuleRemoved))
+	}
+
+	if len(newInCache) > 0 || len(missingFromCache) > 0 {
+		return ms.saveModulesToDisk(modules)
+	}
+	return nil
+}
+
+func (ms *MetricSet) moduleEvent(module *KernelModule, eventType string, action eventAction) mb.Event {
+	event := mb.Event{
+		RootFields: common.MapStr{
+			"event": common.MapStr{
+				"kind":     eventType,
+				"category": []string{"host"},
+				"type":     []string{action.Type()},
+				"action":   action.String(),
+			},
+			"message": moduleMessage(module, action),
+		},
+		MetricSetFields: module.toMapStr(),
+	}
+
+	if ms.HostID() != "" {
+		event.MetricSetFields.Put("entity_id", module.entityID(ms.HostID()))
+	}
+
+	return event
+}
+
+func moduleMessage(module *KernelModule, action eventAction) string {
+	var actionString string
+	switch action {
+	case eventActionExistingModule:
+		actionString = "Existing"
+	case eventActionModuleAdded:
+		actionString = "Loaded"
+	case eventActionModuleRemoved:
+		actionString = "Unloaded"
+	case eventActionModuleChanged:
+		actionString = "Changed"
+	}
+
+	if module.Version != "" {
+		return fmt.Sprintf("%v kernel module %v (version: %v)", actionString, module.Name, module.Version)
+	}
+	return fmt.Sprintf("%v kernel module %v", actionString, module.Name)
+}
+
+func convertToCacheable(modules []*KernelModule) []cache.Cacheable {
+	c := make([]cache.Cacheable, 0, len(modules))
+
+	for _, m := range modules {
+		c = append(c, m)
+	}
+
+	return c
+}
+
+// restoreModulesFromDisk loads the kernel module cache from disk.
+func (ms *MetricSet) restoreModulesFromDisk() (modules []*KernelModule, err error) {
+	var decoder *gob.Decoder
+	err = ms.bucket.Load(bucketKeyModules, func(blob []byte) error {
+		if len(blob) > 0 {
+			buf := bytes.NewBuffer(blob)
+			decoder = gob.NewDecoder(buf)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if decoder != nil {
+		for {
+			module := new(KernelModule)
+			err = decoder.Decode(module)
+			if err == nil {
+				modules = append(modules, module)
+			} else if err == io.EOF {
+				// Read all modules
+				break
+			} else {
+				return nil, errors.Wrap(err, "error decoding kernel modules")
+			}
+		}
+	}
+
+	return modules, nil
+}
+
+// saveModulesToDisk saves the kernel module cache to disk.
+func (ms *MetricSet) saveModulesToDisk(modules []*KernelModule) error {
+	var buf bytes.Buffer
+	encoder := gob.NewEncoder(&buf)
+
+	for _, module := range modules {
+		err := encoder.Encode(*module)
+		if err != nil {
+			return errors.Wrap(err, "error encoding kernel modules")
+		}
+	}
+
+	err := ms.bucket.Store(bucketKeyModules, buf.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "error writing kernel modules to disk")
+	}
+	return nil
+}
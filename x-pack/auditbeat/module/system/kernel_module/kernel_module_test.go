@@ -0,0 +1,118 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// +build linux
+
+package kernelmodule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/v7/auditbeat/core"
+	abtest "github.com/elastic/beats/v7/auditbeat/testing"
+	mbtest "github.com/elastic/beats/v7/metricbeat/mb/testing"
+)
+
+func TestData(t *testing.T) {
+	defer setupTestdata()()
+	defer abtest.SetupDataDir(t)()
+
+	f := mbtest.NewReportingMetricSetV2(t, getConfig())
+	defer f.(*MetricSet).bucket.DeleteBucket()
+
+	events, errs := mbtest.ReportingFetchV2(f)
+	if len(errs) > 0 {
+		t.Fatalf("received error: %+v", errs[0])
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+
+	fullEvent := mbtest.StandardizeEvent(f, events[0], core.AddDatasetToEvent)
+	mbtest.WriteEventToDataJSON(t, fullEvent, "")
+}
+
+func TestGetKernelModules(t *testing.T) {
+	defer setupTestdata()()
+
+	modules, err := getKernelModules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, modules, 2) {
+		return
+	}
+
+	vbox := modules[0]
+	assert.Equal(t, "vboxdrv", vbox.Name)
+	assert.EqualValues(t, 479232, vbox.Size)
+	assert.EqualValues(t, 2, vbox.RefCount)
+	assert.Equal(t, []string{"vboxnetadp", "vboxnetflt"}, vbox.UsedBy)
+	assert.Equal(t, "Live", vbox.State)
+	assert.Equal(t, "1.0.6-ubuntu", vbox.Version)
+	assert.Equal(t, "5CA2D4B4A9A1A7E22BC1E0C", vbox.SrcVersion)
+	assert.Equal(t, "OE", vbox.Taint)
+
+	nft := modules[1]
+	assert.Equal(t, "nf_tables", nft.Name)
+	assert.Empty(t, nft.UsedBy)
+	assert.Empty(t, nft.Version)
+	assert.Empty(t, nft.Taint)
+}
+
+func TestReportChanges(t *testing.T) {
+	defer setupTestdata()()
+	defer abtest.SetupDataDir(t)()
+
+	f := mbtest.NewReportingMetricSetV2(t, getConfig())
+	ms := f.(*MetricSet)
+	defer ms.bucket.DeleteBucket()
+
+	// Pretend the state was sent with an older vboxdrv and a module that
+	// has been unloaded since.
+	ms.lastState = time.Now()
+	ms.cache.DiffAndUpdateCache(convertToCacheable([]*KernelModule{
+		{Name: "vboxdrv", Size: 479232, Version: "1.0.5-ubuntu", SrcVersion: "5CA2D4B4A9A1A7E22BC1E0C", Taint: "OE"},
+		{Name: "nf_tables", Size: 249856, SrcVersion: "4F5E0E2D6E0B4A0C1E2BDF2"},
+		{Name: "usb_storage", Size: 77824},
+	}))
+
+	events, errs := mbtest.ReportingFetchV2(f)
+	if len(errs) > 0 {
+		t.Fatalf("received error: %+v", errs[0])
+	}
+	if !assert.Len(t, events, 2) {
+		return
+	}
+
+	actions := map[string]string{}
+	for _, e := range events {
+		action, _ := e.RootFields.GetValue("event.action")
+		name, _ := e.MetricSetFields.GetValue("name")
+		actions[name.(string)] = action.(string)
+	}
+	assert.Equal(t, map[string]string{
+		"vboxdrv":     "kernel_module_changed",
+		"usb_storage": "kernel_module_removed",
+	}, actions)
+}
+
+func setupTestdata() func() {
+	procModulesFileOld, sysModuleDirOld := procModulesFile, sysModuleDir
+	procModulesFile, sysModuleDir = "testdata/modules", "testdata/sys/module"
+	return func() {
+		procModulesFile, sysModuleDir = procModulesFileOld, sysModuleDirOld
+	}
+}
+
+func getConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"module":   "system",
+		"datasets": []string{"kernel_module"},
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// +build linux
+
+package kernelmodule
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	procModulesFile = "/proc/modules"
+	sysModuleDir    = "/sys/module"
+)
+
+// getKernelModules returns the loaded kernel modules. The list of modules is
+// read from /proc/modules, see proc(5), and completed with the attributes
+// the kernel exports in /sys/module/<name>.
+func getKernelModules() ([]*KernelModule, error) {
+	file, err := os.Open(procModulesFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error opening %v", procModulesFile)
+	}
+	defer file.Close()
+
+	var modules []*KernelModule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		module, err := parseModuleLine(scanner.Text())
+		if err != nil {
+			return nil, err
+		}
+		readSysModule(module)
+		modules = append(modules, module)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "error scanning file %v", procModulesFile)
+	}
+
+	return modules, nil
+}
+
+// parseModuleLine parses a line of /proc/modules. It contains the name,
+// memory size, reference count, dependent modules, load state, and memory
+// offset of a module, e.g.
+//
+//   nf_nat 45056 2 nft_chain_nat,xt_MASQUERADE, Live 0x0000000000000000
+func parseModuleLine(line string) (*KernelModule, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 5 {
+		return nil, errors.Errorf("unexpected line in %v: '%v'", procModulesFile, line)
+	}
+
+	size, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing size of module %v", fields[0])
+	}
+
+	module := &KernelModule{
+		Name:  fields[0],
+		Size:  size,
+		State: fields[4],
+	}
+	if refCount, err := strconv.ParseInt(fields[2], 10, 64); err == nil {
+		module.RefCount = refCount
+	}
+	if fields[3] != "-" {
+		for _, dep := range strings.Split(fields[3], ",") {
+			if dep != "" {
+				module.UsedBy = append(module.UsedBy, dep)
+			}
+		}
+	}
+	return module, nil
+}
+
+// readSysModule reads the version, source checksum, and taint flags of a
+// module from sysfs. Not all modules have a version and a checksum.
+func readSysModule(module *KernelModule) {
+	read := func(name string) string {
+		contents, err := ioutil.ReadFile(filepath.Join(sysModuleDir, module.Name, name))
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(contents))
+	}
+
+	module.Version = read("version")
+	module.SrcVersion = read("srcversion")
+	module.Taint = read("taint")
+}
@@ -14,6 +14,11 @@ import (
 type config struct {
 	StatePeriod        time.Duration `config:"state.period"`
 	PackageStatePeriod time.Duration `config:"package.state.period"`
+
+	// Roots that are searched for packages of language ecosystems.
+	PythonPaths []string `config:"package.python.paths"`
+	NodePaths   []string `config:"package.node.paths"`
+	GoPaths     []string `config:"package.go.paths"`
 }
 
 func (c *config) effectiveStatePeriod() time.Duration {
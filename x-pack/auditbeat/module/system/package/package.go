@@ -50,6 +50,7 @@ var (
 	rpmPath            = "/var/lib/rpm"
 	dpkgPath           = "/var/lib/dpkg"
 	homebrewCellarPath = "/usr/local/Cellar"
+	apkPath            = "/lib/apk/db"
 )
 
 type eventAction uint8
@@ -122,6 +123,7 @@ type Package struct {
 	Summary     string
 	URL         string
 	Type        string
+	Path        string // Location of packages that aren't managed system-wide.
 
 	error error
 }
@@ -133,6 +135,7 @@ func (pkg Package) Hash() uint64 {
 	h.WriteString(pkg.Version)
 	h.WriteString(pkg.Release)
 	binary.Write(h, binary.LittleEndian, pkg.Size)
+	h.WriteString(pkg.Path)
 	return h.Sum64()
 }
 
@@ -184,6 +187,10 @@ func (pkg Package) toMapStr() (common.MapStr, common.MapStr) {
 		ecsMapstr.Put("type", pkg.Type)
 	}
 
+	if pkg.Path != "" {
+		ecsMapstr.Put("path", pkg.Path)
+	}
+
 	return mapstr, ecsMapstr
 }
 
@@ -193,6 +200,7 @@ func (pkg Package) entityID(hostID string) string {
 	h.Write([]byte(hostID))
 	h.Write([]byte(pkg.Name))
 	h.Write([]byte(pkg.Version))
+	h.Write([]byte(pkg.Path))
 	return h.Sum()
 }
 
@@ -325,8 +333,9 @@ func (ms *MetricSet) reportChanges(report mb.ReporterV2) error {
 	newPackages := convertToPackage(newInCache)
 	missingPackages := convertToPackage(missingFromCache)
 
-	// Package names of updated packages
-	updated := make(map[string]struct{})
+	// Package names and paths of updated packages
+	type pkgKey struct{ name, path string }
+	updated := make(map[pkgKey]struct{})
 
 	for _, missingPkg := range missingPackages {
 		found := false
@@ -334,9 +343,9 @@ func (ms *MetricSet) reportChanges(report mb.ReporterV2) error {
 		// Using an inner loop is less efficient than using a map, but in this case
 		// we do not expect a lot of installed or removed packages all at once.
 		for _, newPkg := range newPackages {
-			if missingPkg.Name == newPkg.Name {
+			if missingPkg.Name == newPkg.Name && missingPkg.Path == newPkg.Path {
 				found = true
-				updated[newPkg.Name] = struct{}{}
+				updated[pkgKey{newPkg.Name, newPkg.Path}] = struct{}{}
 				report.Event(ms.packageEvent(newPkg, eventTypeEvent, eventActionPackageUpdated))
 				break
 			}
@@ -348,7 +357,7 @@ func (ms *MetricSet) reportChanges(report mb.ReporterV2) error {
 	}
 
 	for _, newPkg := range newPackages {
-		if _, contains := updated[newPkg.Name]; !contains {
+		if _, contains := updated[pkgKey{newPkg.Name, newPkg.Path}]; !contains {
 			report.Event(ms.packageEvent(newPkg, eventTypeEvent, eventActionPackageInstalled))
 		}
 	}
@@ -475,57 +484,77 @@ func (ms *MetricSet) savePackagesToDisk(packages []*Package) error {
 	return nil
 }
 
-func (ms *MetricSet) getPackages() (packages []*Package, err error) {
-	var foundPackageManager bool
+// packageSource is a package manager whose database is found at path.
+type packageSource struct {
+	name string
+	path *string
+	list func(ms *MetricSet) ([]*Package, error)
+}
+
+// languageSource is a language ecosystem whose packages are searched below
+// the configured roots.
+type languageSource struct {
+	name  string
+	roots func(c *config) []string
+	list  func(root string) ([]*Package, error)
+}
 
-	_, err = os.Stat(rpmPath)
-	if err == nil {
-		foundPackageManager = true
+var packageSources = []packageSource{
+	{"RPM", &rpmPath, func(*MetricSet) ([]*Package, error) { return listRPMPackages() }},
+	{"DEB", &dpkgPath, (*MetricSet).listDebPackages},
+	{"Homebrew", &homebrewCellarPath, func(*MetricSet) ([]*Package, error) { return listBrewPackages() }},
+	{"APK", &apkPath, func(*MetricSet) ([]*Package, error) { return listAPKPackages() }},
+}
 
-		rpmPackages, err := listRPMPackages()
-		if err != nil {
-			return nil, errors.Wrap(err, "error getting RPM packages")
-		}
-		ms.log.Debugf("RPM packages: %v", len(rpmPackages))
+var languageSources = []languageSource{
+	{"Python", func(c *config) []string { return c.PythonPaths }, listPythonPackages},
+	{"Node.js", func(c *config) []string { return c.NodePaths }, listNodePackages},
+	{"Go", func(c *config) []string { return c.GoPaths }, listGoPackages},
+}
 
-		packages = append(packages, rpmPackages...)
-	} else if err != nil && !os.IsNotExist(err) {
-		return nil, errors.Wrapf(err, "error opening %v", rpmPath)
-	}
+func (ms *MetricSet) getPackages() (packages []*Package, err error) {
+	var foundPackageManager bool
 
-	_, err = os.Stat(dpkgPath)
-	if err == nil {
-		foundPackageManager = true
+	for _, source := range packageSources {
+		path := *source.path
+		_, err = os.Stat(path)
+		if err == nil {
+			foundPackageManager = true
 
-		dpkgPackages, err := ms.listDebPackages()
-		if err != nil {
-			return nil, errors.Wrap(err, "error getting DEB packages")
-		}
-		ms.log.Debugf("DEB packages: %v", len(dpkgPackages))
+			sourcePackages, err := source.list(ms)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error getting %v packages", source.name)
+			}
+			ms.log.Debugf("%v packages: %v", source.name, len(sourcePackages))
 
-		packages = append(packages, dpkgPackages...)
-	} else if err != nil && !os.IsNotExist(err) {
-		return nil, errors.Wrapf(err, "error opening %v", dpkgPath)
+			packages = append(packages, sourcePackages...)
+		} else if err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrapf(err, "error opening %v", path)
+		}
 	}
 
-	_, err = os.Stat(homebrewCellarPath)
-	if err == nil {
-		foundPackageManager = true
+	for _, source := range languageSources {
+		for _, root := range source.roots(&ms.config) {
+			sourcePackages, err := source.list(root)
+			if err != nil {
+				// A missing or unreadable root must not prevent reporting
+				// the other packages.
+				ms.log.Warnw("Failed to get packages", "type", source.name, "path", root, "error", err)
+				continue
+			}
+			ms.log.Debugf("%v packages in %v: %v", source.name, root, len(sourcePackages))
 
-		homebrewPackages, err := listBrewPackages()
-		if err != nil {
-			return nil, errors.Wrap(err, "error getting Homebrew packages")
+			packages = append(packages, sourcePackages...)
 		}
-		ms.log.Debugf("Homebrew packages: %v", len(homebrewPackages))
-
-		packages = append(packages, homebrewPackages...)
-	} else if err != nil && !os.IsNotExist(err) {
-		return nil, errors.Wrapf(err, "error opening %v", homebrewCellarPath)
 	}
 
 	if !foundPackageManager && !ms.suppressNoPackageWarnings {
-		ms.log.Warnf("No supported package managers found. None of %v, %v, %v exist.",
-			rpmPath, dpkgPath, homebrewCellarPath)
+		paths := make([]string, 0, len(packageSources))
+		for _, source := range packageSources {
+			paths = append(paths, *source.path)
+		}
+		ms.log.Warnf("No supported package managers found. None of %v exist.",
+			strings.Join(paths, ", "))
 
 		// Only warn once at the start of Auditbeat.
 		ms.suppressNoPackageWarnings = true
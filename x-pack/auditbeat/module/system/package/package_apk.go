@@ -0,0 +1,87 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// +build !windows
+
+package pkg
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// listAPKPackages reads the packages from the installed database of Alpine's
+// apk. Each package is a block of "X:value" lines, see
+// https://wiki.alpinelinux.org/wiki/Apk_spec#Installed_Database_V2.
+func listAPKPackages() ([]*Package, error) {
+	installedFile := filepath.Join(apkPath, "installed")
+
+	file, err := os.Open(installedFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error opening %s", installedFile)
+	}
+	defer file.Close()
+
+	var packages []*Package
+	var pkg *Package
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(strings.TrimSpace(line)) == 0 {
+			// empty line signals new package
+			if pkg != nil {
+				packages = append(packages, pkg)
+			}
+			pkg = nil
+			continue
+		}
+
+		if len(line) < 2 || line[1] != ':' {
+			return nil, errors.Errorf("the following line was unexpected (no ':' found): '%s'", line)
+		}
+		value := line[2:]
+
+		if pkg == nil {
+			pkg = &Package{
+				Type: "apk",
+			}
+		}
+
+		switch line[0] {
+		case 'P':
+			pkg.Name = value
+		case 'V':
+			pkg.Version = value
+		case 'A':
+			pkg.Arch = value
+		case 'I':
+			// Installed size in bytes.
+			if pkg.Size, err = strconv.ParseUint(value, 10, 64); err != nil {
+				pkg.error = errors.Wrapf(err, "error parsing installed size '%v'", value)
+			}
+		case 'T':
+			pkg.Summary = value
+		case 'U':
+			pkg.URL = value
+		case 'L':
+			pkg.License = value
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "error scanning file %v", installedFile)
+	}
+
+	// Append last package if file ends without newline
+	if pkg != nil {
+		packages = append(packages, pkg)
+	}
+
+	return packages, nil
+}
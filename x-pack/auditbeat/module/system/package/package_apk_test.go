@@ -0,0 +1,64 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// +build !windows
+
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/v7/auditbeat/core"
+	abtest "github.com/elastic/beats/v7/auditbeat/testing"
+	"github.com/elastic/beats/v7/libbeat/logp"
+	mbtest "github.com/elastic/beats/v7/metricbeat/mb/testing"
+)
+
+func TestAPK(t *testing.T) {
+	logp.TestingSetup()
+
+	defer abtest.SetupDataDir(t)()
+
+	// Disable all except apk
+	rpmPathOld := rpmPath
+	dpkgPathOld := dpkgPath
+	brewPathOld := homebrewCellarPath
+	apkPathOld := apkPath
+	defer func() {
+		rpmPath = rpmPathOld
+		dpkgPath = dpkgPathOld
+		homebrewCellarPath = brewPathOld
+		apkPath = apkPathOld
+	}()
+	rpmPath = "/does/not/exist"
+	dpkgPath = "/does/not/exist"
+	homebrewCellarPath = "/does/not/exist"
+	apkPath = "testdata/apk/"
+
+	f := mbtest.NewReportingMetricSetV2(t, getConfig())
+	defer f.(*MetricSet).bucket.DeleteBucket()
+
+	events, errs := mbtest.ReportingFetchV2(f)
+	if len(errs) > 0 {
+		t.Fatalf("received error: %+v", errs[0])
+	}
+
+	if assert.Len(t, events, 2) {
+		event := mbtest.StandardizeEvent(f, events[0], core.AddDatasetToEvent)
+		checkFieldValue(t, event, "system.audit.package.name", "musl")
+		checkFieldValue(t, event, "system.audit.package.version", "1.2.2-r0")
+		checkFieldValue(t, event, "system.audit.package.arch", "x86_64")
+		checkFieldValue(t, event, "system.audit.package.size", uint64(622592))
+		checkFieldValue(t, event, "system.audit.package.license", "MIT")
+		checkFieldValue(t, event, "system.audit.package.summary", "the musl c library (libc) implementation")
+		checkFieldValue(t, event, "system.audit.package.url", "https://musl.libc.org/")
+		checkFieldValue(t, event, "package.type", "apk")
+
+		event = mbtest.StandardizeEvent(f, events[1], core.AddDatasetToEvent)
+		checkFieldValue(t, event, "system.audit.package.name", "busybox")
+		checkFieldValue(t, event, "system.audit.package.version", "1.32.1-r6")
+	}
+}
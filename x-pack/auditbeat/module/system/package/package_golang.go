@@ -0,0 +1,267 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// +build !windows
+
+package pkg
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Build info that the Go linker embeds in binaries since Go 1.13, see
+// src/debug/buildinfo in the Go distribution.
+var goBuildInfoMagic = []byte("\xff Go buildinf:")
+
+const (
+	goBuildInfoHeaderSize = 32
+	// Upper limit for strings read from binaries.
+	goBuildInfoMaxString = 1 << 20
+)
+
+var errNotGoBinary = errors.New("not a Go binary")
+
+// goModule is a module listed in the build info of a Go binary.
+type goModule struct {
+	Path, Version string
+}
+
+// listGoPackages finds the Go binaries below root and reports the Go version
+// and the modules they were built with.
+func listGoPackages(root string) ([]*Package, error) {
+	var packages []*Package
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			// Skip unreadable directories.
+			return nil
+		}
+		if !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+			return nil
+		}
+
+		goVersion, modInfo, err := readGoBuildInfo(path)
+		if err != nil {
+			// Most executables aren't Go binaries.
+			return nil
+		}
+		newPackage := func(name, version string) *Package {
+			return &Package{
+				Name:        name,
+				Version:     version,
+				InstallTime: info.ModTime(),
+				Type:        "go",
+				Path:        path,
+			}
+		}
+
+		packages = append(packages, newPackage("stdlib", goVersion))
+		main, deps := parseGoModInfo(modInfo)
+		if main.Path != "" && main.Version != "" && main.Version != "(devel)" {
+			packages = append(packages, newPackage(main.Path, main.Version))
+		}
+		for _, dep := range deps {
+			packages = append(packages, newPackage(dep.Path, dep.Version))
+		}
+		return nil
+	})
+	return packages, err
+}
+
+// parseGoModInfo parses the module information of a Go binary. It contains
+// tab separated lines for the main module ("mod"), dependencies ("dep"), and
+// their replacements ("=>").
+func parseGoModInfo(modInfo string) (main goModule, deps []goModule) {
+	for _, line := range strings.Split(modInfo, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			continue
+		}
+		mod := goModule{Path: fields[1], Version: fields[2]}
+		switch fields[0] {
+		case "mod":
+			main = mod
+		case "dep":
+			deps = append(deps, mod)
+		case "=>":
+			if len(deps) > 0 {
+				deps[len(deps)-1] = mod
+			}
+		}
+	}
+	return main, deps
+}
+
+// goBinary provides access to the data of an ELF or Mach-O executable.
+type goBinary interface {
+	// buildInfo returns the contents of the build info section.
+	buildInfo() ([]byte, error)
+	// readData reads size bytes at the virtual address addr.
+	readData(addr, size uint64) ([]byte, error)
+}
+
+type elfBinary struct{ *elf.File }
+
+func (f elfBinary) buildInfo() ([]byte, error) {
+	s := f.Section(".go.buildinfo")
+	if s == nil {
+		return nil, errNotGoBinary
+	}
+	return s.Data()
+}
+
+func (f elfBinary) readData(addr, size uint64) ([]byte, error) {
+	for _, prog := range f.Progs {
+		if prog.Type == elf.PT_LOAD && prog.Vaddr <= addr && addr+size <= prog.Vaddr+prog.Filesz {
+			return readAt(prog, addr-prog.Vaddr, size)
+		}
+	}
+	return nil, errors.Errorf("address 0x%x not found", addr)
+}
+
+type machoBinary struct{ *macho.File }
+
+func (f machoBinary) buildInfo() ([]byte, error) {
+	s := f.Section("__go_buildinfo")
+	if s == nil {
+		return nil, errNotGoBinary
+	}
+	return s.Data()
+}
+
+func (f machoBinary) readData(addr, size uint64) ([]byte, error) {
+	for _, load := range f.Loads {
+		seg, ok := load.(*macho.Segment)
+		if ok && seg.Name != "__PAGEZERO" && seg.Addr <= addr && addr+size <= seg.Addr+seg.Filesz {
+			return readAt(seg, addr-seg.Addr, size)
+		}
+	}
+	return nil, errors.Errorf("address 0x%x not found", addr)
+}
+
+func readAt(r io.ReaderAt, off, size uint64) ([]byte, error) {
+	if size > goBuildInfoMaxString {
+		return nil, errors.Errorf("size %v exceeds limit", size)
+	}
+	data := make([]byte, size)
+	if _, err := r.ReadAt(data, int64(off)); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// readGoBuildInfo returns the Go version and the module information embedded
+// in the executable at path.
+func readGoBuildInfo(path string) (goVersion, modInfo string, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	magic := make([]byte, 4)
+	if _, err = io.ReadFull(file, magic); err != nil {
+		return "", "", errNotGoBinary
+	}
+
+	var bin goBinary
+	switch {
+	case bytes.Equal(magic, []byte(elf.ELFMAG)):
+		f, err := elf.NewFile(file)
+		if err != nil {
+			return "", "", err
+		}
+		bin = elfBinary{f}
+	case isMachO(magic):
+		f, err := macho.NewFile(file)
+		if err != nil {
+			return "", "", err
+		}
+		bin = machoBinary{f}
+	default:
+		return "", "", errNotGoBinary
+	}
+
+	data, err := bin.buildInfo()
+	if err != nil {
+		return "", "", err
+	}
+	if len(data) < goBuildInfoHeaderSize || !bytes.HasPrefix(data, goBuildInfoMagic) {
+		return "", "", errNotGoBinary
+	}
+
+	ptrSize, flags := int(data[14]), data[15]
+	if flags&2 != 0 {
+		// Since Go 1.18 the strings are stored inline after the header.
+		rest := data[goBuildInfoHeaderSize:]
+		goVersion, rest = decodeGoBuildInfoString(rest)
+		modInfo, _ = decodeGoBuildInfoString(rest)
+	} else {
+		// Before Go 1.18 the header contains pointers to Go strings.
+		if ptrSize != 4 && ptrSize != 8 || len(data) < 16+2*ptrSize {
+			return "", "", errNotGoBinary
+		}
+		var byteOrder binary.ByteOrder = binary.LittleEndian
+		if flags&1 != 0 {
+			byteOrder = binary.BigEndian
+		}
+		readPtr := func(b []byte) uint64 {
+			if ptrSize == 4 {
+				return uint64(byteOrder.Uint32(b))
+			}
+			return byteOrder.Uint64(b)
+		}
+		readString := func(addr uint64) string {
+			hdr, err := bin.readData(addr, uint64(2*ptrSize))
+			if err != nil {
+				return ""
+			}
+			s, err := bin.readData(readPtr(hdr), readPtr(hdr[ptrSize:]))
+			if err != nil {
+				return ""
+			}
+			return string(s)
+		}
+		goVersion = readString(readPtr(data[16:]))
+		modInfo = readString(readPtr(data[16+ptrSize:]))
+	}
+	if goVersion == "" {
+		return "", "", errNotGoBinary
+	}
+
+	// The module information is framed by 16 byte sentinels.
+	if len(modInfo) >= 33 && modInfo[len(modInfo)-17] == '\n' {
+		modInfo = modInfo[16 : len(modInfo)-16]
+	} else {
+		modInfo = ""
+	}
+	return goVersion, modInfo, nil
+}
+
+func decodeGoBuildInfoString(data []byte) (string, []byte) {
+	size, n := binary.Uvarint(data)
+	if n <= 0 || size > uint64(len(data)-n) {
+		return "", nil
+	}
+	return string(data[n : n+int(size)]), data[n+int(size):]
+}
+
+func isMachO(magic []byte) bool {
+	switch binary.BigEndian.Uint32(magic) {
+	case macho.Magic32, macho.Magic64, 0xcefaedfe, 0xcffaedfe:
+		return true
+	}
+	return false
+}
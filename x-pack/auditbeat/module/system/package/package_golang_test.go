@@ -0,0 +1,85 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// +build !windows
+
+package pkg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGoModInfo(t *testing.T) {
+	modInfo := strings.Join([]string{
+		"path\tgithub.com/example/app",
+		"mod\tgithub.com/example/app\tv1.2.3\th1:abc=",
+		"dep\tgithub.com/pkg/errors\tv0.9.1\th1:def=",
+		"dep\tgolang.org/x/sys\tv0.0.0-20200625212154-ddb9806d33ae",
+		"=>\tgithub.com/example/sys\tv0.1.0\th1:ghi=",
+		"",
+	}, "\n")
+
+	main, deps := parseGoModInfo(modInfo)
+	assert.Equal(t, goModule{"github.com/example/app", "v1.2.3"}, main)
+	assert.Equal(t, []goModule{
+		{"github.com/pkg/errors", "v0.9.1"},
+		{"github.com/example/sys", "v0.1.0"},
+	}, deps)
+}
+
+func TestGoPackages(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("Go binaries are only read in ELF and Mach-O format")
+	}
+
+	// The test binary is a Go binary built with the dependencies of this
+	// package.
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "go-packages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	contents, err := ioutil.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := filepath.Join(dir, "app")
+	if err = ioutil.WriteFile(bin, contents, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Files that aren't Go binaries are ignored.
+	if err = ioutil.WriteFile(filepath.Join(dir, "script.sh"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	packages, err := listGoPackages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]*Package{}
+	for _, pkg := range packages {
+		assert.Equal(t, bin, pkg.Path)
+		assert.Equal(t, "go", pkg.Type)
+		got[pkg.Name] = pkg
+	}
+	if assert.Contains(t, got, "stdlib") {
+		assert.Equal(t, runtime.Version(), got["stdlib"].Version)
+	}
+	if assert.Contains(t, got, "github.com/pkg/errors") {
+		assert.NotEmpty(t, got["github.com/pkg/errors"].Version)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// +build !windows
+
+package pkg
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// packageJSON represents the fields of interest in a Node.js package.json.
+type packageJSON struct {
+	Name        string          `json:"name"`
+	Version     string          `json:"version"`
+	Description string          `json:"description"`
+	Homepage    string          `json:"homepage"`
+	License     json.RawMessage `json:"license"`
+}
+
+// license returns the license, which is either an SPDX expression or, in
+// deprecated package.json files, an object with a type.
+func (p *packageJSON) license() string {
+	var license string
+	if json.Unmarshal(p.License, &license) == nil {
+		return license
+	}
+	var object struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(p.License, &object) == nil {
+		return object.Type
+	}
+	return ""
+}
+
+// listNodePackages finds the Node.js packages installed below root. These
+// are the directories with a package.json in a node_modules directory,
+// including scoped packages (node_modules/@scope/name).
+func listNodePackages(root string) ([]*Package, error) {
+	var packages []*Package
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			// Skip unreadable directories.
+			return nil
+		}
+		if info.IsDir() {
+			if info.Name() == ".bin" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Name() != "package.json" || !isNodeModule(filepath.Dir(path)) {
+			return nil
+		}
+
+		pkgDir := filepath.Dir(path)
+		pkg := &Package{
+			Name:        filepath.Base(pkgDir),
+			InstallTime: info.ModTime(),
+			Type:        "npm",
+			Path:        pkgDir,
+		}
+		if err := readPackageJSON(path, pkg); err != nil {
+			pkg.error = err
+		}
+		packages = append(packages, pkg)
+		return nil
+	})
+	return packages, err
+}
+
+// isNodeModule returns true if dir is a package directory in node_modules.
+func isNodeModule(dir string) bool {
+	parent := filepath.Dir(dir)
+	if strings.HasPrefix(filepath.Base(parent), "@") {
+		parent = filepath.Dir(parent)
+	}
+	return filepath.Base(parent) == "node_modules"
+}
+
+func readPackageJSON(path string, pkg *Package) error {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "error reading %v", path)
+	}
+
+	var p packageJSON
+	if err = json.Unmarshal(contents, &p); err != nil {
+		return errors.Wrapf(err, "error unmarshalling JSON in %v", path)
+	}
+
+	if p.Name != "" {
+		pkg.Name = p.Name
+	}
+	pkg.Version = p.Version
+	pkg.Summary = p.Description
+	pkg.URL = p.Homepage
+	pkg.License = p.license()
+	return nil
+}
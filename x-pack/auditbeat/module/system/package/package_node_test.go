@@ -0,0 +1,42 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// +build !windows
+
+package pkg
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodePackages(t *testing.T) {
+	root := "testdata/node"
+	packages, err := listNodePackages(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The application itself and files in the packages aren't reported.
+	got := map[string]*Package{}
+	for _, pkg := range packages {
+		assert.NoError(t, pkg.error)
+		got[pkg.Name] = pkg
+	}
+	if assert.Len(t, got, 2) {
+		pkg := got["left-pad"]
+		assert.Equal(t, "1.3.0", pkg.Version)
+		assert.Equal(t, "String left pad", pkg.Summary)
+		assert.Equal(t, "https://github.com/stevemao/left-pad#readme", pkg.URL)
+		assert.Equal(t, "WTFPL", pkg.License)
+		assert.Equal(t, "npm", pkg.Type)
+		assert.Equal(t, filepath.Join(root, "node_modules/left-pad"), pkg.Path)
+
+		pkg = got["@scope/util"]
+		assert.Equal(t, "0.2.0", pkg.Version)
+		assert.Equal(t, "MIT", pkg.License)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// +build !windows
+
+package pkg
+
+import (
+	"bufio"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// listPythonPackages finds the Python distributions installed below root.
+// Wheels install a *.dist-info directory with a METADATA file, older
+// setuptools installs a *.egg-info directory with a PKG-INFO file, or a
+// *.egg-info file.
+func listPythonPackages(root string) ([]*Package, error) {
+	var packages []*Package
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			// Skip unreadable directories.
+			return nil
+		}
+
+		var metadataFile string
+		switch name := info.Name(); {
+		case info.IsDir() && strings.HasSuffix(name, ".dist-info"):
+			metadataFile = filepath.Join(path, "METADATA")
+		case info.IsDir() && strings.HasSuffix(name, ".egg-info"):
+			metadataFile = filepath.Join(path, "PKG-INFO")
+		case info.Mode().IsRegular() && strings.HasSuffix(name, ".egg-info"):
+			metadataFile = path
+		default:
+			return nil
+		}
+
+		pkg := &Package{
+			InstallTime: info.ModTime(),
+			Type:        "python",
+			Path:        path,
+		}
+		if err := readPythonMetadata(metadataFile, pkg); err != nil {
+			pkg.error = err
+		}
+		if pkg.Name == "" {
+			// Fallback to the name and version in the directory name, e.g.
+			// requests-2.25.1.dist-info or requests-2.25.1-py3.8.egg-info.
+			parts := strings.SplitN(strings.TrimSuffix(strings.TrimSuffix(info.Name(), ".dist-info"), ".egg-info"), "-", 3)
+			pkg.Name = parts[0]
+			if len(parts) > 1 {
+				pkg.Version = parts[1]
+			}
+		}
+		packages = append(packages, pkg)
+		if info.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return packages, err
+}
+
+// readPythonMetadata reads the headers of a core metadata file, see
+// https://packaging.python.org/specifications/core-metadata/.
+func readPythonMetadata(path string, pkg *Package) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "error reading %v", path)
+	}
+	defer file.Close()
+
+	header, err := textproto.NewReader(bufio.NewReader(file)).ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return errors.Wrapf(err, "error parsing %v", path)
+	}
+
+	pkg.Name = header.Get("Name")
+	pkg.Version = header.Get("Version")
+	pkg.Summary = header.Get("Summary")
+	pkg.URL = header.Get("Home-Page")
+	if license := header.Get("License"); license != "" && license != "UNKNOWN" {
+		pkg.License = license
+	}
+	return nil
+}
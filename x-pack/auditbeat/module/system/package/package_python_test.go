@@ -0,0 +1,44 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// +build !windows
+
+package pkg
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPythonPackages(t *testing.T) {
+	root := "testdata/python"
+	packages, err := listPythonPackages(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]*Package{}
+	for _, pkg := range packages {
+		assert.NoError(t, pkg.error)
+		got[pkg.Name] = pkg
+	}
+	if assert.Len(t, got, 2) {
+		pkg := got["requests"]
+		assert.Equal(t, "2.25.1", pkg.Version)
+		assert.Equal(t, "Python HTTP for Humans.", pkg.Summary)
+		assert.Equal(t, "https://requests.readthedocs.io", pkg.URL)
+		assert.Equal(t, "Apache 2.0", pkg.License)
+		assert.Equal(t, "python", pkg.Type)
+		assert.Equal(t, filepath.Join(root, "site-packages/requests-2.25.1.dist-info"), pkg.Path)
+
+		pkg = got["legacy"]
+		assert.Equal(t, "0.1", pkg.Version)
+		assert.Empty(t, pkg.License)
+	}
+
+	_, err = listPythonPackages("/does/not/exist")
+	assert.Error(t, err)
+}
@@ -59,13 +59,16 @@ func TestDpkg(t *testing.T) {
 	rpmPathOld := rpmPath
 	dpkgPathOld := dpkgPath
 	brewPathOld := homebrewCellarPath
+	apkPathOld := apkPath
 	defer func() {
 		rpmPath = rpmPathOld
 		dpkgPath = dpkgPathOld
 		homebrewCellarPath = brewPathOld
+		apkPath = apkPathOld
 	}()
 	rpmPath = "/does/not/exist"
 	homebrewCellarPath = "/does/not/exist"
+	apkPath = "/does/not/exist"
 
 	var err error
 	dpkgPath, err = filepath.Abs("testdata/dpkg/")
@@ -118,13 +121,16 @@ func TestDpkgInstalledSize(t *testing.T) {
 	rpmPathOld := rpmPath
 	dpkgPathOld := dpkgPath
 	brewPathOld := homebrewCellarPath
+	apkPathOld := apkPath
 	defer func() {
 		rpmPath = rpmPathOld
 		dpkgPath = dpkgPathOld
 		homebrewCellarPath = brewPathOld
+		apkPath = apkPathOld
 	}()
 	rpmPath = "/does/not/exist"
 	homebrewCellarPath = "/does/not/exist"
+	apkPath = "/does/not/exist"
 
 	var err error
 	dpkgPath, err = filepath.Abs("testdata/dpkg-size/")
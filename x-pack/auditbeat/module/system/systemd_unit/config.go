@@ -0,0 +1,42 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// +build linux
+
+package systemdunit
+
+import (
+	"time"
+)
+
+// config defines the metricset's configuration options.
+type config struct {
+	StatePeriod            time.Duration `config:"state.period"`
+	SystemdUnitStatePeriod time.Duration `config:"systemd_unit.state.period"`
+
+	// Directories that are searched for unit files, in order of precedence.
+	Paths []string `config:"systemd_unit.paths"`
+}
+
+// The system unit search path of systemd, see systemd.unit(5).
+var defaultPaths = []string{
+	"/etc/systemd/system",
+	"/run/systemd/system",
+	"/usr/local/lib/systemd/system",
+	"/lib/systemd/system",
+	"/usr/lib/systemd/system",
+}
+
+func (c *config) effectiveStatePeriod() time.Duration {
+	if c.SystemdUnitStatePeriod != 0 {
+		return c.SystemdUnitStatePeriod
+	}
+	return c.StatePeriod
+}
+
+func defaultConfig() config {
+	return config{
+		StatePeriod: 12 * time.Hour,
+	}
+}
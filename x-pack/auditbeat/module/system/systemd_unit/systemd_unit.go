@@ -0,0 +1,416 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// +build linux
+
+package systemdunit
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/gofrs/uuid"
+	"github.com/joeshaw/multierror"
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/v7/auditbeat/datastore"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/libbeat/logp"
+	"github.com/elastic/beats/v7/metricbeat/mb"
+	"github.com/elastic/beats/v7/x-pack/auditbeat/cache"
+	"github.com/elastic/beats/v7/x-pack/auditbeat/module/system"
+)
+
+const (
+	moduleName    = "system"
+	metricsetName = "systemd_unit"
+	namespace     = "system.audit.systemd_unit"
+
+	bucketName              = "systemd_unit.v1"
+	bucketKeyUnits          = "units"
+	bucketKeyStateTimestamp = "state_timestamp"
+
+	eventTypeState = "state"
+	eventTypeEvent = "event"
+)
+
+type eventAction uint8
+
+const (
+	eventActionExistingUnit eventAction = iota
+	eventActionUnitAdded
+	eventActionUnitRemoved
+	eventActionUnitChanged
+)
+
+func (action eventAction) String() string {
+	switch action {
+	case eventActionExistingUnit:
+		return "existing_systemd_unit"
+	case eventActionUnitAdded:
+		return "systemd_unit_added"
+	case eventActionUnitRemoved:
+		return "systemd_unit_removed"
+	case eventActionUnitChanged:
+		return "systemd_unit_changed"
+	default:
+		return ""
+	}
+}
+
+func (action eventAction) Type() string {
+	switch action {
+	case eventActionExistingUnit:
+		return "info"
+	case eventActionUnitAdded:
+		return "creation"
+	case eventActionUnitRemoved:
+		return "deletion"
+	case eventActionUnitChanged:
+		return "change"
+	default:
+		return "info"
+	}
+}
+
+// Unit represents a systemd unit file.
+type Unit struct {
+	Name        string
+	Path        string
+	Type        string
+	State       string
+	Target      string
+	Description string
+	ExecStart   []string
+	SHA256      []byte
+}
+
+// Hash creates a hash for Unit.
+func (unit Unit) Hash() uint64 {
+	h := xxhash.New()
+	// The checksum covers the contents of the unit file.
+	h.WriteString(unit.Name)
+	h.WriteString(unit.Path)
+	h.WriteString(unit.State)
+	h.WriteString(unit.Target)
+	h.Write(unit.SHA256)
+	return h.Sum64()
+}
+
+func (unit Unit) toMapStr() common.MapStr {
+	evt := common.MapStr{
+		"name":  unit.Name,
+		"path":  unit.Path,
+		"type":  unit.Type,
+		"state": unit.State,
+	}
+
+	if unit.Target != "" {
+		evt.Put("target", unit.Target)
+	}
+	if unit.Description != "" {
+		evt.Put("description", unit.Description)
+	}
+	if len(unit.ExecStart) > 0 {
+		evt.Put("exec_start", unit.ExecStart)
+	}
+	if len(unit.SHA256) > 0 {
+		evt.Put("hash.sha256", hex.EncodeToString(unit.SHA256))
+	}
+
+	return evt
+}
+
+// entityID creates an ID that uniquely identifies this unit across machines.
+func (unit Unit) entityID(hostID string) string {
+	h := system.NewEntityHash()
+	h.Write([]byte(hostID))
+	h.Write([]byte(unit.Name))
+	return h.Sum()
+}
+
+func init() {
+	mb.Registry.MustAddMetricSet(moduleName, metricsetName, New,
+		mb.WithNamespace(namespace),
+	)
+}
+
+// MetricSet collects data about the systemd unit files of a system.
+type MetricSet struct {
+	system.SystemMetricSet
+	config    config
+	log       *logp.Logger
+	cache     *cache.Cache
+	bucket    datastore.Bucket
+	lastState time.Time
+}
+
+// New constructs a new MetricSet.
+func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	cfgwarn.Beta("The %v/%v dataset is beta", moduleName, metricsetName)
+	config := defaultConfig()
+	if err := base.Module().UnpackConfig(&config); err != nil {
+		return nil, errors.Wrapf(err, "failed to unpack the %v/%v config", moduleName, metricsetName)
+	}
+	if len(config.Paths) == 0 {
+		// Set here instead of in defaultConfig, a configured list would
+		// be merged with the default list otherwise.
+		config.Paths = defaultPaths
+	}
+
+	bucket, err := datastore.OpenBucket(bucketName)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open persistent datastore")
+	}
+
+	ms := &MetricSet{
+		SystemMetricSet: system.NewSystemMetricSet(base),
+		config:          config,
+		log:             logp.NewLogger(metricsetName),
+		cache:           cache.New(),
+		bucket:          bucket,
+	}
+
+	// Load from disk: Time when state was last sent
+	err = bucket.Load(bucketKeyStateTimestamp, func(blob []byte) error {
+		if len(blob) > 0 {
+			return ms.lastState.UnmarshalBinary(blob)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ms.lastState.IsZero() {
+		ms.log.Debugf("Last state was sent at %v. Next state update by %v.", ms.lastState, ms.lastState.Add(ms.config.effectiveStatePeriod()))
+	} else {
+		ms.log.Debug("No state timestamp found")
+	}
+
+	// Load from disk: Units
+	units, err := ms.restoreUnitsFromDisk()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to restore units from disk")
+	}
+	ms.log.Debugf("Restored %d units from disk", len(units))
+
+	ms.cache.DiffAndUpdateCache(convertToCacheable(units))
+
+	return ms, nil
+}
+
+// Close cleans up the MetricSet when it finishes.
+func (ms *MetricSet) Close() error {
+	if ms.bucket != nil {
+		return ms.bucket.Close()
+	}
+	return nil
+}
+
+// Fetch collects the unit information. It is invoked periodically.
+func (ms *MetricSet) Fetch(report mb.ReporterV2) {
+	needsStateUpdate := time.Since(ms.lastState) > ms.config.effectiveStatePeriod()
+	if needsStateUpdate || ms.cache.IsEmpty() {
+		ms.log.Debugf("State update needed (needsStateUpdate=%v, cache.IsEmpty()=%v)", needsStateUpdate, ms.cache.IsEmpty())
+		err := ms.reportState(report)
+		if err != nil {
+			ms.log.Error(err)
+			report.Error(err)
+		}
+		ms.log.Debugf("Next state update by %v", ms.lastState.Add(ms.config.effectiveStatePeriod()))
+	}
+
+	err := ms.reportChanges(report)
+	if err != nil {
+		ms.log.Error(err)
+		report.Error(err)
+	}
+}
+
+// reportState reports all existing unit files.
+func (ms *MetricSet) reportState(report mb.ReporterV2) error {
+	var errs multierror.Errors
+	ms.lastState = time.Now()
+
+	units, err := getUnits(ms.config.Paths)
+	if err != nil {
+		errs = append(errs, errors.Wrap(err, "error while getting units"))
+	}
+
+	ms.log.Debugf("Found %v units", len(units))
+	if len(units) > 0 {
+		stateID, err := uuid.NewV4()
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "error generating state ID"))
+		}
+
+		for _, unit := range units {
+			event := ms.unitEvent(unit, eventTypeState, eventActionExistingUnit)
+			event.RootFields.Put("event.id", stateID.String())
+			report.Event(event)
+		}
+
+		ms.cache.DiffAndUpdateCache(convertToCacheable(units))
+
+		// Save time so we know when to send the state again (config.StatePeriod)
+		timeBytes, err := ms.lastState.MarshalBinary()
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			err = ms.bucket.Store(bucketKeyStateTimestamp, timeBytes)
+			if err != nil {
+				errs = append(errs, errors.Wrap(err, "error writing state timestamp to disk"))
+			}
+		}
+
+		err = ms.saveUnitsToDisk(units)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs.Err()
+}
+
+// reportChanges detects and reports any changes to unit files since the last call.
+func (ms *MetricSet) reportChanges(report mb.ReporterV2) error {
+	units, err := getUnits(ms.config.Paths)
+	if err != nil {
+		return errors.Wrap(err, "error while getting units")
+	}
+	ms.log.Debugf("Found %v units", len(units))
+
+	newInCache, missingFromCache := ms.cache.DiffAndUpdateCache(convertToCacheable(units))
+
+	// Units that are both new and missing have been changed, e.g. they
+	// have been enabled or their unit file has been edited.
+	missingUnits := make(map[string]*Unit, len(missingFromCache))
+	for _, missing := range missingFromCache {
+		unit := missing.(*Unit)
+		missingUnits[unit.Name] = unit
+	}
+
+	for _, added := range newInCache {
+		unit := added.(*Unit)
+		if _, found := missingUnits[unit.Name]; found {
+			report.Event(ms.unitEvent(unit, eventTypeEvent, eventActionUnitChanged))
+			delete(missingUnits, unit.Name)
+		} else {
+			report.Event(ms.unitEvent(unit, eventTypeEvent, eventActionUnitAdded))
+		}
+	}
+
+	for _, unit := range missingUnits {
+		report.Event(ms.unitEvent(unit, eventTypeEvent, eventActionUnitRemoved))
+	}
+
+	if len(newInCache) > 0 || len(missingFromCache) > 0 {
+		return ms.saveUnitsToDisk(units)
+	}
+	return nil
+}
+
+func (ms *MetricSet) unitEvent(unit *Unit, eventType string, action eventAction) mb.Event {
+	event := mb.Event{
+		RootFields: common.MapStr{
+			"event": common.MapStr{
+				"kind":     eventType,
+				"category": []string{"host"},
+				"type":     []string{action.Type()},
+				"action":   action.String(),
+			},
+			"message": unitMessage(unit, action),
+		},
+		MetricSetFields: unit.toMapStr(),
+	}
+
+	if ms.HostID() != "" {
+		event.MetricSetFields.Put("entity_id", unit.entityID(ms.HostID()))
+	}
+
+	return event
+}
+
+func unitMessage(unit *Unit, action eventAction) string {
+	var actionString string
+	switch action {
+	case eventActionExistingUnit:
+		actionString = "Existing"
+	case eventActionUnitAdded:
+		actionString = "New"
+	case eventActionUnitRemoved:
+		actionString = "Removed"
+	case eventActionUnitChanged:
+		actionString = "Changed"
+	}
+
+	return fmt.Sprintf("%v systemd unit %v (state: %v)", actionString, unit.Name, unit.State)
+}
+
+func convertToCacheable(units []*Unit) []cache.Cacheable {
+	c := make([]cache.Cacheable, 0, len(units))
+
+	for _, u := range units {
+		c = append(c, u)
+	}
+
+	return c
+}
+
+// restoreUnitsFromDisk loads the unit cache from disk.
+func (ms *MetricSet) restoreUnitsFromDisk() (units []*Unit, err error) {
+	var decoder *gob.Decoder
+	err = ms.bucket.Load(bucketKeyUnits, func(blob []byte) error {
+		if len(blob) > 0 {
+			buf := bytes.NewBuffer(blob)
+			decoder = gob.NewDecoder(buf)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if decoder != nil {
+		for {
+			unit := new(Unit)
+			err = decoder.Decode(unit)
+			if err == nil {
+				units = append(units, unit)
+			} else if err == io.EOF {
+				// Read all units
+				break
+			} else {
+				return nil, errors.Wrap(err, "error decoding units")
+			}
+		}
+	}
+
+	return units, nil
+}
+
+// saveUnitsToDisk saves the unit cache to disk.
+func (ms *MetricSet) saveUnitsToDisk(units []*Unit) error {
+	var buf bytes.Buffer
+	encoder := gob.NewEncoder(&buf)
+
+	for _, unit := range units {
+		err := encoder.Encode(*unit)
+		if err != nil {
+			return errors.Wrap(err, "error encoding units")
+		}
+	}
+
+	err := ms.bucket.Store(bucketKeyUnits, buf.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "error writing units to disk")
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// +build linux
+
+package systemdunit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/v7/auditbeat/core"
+	abtest "github.com/elastic/beats/v7/auditbeat/testing"
+	mbtest "github.com/elastic/beats/v7/metricbeat/mb/testing"
+)
+
+var testPaths = []string{"testdata/etc/systemd/system", "testdata/lib/systemd/system"}
+
+func TestData(t *testing.T) {
+	defer abtest.SetupDataDir(t)()
+
+	f := mbtest.NewReportingMetricSetV2(t, getConfig())
+	defer f.(*MetricSet).bucket.DeleteBucket()
+
+	events, errs := mbtest.ReportingFetchV2(f)
+	if len(errs) > 0 {
+		t.Fatalf("received error: %+v", errs[0])
+	}
+
+	for _, e := range events {
+		if name, _ := e.MetricSetFields.GetValue("name"); name == "ssh.service" {
+			fullEvent := mbtest.StandardizeEvent(f, e, core.AddDatasetToEvent)
+			mbtest.WriteEventToDataJSON(t, fullEvent, "")
+			return
+		}
+	}
+	t.Fatal("unit not found")
+}
+
+func TestGetUnits(t *testing.T) {
+	units, err := getUnits(append(testPaths, "testdata/does/not/exist"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byName := map[string]*Unit{}
+	for _, unit := range units {
+		byName[unit.Name] = unit
+	}
+
+	states := map[string]string{}
+	for name, unit := range byName {
+		states[name] = unit.State
+	}
+	assert.Equal(t, map[string]string{
+		"backup.service": "static",
+		"cups.service":   "disabled",
+		"dbus.service":   "static",
+		"getty@.service": "enabled",
+		"ssh.service":    "enabled",
+		"sshd.service":   "alias",
+		"telnet.socket":  "masked",
+	}, states)
+
+	ssh := byName["ssh.service"]
+	assert.Equal(t, "testdata/lib/systemd/system/ssh.service", ssh.Path)
+	assert.Equal(t, "service", ssh.Type)
+	assert.Equal(t, "OpenBSD Secure Shell server", ssh.Description)
+	assert.Equal(t, []string{"/usr/sbin/sshd -D $SSHD_OPTS"}, ssh.ExecStart)
+	assert.Len(t, ssh.SHA256, 32)
+
+	// The unit file in /etc overrides the one in /lib.
+	backup := byName["backup.service"]
+	assert.Equal(t, "testdata/etc/systemd/system/backup.service", backup.Path)
+	assert.Equal(t, []string{"/usr/local/bin/backup --incremental"}, backup.ExecStart)
+
+	assert.Equal(t, "../../../lib/systemd/system/ssh.service", byName["sshd.service"].Target)
+	assert.Equal(t, "/dev/null", byName["telnet.socket"].Target)
+	assert.Empty(t, byName["telnet.socket"].SHA256)
+}
+
+func TestReportChanges(t *testing.T) {
+	defer abtest.SetupDataDir(t)()
+
+	f := mbtest.NewReportingMetricSetV2(t, getConfig())
+	ms := f.(*MetricSet)
+	defer ms.bucket.DeleteBucket()
+
+	units, err := getUnits(testPaths)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pretend cups was enabled and there was another unit when the state
+	// was sent.
+	var previous []*Unit
+	for _, unit := range units {
+		u := *unit
+		if u.Name == "cups.service" {
+			u.State = unitStateEnabled
+		}
+		previous = append(previous, &u)
+	}
+	previous = append(previous, &Unit{Name: "miner.service", Path: "/etc/systemd/system/miner.service", State: unitStateEnabled})
+	ms.lastState = time.Now()
+	ms.cache.DiffAndUpdateCache(convertToCacheable(previous))
+
+	events, errs := mbtest.ReportingFetchV2(f)
+	if len(errs) > 0 {
+		t.Fatalf("received error: %+v", errs[0])
+	}
+
+	actions := map[string]string{}
+	for _, e := range events {
+		action, _ := e.RootFields.GetValue("event.action")
+		name, _ := e.MetricSetFields.GetValue("name")
+		actions[name.(string)] = action.(string)
+	}
+	assert.Equal(t, map[string]string{
+		"cups.service":  "systemd_unit_changed",
+		"miner.service": "systemd_unit_removed",
+	}, actions)
+}
+
+func getConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"module":             "system",
+		"datasets":           []string{"systemd_unit"},
+		"systemd_unit.paths": testPaths,
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// +build linux
+
+package systemdunit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// unitTypes are the suffixes of unit files, see systemd.unit(5).
+var unitTypes = map[string]struct{}{
+	"service":   {},
+	"socket":    {},
+	"device":    {},
+	"mount":     {},
+	"automount": {},
+	"swap":      {},
+	"target":    {},
+	"path":      {},
+	"timer":     {},
+	"slice":     {},
+	"scope":     {},
+}
+
+// Unit file states, as shown by systemctl list-unit-files.
+const (
+	unitStateEnabled  = "enabled"
+	unitStateDisabled = "disabled"
+	unitStateStatic   = "static"
+	unitStateMasked   = "masked"
+	unitStateAlias    = "alias"
+	unitStateBad      = "bad"
+)
+
+// getUnits returns the unit files found in the given directories. A unit
+// file in a directory hides the unit files of the same name in the
+// directories that follow it.
+//
+// The enabled state is derived from the symlinks in the *.wants and
+// *.requires directories that systemctl enable creates, so that it can be
+// determined without a running systemd.
+func getUnits(paths []string) ([]*Unit, error) {
+	wanted := wantedUnits(paths)
+
+	var units []*Unit
+	seen := map[string]struct{}{}
+	for _, dir := range paths {
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "error reading directory %v", dir)
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if _, found := seen[name]; found || !isUnitName(name) || entry.IsDir() {
+				continue
+			}
+			seen[name] = struct{}{}
+
+			unit, err := readUnit(filepath.Join(dir, name), wanted)
+			if err != nil {
+				return nil, err
+			}
+			units = append(units, unit)
+		}
+	}
+
+	return units, nil
+}
+
+// wantedUnits returns the names of the units that are pulled in by a
+// dependency symlink. For instances of template units, e.g. getty@tty1.service,
+// the template getty@.service is included as well.
+func wantedUnits(paths []string) map[string]struct{} {
+	wanted := map[string]struct{}{}
+	for _, dir := range paths {
+		for _, pattern := range []string{"*.wants/*", "*.requires/*"} {
+			links, _ := filepath.Glob(filepath.Join(dir, pattern))
+			for _, link := range links {
+				name := filepath.Base(link)
+				wanted[name] = struct{}{}
+				if at := strings.IndexByte(name, '@'); at >= 0 {
+					wanted[name[:at+1]+name[strings.LastIndexByte(name, '.'):]] = struct{}{}
+				}
+			}
+		}
+	}
+	return wanted
+}
+
+func isUnitName(name string) bool {
+	dot := strings.LastIndexByte(name, '.')
+	if dot <= 0 {
+		return false
+	}
+	_, found := unitTypes[name[dot+1:]]
+	return found
+}
+
+func readUnit(path string, wanted map[string]struct{}) (*Unit, error) {
+	name := filepath.Base(path)
+	unit := &Unit{
+		Name: name,
+		Path: path,
+		Type: name[strings.LastIndexByte(name, '.')+1:],
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading unit file %v", path)
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		if unit.Target, err = os.Readlink(path); err != nil {
+			return nil, errors.Wrapf(err, "error reading link %v", path)
+		}
+		if unit.Target == os.DevNull {
+			unit.State = unitStateMasked
+			return unit, nil
+		}
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Dangling symlink.
+			unit.State = unitStateBad
+			return unit, nil
+		}
+		return nil, errors.Wrapf(err, "error reading unit file %v", path)
+	}
+	if len(contents) == 0 {
+		// An empty unit file masks the unit as well.
+		unit.State = unitStateMasked
+		return unit, nil
+	}
+
+	sum := sha256.Sum256(contents)
+	unit.SHA256 = sum[:]
+	hasInstall := parseUnitFile(contents, unit)
+
+	_, isWanted := wanted[name]
+	switch {
+	case unit.Target != "" && filepath.Base(unit.Target) != name:
+		unit.State = unitStateAlias
+	case isWanted:
+		unit.State = unitStateEnabled
+	case !hasInstall:
+		unit.State = unitStateStatic
+	default:
+		unit.State = unitStateDisabled
+	}
+	return unit, nil
+}
+
+// parseUnitFile reads the description and commands of a unit file. It
+// returns true if the unit file has an [Install] section, i.e. if it can be
+// enabled.
+func parseUnitFile(contents []byte, unit *Unit) (hasInstall bool) {
+	var section, line string
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if strings.HasSuffix(text, `\`) {
+			// Continuation line.
+			line += strings.TrimSpace(strings.TrimSuffix(text, `\`)) + " "
+			continue
+		}
+		line, text = "", line+text
+
+		if text == "" || text[0] == '#' || text[0] == ';' {
+			continue
+		}
+		if text[0] == '[' && text[len(text)-1] == ']' {
+			section = text[1 : len(text)-1]
+			continue
+		}
+
+		eq := strings.IndexByte(text, '=')
+		if eq < 0 {
+			continue
+		}
+		key, value := strings.TrimSpace(text[:eq]), strings.TrimSpace(text[eq+1:])
+		switch {
+		case section == "Unit" && key == "Description":
+			unit.Description = value
+		case section == "Service" && key == "ExecStart":
+			if value == "" {
+				// An empty assignment resets the list.
+				unit.ExecStart = nil
+			} else {
+				unit.ExecStart = append(unit.ExecStart, value)
+			}
+		case section == "Install":
+			switch key {
+			case "WantedBy", "RequiredBy", "UpheldBy", "Alias", "Also":
+				if value != "" {
+					hasInstall = true
+				}
+			}
+		}
+	}
+	return hasInstall
+}
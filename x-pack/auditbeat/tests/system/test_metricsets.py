@@ -86,3 +86,34 @@ class Test(AuditbeatXPackTest):
         fields = ["user.entity_id", "system.audit.user.name"]
 
         self.check_metricset("system", "user", COMMON_FIELDS + fields)
+
+    @unittest.skipUnless(sys.platform.startswith('linux'), "Only implemented for Linux")
+    @unittest.skipUnless(os.path.isfile("/proc/modules") and open("/proc/modules").read(), "No kernel modules loaded")
+    def test_metricset_kernel_module(self):
+        """
+        kernel_module metricset collects information about loaded kernel modules.
+        """
+
+        fields = ["system.audit.kernel_module.entity_id", "system.audit.kernel_module.name",
+                  "system.audit.kernel_module.size"]
+
+        # Metricset is beta and that generates a warning, TODO: remove later
+        self.check_metricset("system", "kernel_module", COMMON_FIELDS + fields, warnings_allowed=True)
+
+    @unittest.skipUnless(sys.platform.startswith('linux'), "Only implemented for Linux")
+    def test_metricset_systemd_unit(self):
+        """
+        systemd_unit metricset collects information about systemd unit files.
+        """
+
+        fields = ["system.audit.systemd_unit.entity_id", "system.audit.systemd_unit.name",
+                  "system.audit.systemd_unit.state"]
+
+        testdata = os.path.abspath(os.path.join(self.beat_path, "module/system/systemd_unit/testdata"))
+        config = {
+            "systemd_unit.paths": [os.path.join(testdata, "etc/systemd/system"),
+                                   os.path.join(testdata, "lib/systemd/system")],
+        }
+
+        # Metricset is beta and that generates a warning, TODO: remove later
+        self.check_metricset("system", "systemd_unit", COMMON_FIELDS + fields, config, warnings_allowed=True)
@@ -0,0 +1,23 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// Code generated by beats/dev-tools/cmd/asset/asset.go - DO NOT EDIT.
+
+package otlp
+
+import (
+	"github.com/elastic/beats/v7/libbeat/asset"
+)
+
+func init() {
+	if err := asset.SetFields("metricbeat", "otlp", asset.ModuleFieldsPri, AssetOtlp); err != nil {
+		panic(err)
+	}
+}
+
+// AssetOtlp returns asset data.
+// This is the base64 encoded gzipped contents of module/otlp.
+func AssetOtlp() string {
+	return "eJzMldFuEz0Qhe/zFEe56f9X7T7AXiAhkAAJWgQVNwhVzu50Y2p7VuNxSt4eebObbkiWopSKSr5Ixt7x+Txn7HPc0roEq2tngFp1VGJ+2VK4IkeeVNbzGVBTrMS2ajmUeDEDgA+kYqsIoYrsimrcWV1Cl4Sdr9EKK1fs8N/l1fuP/xczQMiRiVRiQWpmQCRVG5pY4us8Rjf/NgNuLLk6lt1W5wjG01ZlDum6pRKNcBoiByTm8YkiJ6noDLHilmBCDaMqdpGUIvimk+wPwmTBRZ9prGesSfr8xX3S4nS7ahDKi+9U6Si8CVxvZm9pfcdSj6YnYPJ4uSd+kABdGs3nXaeK6jFXgdesETbk4E62reruiCOMEIRaZ3KGxRop1CSxYqFY7LF3J1rk36OcRyBdGE8DjA1RJXkKarLb+qpNk02IWpFEy+Fxur5skvw1af/OIrVRg5Zt0F0vHKr/Tqo/9kIPPfp4v0UfkH34RslNeLbxYVZsBSy2scG4bueRlP2LBTjcvGPhJ6fFyrhEJzuzk1XZq0zNaeFoesW1N21rQ9Mvn5/Of1n7mxPJ441JzeCpM7AgJt//3TS8jQisMPAcWDnYChWnoCTFFHA//1yRXyWfnFG7ohHTPfYklhh9tmV8FyrJj17uSHMQC9GGimA1ohVaWU5x1LeT0EsblRsx/ljybYKng387bJH9Sz9aDhTUGne/90Ol7Rx7LKHj0Dwd3EXyC5Jc1+4e6a5Wg+WjkWPyz9XMn5MfXpYjmX8OAKhK/0Q="
+}
@@ -0,0 +1,44 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/elastic/beats/v7/libbeat/common/cfgtype"
+	"github.com/elastic/beats/v7/libbeat/common/transport/tlscommon"
+)
+
+type config struct {
+	TLS  *tlscommon.ServerConfig `config:"ssl"`
+	GRPC grpcConfig              `config:"grpc"`
+
+	// CumulativeCacheTimeout is the time after which the last value of a
+	// cumulative series is forgotten if no new data point is received.
+	CumulativeCacheTimeout time.Duration `config:"cumulative_cache_timeout" validate:"positive"`
+
+	// MaxMessageSize is the maximum size of a request, after decompression.
+	MaxMessageSize cfgtype.ByteSize `config:"max_message_size" validate:"nonzero,positive"`
+}
+
+type grpcConfig struct {
+	Enabled bool   `config:"enabled"`
+	Host    string `config:"host"`
+	Port    int    `config:"port"`
+}
+
+func defaultConfig() config {
+	return config{
+		GRPC: grpcConfig{
+			Enabled: false,
+			Host:    "localhost",
+			Port:    4317,
+		},
+		CumulativeCacheTimeout: 10 * time.Minute,
+		MaxMessageSize:         4 * humanize.MiByte,
+	}
+}
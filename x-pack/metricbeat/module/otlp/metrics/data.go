@@ -0,0 +1,385 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package metrics
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/metricbeat/mb"
+)
+
+// eventGenerator converts OTLP metrics to events. Data points with the same
+// resource, scope, attributes and timestamp are grouped in the same event.
+//
+// Cumulative values are kept in a cache to report the increase since the
+// previous data point of the same series, so cumulative and delta data points
+// can be aggregated in the same way.
+type eventGenerator struct {
+	cache   *common.Cache
+	timeout time.Duration
+}
+
+// cumulativeState is the last cumulative data point received for a series.
+type cumulativeState struct {
+	start  uint64
+	value  float64
+	layout string
+	counts map[bucketKey]uint64
+	count  uint64
+}
+
+// histogramPoint is a histogram data point with its buckets converted to
+// centroids. layout identifies the bucket boundaries, the bucket keys of data
+// points with different layouts are not comparable.
+type histogramPoint struct {
+	layout  string
+	buckets []bucket
+	count   uint64
+	sum     *float64
+}
+
+// bucket is a bucket of a histogram, represented by its centroid.
+type bucket struct {
+	key   bucketKey
+	value float64
+	count uint64
+}
+
+// bucketKey identifies a bucket in a histogram layout. sign is -1 for the
+// negative buckets of exponential histograms, 0 for their zero bucket, and 1
+// for their positive buckets and the buckets of explicit histograms.
+type bucketKey struct {
+	sign  int8
+	index int
+}
+
+// eventGroup holds the events being generated for a request, in the order
+// they were created.
+type eventGroup struct {
+	keys   map[string]int
+	events []mb.Event
+}
+
+func newEventGenerator(timeout time.Duration) *eventGenerator {
+	return &eventGenerator{
+		cache:   common.NewCache(timeout, 0),
+		timeout: timeout,
+	}
+}
+
+// Start starts the cleanup of the expired cumulative series.
+func (g *eventGenerator) Start() {
+	g.cache.StartJanitor(g.timeout)
+}
+
+// Stop stops the cleanup of the expired cumulative series.
+func (g *eventGenerator) Stop() {
+	g.cache.StopJanitor()
+}
+
+// GenerateEvents converts the metrics of an export request to events.
+func (g *eventGenerator) GenerateEvents(req *exportRequest) []mb.Event {
+	group := &eventGroup{keys: map[string]int{}}
+
+	for i := range req.ResourceMetrics {
+		rm := &req.ResourceMetrics[i]
+		resAttrs := attributesToMapStr(rm.Resource.Attributes)
+
+		for _, sm := range rm.scopeMetrics() {
+			series := &seriesContext{
+				resource:    resAttrs,
+				resourceKey: resAttrs.String(),
+				scope:       sm.Scope,
+			}
+			for j := range sm.Metrics {
+				g.addMetric(group, series, &sm.Metrics[j])
+			}
+		}
+	}
+
+	return group.events
+}
+
+// seriesContext holds the resource and scope of the metrics being converted.
+type seriesContext struct {
+	resource    common.MapStr
+	resourceKey string
+	scope       scope
+}
+
+func (g *eventGenerator) addMetric(group *eventGroup, series *seriesContext, m *metric) {
+	switch {
+	case m.Gauge != nil:
+		for i := range m.Gauge.DataPoints {
+			p := &m.Gauge.DataPoints[i]
+			if v, ok := p.value(); ok {
+				group.get(series, p.Attributes, p.TimeUnixNano).Put(m.Name+".value", v)
+			}
+		}
+
+	case m.Sum != nil:
+		for i := range m.Sum.DataPoints {
+			p := &m.Sum.DataPoints[i]
+			v, ok := p.value()
+			if !ok {
+				continue
+			}
+			fields := group.get(series, p.Attributes, p.TimeUnixNano)
+
+			switch {
+			case m.Sum.AggregationTemporality == temporalityDelta:
+				fields.Put(m.Name+".rate", v)
+			case m.Sum.AggregationTemporality == temporalityCumulative && m.Sum.IsMonotonic:
+				fields.Put(m.Name+".counter", v)
+				key := series.key(m.Name, p.Attributes)
+				if rate, ok := g.rate(key, uint64(p.StartTimeUnixNano), v); ok {
+					fields.Put(m.Name+".rate", rate)
+				}
+			default:
+				fields.Put(m.Name+".value", v)
+			}
+		}
+
+	case m.Histogram != nil:
+		for i := range m.Histogram.DataPoints {
+			p := &m.Histogram.DataPoints[i]
+			h := histogramPoint{
+				layout:  explicitLayout(p.ExplicitBounds),
+				buckets: explicitBuckets(p.ExplicitBounds, p.BucketCounts),
+				count:   uint64(p.Count),
+				sum:     p.Sum,
+			}
+			g.addHistogram(group, series, m.Name, m.Histogram.AggregationTemporality,
+				p.Attributes, p.StartTimeUnixNano, p.TimeUnixNano, h)
+		}
+
+	case m.ExponentialHistogram != nil:
+		for i := range m.ExponentialHistogram.DataPoints {
+			p := &m.ExponentialHistogram.DataPoints[i]
+			h := histogramPoint{
+				layout:  "scale:" + strconv.Itoa(int(p.Scale)),
+				buckets: exponentialBuckets(p),
+				count:   uint64(p.Count),
+				sum:     p.Sum,
+			}
+			g.addHistogram(group, series, m.Name, m.ExponentialHistogram.AggregationTemporality,
+				p.Attributes, p.StartTimeUnixNano, p.TimeUnixNano, h)
+		}
+	}
+}
+
+func (g *eventGenerator) addHistogram(group *eventGroup, series *seriesContext, name string, t temporality,
+	attributes []keyValue, start, ts jsonUint64, h histogramPoint) {
+	if t == temporalityCumulative {
+		key := series.key(name, attributes)
+		var ok bool
+		h, ok = g.deltaHistogram(key, uint64(start), h)
+		if !ok {
+			return
+		}
+	}
+
+	fields := group.get(series, attributes, ts)
+	fields.Put(name+".count", h.count)
+	if h.sum != nil {
+		fields.Put(name+".sum", *h.sum)
+	}
+
+	values := make([]float64, 0, len(h.buckets))
+	counts := make([]uint64, 0, len(h.buckets))
+	for _, b := range h.buckets {
+		if b.count == 0 {
+			continue
+		}
+		values = append(values, b.value)
+		counts = append(counts, b.count)
+	}
+
+	if len(values) == 0 {
+		return
+	}
+	fields.Put(name+".histogram", common.MapStr{
+		"values": values,
+		"counts": counts,
+	})
+}
+
+// rate returns the increase of a cumulative value since its previous data
+// point, and false if this is the first data point of the series. A different
+// start time, or a lower value, is considered a reset of the series.
+func (g *eventGenerator) rate(key string, start uint64, value float64) (float64, bool) {
+	prev, _ := g.cache.PutWithTimeout(key, &cumulativeState{start: start, value: value}, g.timeout).(*cumulativeState)
+	switch {
+	case prev == nil:
+		return 0, false
+	case isReset(prev.start, start) || value < prev.value:
+		return value, true
+	}
+	return value - prev.value, true
+}
+
+// deltaHistogram returns the increase of the counts and sum of a cumulative
+// histogram since its previous data point, and false if this is the first data
+// point of the series. A different start time or bucket layout, or a lower
+// count, is considered a reset of the series.
+func (g *eventGenerator) deltaHistogram(key string, start uint64, h histogramPoint) (histogramPoint, bool) {
+	state := &cumulativeState{
+		start:  start,
+		layout: h.layout,
+		counts: make(map[bucketKey]uint64, len(h.buckets)),
+		count:  h.count,
+	}
+	if h.sum != nil {
+		state.value = *h.sum
+	}
+	for _, b := range h.buckets {
+		state.counts[b.key] = b.count
+	}
+
+	prev, _ := g.cache.PutWithTimeout(key, state, g.timeout).(*cumulativeState)
+	if prev == nil {
+		return h, false
+	}
+	if isReset(prev.start, start) || prev.layout != h.layout || h.count < prev.count {
+		return h, true
+	}
+
+	delta := histogramPoint{
+		layout:  h.layout,
+		buckets: make([]bucket, len(h.buckets)),
+		count:   h.count - prev.count,
+	}
+	if h.sum != nil {
+		sum := *h.sum - prev.value
+		delta.sum = &sum
+	}
+	for i, b := range h.buckets {
+		prevCount := prev.counts[b.key]
+		if b.count < prevCount {
+			// Counts can only decrease after a reset.
+			return h, true
+		}
+		delta.buckets[i] = bucket{key: b.key, value: b.value, count: b.count - prevCount}
+	}
+	return delta, true
+}
+
+func isReset(prevStart, start uint64) bool {
+	return start != 0 && prevStart != start
+}
+
+// explicitBuckets converts the buckets of a histogram to their centroids, in
+// the same way as the prometheus module does for Prometheus histograms. The
+// last bucket has no upper bound, its value is interpolated.
+func explicitBuckets(bounds []float64, counts []jsonUint64) []bucket {
+	buckets := make([]bucket, 0, len(counts))
+
+	var lastUpper, prevUpper float64
+	for i, count := range counts {
+		var value float64
+		if i < len(bounds) {
+			value = lastUpper + (bounds[i]-lastUpper)/2.0
+			prevUpper = lastUpper
+			lastUpper = bounds[i]
+		} else {
+			value = lastUpper + (lastUpper - prevUpper)
+		}
+		buckets = append(buckets, bucket{key: bucketKey{sign: 1, index: i}, value: value, count: uint64(count)})
+	}
+	return buckets
+}
+
+// explicitLayout identifies the bounds of an explicit histogram.
+func explicitLayout(bounds []float64) string {
+	b := make([]byte, 0, len(bounds)*8)
+	for _, bound := range bounds {
+		b = strconv.AppendFloat(b, bound, 'g', -1, 64)
+		b = append(b, ',')
+	}
+	return string(b)
+}
+
+// exponentialBuckets converts the buckets of an exponential histogram to
+// their centroids, sorted by value. The bucket with index i contains the
+// values in (base^i, base^(i+1)], with base = 2^(2^-scale).
+func exponentialBuckets(p *exponentialHistogramDataPoint) []bucket {
+	bound := func(index int) float64 {
+		return math.Exp2(float64(index) * math.Exp2(-float64(p.Scale)))
+	}
+	centroid := func(index int) float64 {
+		return (bound(index) + bound(index+1)) / 2
+	}
+
+	buckets := make([]bucket, 0, len(p.Negative.BucketCounts)+len(p.Positive.BucketCounts)+1)
+	for i := len(p.Negative.BucketCounts) - 1; i >= 0; i-- {
+		index := int(p.Negative.Offset) + i
+		buckets = append(buckets, bucket{
+			key:   bucketKey{sign: -1, index: index},
+			value: -centroid(index),
+			count: uint64(p.Negative.BucketCounts[i]),
+		})
+	}
+	buckets = append(buckets, bucket{value: 0, count: uint64(p.ZeroCount)})
+	for i, count := range p.Positive.BucketCounts {
+		index := int(p.Positive.Offset) + i
+		buckets = append(buckets, bucket{
+			key:   bucketKey{sign: 1, index: index},
+			value: centroid(index),
+			count: uint64(count),
+		})
+	}
+
+	sort.SliceStable(buckets, func(i, j int) bool { return buckets[i].value < buckets[j].value })
+	return buckets
+}
+
+// key identifies a series in the cumulative cache.
+func (s *seriesContext) key(name string, attributes []keyValue) string {
+	return s.resourceKey + "|" + s.scope.Name + "|" + s.scope.Version + "|" + name + "|" +
+		attributesToMapStr(attributes).String()
+}
+
+// get returns the metricset fields of the event for the given attributes and
+// timestamp, creating the event if needed.
+func (eg *eventGroup) get(series *seriesContext, attributes []keyValue, ts jsonUint64) common.MapStr {
+	attrs := attributesToMapStr(attributes)
+	key := series.resourceKey + "|" + series.scope.Name + "|" + series.scope.Version + "|" +
+		attrs.String() + "|" + strconv.FormatUint(uint64(ts), 10)
+	if i, found := eg.keys[key]; found {
+		return eg.events[i].MetricSetFields
+	}
+
+	event := mb.Event{
+		Timestamp:       time.Now().UTC(),
+		ModuleFields:    common.MapStr{},
+		MetricSetFields: common.MapStr{},
+	}
+	if ts > 0 {
+		event.Timestamp = time.Unix(0, int64(ts)).UTC()
+	}
+	if len(series.resource) > 0 {
+		event.ModuleFields.Put("resource.attributes", series.resource.Clone())
+		if name, ok := series.resource["service_name"].(string); ok {
+			event.RootFields = common.MapStr{"service": common.MapStr{"name": name}}
+		}
+	}
+	if series.scope.Name != "" {
+		event.ModuleFields.Put("scope.name", series.scope.Name)
+	}
+	if series.scope.Version != "" {
+		event.ModuleFields.Put("scope.version", series.scope.Version)
+	}
+	if len(attrs) > 0 {
+		event.ModuleFields.Put("attributes", attrs)
+	}
+
+	eg.keys[key] = len(eg.events)
+	eg.events = append(eg.events, event)
+	return event.MetricSetFields
+}
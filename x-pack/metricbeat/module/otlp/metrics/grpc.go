@@ -0,0 +1,133 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package metrics
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	_ "google.golang.org/grpc/encoding/gzip" // Register the gzip compressor used by OTLP exporters.
+	"google.golang.org/grpc/status"
+
+	"github.com/elastic/beats/v7/libbeat/common/transport/tlscommon"
+	"github.com/elastic/beats/v7/libbeat/logp"
+)
+
+// grpcServer receives OTLP metrics through the gRPC MetricsService. Messages
+// are not decoded by gRPC, but passed as raw bytes to the export function.
+type grpcServer struct {
+	server   *grpc.Server
+	listener net.Listener
+	export   func(ctx context.Context, req *exportRequest) error
+	logger   *logp.Logger
+}
+
+// rawMessage is the type of the gRPC messages handled by rawCodec.
+type rawMessage []byte
+
+// rawCodec is a gRPC codec that does not decode messages, so the service can
+// be implemented without generated code.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(*rawMessage)
+	if !ok {
+		return nil, errors.Errorf("unexpected message type %T", v)
+	}
+	return *msg, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(*rawMessage)
+	if !ok {
+		return errors.Errorf("unexpected message type %T", v)
+	}
+	*msg = append((*msg)[:0], data...)
+	return nil
+}
+
+func (rawCodec) String() string { return "raw" }
+
+var metricsServiceDesc = grpc.ServiceDesc{
+	ServiceName: "opentelemetry.proto.collector.metrics.v1.MetricsService",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Export",
+			Handler:    exportHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "opentelemetry/proto/collector/metrics/v1/metrics_service.proto",
+}
+
+func exportHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+	var msg rawMessage
+	if err := dec(&msg); err != nil {
+		return nil, err
+	}
+
+	req, err := decodeExportRequest(msg)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := srv.(*grpcServer).export(ctx, req); err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+
+	// An empty ExportMetricsServiceResponse.
+	return &rawMessage{}, nil
+}
+
+func newGRPCServer(cfg grpcConfig, tlsCfg *tlscommon.ServerConfig, maxMessageSize int, export func(context.Context, *exportRequest) error) (*grpcServer, error) {
+	opts := []grpc.ServerOption{
+		grpc.CustomCodec(rawCodec{}),
+		grpc.MaxRecvMsgSize(maxMessageSize),
+	}
+
+	tlsConfig, err := tlscommon.LoadTLSServerConfig(tlsCfg)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig.BuildServerConfig(cfg.Host))))
+	}
+
+	s := &grpcServer{
+		server: grpc.NewServer(opts...),
+		export: export,
+		logger: logp.NewLogger("otlp.grpc"),
+	}
+	s.server.RegisterService(&metricsServiceDesc, s)
+	return s, nil
+}
+
+// Start starts listening for gRPC requests on the configured address.
+func (s *grpcServer) Start(cfg grpcConfig) error {
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen on %s", addr)
+	}
+	s.listener = listener
+
+	s.logger.Infof("Starting OTLP gRPC server on %s", listener.Addr())
+	go func() {
+		if err := s.server.Serve(listener); err != nil {
+			s.logger.Errorf("OTLP gRPC server failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Stop stops the server, waiting for the pending requests to finish.
+func (s *grpcServer) Stop() {
+	s.server.GracefulStop()
+}
@@ -0,0 +1,240 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package metrics
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	serverhelper "github.com/elastic/beats/v7/metricbeat/helper/server"
+	httpserver "github.com/elastic/beats/v7/metricbeat/helper/server/http"
+	"github.com/elastic/beats/v7/metricbeat/mb"
+	"github.com/elastic/beats/v7/metricbeat/mb/parse"
+)
+
+const (
+	metricsPath      = "/v1/metrics"
+	contentTypeJSON  = "application/json"
+	contentTypeProto = "application/x-protobuf"
+)
+
+func init() {
+	mb.Registry.MustAddMetricSet("otlp", "metrics", New,
+		mb.WithHostParser(parse.EmptyHostParser),
+		mb.DefaultMetricSet(),
+	)
+}
+
+// MetricSet receives metrics sent with the OpenTelemetry protocol (OTLP), over
+// HTTP and optionally over gRPC.
+type MetricSet struct {
+	mb.BaseMetricSet
+	config     config
+	server     serverhelper.Server
+	grpcServer *grpcServer
+	events     chan mb.Event
+	done       chan struct{}
+	generator  *eventGenerator
+}
+
+// errStopped is returned by export when the metricset is stopped while the
+// events of a request are being reported.
+var errStopped = errors.New("metricset is stopped")
+
+// errMessageTooLarge is returned when a request exceeds max_message_size.
+var errMessageTooLarge = errors.New("request body too large")
+
+// New creates a new instance of the MetricSet.
+func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	cfgwarn.Beta("The otlp metrics metricset is beta.")
+
+	config := defaultConfig()
+	if err := base.Module().UnpackConfig(&config); err != nil {
+		return nil, err
+	}
+
+	m := &MetricSet{
+		BaseMetricSet: base,
+		config:        config,
+		events:        make(chan mb.Event),
+		done:          make(chan struct{}),
+		generator:     newEventGenerator(config.CumulativeCacheTimeout),
+	}
+
+	svc, err := httpserver.NewHttpServerWithHandler(base, m.handleFunc)
+	if err != nil {
+		return nil, err
+	}
+	m.server = svc
+
+	if config.GRPC.Enabled {
+		m.grpcServer, err = newGRPCServer(config.GRPC, config.TLS, int(config.MaxMessageSize), m.export)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create gRPC server")
+		}
+	}
+
+	return m, nil
+}
+
+// Run starts the servers and reports the received metrics until the
+// metricset is stopped.
+func (m *MetricSet) Run(reporter mb.PushReporterV2) {
+	m.generator.Start()
+	defer m.generator.Stop()
+
+	if err := m.server.Start(); err != nil {
+		reporter.Error(errors.Wrap(err, "failed to start HTTP server"))
+		return
+	}
+	defer m.server.Stop()
+
+	if m.grpcServer != nil {
+		if err := m.grpcServer.Start(m.config.GRPC); err != nil {
+			close(m.done)
+			reporter.Error(err)
+			return
+		}
+		defer m.grpcServer.Stop()
+	}
+
+	// Pending exports are aborted before the servers are stopped, as the
+	// events are not read anymore and the gRPC server waits for them.
+	defer close(m.done)
+
+	for {
+		select {
+		case <-reporter.Done():
+			return
+		case e := <-m.events:
+			reporter.Event(e)
+		}
+	}
+}
+
+// export generates the events for the metrics in a request and sends them to
+// the reporter.
+func (m *MetricSet) export(ctx context.Context, req *exportRequest) error {
+	for _, e := range m.generator.GenerateEvents(req) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-m.done:
+			return errStopped
+		case m.events <- e:
+		}
+	}
+	return nil
+}
+
+func (m *MetricSet) handleFunc(writer http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != metricsPath {
+		http.NotFound(writer, req)
+		return
+	}
+	if req.Method != http.MethodPost {
+		writer.Header().Set("Allow", http.MethodPost)
+		http.Error(writer, "only POST requests are supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType != contentTypeJSON {
+		mediaType = contentTypeProto
+	}
+
+	exportReq, err := decodeRequest(req, mediaType, int64(m.config.MaxMessageSize))
+	if err == errMessageTooLarge {
+		writeStatus(writer, mediaType, http.StatusRequestEntityTooLarge, codes.ResourceExhausted, err)
+		return
+	}
+	if err != nil {
+		m.Logger().Errorf("Decode error %v", err)
+		writeStatus(writer, mediaType, http.StatusBadRequest, codes.InvalidArgument, err)
+		return
+	}
+
+	if err := m.export(req.Context(), exportReq); err != nil {
+		// The metricset is stopping or the client is gone, the client can
+		// retry later.
+		writeStatus(writer, mediaType, http.StatusServiceUnavailable, codes.Unavailable, err)
+		return
+	}
+
+	// An empty ExportMetricsServiceResponse in the encoding of the request.
+	writer.Header().Set("Content-Type", mediaType)
+	writer.WriteHeader(http.StatusOK)
+	if mediaType == contentTypeJSON {
+		writer.Write([]byte("{}"))
+	}
+}
+
+// writeStatus writes an OTLP/HTTP error response, a google.rpc.Status message
+// in the encoding of the request.
+func writeStatus(writer http.ResponseWriter, mediaType string, statusCode int, code codes.Code, err error) {
+	var body []byte
+	if mediaType == contentTypeJSON {
+		body, _ = json.Marshal(struct {
+			Code    codes.Code `json:"code"`
+			Message string     `json:"message"`
+		}{code, err.Error()})
+	} else {
+		body = encodeStatus(uint64(code), err.Error())
+	}
+
+	writer.Header().Set("Content-Type", mediaType)
+	writer.WriteHeader(statusCode)
+	writer.Write(body)
+}
+
+// decodeRequest decodes the body of an OTLP/HTTP request, in JSON or protobuf
+// encoding, optionally compressed with gzip. Bodies bigger than maxSize once
+// decompressed are rejected with errMessageTooLarge.
+func decodeRequest(req *http.Request, mediaType string, maxSize int64) (*exportRequest, error) {
+	var body io.Reader = req.Body
+	switch req.Header.Get("Content-Encoding") {
+	case "", "identity":
+	case "gzip":
+		gz, err := gzip.NewReader(req.Body)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid gzip body")
+		}
+		defer gz.Close()
+		body = gz
+	default:
+		return nil, errors.Errorf("unsupported content encoding '%s'", req.Header.Get("Content-Encoding"))
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(body, maxSize+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read body")
+	}
+	if int64(len(data)) > maxSize {
+		return nil, errMessageTooLarge
+	}
+
+	if mediaType == contentTypeJSON {
+		var exportReq exportRequest
+		if err := json.Unmarshal(data, &exportReq); err != nil {
+			return nil, errors.Wrap(err, "invalid JSON request")
+		}
+		return &exportReq, nil
+	}
+	return decodeExportRequest(data)
+}
+
+// Close stops the metricset.
+func (m *MetricSet) Close() error {
+	return nil
+}
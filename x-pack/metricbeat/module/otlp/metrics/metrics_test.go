@@ -0,0 +1,485 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package metrics
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+	serverhelper "github.com/elastic/beats/v7/metricbeat/helper/server"
+	"github.com/elastic/beats/v7/metricbeat/mb"
+)
+
+const jsonRequest = `{
+  "resourceMetrics": [{
+    "resource": {
+      "attributes": [{"key": "service.name", "value": {"stringValue": "checkout"}}]
+    },
+    "scopeMetrics": [{
+      "scope": {"name": "meter", "version": "1.0"},
+      "metrics": [
+        {
+          "name": "queue_size",
+          "gauge": {"dataPoints": [
+            {"timeUnixNano": "1600000000000000000", "asInt": "3", "attributes": [{"key": "queue", "value": {"stringValue": "a"}}]}
+          ]}
+        },
+        {
+          "name": "requests",
+          "sum": {
+            "aggregationTemporality": "AGGREGATION_TEMPORALITY_DELTA",
+            "isMonotonic": true,
+            "dataPoints": [
+              {"timeUnixNano": "1600000000000000000", "asDouble": 10, "attributes": [{"key": "queue", "value": {"stringValue": "a"}}]}
+            ]
+          }
+        },
+        {
+          "name": "latency",
+          "histogram": {
+            "aggregationTemporality": 1,
+            "dataPoints": [
+              {"timeUnixNano": "1600000000000000000", "count": "3", "sum": 6.5, "bucketCounts": ["1", "0", "2"], "explicitBounds": [1, 2]}
+            ]
+          }
+        }
+      ]
+    }]
+  }]
+}`
+
+func TestGenerateEventsJSON(t *testing.T) {
+	var req exportRequest
+	require.NoError(t, json.Unmarshal([]byte(jsonRequest), &req))
+
+	g := newEventGenerator(time.Minute)
+	events := g.GenerateEvents(&req)
+	require.Len(t, events, 2)
+
+	ts := time.Unix(0, 1600000000000000000).UTC()
+
+	assert.Equal(t, ts, events[0].Timestamp)
+	assert.Equal(t, common.MapStr{
+		"queue_size": common.MapStr{"value": float64(3)},
+		"requests":   common.MapStr{"rate": float64(10)},
+	}, events[0].MetricSetFields)
+	assert.Equal(t, common.MapStr{
+		"resource": common.MapStr{
+			"attributes": common.MapStr{"service_name": "checkout"},
+		},
+		"scope":      common.MapStr{"name": "meter", "version": "1.0"},
+		"attributes": common.MapStr{"queue": "a"},
+	}, events[0].ModuleFields)
+	assert.Equal(t, common.MapStr{"service": common.MapStr{"name": "checkout"}}, events[0].RootFields)
+
+	assert.Equal(t, common.MapStr{
+		"latency": common.MapStr{
+			"count": uint64(3),
+			"sum":   6.5,
+			"histogram": common.MapStr{
+				"values": []float64{0.5, 3},
+				"counts": []uint64{1, 2},
+			},
+		},
+	}, events[1].MetricSetFields)
+}
+
+func TestGenerateEventsCumulative(t *testing.T) {
+	g := newEventGenerator(time.Minute)
+
+	request := func(start, ts uint64, value float64, counts ...uint64) *exportRequest {
+		var count uint64
+		bucketCounts := make([]jsonUint64, len(counts))
+		for i, c := range counts {
+			bucketCounts[i] = jsonUint64(c)
+			count += c
+		}
+		latencySum := 2 * value
+		return &exportRequest{ResourceMetrics: []resourceMetrics{{
+			ScopeMetrics: []scopeMetrics{{
+				Metrics: []metric{
+					{
+						Name: "requests",
+						Sum: &sum{
+							AggregationTemporality: temporalityCumulative,
+							IsMonotonic:            true,
+							DataPoints: []numberDataPoint{{
+								StartTimeUnixNano: jsonUint64(start),
+								TimeUnixNano:      jsonUint64(ts),
+								AsDouble:          &value,
+							}},
+						},
+					},
+					{
+						Name: "latency",
+						Histogram: &histogram{
+							AggregationTemporality: temporalityCumulative,
+							DataPoints: []histogramDataPoint{{
+								StartTimeUnixNano: jsonUint64(start),
+								TimeUnixNano:      jsonUint64(ts),
+								Count:             jsonUint64(count),
+								Sum:               &latencySum,
+								ExplicitBounds:    []float64{1},
+								BucketCounts:      bucketCounts,
+							}},
+						},
+					},
+				},
+			}},
+		}}}
+	}
+
+	// First data point, no rate nor histogram can be calculated.
+	events := g.GenerateEvents(request(1, 10, 5, 1, 1))
+	require.Len(t, events, 1)
+	assert.Equal(t, common.MapStr{
+		"requests": common.MapStr{"counter": float64(5)},
+	}, events[0].MetricSetFields)
+
+	events = g.GenerateEvents(request(1, 20, 8, 3, 1))
+	require.Len(t, events, 1)
+	assert.Equal(t, common.MapStr{
+		"requests": common.MapStr{"counter": float64(8), "rate": float64(3)},
+		"latency": common.MapStr{
+			"count": uint64(2),
+			"sum":   float64(6),
+			"histogram": common.MapStr{
+				"values": []float64{0.5},
+				"counts": []uint64{2},
+			},
+		},
+	}, events[0].MetricSetFields)
+
+	// The series is reset when the start time changes.
+	events = g.GenerateEvents(request(30, 40, 2, 1, 1))
+	require.Len(t, events, 1)
+	assert.Equal(t, common.MapStr{
+		"requests": common.MapStr{"counter": float64(2), "rate": float64(2)},
+		"latency": common.MapStr{
+			"count": uint64(2),
+			"sum":   float64(4),
+			"histogram": common.MapStr{
+				"values": []float64{0.5, 2},
+				"counts": []uint64{1, 1},
+			},
+		},
+	}, events[0].MetricSetFields)
+}
+
+func TestExponentialBuckets(t *testing.T) {
+	p := &exponentialHistogramDataPoint{
+		Scale:     0,
+		ZeroCount: 1,
+		Positive:  buckets{Offset: 0, BucketCounts: []jsonUint64{2, 3}},
+		Negative:  buckets{Offset: 1, BucketCounts: []jsonUint64{4}},
+	}
+
+	assert.Equal(t, []bucket{
+		{key: bucketKey{sign: -1, index: 1}, value: -3, count: 4},
+		{key: bucketKey{sign: 0, index: 0}, value: 0, count: 1},
+		{key: bucketKey{sign: 1, index: 0}, value: 1.5, count: 2},
+		{key: bucketKey{sign: 1, index: 1}, value: 3, count: 3},
+	}, exponentialBuckets(p))
+}
+
+func TestGenerateEventsExponentialScaleChange(t *testing.T) {
+	g := newEventGenerator(time.Minute)
+
+	request := func(ts uint64, scale int32, counts ...uint64) *exportRequest {
+		var count uint64
+		bucketCounts := make([]jsonUint64, len(counts))
+		for i, c := range counts {
+			bucketCounts[i] = jsonUint64(c)
+			count += c
+		}
+		return &exportRequest{ResourceMetrics: []resourceMetrics{{
+			ScopeMetrics: []scopeMetrics{{
+				Metrics: []metric{{
+					Name: "latency",
+					ExponentialHistogram: &exponentialHistogram{
+						AggregationTemporality: temporalityCumulative,
+						DataPoints: []exponentialHistogramDataPoint{{
+							StartTimeUnixNano: 1,
+							TimeUnixNano:      jsonUint64(ts),
+							Count:             jsonUint64(count),
+							Scale:             scale,
+							Positive:          buckets{BucketCounts: bucketCounts},
+						}},
+					},
+				}},
+			}},
+		}}}
+	}
+
+	events := g.GenerateEvents(request(10, 1, 1, 1))
+	require.Len(t, events, 0)
+
+	events = g.GenerateEvents(request(20, 1, 2, 3))
+	require.Len(t, events, 1)
+	assert.Equal(t, common.MapStr{
+		"latency": common.MapStr{
+			"count": uint64(3),
+			"histogram": common.MapStr{
+				"values": []float64{(1 + math.Sqrt2) / 2, (math.Sqrt2 + 2) / 2},
+				"counts": []uint64{1, 2},
+			},
+		},
+	}, events[0].MetricSetFields)
+
+	// The buckets are merged when the scale decreases, so their counts are
+	// not comparable with the previous ones and the series is reset.
+	events = g.GenerateEvents(request(30, 0, 6, 1))
+	require.Len(t, events, 1)
+	assert.Equal(t, common.MapStr{
+		"latency": common.MapStr{
+			"count": uint64(7),
+			"histogram": common.MapStr{
+				"values": []float64{1.5, 3},
+				"counts": []uint64{6, 1},
+			},
+		},
+	}, events[0].MetricSetFields)
+}
+
+func TestDecodeExportRequest(t *testing.T) {
+	keyValue := func(key, value string) []byte {
+		var anyValue []byte
+		anyValue = protowire.AppendTag(anyValue, 1, protowire.BytesType)
+		anyValue = protowire.AppendString(anyValue, value)
+
+		var b []byte
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendString(b, key)
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		return protowire.AppendBytes(b, anyValue)
+	}
+
+	// NumberDataPoint
+	var point []byte
+	point = protowire.AppendTag(point, 7, protowire.BytesType)
+	point = protowire.AppendBytes(point, keyValue("queue", "a"))
+	point = protowire.AppendTag(point, 3, protowire.Fixed64Type)
+	point = protowire.AppendFixed64(point, 1600000000000000000)
+	point = protowire.AppendTag(point, 4, protowire.Fixed64Type)
+	point = protowire.AppendFixed64(point, math.Float64bits(1.5))
+
+	// Sum
+	var s []byte
+	s = protowire.AppendTag(s, 1, protowire.BytesType)
+	s = protowire.AppendBytes(s, point)
+	s = protowire.AppendTag(s, 2, protowire.VarintType)
+	s = protowire.AppendVarint(s, uint64(temporalityDelta))
+	s = protowire.AppendTag(s, 3, protowire.VarintType)
+	s = protowire.AppendVarint(s, protowire.EncodeBool(true))
+
+	// Metric
+	var m []byte
+	m = protowire.AppendTag(m, 1, protowire.BytesType)
+	m = protowire.AppendString(m, "requests")
+	m = protowire.AppendTag(m, 7, protowire.BytesType)
+	m = protowire.AppendBytes(m, s)
+
+	// Scope
+	var sc []byte
+	sc = protowire.AppendTag(sc, 1, protowire.BytesType)
+	sc = protowire.AppendString(sc, "meter")
+
+	// ScopeMetrics
+	var sm []byte
+	sm = protowire.AppendTag(sm, 1, protowire.BytesType)
+	sm = protowire.AppendBytes(sm, sc)
+	sm = protowire.AppendTag(sm, 2, protowire.BytesType)
+	sm = protowire.AppendBytes(sm, m)
+
+	// Resource
+	var res []byte
+	res = protowire.AppendTag(res, 1, protowire.BytesType)
+	res = protowire.AppendBytes(res, keyValue("service.name", "checkout"))
+
+	// ResourceMetrics
+	var rm []byte
+	rm = protowire.AppendTag(rm, 1, protowire.BytesType)
+	rm = protowire.AppendBytes(rm, res)
+	rm = protowire.AppendTag(rm, 2, protowire.BytesType)
+	rm = protowire.AppendBytes(rm, sm)
+
+	// ExportMetricsServiceRequest
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendBytes(b, rm)
+
+	req, err := decodeExportRequest(b)
+	require.NoError(t, err)
+
+	events := newEventGenerator(time.Minute).GenerateEvents(req)
+	require.Len(t, events, 1)
+	assert.Equal(t, time.Unix(0, 1600000000000000000).UTC(), events[0].Timestamp)
+	assert.Equal(t, common.MapStr{
+		"requests": common.MapStr{"rate": 1.5},
+	}, events[0].MetricSetFields)
+	assert.Equal(t, common.MapStr{
+		"resource": common.MapStr{
+			"attributes": common.MapStr{"service_name": "checkout"},
+		},
+		"scope":      common.MapStr{"name": "meter"},
+		"attributes": common.MapStr{"queue": "a"},
+	}, events[0].ModuleFields)
+
+	_, err = decodeExportRequest([]byte{0x0a, 0xff})
+	assert.Error(t, err)
+}
+
+func TestHandleExportError(t *testing.T) {
+	m := &MetricSet{
+		config:    defaultConfig(),
+		events:    make(chan mb.Event),
+		generator: newEventGenerator(time.Minute),
+	}
+
+	// Nobody receives the events, the export fails when the request is
+	// cancelled.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req := httptest.NewRequest(http.MethodPost, metricsPath, strings.NewReader(jsonRequest)).WithContext(ctx)
+	req.Header.Set("Content-Type", contentTypeJSON)
+	rec := httptest.NewRecorder()
+	m.handleFunc(rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, contentTypeJSON, rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"code":14,"message":"context canceled"}`, rec.Body.String())
+}
+
+func TestHandleMessageTooLarge(t *testing.T) {
+	m := &MetricSet{
+		config:    defaultConfig(),
+		events:    make(chan mb.Event),
+		generator: newEventGenerator(time.Minute),
+	}
+	m.config.MaxMessageSize = 1024
+
+	// A small compressed body that is too large once decompressed.
+	var body bytes.Buffer
+	gz := gzip.NewWriter(&body)
+	gz.Write(make([]byte, 2048))
+	require.NoError(t, gz.Close())
+	require.True(t, body.Len() < 1024)
+
+	req := httptest.NewRequest(http.MethodPost, metricsPath, &body)
+	req.Header.Set("Content-Type", contentTypeJSON)
+	req.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	m.handleFunc(rec, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.JSONEq(t, `{"code":8,"message":"request body too large"}`, rec.Body.String())
+}
+
+// stoppingReporter stops the metricset when it receives the first event.
+type stoppingReporter struct {
+	once sync.Once
+	done chan struct{}
+}
+
+func (r *stoppingReporter) Event(mb.Event) bool {
+	r.once.Do(func() { close(r.done) })
+	return false
+}
+func (r *stoppingReporter) Error(error) bool      { return true }
+func (r *stoppingReporter) Done() <-chan struct{} { return r.done }
+
+type fakeServer struct{}
+
+func (fakeServer) Start() error                       { return nil }
+func (fakeServer) Stop()                              {}
+func (fakeServer) GetEvents() chan serverhelper.Event { return nil }
+
+func TestStopWithPendingExport(t *testing.T) {
+	m := &MetricSet{
+		config:    defaultConfig(),
+		server:    fakeServer{},
+		events:    make(chan mb.Event),
+		done:      make(chan struct{}),
+		generator: newEventGenerator(time.Minute),
+	}
+
+	// A request with many events, the metricset is stopped before all of
+	// them are reported.
+	points := make([]string, 100)
+	for i := range points {
+		points[i] = fmt.Sprintf(`{"timeUnixNano": "%d", "asInt": "1"}`, 1600000000000000000+i)
+	}
+	body := `{"resourceMetrics": [{"scopeMetrics": [{"metrics": [{"name": "queue_size", "gauge": {"dataPoints": [` +
+		strings.Join(points, ",") + `]}}]}]}]}`
+
+	ran := make(chan struct{})
+	go func() {
+		defer close(ran)
+		m.Run(&stoppingReporter{done: make(chan struct{})})
+	}()
+
+	req := httptest.NewRequest(http.MethodPost, metricsPath, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentTypeJSON)
+	rec := httptest.NewRecorder()
+	handled := make(chan struct{})
+	go func() {
+		defer close(handled)
+		m.handleFunc(rec, req)
+	}()
+
+	for _, c := range []chan struct{}{ran, handled} {
+		select {
+		case <-c:
+		case <-time.After(5 * time.Second):
+			t.Fatal("export not aborted when the metricset is stopped")
+		}
+	}
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestGRPCServer(t *testing.T) {
+	var received *exportRequest
+	server, err := newGRPCServer(grpcConfig{Host: "localhost"}, nil, 4<<20, func(_ context.Context, req *exportRequest) error {
+		received = req
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, server.Start(grpcConfig{Host: "localhost", Port: 0}))
+	defer server.Stop()
+
+	conn, err := grpc.Dial(server.listener.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendBytes(b, nil)
+
+	var resp rawMessage
+	req := rawMessage(b)
+	err = conn.Invoke(context.Background(), "/opentelemetry.proto.collector.metrics.v1.MetricsService/Export",
+		&req, &resp, grpc.CallCustomCodec(rawCodec{}))
+	require.NoError(t, err)
+	assert.Empty(t, resp)
+	require.NotNil(t, received)
+	assert.Len(t, received.ResourceMetrics, 1)
+}
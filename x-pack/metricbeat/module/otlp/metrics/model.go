@@ -0,0 +1,272 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+)
+
+// The types in this file are the subset of the OTLP metrics data model used by
+// the metricset. They can be decoded both from the protobuf encoding (see
+// proto.go) and from the JSON encoding of OTLP, where 64 bits integers are
+// encoded as strings and field names are in lowerCamelCase.
+
+type exportRequest struct {
+	ResourceMetrics []resourceMetrics `json:"resourceMetrics"`
+}
+
+type resourceMetrics struct {
+	Resource     resource       `json:"resource"`
+	ScopeMetrics []scopeMetrics `json:"scopeMetrics"`
+
+	// Deprecated name of ScopeMetrics, still used by older SDKs.
+	InstrumentationLibraryMetrics []scopeMetrics `json:"instrumentationLibraryMetrics"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeMetrics struct {
+	Scope   scope    `json:"scope"`
+	Metrics []metric `json:"metrics"`
+
+	// Deprecated name of Scope, still used by older SDKs.
+	InstrumentationLibrary scope `json:"instrumentationLibrary"`
+}
+
+type scope struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type metric struct {
+	Name                 string                `json:"name"`
+	Description          string                `json:"description"`
+	Unit                 string                `json:"unit"`
+	Gauge                *gauge                `json:"gauge"`
+	Sum                  *sum                  `json:"sum"`
+	Histogram            *histogram            `json:"histogram"`
+	ExponentialHistogram *exponentialHistogram `json:"exponentialHistogram"`
+	Summary              *summary              `json:"summary"`
+}
+
+type gauge struct {
+	DataPoints []numberDataPoint `json:"dataPoints"`
+}
+
+// summary metrics are not supported, their data points are ignored.
+type summary struct{}
+
+type sum struct {
+	DataPoints             []numberDataPoint `json:"dataPoints"`
+	AggregationTemporality temporality       `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+}
+
+type histogram struct {
+	DataPoints             []histogramDataPoint `json:"dataPoints"`
+	AggregationTemporality temporality          `json:"aggregationTemporality"`
+}
+
+type exponentialHistogram struct {
+	DataPoints             []exponentialHistogramDataPoint `json:"dataPoints"`
+	AggregationTemporality temporality                     `json:"aggregationTemporality"`
+}
+
+type numberDataPoint struct {
+	Attributes        []keyValue `json:"attributes"`
+	StartTimeUnixNano jsonUint64 `json:"startTimeUnixNano"`
+	TimeUnixNano      jsonUint64 `json:"timeUnixNano"`
+	AsDouble          *float64   `json:"asDouble"`
+	AsInt             *jsonInt64 `json:"asInt"`
+}
+
+type histogramDataPoint struct {
+	Attributes        []keyValue   `json:"attributes"`
+	StartTimeUnixNano jsonUint64   `json:"startTimeUnixNano"`
+	TimeUnixNano      jsonUint64   `json:"timeUnixNano"`
+	Count             jsonUint64   `json:"count"`
+	Sum               *float64     `json:"sum"`
+	BucketCounts      []jsonUint64 `json:"bucketCounts"`
+	ExplicitBounds    []float64    `json:"explicitBounds"`
+}
+
+type exponentialHistogramDataPoint struct {
+	Attributes        []keyValue `json:"attributes"`
+	StartTimeUnixNano jsonUint64 `json:"startTimeUnixNano"`
+	TimeUnixNano      jsonUint64 `json:"timeUnixNano"`
+	Count             jsonUint64 `json:"count"`
+	Sum               *float64   `json:"sum"`
+	Scale             int32      `json:"scale"`
+	ZeroCount         jsonUint64 `json:"zeroCount"`
+	Positive          buckets    `json:"positive"`
+	Negative          buckets    `json:"negative"`
+}
+
+type buckets struct {
+	Offset       int32        `json:"offset"`
+	BucketCounts []jsonUint64 `json:"bucketCounts"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string      `json:"stringValue"`
+	BoolValue   *bool        `json:"boolValue"`
+	IntValue    *jsonInt64   `json:"intValue"`
+	DoubleValue *float64     `json:"doubleValue"`
+	ArrayValue  *arrayValue  `json:"arrayValue"`
+	KvlistValue *kvlistValue `json:"kvlistValue"`
+	BytesValue  []byte       `json:"bytesValue"`
+}
+
+type arrayValue struct {
+	Values []anyValue `json:"values"`
+}
+
+type kvlistValue struct {
+	Values []keyValue `json:"values"`
+}
+
+// temporality is the aggregation temporality of sums and histograms.
+type temporality int32
+
+const (
+	temporalityUnspecified temporality = 0
+	temporalityDelta       temporality = 1
+	temporalityCumulative  temporality = 2
+)
+
+// UnmarshalJSON accepts both the numeric and the enum name representations
+// of the temporality.
+func (t *temporality) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		var n int32
+		if err := json.Unmarshal(data, &n); err != nil {
+			return errors.Wrap(err, "invalid aggregation temporality")
+		}
+		*t = temporality(n)
+		return nil
+	}
+
+	switch strings.TrimPrefix(name, "AGGREGATION_TEMPORALITY_") {
+	case "DELTA":
+		*t = temporalityDelta
+	case "CUMULATIVE":
+		*t = temporalityCumulative
+	case "UNSPECIFIED":
+		*t = temporalityUnspecified
+	default:
+		return errors.Errorf("invalid aggregation temporality '%s'", name)
+	}
+	return nil
+}
+
+// jsonUint64 is an uint64 that can be decoded from a JSON string or number.
+type jsonUint64 uint64
+
+func (u *jsonUint64) UnmarshalJSON(data []byte) error {
+	v, err := strconv.ParseUint(string(bytes.Trim(data, `"`)), 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "invalid unsigned integer")
+	}
+	*u = jsonUint64(v)
+	return nil
+}
+
+// jsonInt64 is an int64 that can be decoded from a JSON string or number.
+type jsonInt64 int64
+
+func (i *jsonInt64) UnmarshalJSON(data []byte) error {
+	v, err := strconv.ParseInt(string(bytes.Trim(data, `"`)), 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "invalid integer")
+	}
+	*i = jsonInt64(v)
+	return nil
+}
+
+// scopeMetrics returns the scope metrics of the resource, including the ones
+// sent with the deprecated instrumentation library fields.
+func (rm *resourceMetrics) scopeMetrics() []scopeMetrics {
+	if len(rm.InstrumentationLibraryMetrics) == 0 {
+		return rm.ScopeMetrics
+	}
+
+	all := make([]scopeMetrics, 0, len(rm.ScopeMetrics)+len(rm.InstrumentationLibraryMetrics))
+	all = append(all, rm.ScopeMetrics...)
+	for _, sm := range rm.InstrumentationLibraryMetrics {
+		if sm.Scope == (scope{}) {
+			sm.Scope = sm.InstrumentationLibrary
+		}
+		all = append(all, sm)
+	}
+	return all
+}
+
+// value returns the value of the data point as a float64.
+func (p *numberDataPoint) value() (float64, bool) {
+	switch {
+	case p.AsDouble != nil:
+		return *p.AsDouble, true
+	case p.AsInt != nil:
+		return float64(*p.AsInt), true
+	}
+	return 0, false
+}
+
+// value converts the attribute value to the types used in events.
+func (v *anyValue) value() interface{} {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return *v.BoolValue
+	case v.IntValue != nil:
+		return int64(*v.IntValue)
+	case v.DoubleValue != nil:
+		return *v.DoubleValue
+	case v.ArrayValue != nil:
+		values := make([]interface{}, 0, len(v.ArrayValue.Values))
+		for i := range v.ArrayValue.Values {
+			values = append(values, v.ArrayValue.Values[i].value())
+		}
+		return values
+	case v.KvlistValue != nil:
+		return attributesToMapStr(v.KvlistValue.Values)
+	case v.BytesValue != nil:
+		return v.BytesValue
+	}
+	return nil
+}
+
+// attributesToMapStr converts a list of attributes to a MapStr. Dots in the
+// attribute keys are replaced by underscores so attributes like `k8s.pod` and
+// `k8s.pod.name` don't conflict in the mapping.
+func attributesToMapStr(attributes []keyValue) common.MapStr {
+	if len(attributes) == 0 {
+		return nil
+	}
+
+	m := make(common.MapStr, len(attributes))
+	for i := range attributes {
+		if v := attributes[i].Value.value(); v != nil {
+			m[common.DeDot(attributes[i].Key)] = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,425 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package metrics
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The functions in this file decode the protobuf encoding of an OTLP
+// ExportMetricsServiceRequest into the types defined in model.go. Field
+// numbers are the ones of the opentelemetry-proto definitions. Unknown fields,
+// and fields with an unexpected wire type, are skipped.
+
+// protoField is a decoded protobuf field. Scalar values are stored in value,
+// length-delimited values in bytes.
+type protoField struct {
+	num   protowire.Number
+	typ   protowire.Type
+	value uint64
+	bytes []byte
+}
+
+func decodeFields(b []byte, fn func(f protoField) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		f := protoField{num: num, typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			f.value, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			f.value, n = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(b)
+			f.value = uint64(v)
+		case protowire.BytesType:
+			f.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f protoField) isMessage() bool { return f.typ == protowire.BytesType }
+func (f protoField) isVarint() bool  { return f.typ == protowire.VarintType }
+func (f protoField) isFixed64() bool { return f.typ == protowire.Fixed64Type }
+
+func (f protoField) double() float64 { return math.Float64frombits(f.value) }
+func (f protoField) sint32() int32   { return int32(protowire.DecodeZigZag(f.value)) }
+
+// appendFixed64s decodes a repeated fixed64 field, packed or not.
+func appendFixed64s(dst []jsonUint64, f protoField) ([]jsonUint64, error) {
+	switch f.typ {
+	case protowire.Fixed64Type:
+		return append(dst, jsonUint64(f.value)), nil
+	case protowire.BytesType:
+		b := f.bytes
+		for len(b) > 0 {
+			v, n := protowire.ConsumeFixed64(b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			dst = append(dst, jsonUint64(v))
+			b = b[n:]
+		}
+	}
+	return dst, nil
+}
+
+// appendVarints decodes a repeated uint64 field, packed or not.
+func appendVarints(dst []jsonUint64, f protoField) ([]jsonUint64, error) {
+	switch f.typ {
+	case protowire.VarintType:
+		return append(dst, jsonUint64(f.value)), nil
+	case protowire.BytesType:
+		b := f.bytes
+		for len(b) > 0 {
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			dst = append(dst, jsonUint64(v))
+			b = b[n:]
+		}
+	}
+	return dst, nil
+}
+
+// appendDoubles decodes a repeated double field, packed or not.
+func appendDoubles(dst []float64, f protoField) ([]float64, error) {
+	values, err := appendFixed64s(nil, f)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range values {
+		dst = append(dst, math.Float64frombits(uint64(v)))
+	}
+	return dst, nil
+}
+
+func decodeExportRequest(b []byte) (*exportRequest, error) {
+	var req exportRequest
+	err := decodeFields(b, func(f protoField) error {
+		if f.num == 1 && f.isMessage() {
+			var rm resourceMetrics
+			if err := decodeResourceMetrics(f.bytes, &rm); err != nil {
+				return err
+			}
+			req.ResourceMetrics = append(req.ResourceMetrics, rm)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode OTLP metrics request")
+	}
+	return &req, nil
+}
+
+func decodeResourceMetrics(b []byte, rm *resourceMetrics) error {
+	return decodeFields(b, func(f protoField) error {
+		if !f.isMessage() {
+			return nil
+		}
+		switch f.num {
+		case 1:
+			return decodeFields(f.bytes, func(f protoField) error {
+				if f.num == 1 && f.isMessage() {
+					return appendKeyValue(&rm.Resource.Attributes, f.bytes)
+				}
+				return nil
+			})
+		case 2, 1000:
+			// 1000 is the deprecated instrumentation_library_metrics field,
+			// its messages have the same layout as scope_metrics.
+			var sm scopeMetrics
+			if err := decodeScopeMetrics(f.bytes, &sm); err != nil {
+				return err
+			}
+			rm.ScopeMetrics = append(rm.ScopeMetrics, sm)
+		}
+		return nil
+	})
+}
+
+func decodeScopeMetrics(b []byte, sm *scopeMetrics) error {
+	return decodeFields(b, func(f protoField) error {
+		if !f.isMessage() {
+			return nil
+		}
+		switch f.num {
+		case 1:
+			return decodeFields(f.bytes, func(f protoField) error {
+				switch {
+				case f.num == 1 && f.isMessage():
+					sm.Scope.Name = string(f.bytes)
+				case f.num == 2 && f.isMessage():
+					sm.Scope.Version = string(f.bytes)
+				}
+				return nil
+			})
+		case 2:
+			var m metric
+			if err := decodeMetric(f.bytes, &m); err != nil {
+				return err
+			}
+			sm.Metrics = append(sm.Metrics, m)
+		}
+		return nil
+	})
+}
+
+func decodeMetric(b []byte, m *metric) error {
+	return decodeFields(b, func(f protoField) error {
+		if !f.isMessage() {
+			return nil
+		}
+		switch f.num {
+		case 1:
+			m.Name = string(f.bytes)
+		case 2:
+			m.Description = string(f.bytes)
+		case 3:
+			m.Unit = string(f.bytes)
+		case 5:
+			m.Gauge = &gauge{}
+			return decodeFields(f.bytes, func(f protoField) error {
+				if f.num == 1 && f.isMessage() {
+					return appendNumberDataPoint(&m.Gauge.DataPoints, f.bytes)
+				}
+				return nil
+			})
+		case 7:
+			m.Sum = &sum{}
+			return decodeFields(f.bytes, func(f protoField) error {
+				switch {
+				case f.num == 1 && f.isMessage():
+					return appendNumberDataPoint(&m.Sum.DataPoints, f.bytes)
+				case f.num == 2 && f.isVarint():
+					m.Sum.AggregationTemporality = temporality(f.value)
+				case f.num == 3 && f.isVarint():
+					m.Sum.IsMonotonic = protowire.DecodeBool(f.value)
+				}
+				return nil
+			})
+		case 9:
+			m.Histogram = &histogram{}
+			return decodeFields(f.bytes, func(f protoField) error {
+				switch {
+				case f.num == 1 && f.isMessage():
+					return appendHistogramDataPoint(&m.Histogram.DataPoints, f.bytes)
+				case f.num == 2 && f.isVarint():
+					m.Histogram.AggregationTemporality = temporality(f.value)
+				}
+				return nil
+			})
+		case 10:
+			m.ExponentialHistogram = &exponentialHistogram{}
+			return decodeFields(f.bytes, func(f protoField) error {
+				switch {
+				case f.num == 1 && f.isMessage():
+					return appendExponentialHistogramDataPoint(&m.ExponentialHistogram.DataPoints, f.bytes)
+				case f.num == 2 && f.isVarint():
+					m.ExponentialHistogram.AggregationTemporality = temporality(f.value)
+				}
+				return nil
+			})
+		case 11:
+			m.Summary = &summary{}
+		}
+		return nil
+	})
+}
+
+func appendNumberDataPoint(dst *[]numberDataPoint, b []byte) error {
+	var p numberDataPoint
+	err := decodeFields(b, func(f protoField) error {
+		switch {
+		case f.num == 7 && f.isMessage():
+			return appendKeyValue(&p.Attributes, f.bytes)
+		case f.num == 2 && f.isFixed64():
+			p.StartTimeUnixNano = jsonUint64(f.value)
+		case f.num == 3 && f.isFixed64():
+			p.TimeUnixNano = jsonUint64(f.value)
+		case f.num == 4 && f.isFixed64():
+			v := f.double()
+			p.AsDouble = &v
+		case f.num == 6 && f.isFixed64():
+			v := jsonInt64(f.value)
+			p.AsInt = &v
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	*dst = append(*dst, p)
+	return nil
+}
+
+func appendHistogramDataPoint(dst *[]histogramDataPoint, b []byte) error {
+	var p histogramDataPoint
+	err := decodeFields(b, func(f protoField) (err error) {
+		switch {
+		case f.num == 9 && f.isMessage():
+			return appendKeyValue(&p.Attributes, f.bytes)
+		case f.num == 2 && f.isFixed64():
+			p.StartTimeUnixNano = jsonUint64(f.value)
+		case f.num == 3 && f.isFixed64():
+			p.TimeUnixNano = jsonUint64(f.value)
+		case f.num == 4 && f.isFixed64():
+			p.Count = jsonUint64(f.value)
+		case f.num == 5 && f.isFixed64():
+			v := f.double()
+			p.Sum = &v
+		case f.num == 6:
+			p.BucketCounts, err = appendFixed64s(p.BucketCounts, f)
+		case f.num == 7:
+			p.ExplicitBounds, err = appendDoubles(p.ExplicitBounds, f)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	*dst = append(*dst, p)
+	return nil
+}
+
+func appendExponentialHistogramDataPoint(dst *[]exponentialHistogramDataPoint, b []byte) error {
+	var p exponentialHistogramDataPoint
+	err := decodeFields(b, func(f protoField) error {
+		switch {
+		case f.num == 1 && f.isMessage():
+			return appendKeyValue(&p.Attributes, f.bytes)
+		case f.num == 2 && f.isFixed64():
+			p.StartTimeUnixNano = jsonUint64(f.value)
+		case f.num == 3 && f.isFixed64():
+			p.TimeUnixNano = jsonUint64(f.value)
+		case f.num == 4 && f.isFixed64():
+			p.Count = jsonUint64(f.value)
+		case f.num == 5 && f.isFixed64():
+			v := f.double()
+			p.Sum = &v
+		case f.num == 6 && f.isVarint():
+			p.Scale = f.sint32()
+		case f.num == 7 && f.isFixed64():
+			p.ZeroCount = jsonUint64(f.value)
+		case f.num == 8 && f.isMessage():
+			return decodeBuckets(f.bytes, &p.Positive)
+		case f.num == 9 && f.isMessage():
+			return decodeBuckets(f.bytes, &p.Negative)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	*dst = append(*dst, p)
+	return nil
+}
+
+func decodeBuckets(b []byte, bk *buckets) error {
+	return decodeFields(b, func(f protoField) (err error) {
+		switch {
+		case f.num == 1 && f.isVarint():
+			bk.Offset = f.sint32()
+		case f.num == 2:
+			bk.BucketCounts, err = appendVarints(bk.BucketCounts, f)
+		}
+		return err
+	})
+}
+
+func appendKeyValue(dst *[]keyValue, b []byte) error {
+	var kv keyValue
+	err := decodeFields(b, func(f protoField) error {
+		if !f.isMessage() {
+			return nil
+		}
+		switch f.num {
+		case 1:
+			kv.Key = string(f.bytes)
+		case 2:
+			return decodeAnyValue(f.bytes, &kv.Value)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	*dst = append(*dst, kv)
+	return nil
+}
+
+func decodeAnyValue(b []byte, v *anyValue) error {
+	return decodeFields(b, func(f protoField) error {
+		switch {
+		case f.num == 1 && f.isMessage():
+			s := string(f.bytes)
+			v.StringValue = &s
+		case f.num == 2 && f.isVarint():
+			b := protowire.DecodeBool(f.value)
+			v.BoolValue = &b
+		case f.num == 3 && f.isVarint():
+			i := jsonInt64(f.value)
+			v.IntValue = &i
+		case f.num == 4 && f.isFixed64():
+			d := f.double()
+			v.DoubleValue = &d
+		case f.num == 5 && f.isMessage():
+			v.ArrayValue = &arrayValue{}
+			return decodeFields(f.bytes, func(f protoField) error {
+				if f.num == 1 && f.isMessage() {
+					var item anyValue
+					if err := decodeAnyValue(f.bytes, &item); err != nil {
+						return err
+					}
+					v.ArrayValue.Values = append(v.ArrayValue.Values, item)
+				}
+				return nil
+			})
+		case f.num == 6 && f.isMessage():
+			v.KvlistValue = &kvlistValue{}
+			return decodeFields(f.bytes, func(f protoField) error {
+				if f.num == 1 && f.isMessage() {
+					return appendKeyValue(&v.KvlistValue.Values, f.bytes)
+				}
+				return nil
+			})
+		case f.num == 7 && f.isMessage():
+			v.BytesValue = append([]byte{}, f.bytes...)
+		}
+		return nil
+	})
+}
+
+// encodeStatus encodes a google.rpc.Status message, the body of the error
+// responses of OTLP/HTTP.
+func encodeStatus(code uint64, message string) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, code)
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	b = protowire.AppendString(b, message)
+	return b
+}
@@ -7,13 +7,17 @@ package collector
 import "errors"
 
 type config struct {
-	UseTypes     bool `config:"use_types"`
+	UseTypes    bool `config:"use_types"`
+	NativeTypes bool `config:"native_types"`
+
+	// RateCounters is also used by the default generator when UseTypes is
+	// not enabled.
 	RateCounters bool `config:"rate_counters"`
 }
 
 func (c *config) Validate() error {
-	if c.RateCounters && !c.UseTypes {
-		return errors.New("'rate_counters' can only be enabled when `use_types` is also enabled")
+	if c.NativeTypes && c.UseTypes {
+		return errors.New("'native_types' can not be enabled when `use_types` is also enabled")
 	}
 
 	return nil
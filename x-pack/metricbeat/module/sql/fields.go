@@ -19,5 +19,5 @@ func init() {
 // AssetSql returns asset data.
 // This is the base64 encoded gzipped contents of module/sql.
 func AssetSql() string {
-	return "eJy0k0Fu8jAQhfc5xRPLX4IDZPEvqi4REuIAlWM/wMXJgD2mze0rEoJSpa1AVb18npnvy0Se48C2RDqFAlCvgSVmm/VyVgCRgSaxREU1BeCYbPRH9dKU+F8AwGa9RC0uB2JLtXsm1NTobcI2Sg3TVTijpjKJBbD1DC6VXfMcjak5wC9H2yNL7KLk4zUZ1497XPRnxls8tB7Yvkl0o/wL6eE8dzOQEx1UwHfarITuiVNmbBcTahf/Drq+jBhYHddKCLQ6LG5KHS6aXDN6u/g3MZDqlVZHcR+89LdOchV4n96qZ9z+4lWO7nutpNE3u4etHtraSpr59fNxNiHzLrNKJNA0f6v21EN+1Pr8kj4CAAD//07X+ko="
+	return "eJzElE1u6jAUhedZxRFDJFhABm/w1CFCQiygcuwDuDhxsK+h2X1FgqtUtBW0qpBnx773+/wjz7BnVyIeXAGIFccSk/VqMSmAQEcVWaKiqAIwjDrYVqxvSvwrAGC9WqD2JjliQ9E7RtSUYHXEJvgaql9hlKhKRRbAxtKZWPbFMzSqZoafh3QtS2yDT+0lGa8f15hgjwzvcS7dszv5YEb5J9J5PPU9kCINxIOv1EkI2RGHxNDNr6h9/Dvo6twis3qu9s5RSz64a2qeaFLNYPV8Omo4bNtXL9QyiofgeZg1PlWOt+ktB0ZmZjmar7WiBNts77a669SWvpldto+jcok3mVXeO6rmb9X+D5A7tKaPvUlUHdTwwnGysoPq/WKrNL+TfvQ9/9T7oa/gVumPf+3bAJLtmXg="
 }
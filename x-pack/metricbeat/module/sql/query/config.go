@@ -0,0 +1,113 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package query
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// represents the type of the cursor column
+const (
+	integerCursorType   = "integer"
+	timestampCursorType = "timestamp"
+)
+
+type config struct {
+	Driver         string `config:"driver" validate:"nonzero,required"`
+	Query          string `config:"sql_query"`
+	ResponseFormat string `config:"sql_response_format"`
+
+	Queries []queryConfig `config:"sql_queries"`
+}
+
+// queryConfig is the configuration of a single query of the sql_queries list.
+type queryConfig struct {
+	Query          string        `config:"query" validate:"nonzero,required"`
+	ResponseFormat string        `config:"response_format"`
+	Namespace      string        `config:"namespace"`
+	Cursor         *cursorConfig `config:"cursor"`
+}
+
+// cursorConfig configures the column used to fetch only the rows added since
+// the previous fetch.
+type cursorConfig struct {
+	Column  string `config:"column" validate:"nonzero,required"`
+	Type    string `config:"type"`
+	Default string `config:"default" validate:"nonzero,required"`
+}
+
+var defaultConfig = config{
+	ResponseFormat: tableResponseFormat,
+}
+
+func (c *config) Validate() error {
+	if c.Query == "" && len(c.Queries) == 0 {
+		return errors.New("one of sql_query or sql_queries must be set")
+	}
+	if c.Query != "" && len(c.Queries) > 0 {
+		return errors.New("sql_query and sql_queries cannot be used at the same time")
+	}
+	if err := validateResponseFormat(c.ResponseFormat); err != nil {
+		return errors.Wrap(err, "invalid sql_response_format value")
+	}
+	return nil
+}
+
+func (c *queryConfig) Validate() error {
+	if err := validateResponseFormat(c.ResponseFormat); err != nil {
+		return errors.Wrap(err, "invalid response_format value")
+	}
+	if strings.Contains(c.Namespace, ".") {
+		return errors.Errorf("invalid namespace '%s': it cannot contain dots", c.Namespace)
+	}
+	if c.Cursor != nil && c.ResponseFormat == variableResponseFormat {
+		return errors.New("cursor can only be used with the table response format")
+	}
+	return nil
+}
+
+func (c *cursorConfig) Validate() error {
+	switch c.Type {
+	case "", integerCursorType, timestampCursorType:
+	default:
+		return errors.Errorf("invalid cursor type '%s'", c.Type)
+	}
+	_, err := parseCursorValue(c.cursorType(), c.Default)
+	return errors.Wrap(err, "invalid cursor default")
+}
+
+func (c *cursorConfig) cursorType() string {
+	if c.Type == "" {
+		return integerCursorType
+	}
+	return c.Type
+}
+
+// queries returns the queries to run on each fetch, including the one
+// configured with the legacy sql_query setting.
+func (c *config) queries() []queryConfig {
+	if c.Query != "" {
+		return []queryConfig{{Query: c.Query, ResponseFormat: c.ResponseFormat}}
+	}
+
+	queries := make([]queryConfig, len(c.Queries))
+	for i, q := range c.Queries {
+		if q.ResponseFormat == "" {
+			q.ResponseFormat = tableResponseFormat
+		}
+		queries[i] = q
+	}
+	return queries
+}
+
+func validateResponseFormat(format string) error {
+	switch format {
+	case "", tableResponseFormat, variableResponseFormat:
+		return nil
+	}
+	return errors.Errorf("'%s' is not one of %s or %s", format, tableResponseFormat, variableResponseFormat)
+}
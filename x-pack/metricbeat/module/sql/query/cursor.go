@@ -0,0 +1,306 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package query
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/v7/libbeat/logp"
+	"github.com/elastic/beats/v7/libbeat/paths"
+	"github.com/elastic/beats/v7/libbeat/statestore"
+	"github.com/elastic/beats/v7/libbeat/statestore/backend/memlog"
+	"github.com/elastic/beats/v7/metricbeat/helper/sql"
+)
+
+const (
+	// cursorParameter is the named parameter replaced by the cursor value
+	// in the queries.
+	cursorParameter = ":cursor"
+
+	cursorStoreName = "cursors"
+)
+
+// Layouts accepted for timestamp cursors, in addition to RFC3339.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// cursorState is the cursor information persisted in the state store.
+type cursorState struct {
+	Type    string    `json:"type" struct:"type"`
+	Value   string    `json:"value" struct:"value"`
+	Updated time.Time `json:"updated" struct:"updated"`
+}
+
+// cursor keeps track of the last value of the cursor column returned by a
+// query. Values are int64 for integer cursors and time.Time for timestamp
+// cursors.
+type cursor struct {
+	column string
+	typ    string
+	key    string
+	value  interface{}
+	store  *statestore.Store
+}
+
+// newCursor creates a cursor for the query, restoring its last value from the
+// store. The default value of the cursor is used if there is no stored state.
+func newCursor(store *statestore.Store, id string, cfg *cursorConfig, log *logp.Logger) (*cursor, error) {
+	c := &cursor{
+		column: strings.ToLower(cfg.Column),
+		typ:    cfg.cursorType(),
+		key:    cursorKey(id, cfg.Column),
+		store:  store,
+	}
+
+	value, err := parseCursorValue(c.typ, cfg.Default)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid cursor default")
+	}
+	c.value = value
+
+	has, err := store.Has(c.key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read cursor state")
+	}
+	if !has {
+		return c, nil
+	}
+
+	var st cursorState
+	if err := store.Get(c.key, &st); err != nil {
+		return nil, errors.Wrap(err, "failed to read cursor state")
+	}
+	if st.Type != c.typ {
+		log.Warnf("Ignoring stored %s cursor for column '%s', the cursor is now of type %s.", st.Type, cfg.Column, c.typ)
+		return c, nil
+	}
+	value, err = parseCursorValue(st.Type, st.Value)
+	if err != nil {
+		log.Warnf("Ignoring invalid stored cursor for column '%s': %v", cfg.Column, err)
+		return c, nil
+	}
+	c.value = value
+	log.Debugf("Restored cursor for column '%s' with value %v", cfg.Column, value)
+
+	return c, nil
+}
+
+// bind replaces the cursor parameter in the query by the placeholders of the
+// driver, and returns the arguments to use with the resulting query.
+func (c *cursor) bind(driver, query string) (string, []interface{}) {
+	var (
+		b    strings.Builder
+		args []interface{}
+	)
+	for {
+		idx := indexParameter(query)
+		if idx < 0 {
+			b.WriteString(query)
+			break
+		}
+		args = append(args, c.value)
+		b.WriteString(query[:idx])
+		b.WriteString(sql.Placeholder(driver, len(args)))
+		query = query[idx+len(cursorParameter):]
+	}
+	return b.String(), args
+}
+
+// update advances the cursor if the value of the cursor column in a row is
+// greater. The value is the one returned by the driver, before its conversion
+// to float64, so big integers keep their precision. It returns an error if the
+// row contains no valid value.
+func (c *cursor) update(v interface{}) error {
+	if v == nil {
+		return errors.Errorf("cursor column '%s' not found in row or null", c.column)
+	}
+
+	switch c.typ {
+	case integerCursorType:
+		i, err := toInt64(v)
+		if err != nil {
+			return errors.Wrapf(err, "invalid integer value in cursor column '%s'", c.column)
+		}
+		if i > c.value.(int64) {
+			c.value = i
+		}
+	case timestampCursorType:
+		t, ok := v.(time.Time)
+		if ok {
+			t = t.UTC()
+		} else {
+			var err error
+			if t, err = parseTimestamp(toString(v)); err != nil {
+				return errors.Wrapf(err, "invalid timestamp value in cursor column '%s'", c.column)
+			}
+		}
+		if t.After(c.value.(time.Time)) {
+			c.value = t
+		}
+	}
+	return nil
+}
+
+// save persists the current value of the cursor.
+func (c *cursor) save() error {
+	st := cursorState{
+		Type:    c.typ,
+		Value:   formatCursorValue(c.value),
+		Updated: time.Now().UTC(),
+	}
+	return errors.Wrap(c.store.Set(c.key, st), "failed to store cursor state")
+}
+
+// indexParameter returns the index of the first cursor parameter in the
+// query, or -1 if there is none. Occurrences that are part of a longer
+// identifier or of a cast (e.g. '::cursor') are ignored.
+func indexParameter(query string) int {
+	offset := 0
+	for {
+		idx := strings.Index(query[offset:], cursorParameter)
+		if idx < 0 {
+			return -1
+		}
+		idx += offset
+		end := idx + len(cursorParameter)
+		if (idx == 0 || query[idx-1] != ':') && (end == len(query) || !isIdentifierChar(query[end])) {
+			return idx
+		}
+		offset = end
+	}
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func parseCursorValue(typ, s string) (interface{}, error) {
+	if typ == timestampCursorType {
+		return parseTimestamp(s)
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+func formatCursorValue(v interface{}) string {
+	switch v := v.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	}
+	return toString(v)
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, errors.Errorf("cannot parse '%s' as a timestamp", s)
+}
+
+// toInt64 converts an integer value returned by a driver. Integers returned as
+// strings or bytes are parsed, so they don't lose precision.
+func toInt64(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case int64:
+		return n, nil
+	case int32:
+		return int64(n), nil
+	case int:
+		return int64(n), nil
+	case uint64:
+		if n > math.MaxInt64 {
+			return 0, errors.Errorf("value %d out of range", n)
+		}
+		return int64(n), nil
+	case float64:
+		if n != math.Trunc(n) || math.Abs(n) >= 1<<63 {
+			return 0, errors.Errorf("value %v is not an integer", n)
+		}
+		return int64(n), nil
+	}
+	return strconv.ParseInt(toString(v), 10, 64)
+}
+
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// cursorKey identifies the cursor of a query in the store. The identifier is
+// hashed so the connection string is not persisted.
+func cursorKey(id, column string) string {
+	h := sha256.Sum256([]byte(id + "\x00" + strings.ToLower(column)))
+	return "sql::cursor::" + hex.EncodeToString(h[:])
+}
+
+// The registry holding the cursor states is shared by all the query
+// metricsets, and closed once no metricset uses it anymore.
+var cursorRegistry struct {
+	sync.Mutex
+	registry *statestore.Registry
+	refs     int
+}
+
+func openCursorStore(log *logp.Logger) (*statestore.Store, error) {
+	cursorRegistry.Lock()
+	defer cursorRegistry.Unlock()
+
+	if cursorRegistry.registry == nil {
+		backend, err := memlog.New(log, memlog.Settings{
+			Root: paths.Resolve(paths.Data, "sql"),
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to open cursor registry")
+		}
+		cursorRegistry.registry = statestore.NewRegistry(backend)
+	}
+
+	store, err := cursorRegistry.registry.Get(cursorStoreName)
+	if err != nil {
+		if cursorRegistry.refs == 0 {
+			cursorRegistry.registry.Close()
+			cursorRegistry.registry = nil
+		}
+		return nil, errors.Wrap(err, "failed to open cursor store")
+	}
+	cursorRegistry.refs++
+	return store, nil
+}
+
+func closeCursorStore(store *statestore.Store) error {
+	cursorRegistry.Lock()
+	defer cursorRegistry.Unlock()
+
+	err := store.Close()
+	cursorRegistry.refs--
+	if cursorRegistry.refs == 0 {
+		cursorRegistry.registry.Close()
+		cursorRegistry.registry = nil
+	}
+	return err
+}
@@ -0,0 +1,205 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package query
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/logp"
+	"github.com/elastic/beats/v7/libbeat/statestore"
+	"github.com/elastic/beats/v7/libbeat/statestore/backend/memlog"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		config map[string]interface{}
+		valid  bool
+	}{
+		"single query": {
+			config: map[string]interface{}{"driver": "mysql", "sql_query": "select 1"},
+			valid:  true,
+		},
+		"no query": {
+			config: map[string]interface{}{"driver": "mysql"},
+		},
+		"both query settings": {
+			config: map[string]interface{}{
+				"driver":      "mysql",
+				"sql_query":   "select 1",
+				"sql_queries": []map[string]interface{}{{"query": "select 2"}},
+			},
+		},
+		"invalid legacy response format": {
+			config: map[string]interface{}{"driver": "mysql", "sql_query": "select 1", "sql_response_format": "rows"},
+		},
+		"multiple queries": {
+			config: map[string]interface{}{
+				"driver": "mysql",
+				"sql_queries": []map[string]interface{}{
+					{"query": "select 1", "namespace": "one"},
+					{"query": "show status", "response_format": "variables"},
+				},
+			},
+			valid: true,
+		},
+		"namespace with dots": {
+			config: map[string]interface{}{
+				"driver":      "mysql",
+				"sql_queries": []map[string]interface{}{{"query": "select 1", "namespace": "a.b"}},
+			},
+		},
+		"cursor": {
+			config: map[string]interface{}{
+				"driver": "mysql",
+				"sql_queries": []map[string]interface{}{{
+					"query":  "select * from audit where id > :cursor",
+					"cursor": map[string]interface{}{"column": "id", "default": "0"},
+				}},
+			},
+			valid: true,
+		},
+		"cursor with variables format": {
+			config: map[string]interface{}{
+				"driver": "mysql",
+				"sql_queries": []map[string]interface{}{{
+					"query":           "show status",
+					"response_format": "variables",
+					"cursor":          map[string]interface{}{"column": "id", "default": "0"},
+				}},
+			},
+		},
+		"cursor with invalid default": {
+			config: map[string]interface{}{
+				"driver": "mysql",
+				"sql_queries": []map[string]interface{}{{
+					"query":  "select * from audit where ts > :cursor",
+					"cursor": map[string]interface{}{"column": "ts", "type": "timestamp", "default": "yesterday"},
+				}},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := defaultConfig
+			err := common.MustNewConfigFrom(test.config).Unpack(&c)
+			if test.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				t.Log(err)
+			}
+		})
+	}
+}
+
+func TestConfigQueries(t *testing.T) {
+	c := defaultConfig
+	c.Query = "select 1"
+	assert.Equal(t, []queryConfig{{Query: "select 1", ResponseFormat: tableResponseFormat}}, c.queries())
+
+	c = defaultConfig
+	c.Queries = []queryConfig{{Query: "select 1"}, {Query: "show status", ResponseFormat: variableResponseFormat}}
+	queries := c.queries()
+	assert.Equal(t, tableResponseFormat, queries[0].ResponseFormat)
+	assert.Equal(t, variableResponseFormat, queries[1].ResponseFormat)
+}
+
+func TestCursorBind(t *testing.T) {
+	c := &cursor{typ: integerCursorType, value: int64(42)}
+
+	q, args := c.bind("postgres", "SELECT * FROM t WHERE id > :cursor AND id < :cursor + 100 AND ts::date = now()::date AND x = ':cursors'")
+	assert.Equal(t, "SELECT * FROM t WHERE id > $1 AND id < $2 + 100 AND ts::date = now()::date AND x = ':cursors'", q)
+	assert.Equal(t, []interface{}{int64(42), int64(42)}, args)
+
+	q, args = c.bind("mysql", "SELECT * FROM t WHERE id > :cursor")
+	assert.Equal(t, "SELECT * FROM t WHERE id > ?", q)
+	assert.Equal(t, []interface{}{int64(42)}, args)
+
+	q, args = c.bind("mysql", "SELECT 1")
+	assert.Equal(t, "SELECT 1", q)
+	assert.Empty(t, args)
+}
+
+func TestCursorBigIntegers(t *testing.T) {
+	c, err := newCursor(newTestStore(t), "query", &cursorConfig{Column: "id", Default: "0"}, logp.NewLogger("test"))
+	require.NoError(t, err)
+
+	// IDs above 2^53 cannot be represented as float64 without losing
+	// precision, the value returned by the driver is used.
+	const id = int64(1<<53 + 1)
+	require.NoError(t, c.update(id))
+	assert.Equal(t, id, c.value)
+
+	require.NoError(t, c.update([]byte("9007199254740993")))
+	assert.Equal(t, int64(9007199254740993), c.value)
+	require.NoError(t, c.update("9223372036854775807"))
+	assert.Equal(t, int64(math.MaxInt64), c.value)
+}
+
+func TestCursorPersistence(t *testing.T) {
+	store := newTestStore(t)
+
+	integerCfg := &cursorConfig{Column: "ID", Default: "10"}
+	c, err := newCursor(store, "query", integerCfg, logp.NewLogger("test"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), c.value)
+
+	for _, v := range []interface{}{int64(12), []byte("15"), float64(11)} {
+		assert.NoError(t, c.update(v))
+	}
+	assert.Error(t, c.update(nil))
+	assert.Error(t, c.update(1.5))
+	assert.Equal(t, int64(15), c.value)
+	require.NoError(t, c.save())
+
+	c, err = newCursor(store, "query", integerCfg, logp.NewLogger("test"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(15), c.value)
+
+	// A cursor of a different type for the same column starts from its default.
+	timestampCfg := &cursorConfig{Column: "id", Type: timestampCursorType, Default: "2020-01-01T00:00:00Z"}
+	c, err = newCursor(store, "query", timestampCfg, logp.NewLogger("test"))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), c.value)
+
+	assert.NoError(t, c.update([]byte("2020-06-09 15:09:14.407")))
+	assert.NoError(t, c.update(time.Date(2020, 6, 7, 20, 50, 12, 632975000, time.UTC)))
+	assert.Equal(t, time.Date(2020, 6, 9, 15, 9, 14, 407000000, time.UTC), c.value)
+	require.NoError(t, c.save())
+
+	c, err = newCursor(store, "query", timestampCfg, logp.NewLogger("test"))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 6, 9, 15, 9, 14, 407000000, time.UTC), c.value)
+
+	// Other queries have their own cursor.
+	c, err = newCursor(store, "other query", integerCfg, logp.NewLogger("test"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), c.value)
+}
+
+func newTestStore(t *testing.T) *statestore.Store {
+	dir, err := ioutil.TempDir("", "sql-cursor")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	backend, err := memlog.New(logp.NewLogger("test"), memlog.Settings{Root: dir})
+	require.NoError(t, err)
+	registry := statestore.NewRegistry(backend)
+	t.Cleanup(func() { registry.Close() })
+
+	store, err := registry.Get(cursorStoreName)
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
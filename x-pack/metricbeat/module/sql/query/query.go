@@ -6,13 +6,13 @@ package query
 
 import (
 	"context"
-	"fmt"
 
-	"github.com/jmoiron/sqlx"
+	"github.com/joeshaw/multierror"
 	"github.com/pkg/errors"
 
 	"github.com/elastic/beats/v7/libbeat/common"
 	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/libbeat/statestore"
 	"github.com/elastic/beats/v7/metricbeat/helper/sql"
 	"github.com/elastic/beats/v7/metricbeat/mb"
 )
@@ -39,11 +39,18 @@ func init() {
 // interface methods except for Fetch.
 type MetricSet struct {
 	mb.BaseMetricSet
-	Driver         string
-	Query          string
-	ResponseFormat string
+	Driver  string
+	Queries []*query
 
-	db *sqlx.DB
+	db    *sql.DbClient
+	store *statestore.Store
+}
+
+// query is a query executed on each fetch, with the cursor tracking its
+// progress if it has one.
+type query struct {
+	queryConfig
+	cursor *cursor
 }
 
 // New creates a new instance of the MetricSet. New is responsible for unpacking
@@ -51,69 +58,131 @@ type MetricSet struct {
 func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
 	cfgwarn.Beta("The sql query metricset is beta.")
 
-	config := struct {
-		Driver         string `config:"driver" validate:"nonzero,required"`
-		Query          string `config:"sql_query" validate:"nonzero,required"`
-		ResponseFormat string `config:"sql_response_format"`
-	}{ResponseFormat: tableResponseFormat}
-
+	config := defaultConfig
 	if err := base.Module().UnpackConfig(&config); err != nil {
 		return nil, err
 	}
 
-	if config.ResponseFormat != variableResponseFormat && config.ResponseFormat != tableResponseFormat {
-		return nil, fmt.Errorf("invalid sql_response_format value: %s", config.ResponseFormat)
+	m := &MetricSet{
+		BaseMetricSet: base,
+		Driver:        config.Driver,
+	}
+
+	for _, qc := range config.queries() {
+		q := &query{queryConfig: qc}
+		if qc.Cursor != nil {
+			if err := m.initCursor(q); err != nil {
+				m.Close()
+				return nil, err
+			}
+		}
+		m.Queries = append(m.Queries, q)
+	}
+
+	return m, nil
+}
+
+func (m *MetricSet) initCursor(q *query) error {
+	if m.store == nil {
+		store, err := openCursorStore(m.Logger())
+		if err != nil {
+			return err
+		}
+		m.store = store
 	}
 
-	return &MetricSet{
-		BaseMetricSet:  base,
-		Driver:         config.Driver,
-		Query:          config.Query,
-		ResponseFormat: config.ResponseFormat,
-	}, nil
+	id := m.Driver + "\x00" + m.HostData().URI + "\x00" + q.Query
+	c, err := newCursor(m.store, id, q.Cursor, m.Logger())
+	if err != nil {
+		return errors.Wrapf(err, "error initializing cursor for query '%s'", q.Query)
+	}
+	q.cursor = c
+	return nil
 }
 
 // Fetch methods implements the data gathering and data conversion to the right
 // format. It publishes the event which is then forwarded to the output. In case
 // of an error set the Error field of mb.Event or simply call report.Error().
-// It calls m.fetchTableMode() or m.fetchVariableMode() depending on the response
-// format of the query.
+// All the queries are executed using the same connection pool, that is opened
+// on the first fetch and kept open until the metricset is closed.
 func (m *MetricSet) Fetch(ctx context.Context, report mb.ReporterV2) error {
-	db, err := sql.NewDBClient(m.Driver, m.HostData().URI, m.Logger())
-	if err != nil {
-		return errors.Wrap(err, "error opening connection")
-	}
-	defer db.Close()
-
-	if m.ResponseFormat == tableResponseFormat {
-		mss, err := db.FetchTableMode(ctx, m.Query)
+	if m.db == nil {
+		db, err := sql.NewDBClient(m.Driver, m.HostData().URI, m.Logger())
 		if err != nil {
-			return err
+			return errors.Wrap(err, "error opening connection")
 		}
+		m.db = db
+	}
 
-		for _, ms := range mss {
-			report.Event(m.getEvent(ms))
+	var errs multierror.Errors
+	for _, q := range m.Queries {
+		if err := m.fetchQuery(ctx, q, report); err != nil {
+			errs = append(errs, errors.Wrapf(err, "error executing query '%s'", q.Query))
 		}
+	}
+	return errs.Err()
+}
 
+// fetchQuery runs a single query. It calls m.db.FetchTableMode() or
+// m.db.FetchVariableMode() depending on the response format of the query.
+func (m *MetricSet) fetchQuery(ctx context.Context, q *query, report mb.ReporterV2) error {
+	if q.ResponseFormat == variableResponseFormat {
+		ms, err := m.db.FetchVariableMode(ctx, q.Query)
+		if err != nil {
+			return err
+		}
+		report.Event(m.getEvent(q, ms))
 		return nil
 	}
 
-	ms, err := db.FetchVariableMode(ctx, m.Query)
+	stmt, args := q.Query, []interface{}(nil)
+	if q.cursor != nil {
+		stmt, args = q.cursor.bind(m.Driver, q.Query)
+	}
+
+	var (
+		mss []common.MapStr
+		raw []interface{}
+		err error
+	)
+	if q.cursor != nil {
+		mss, raw, err = m.db.FetchTableModeWithRaw(ctx, stmt, q.cursor.column, args...)
+	} else {
+		mss, err = m.db.FetchTableMode(ctx, stmt, args...)
+	}
 	if err != nil {
 		return err
 	}
-	report.Event(m.getEvent(ms))
 
+	for i, ms := range mss {
+		if !report.Event(m.getEvent(q, ms)) {
+			break
+		}
+		if q.cursor != nil {
+			if err := q.cursor.update(raw[i]); err != nil {
+				m.Logger().Warnf("Cursor not updated for query '%s': %v", q.Query, err)
+			}
+		}
+	}
+
+	if q.cursor != nil && len(mss) > 0 {
+		return q.cursor.save()
+	}
 	return nil
 }
 
-func (m *MetricSet) getEvent(ms common.MapStr) mb.Event {
+func (m *MetricSet) getEvent(q *query, ms common.MapStr) mb.Event {
+	metrics := getMetrics(ms)
+	if q.Namespace != "" {
+		metrics = common.MapStr{q.Namespace: metrics}
+	}
+
 	return mb.Event{
 		RootFields: common.MapStr{
 			"sql": common.MapStr{
 				"driver":  m.Driver,
-				"query":   m.Query,
-				"metrics": getMetrics(ms),
+				"query":   q.Query,
+				"metrics": metrics,
 			},
 		},
 	}
@@ -156,10 +225,20 @@ func getMetrics(ms common.MapStr) (ret common.MapStr) {
 	return
 }
 
-// Close closes the connection pool releasing its resources
+// Close closes the connection pool and the cursor store releasing their
+// resources
 func (m *MetricSet) Close() error {
-	if m.db == nil {
-		return nil
+	var errs multierror.Errors
+	if m.db != nil {
+		if err := m.db.Close(); err != nil {
+			errs = append(errs, errors.Wrap(err, "closing connection"))
+		}
+	}
+	if m.store != nil {
+		if err := closeCursorStore(m.store); err != nil {
+			errs = append(errs, errors.Wrap(err, "closing cursor store"))
+		}
+		m.store = nil
 	}
-	return errors.Wrap(m.db.Close(), "closing connection")
+	return errs.Err()
 }